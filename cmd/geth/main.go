@@ -229,6 +229,8 @@ func init() {
 		dumpConfigCommand,
 		// see dbcmd.go
 		dbCommand,
+		// See poatccmd.go
+		poatcCommand,
 		// See cmd/utils/flags_legacy.go
 		utils.ShowDeprecated,
 		// See snapshot.go
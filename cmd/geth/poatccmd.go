@@ -0,0 +1,426 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	poatcFromFlag = &cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block of the range (inclusive)",
+	}
+	poatcToFlag = &cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block of the range (inclusive, 0 = head)",
+	}
+	poatcTypeFlag = &cli.StringFlag{
+		Name:  "type",
+		Usage: "Only export trace events of the given type",
+	}
+	poatcAddressFlag = &cli.StringFlag{
+		Name:  "address",
+		Usage: "Only export trace events about the given address",
+	}
+	poatcFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format of the report (json, csv)",
+		Value: "json",
+	}
+	poatcLimitFlag = &cli.IntFlag{
+		Name:  "limit",
+		Usage: "Number of most recent reputation events to print (0 = all)",
+		Value: 20,
+	}
+
+	poatcCommand = &cli.Command{
+		Name:      "poatc",
+		Usage:     "Inspect the POATC consensus state of a stopped node",
+		ArgsUsage: "",
+		Description: `
+The commands in this group open the database of the node read-only, so they can
+be used while the node is stopped, e.g. during an incident when it won't start.`,
+		Subcommands: []*cli.Command{
+			poatcSnapshotCmd,
+			poatcCheckpointsCmd,
+			poatcReputationCmd,
+			poatcExportTracesCmd,
+			poatcReplayAnomaliesCmd,
+			poatcSignerReportCmd,
+			poatcVerifyProofCmd,
+		},
+	}
+	poatcSnapshotCmd = &cli.Command{
+		Action:      poatcSnapshot,
+		Name:        "snapshot",
+		Usage:       "Dump the voting snapshot at a block",
+		ArgsUsage:   "[<number>]",
+		Flags:       flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: "Rebuilds the voting snapshot after the given canonical block (the head if omitted) and prints it as JSON.",
+	}
+	poatcCheckpointsCmd = &cli.Command{
+		Action:      poatcCheckpoints,
+		Name:        "checkpoints",
+		Usage:       "List the stored checkpoint snapshots",
+		Flags:       flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: "Lists the voting snapshots the node stored at checkpoints, ordered by block number.",
+	}
+	poatcReputationCmd = &cli.Command{
+		Action: poatcReputation,
+		Name:   "reputation",
+		Usage:  "Print the stored reputation scores and events",
+		Flags: flags.Merge([]cli.Flag{
+			poatcLimitFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: "Prints the reputation scores and most recent events last saved by the reputation system.",
+	}
+	poatcExportTracesCmd = &cli.Command{
+		Action:    poatcExportTraces,
+		Name:      "export-traces",
+		Usage:     "Export the stored trace events as JSON",
+		ArgsUsage: "<file>",
+		Flags: flags.Merge([]cli.Flag{
+			poatcFromFlag,
+			poatcToFlag,
+			poatcTypeFlag,
+			poatcAddressFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: "Exports the persisted trace events matching the filters to the given file, or stdout if it's '-'.",
+	}
+	poatcReplayAnomaliesCmd = &cli.Command{
+		Action: poatcReplayAnomalies,
+		Name:   "replay-anomalies",
+		Usage:  "Replay a block range through the anomaly detector",
+		Flags: flags.Merge([]cli.Flag{
+			poatcFromFlag,
+			poatcToFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `
+Feeds the canonical blocks in the range to a fresh anomaly detector configured by
+the chain config, and prints the anomalies it detects as JSON.`,
+	}
+	poatcSignerReportCmd = &cli.Command{
+		Action:    poatcSignerReport,
+		Name:      "signer-report",
+		Usage:     "Report the sealing activity of the signers over a block range",
+		ArgsUsage: "[<file>]",
+		Flags: flags.Merge([]cli.Flag{
+			poatcFromFlag,
+			poatcToFlag,
+			poatcFormatFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `
+Reports, per signer, the blocks sealed in and out of turn, the missed in-turn
+slots, the average and 95th percentile seal delays, the longest absence and the
+anomaly and violation counts over the canonical blocks in the range. The report
+is written to the given file, or stdout if omitted.`,
+	}
+	poatcVerifyProofCmd = &cli.Command{
+		Action:    poatcVerifyProof,
+		Name:      "verify-proof",
+		Usage:     "Verify a trace event Merkle proof file",
+		ArgsUsage: "<proof.json>",
+		Flags:     flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `
+Verifies a Merkle proof as returned by poatc_getMerkleProof. Proofs anchored in a
+block are also checked against the trace root committed to by the block.`,
+	}
+)
+
+// openPoatcInspector opens the chain database read-only and creates a POATC
+// inspector for the chain it contains.
+func openPoatcInspector(ctx *cli.Context) (*poatc.Inspector, ethdb.Database, func(), error) {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	closer := func() {
+		db.Close()
+		stack.Close()
+	}
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	config := rawdb.ReadChainConfig(db, genesis)
+	if config == nil {
+		closer()
+		return nil, nil, nil, errors.New("chain config not found in database")
+	}
+	inspector, err := poatc.NewInspector(config, db)
+	if err != nil {
+		closer()
+		return nil, nil, nil, err
+	}
+	return inspector, db, closer, nil
+}
+
+// headNumber returns the number of the head header stored in the database.
+func headNumber(db ethdb.Database) (uint64, error) {
+	number := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+	if number == nil {
+		return 0, errors.New("head header not found in database")
+	}
+	return *number, nil
+}
+
+// printJSON writes a value as indented JSON to the given writer.
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func poatcSnapshot(ctx *cli.Context) error {
+	if ctx.NArg() > 1 {
+		return fmt.Errorf("max 1 argument: %v", ctx.Command.ArgsUsage)
+	}
+	inspector, db, closer, err := openPoatcInspector(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	var number uint64
+	if ctx.NArg() == 1 {
+		if number, err = strconv.ParseUint(ctx.Args().Get(0), 0, 64); err != nil {
+			return fmt.Errorf("invalid block number: %v", err)
+		}
+	} else if number, err = headNumber(db); err != nil {
+		return err
+	}
+	snap, err := inspector.Snapshot(number)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, snap)
+}
+
+func poatcCheckpoints(ctx *cli.Context) error {
+	inspector, _, closer, err := openPoatcInspector(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	snaps, err := inspector.Checkpoints()
+	if err != nil {
+		return err
+	}
+	var data [][]string
+	for _, snap := range snaps {
+		data = append(data, []string{strconv.FormatUint(snap.Number, 10), snap.Hash.Hex(), strconv.Itoa(len(snap.Signers))})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Number", "Hash", "Signers"})
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func poatcReputation(ctx *cli.Context) error {
+	inspector, _, closer, err := openPoatcInspector(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	scores, err := inspector.ReputationScores()
+	if err != nil {
+		return err
+	}
+	addrs := make([]common.Address, 0, len(scores))
+	for addr := range scores {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return scores[addrs[i]].CurrentScore > scores[addrs[j]].CurrentScore })
+
+	var data [][]string
+	for _, addr := range addrs {
+		score := scores[addr]
+		data = append(data, []string{
+			addr.Hex(),
+			fmt.Sprintf("%.4f", score.CurrentScore),
+			strconv.Itoa(score.TotalBlocksMined),
+			strconv.Itoa(score.ViolationCount),
+			strconv.FormatBool(score.IsActive),
+		})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Validator", "Score", "Blocks", "Violations", "Active"})
+	table.AppendBulk(data)
+	table.Render()
+
+	events, err := inspector.ReputationEvents()
+	if err != nil {
+		return err
+	}
+	if limit := ctx.Int(poatcLimitFlag.Name); limit > 0 && limit < len(events) {
+		events = events[len(events)-limit:]
+	}
+	data = data[:0]
+	for _, event := range events {
+		data = append(data, []string{
+			strconv.FormatUint(event.BlockNumber, 10),
+			event.Address.Hex(),
+			event.EventType,
+			fmt.Sprintf("%+.4f", event.ScoreChange),
+			event.Description,
+		})
+	}
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Block", "Validator", "Event", "Change", "Description"})
+	table.AppendBulk(data)
+	table.Render()
+	return nil
+}
+
+func poatcExportTraces(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	filter := &poatc.TraceFilter{
+		FromBlock: ctx.Uint64(poatcFromFlag.Name),
+		ToBlock:   ctx.Uint64(poatcToFlag.Name),
+	}
+	if ctx.IsSet(poatcAddressFlag.Name) {
+		hex := ctx.String(poatcAddressFlag.Name)
+		if !common.IsHexAddress(hex) {
+			return fmt.Errorf("invalid address: %s", hex)
+		}
+		addr := common.HexToAddress(hex)
+		filter.Address = &addr
+	}
+	inspector, _, closer, err := openPoatcInspector(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	events := inspector.TraceEvents(poatc.TraceEventType(ctx.String(poatcTypeFlag.Name)), filter)
+
+	out := os.Stdout
+	if path := ctx.Args().Get(0); path != "-" {
+		if out, err = os.Create(path); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	if err := printJSON(out, events); err != nil {
+		return err
+	}
+	log.Info("Exported trace events", "count", len(events))
+	return nil
+}
+
+func poatcReplayAnomalies(ctx *cli.Context) error {
+	inspector, db, closer, err := openPoatcInspector(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	from, to := ctx.Uint64(poatcFromFlag.Name), ctx.Uint64(poatcToFlag.Name)
+	if to == 0 {
+		if to, err = headNumber(db); err != nil {
+			return err
+		}
+	}
+	if from > to {
+		return fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	anomalies, err := inspector.ReplayAnomalies(from, to, nil)
+	if err != nil {
+		return err
+	}
+	log.Info("Replayed blocks through anomaly detector", "from", from, "to", to, "anomalies", len(anomalies))
+	return printJSON(os.Stdout, anomalies)
+}
+
+func poatcSignerReport(ctx *cli.Context) error {
+	if ctx.NArg() > 1 {
+		return fmt.Errorf("max 1 argument: %v", ctx.Command.ArgsUsage)
+	}
+	format := ctx.String(poatcFormatFlag.Name)
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+	inspector, db, closer, err := openPoatcInspector(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	from, to := ctx.Uint64(poatcFromFlag.Name), ctx.Uint64(poatcToFlag.Name)
+	if to == 0 {
+		if to, err = headNumber(db); err != nil {
+			return err
+		}
+	}
+	report, err := inspector.SignerReport(from, to)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if ctx.NArg() == 1 {
+		if out, err = os.Create(ctx.Args().Get(0)); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	if format == "csv" {
+		return report.WriteCSV(out)
+	}
+	return printJSON(out, report)
+}
+
+func poatcVerifyProof(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	blob, err := os.ReadFile(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	proof := new(poatc.TraceProof)
+	if err := json.Unmarshal(blob, proof); err != nil {
+		return fmt.Errorf("invalid proof file: %v", err)
+	}
+	inspector, _, closer, err := openPoatcInspector(ctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if err := inspector.VerifyProof(proof); err != nil {
+		return fmt.Errorf("proof verification failed: %v", err)
+	}
+	fmt.Printf("Proof of leaf %x against root %x is valid\n", proof.Leaf, proof.Root)
+	return nil
+}
@@ -17,13 +17,18 @@
 package poatc
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/exp/slices"
 )
 
 // AnomalyType represents different types of anomalies that can be detected
@@ -36,6 +41,11 @@ const (
 	AnomalyHighFrequency                 // Signer appears too frequently
 	AnomalyMissingSigner                 // Expected signer is missing for too long
 	AnomalyTimestampDrift                // Block timestamps show unusual patterns
+	AnomalyGasUsage                      // Gas used deviates from the signer's baseline or its peers
+	AnomalyTxCount                       // Transaction count deviates from the signer's baseline or its peers
+	AnomalyEmptyBlocks                   // Signer seals empty blocks more often than its peers
+	AnomalyOutOfTurn                     // Signer seals out of turn more often than its peers
+	AnomalyBlockDelay                    // Inter-block delay deviates from the signer's baseline or its peers
 )
 
 // AnomalyDetectionConfig contains configuration for anomaly detection
@@ -53,6 +63,13 @@ type AnomalyDetectionConfig struct {
 	// Pattern detection
 	PatternWindowSize   int // Number of blocks to analyze for patterns
 	SuspiciousThreshold int // Number of consecutive blocks by same signer to trigger alert
+
+	// Individual detectors
+	EnableRapidSigning      bool // Detect signers sealing too many blocks in the window
+	EnableSuspiciousPattern bool // Detect consecutive blocks by the same signer
+	EnableTimestampDrift    bool // Detect unusual gaps between block timestamps
+
+	Detectors map[string]DetectorConfig // Per detector switches and settings, keyed by detector name
 }
 
 // DefaultAnomalyDetectionConfig returns a default configuration for anomaly detection
@@ -66,6 +83,10 @@ func DefaultAnomalyDetectionConfig() *AnomalyDetectionConfig {
 		MaxTimestampDrift:   30,  // 30 seconds
 		PatternWindowSize:   20,
 		SuspiciousThreshold: 5,
+
+		EnableRapidSigning:      true,
+		EnableSuspiciousPattern: true,
+		EnableTimestampDrift:    true,
 	}
 }
 
@@ -78,13 +99,7 @@ type AnomalyResult struct {
 	BlockNumber uint64                 `json:"block_number"`
 	Timestamp   time.Time              `json:"timestamp"`
 	Details     map[string]interface{} `json:"details,omitempty"`
-}
-
-// AnomalyDetector handles anomaly detection for POA consensus
-type AnomalyDetector struct {
-	config       *AnomalyDetectionConfig
-	signers      map[common.Address]struct{}
-	blockHistory []BlockRecord
+	Detector    string                 `json:"detector"` // Name of the detector reporting the anomaly
 }
 
 // BlockRecord represents a block for anomaly analysis
@@ -94,290 +109,243 @@ type BlockRecord struct {
 	Signer     common.Address
 	Timestamp  time.Time
 	Difficulty *big.Int
-}
-
-// NewAnomalyDetector creates a new anomaly detector
-func NewAnomalyDetector(config *AnomalyDetectionConfig, signers []common.Address) *AnomalyDetector {
-	if config == nil {
-		config = DefaultAnomalyDetectionConfig()
-	}
+	Signers    []common.Address // Authorized signers when the block was sealed, in ascending order
 
-	signerMap := make(map[common.Address]struct{})
-	for _, signer := range signers {
-		signerMap[signer] = struct{}{}
-	}
-
-	return &AnomalyDetector{
-		config:       config,
-		signers:      signerMap,
-		blockHistory: make([]BlockRecord, 0),
-	}
+	GasUsed  uint64
+	GasLimit uint64
+	TxCount  int           // Number of transactions in the block, negative if unknown
+	InTurn   bool          // Whether the block was sealed in turn
+	Delay    time.Duration // Time elapsed since the parent block, negative if unknown
 }
 
-// AddBlock adds a new block to the analysis history
-func (ad *AnomalyDetector) AddBlock(header *types.Header, signer common.Address) {
+// newBlockRecord creates the record of a block from its header. The transaction
+// count is only known for blocks without transactions, the delay not at all.
+func newBlockRecord(header *types.Header, signer common.Address) BlockRecord {
 	record := BlockRecord{
 		Number:     header.Number.Uint64(),
 		Hash:       header.Hash(),
 		Signer:     signer,
 		Timestamp:  time.Unix(int64(header.Time), 0),
 		Difficulty: header.Difficulty,
+		GasUsed:    header.GasUsed,
+		GasLimit:   header.GasLimit,
+		TxCount:    -1,
+		InTurn:     header.Difficulty != nil && header.Difficulty.Cmp(diffInTurn) == 0,
+		Delay:      -1,
 	}
-
-	ad.blockHistory = append(ad.blockHistory, record)
-
-	// Keep only recent history to avoid memory issues
-	if len(ad.blockHistory) > ad.config.PatternWindowSize*2 {
-		ad.blockHistory = ad.blockHistory[len(ad.blockHistory)-ad.config.PatternWindowSize:]
+	if header.TxHash == types.EmptyTxsHash {
+		record.TxCount = 0
 	}
+	return record
 }
 
-// DetectAnomalies analyzes the block history and detects anomalies
-func (ad *AnomalyDetector) DetectAnomalies() []AnomalyResult {
-	var anomalies []AnomalyResult
-
-	if len(ad.blockHistory) < 3 {
-		return anomalies // Need at least 3 blocks for meaningful analysis
-	}
+// Detector is a single anomaly detection rule. Detectors are fed every sealed
+// block in order and evaluated after each of them; they only ever see blocks,
+// so they hold whatever history their rule needs themselves.
+type Detector interface {
+	// Name returns the unique name the detector is configured and reported by.
+	Name() string
 
-	// Check for rapid signing
-	anomalies = append(anomalies, ad.detectRapidSigning()...)
+	// Observe feeds the detector the next sealed block.
+	Observe(record BlockRecord)
 
-	// Check for suspicious patterns
-	anomalies = append(anomalies, ad.detectSuspiciousPatterns()...)
+	// Evaluate returns the anomalies found in the blocks observed so far.
+	Evaluate() []AnomalyResult
 
-	// Check for frequency anomalies
-	anomalies = append(anomalies, ad.detectFrequencyAnomalies()...)
+	// Config returns the detector specific configuration, for reporting.
+	Config() interface{}
+}
 
-	// Check for timestamp drift
-	anomalies = append(anomalies, ad.detectTimestampDrift()...)
+// DetectorConfig enables or disables a detector and carries its settings.
+type DetectorConfig struct {
+	Enabled  bool            `json:"enabled"`
+	Settings json.RawMessage `json:"settings,omitempty"` // Detector specific settings, decoded by its factory
+}
 
-	// Check for missing signers
-	anomalies = append(anomalies, ad.detectMissingSigners()...)
+// DetectorFactory creates a detector from the anomaly detection config and the
+// detector specific settings, which are empty unless configured.
+type DetectorFactory func(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error)
 
-	return anomalies
-}
+var (
+	detectorRegistry = make(map[string]DetectorFactory)
+	detectorLock     sync.RWMutex
+)
 
-// detectRapidSigning detects if a signer is signing too many blocks in a short time
-func (ad *AnomalyDetector) detectRapidSigning() []AnomalyResult {
-	var anomalies []AnomalyResult
-	signerCounts := make(map[common.Address]int)
+// RegisterDetector adds a detector to the registry the anomaly detector creates
+// its detectors from. Registered detectors run unless disabled in the config.
+// It's meant to be called from init functions and panics on duplicate names.
+func RegisterDetector(name string, factory DetectorFactory) {
+	detectorLock.Lock()
+	defer detectorLock.Unlock()
 
-	// Count blocks per signer in recent history
-	for _, record := range ad.blockHistory {
-		signerCounts[record.Signer]++
+	if _, ok := detectorRegistry[name]; ok {
+		panic(fmt.Sprintf("anomaly detector %q already registered", name))
 	}
+	detectorRegistry[name] = factory
+}
 
-	// Check if any signer exceeds the threshold
-	for signer, count := range signerCounts {
-		if count > ad.config.MaxBlocksPerSigner {
-			severity := "medium"
-			if count > ad.config.MaxBlocksPerSigner*2 {
-				severity = "high"
-			}
-			if count > ad.config.MaxBlocksPerSigner*3 {
-				severity = "critical"
-			}
+// registeredDetectors returns the names of the registered detectors in ascending
+// order.
+func registeredDetectors() []string {
+	detectorLock.RLock()
+	defer detectorLock.RUnlock()
 
-			anomalies = append(anomalies, AnomalyResult{
-				Type:     AnomalyRapidSigning,
-				Severity: severity,
-				Message: fmt.Sprintf("Signer %s has signed %d blocks in recent history (max: %d)",
-					signer.Hex(), count, ad.config.MaxBlocksPerSigner),
-				Signer:      signer,
-				BlockNumber: ad.blockHistory[len(ad.blockHistory)-1].Number,
-				Timestamp:   time.Now(),
-				Details: map[string]interface{}{
-					"blocks_signed": count,
-					"max_allowed":   ad.config.MaxBlocksPerSigner,
-					"window_size":   len(ad.blockHistory),
-				},
-			})
-		}
+	names := make([]string, 0, len(detectorRegistry))
+	for name := range detectorRegistry {
+		names = append(names, name)
 	}
-
-	return anomalies
+	sort.Strings(names)
+	return names
 }
 
-// detectSuspiciousPatterns detects suspicious signing patterns
-func (ad *AnomalyDetector) detectSuspiciousPatterns() []AnomalyResult {
-	var anomalies []AnomalyResult
+// AnomalyDetector handles anomaly detection for POA consensus by running every
+// enabled detector of the registry over the sealed blocks.
+type AnomalyDetector struct {
+	config       *AnomalyDetectionConfig
+	signers      map[common.Address]struct{}
+	blockHistory []BlockRecord
 
-	if len(ad.blockHistory) < ad.config.SuspiciousThreshold {
-		return anomalies
-	}
+	detectors []Detector // Enabled detectors, in name order
+	disabled  []string   // Names of the registered but disabled detectors
 
-	// Check for consecutive blocks by the same signer
-	consecutiveCount := 1
-	currentSigner := ad.blockHistory[len(ad.blockHistory)-1].Signer
+	feed *event.Feed // Feed the detected anomalies are published to, if any
+}
 
-	for i := len(ad.blockHistory) - 2; i >= 0; i-- {
-		if ad.blockHistory[i].Signer == currentSigner {
-			consecutiveCount++
-		} else {
-			break
-		}
+// NewAnomalyDetector creates a new anomaly detector
+func NewAnomalyDetector(config *AnomalyDetectionConfig, signers []common.Address) *AnomalyDetector {
+	if config == nil {
+		config = DefaultAnomalyDetectionConfig()
 	}
 
-	// Debug logging
-	log.Debug("Suspicious pattern detection",
-		"consecutive_count", consecutiveCount,
-		"threshold", ad.config.SuspiciousThreshold,
-		"current_signer", currentSigner.Hex())
+	signerMap := make(map[common.Address]struct{})
+	for _, signer := range signers {
+		signerMap[signer] = struct{}{}
+	}
 
-	if consecutiveCount >= ad.config.SuspiciousThreshold {
-		severity := "low"
-		if consecutiveCount >= ad.config.SuspiciousThreshold*2 {
-			severity = "medium"
+	ad := &AnomalyDetector{
+		config:       config,
+		signers:      signerMap,
+		blockHistory: make([]BlockRecord, 0),
+	}
+	for _, name := range registeredDetectors() {
+		if !config.detectorEnabled(name) {
+			ad.disabled = append(ad.disabled, name)
+			continue
 		}
-		if consecutiveCount >= ad.config.SuspiciousThreshold*3 {
-			severity = "high"
+		detectorLock.RLock()
+		factory := detectorRegistry[name]
+		detectorLock.RUnlock()
+
+		detector, err := factory(config, config.Detectors[name].Settings)
+		if err != nil {
+			log.Warn("Failed to create anomaly detector", "name", name, "err", err)
+			ad.disabled = append(ad.disabled, name)
+			continue
 		}
-
-		anomalies = append(anomalies, AnomalyResult{
-			Type:     AnomalySuspiciousPattern,
-			Severity: severity,
-			Message: fmt.Sprintf("Signer %s has signed %d consecutive blocks",
-				currentSigner.Hex(), consecutiveCount),
-			Signer:      currentSigner,
-			BlockNumber: ad.blockHistory[len(ad.blockHistory)-1].Number,
-			Timestamp:   time.Now(),
-			Details: map[string]interface{}{
-				"consecutive_blocks": consecutiveCount,
-				"threshold":          ad.config.SuspiciousThreshold,
-			},
-		})
+		ad.detectors = append(ad.detectors, detector)
 	}
-
-	return anomalies
+	return ad
 }
 
-// detectFrequencyAnomalies detects if signers appear too frequently or too rarely
-func (ad *AnomalyDetector) detectFrequencyAnomalies() []AnomalyResult {
-	var anomalies []AnomalyResult
-	signerCounts := make(map[common.Address]int)
-	totalBlocks := len(ad.blockHistory)
+// detectorEnabled returns whether the named detector should run. Detectors run
+// unless configured otherwise, the legacy switches still turn off the builtin
+// ones.
+func (config *AnomalyDetectionConfig) detectorEnabled(name string) bool {
+	if cfg, ok := config.Detectors[name]; ok && !cfg.Enabled {
+		return false
+	}
+	switch name {
+	case detectorRapidSigning:
+		return config.EnableRapidSigning
+	case detectorSuspiciousPattern:
+		return config.EnableSuspiciousPattern
+	case detectorTimestampDrift:
+		return config.EnableTimestampDrift
+	}
+	return true
+}
 
-	// Count blocks per signer
-	for _, record := range ad.blockHistory {
-		signerCounts[record.Signer]++
+// UpdateSigners replaces the set of authorized signers blocks are checked against.
+func (ad *AnomalyDetector) UpdateSigners(signers []common.Address) {
+	ad.signers = make(map[common.Address]struct{}, len(signers))
+	for _, signer := range signers {
+		ad.signers[signer] = struct{}{}
 	}
+}
 
-	// Check frequency for each signer
-	for signer := range ad.signers {
-		count := signerCounts[signer]
-		frequency := float64(count) / float64(totalBlocks)
-
-		if frequency > ad.config.MaxSignerFrequency {
-			anomalies = append(anomalies, AnomalyResult{
-				Type:     AnomalyHighFrequency,
-				Severity: "medium",
-				Message: fmt.Sprintf("Signer %s appears too frequently: %.2f%% (max: %.2f%%)",
-					signer.Hex(), frequency*100, ad.config.MaxSignerFrequency*100),
-				Signer:      signer,
-				BlockNumber: ad.blockHistory[len(ad.blockHistory)-1].Number,
-				Timestamp:   time.Now(),
-				Details: map[string]interface{}{
-					"frequency":     frequency,
-					"max_frequency": ad.config.MaxSignerFrequency,
-					"blocks_signed": count,
-					"total_blocks":  totalBlocks,
-				},
-			})
-		}
+// AddBlock adds a new block to the analysis history
+func (ad *AnomalyDetector) AddBlock(header *types.Header, signer common.Address) {
+	ad.AddRecord(newBlockRecord(header, signer))
+}
 
-		if frequency < ad.config.MinSignerFrequency && totalBlocks > 10 {
-			anomalies = append(anomalies, AnomalyResult{
-				Type:     AnomalyMissingSigner,
-				Severity: "low",
-				Message: fmt.Sprintf("Signer %s appears too rarely: %.2f%% (min: %.2f%%)",
-					signer.Hex(), frequency*100, ad.config.MinSignerFrequency*100),
-				Signer:      signer,
-				BlockNumber: ad.blockHistory[len(ad.blockHistory)-1].Number,
-				Timestamp:   time.Now(),
-				Details: map[string]interface{}{
-					"frequency":     frequency,
-					"min_frequency": ad.config.MinSignerFrequency,
-					"blocks_signed": count,
-					"total_blocks":  totalBlocks,
-				},
-			})
+// AddRecord adds a new block record to the analysis history. Blocks at or below
+// the last one added, such as re-executed or reorged blocks, are ignored.
+func (ad *AnomalyDetector) AddRecord(record BlockRecord) {
+	var prev *BlockRecord
+	if len(ad.blockHistory) > 0 {
+		prev = &ad.blockHistory[len(ad.blockHistory)-1]
+		if record.Number <= prev.Number {
+			return
 		}
 	}
+	if record.Delay < 0 && prev != nil && prev.Number+1 == record.Number {
+		record.Delay = record.Timestamp.Sub(prev.Timestamp)
+	}
+	record.Signers = make([]common.Address, 0, len(ad.signers))
+	for signer := range ad.signers {
+		record.Signers = append(record.Signers, signer)
+	}
+	slices.SortFunc(record.Signers, common.Address.Cmp)
 
-	return anomalies
+	ad.blockHistory = append(ad.blockHistory, record)
+
+	// Keep only recent history to avoid memory issues
+	if len(ad.blockHistory) > ad.config.PatternWindowSize*2 {
+		ad.blockHistory = ad.blockHistory[len(ad.blockHistory)-ad.config.PatternWindowSize:]
+	}
+	for _, detector := range ad.detectors {
+		detector.Observe(record)
+	}
 }
 
-// detectTimestampDrift detects unusual timestamp patterns
-func (ad *AnomalyDetector) detectTimestampDrift() []AnomalyResult {
+// DetectAnomalies evaluates every enabled detector and collects the anomalies
+// they found, tagged with the name of the detector. Anomalies the detector did
+// not time carry the timestamp of the latest block, so every node reports them
+// at the same time.
+func (ad *AnomalyDetector) DetectAnomalies() []AnomalyResult {
 	var anomalies []AnomalyResult
 
-	if len(ad.blockHistory) < 2 {
-		return anomalies
+	if len(ad.blockHistory) < 3 {
+		return anomalies // Need at least 3 blocks for meaningful analysis
 	}
-
-	// Check timestamp differences between consecutive blocks
-	for i := 1; i < len(ad.blockHistory); i++ {
-		prevTime := ad.blockHistory[i-1].Timestamp
-		currTime := ad.blockHistory[i].Timestamp
-		diff := currTime.Sub(prevTime)
-
-		// Check if timestamp difference is too large or too small
-		if diff.Seconds() > float64(ad.config.MaxTimestampDrift) {
-			anomalies = append(anomalies, AnomalyResult{
-				Type:     AnomalyTimestampDrift,
-				Severity: "medium",
-				Message: fmt.Sprintf("Large timestamp drift detected: %.2f seconds between blocks %d and %d",
-					diff.Seconds(), ad.blockHistory[i-1].Number, ad.blockHistory[i].Number),
-				BlockNumber: ad.blockHistory[i].Number,
-				Timestamp:   time.Now(),
-				Details: map[string]interface{}{
-					"time_drift_seconds": diff.Seconds(),
-					"max_drift_seconds":  ad.config.MaxTimestampDrift,
-					"prev_block":         ad.blockHistory[i-1].Number,
-					"curr_block":         ad.blockHistory[i].Number,
-				},
-			})
+	latest := ad.blockHistory[len(ad.blockHistory)-1]
+	for _, detector := range ad.detectors {
+		for _, anomaly := range detector.Evaluate() {
+			anomaly.Detector = detector.Name()
+			if anomaly.Timestamp.IsZero() {
+				anomaly.Timestamp = latest.Timestamp
+			}
+			anomalies = append(anomalies, anomaly)
 		}
 	}
-
 	return anomalies
 }
 
-// detectMissingSigners detects if expected signers are missing for too long
-func (ad *AnomalyDetector) detectMissingSigners() []AnomalyResult {
-	var anomalies []AnomalyResult
-
-	if len(ad.blockHistory) < 10 {
-		return anomalies // Need enough history
-	}
+// SetFeed sets the feed the anomalies detected in the sealed blocks are
+// published to.
+func (ad *AnomalyDetector) SetFeed(feed *event.Feed) {
+	ad.feed = feed
+}
 
-	// Check last 10 blocks for missing signers
-	recentSigners := make(map[common.Address]bool)
-	for i := len(ad.blockHistory) - 10; i < len(ad.blockHistory); i++ {
-		recentSigners[ad.blockHistory[i].Signer] = true
+// PublishAnomalies sends the detected anomalies to the subscribers of the feed,
+// one by one.
+func (ad *AnomalyDetector) PublishAnomalies(anomalies []AnomalyResult) {
+	if ad.feed == nil {
+		return
 	}
-
-	// Check if any authorized signer is missing
-	for signer := range ad.signers {
-		if !recentSigners[signer] {
-			anomalies = append(anomalies, AnomalyResult{
-				Type:     AnomalyMissingSigner,
-				Severity: "low",
-				Message: fmt.Sprintf("Signer %s has not signed any blocks in recent history",
-					signer.Hex()),
-				Signer:      signer,
-				BlockNumber: ad.blockHistory[len(ad.blockHistory)-1].Number,
-				Timestamp:   time.Now(),
-				Details: map[string]interface{}{
-					"recent_blocks_checked": 10,
-					"total_signers":         len(ad.signers),
-				},
-			})
-		}
+	for _, anomaly := range anomalies {
+		ad.feed.Send(anomaly)
 	}
-
-	return anomalies
 }
 
 // LogAnomalies logs detected anomalies with appropriate log levels
@@ -420,28 +388,47 @@ func (ad *AnomalyDetector) LogAnomalies(anomalies []AnomalyResult) {
 	}
 }
 
-// GetAnomalyStats returns statistics about detected anomalies
-func (ad *AnomalyDetector) GetAnomalyStats() map[string]interface{} {
+// AnomalyStats are the statistics of the anomalies currently detected.
+type AnomalyStats struct {
+	TotalAnomalies   int                       `json:"total_anomalies"`
+	ByType           map[AnomalyType]int       `json:"by_type"`
+	BySeverity       map[string]int            `json:"by_severity"`
+	ByDetector       map[string]*DetectorStats `json:"by_detector"`
+	BlockHistorySize int                       `json:"block_history_size"`
+}
+
+// DetectorStats are the statistics of a single registered detector.
+type DetectorStats struct {
+	Enabled   bool        `json:"enabled"`
+	Config    interface{} `json:"config,omitempty"` // Settings of the detector, nil if disabled
+	Anomalies int         `json:"anomalies"`
+}
+
+// GetAnomalyStats returns statistics about detected anomalies, overall and per
+// detector.
+func (ad *AnomalyDetector) GetAnomalyStats() *AnomalyStats {
 	anomalies := ad.DetectAnomalies()
 
-	stats := map[string]interface{}{
-		"total_anomalies":    len(anomalies),
-		"by_type":            make(map[AnomalyType]int),
-		"by_severity":        make(map[string]int),
-		"block_history_size": len(ad.blockHistory),
+	stats := &AnomalyStats{
+		TotalAnomalies:   len(anomalies),
+		ByType:           make(map[AnomalyType]int),
+		BySeverity:       make(map[string]int),
+		ByDetector:       make(map[string]*DetectorStats),
+		BlockHistorySize: len(ad.blockHistory),
+	}
+	for _, detector := range ad.detectors {
+		stats.ByDetector[detector.Name()] = &DetectorStats{Enabled: true, Config: detector.Config()}
+	}
+	for _, name := range ad.disabled {
+		stats.ByDetector[name] = &DetectorStats{Enabled: false}
 	}
-
 	for _, anomaly := range anomalies {
-		// Count by type
-		if count, ok := stats["by_type"].(map[AnomalyType]int); ok {
-			count[anomaly.Type]++
-		}
+		stats.ByType[anomaly.Type]++
+		stats.BySeverity[anomaly.Severity]++
 
-		// Count by severity
-		if count, ok := stats["by_severity"].(map[string]int); ok {
-			count[anomaly.Severity]++
+		if detector, ok := stats.ByDetector[anomaly.Detector]; ok {
+			detector.Anomalies++
 		}
 	}
-
 	return stats
 }
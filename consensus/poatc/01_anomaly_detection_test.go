@@ -17,6 +17,7 @@
 package poatc
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 	"time"
@@ -228,6 +229,11 @@ func TestAnomalyDetectorTimestampDrift(t *testing.T) {
 		if anomaly.Type == AnomalyTimestampDrift {
 			timestampDriftFound = true
 			t.Logf("✓ Detected timestamp drift: %s", anomaly.Message)
+
+			// Anomalies are timed by the block they were detected at
+			if want := time.Unix(int64(header4.Time), 0); !anomaly.Timestamp.Equal(want) {
+				t.Errorf("Anomaly time mismatch: have %v, want %v", anomaly.Timestamp, want)
+			}
 			break
 		}
 	}
@@ -267,10 +273,167 @@ func TestAnomalyDetectorStats(t *testing.T) {
 	t.Logf("Anomaly detector stats: %+v", stats)
 
 	// Check that stats contain expected fields
-	if stats["total_anomalies"] == nil {
-		t.Error("Stats should contain total_anomalies field")
+	if stats.BlockHistorySize != 5 {
+		t.Errorf("block history size mismatch: have %d, want 5", stats.BlockHistorySize)
+	}
+}
+
+// signerDetector is a custom detector flagging every block sealed by a given
+// signer, used to test the detector registry.
+type signerDetector struct {
+	config struct {
+		Signer common.Address `json:"signer"`
+	}
+	flagged []BlockRecord
+}
+
+func (d *signerDetector) Name() string        { return "test_signer" }
+func (d *signerDetector) Config() interface{} { return d.config }
+
+func (d *signerDetector) Observe(record BlockRecord) {
+	if record.Signer == d.config.Signer {
+		d.flagged = append(d.flagged, record)
+	}
+}
+
+func (d *signerDetector) Evaluate() []AnomalyResult {
+	var anomalies []AnomalyResult
+	for _, record := range d.flagged {
+		anomalies = append(anomalies, AnomalyResult{Severity: "low", Signer: record.Signer, BlockNumber: record.Number})
+	}
+	return anomalies
+}
+
+func init() {
+	RegisterDetector("test_signer", func(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error) {
+		d := new(signerDetector)
+		return d, decodeSettings("test_signer", settings, &d.config)
+	})
+}
+
+// Tests that detectors registered outside the builtin set run alongside them,
+// that every detector can be switched off and tuned through the config, and
+// that the stats report each detector separately.
+func TestAnomalyDetectorRegistry(t *testing.T) {
+	signers := []common.Address{
+		common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		common.HexToAddress("0x2222222222222222222222222222222222222222"),
+	}
+	settings, _ := json.Marshal(map[string]interface{}{"signer": signers[1]})
+
+	config := DefaultAnomalyDetectionConfig()
+	config.MaxSignerFrequency = 0.1 // Would flag both signers if enabled
+	config.Detectors = map[string]DetectorConfig{
+		"test_signer":     {Enabled: true, Settings: settings},
+		detectorFrequency: {Enabled: false},
+	}
+	detector := NewAnomalyDetector(config, signers)
+
+	baseTime := time.Now()
+	for i := 0; i < 6; i++ {
+		header := &types.Header{
+			Number:     big.NewInt(int64(i + 1)),
+			Time:       uint64(baseTime.Add(time.Duration(i) * 15 * time.Second).Unix()),
+			Difficulty: big.NewInt(1),
+		}
+		detector.AddBlock(header, signers[i%len(signers)])
+	}
+	var custom int
+	for _, anomaly := range detector.DetectAnomalies() {
+		switch anomaly.Detector {
+		case "test_signer":
+			if anomaly.Signer != signers[1] {
+				t.Errorf("custom detector flagged wrong signer: have %x, want %x", anomaly.Signer, signers[1])
+			}
+			custom++
+		case detectorFrequency:
+			t.Errorf("disabled detector reported anomaly: %s", anomaly.Message)
+		}
+	}
+	if custom != 3 {
+		t.Errorf("custom detector anomaly count mismatch: have %d, want 3", custom)
+	}
+	stats := detector.GetAnomalyStats().ByDetector
+	if stats[detectorFrequency].Enabled {
+		t.Errorf("disabled detector reported as enabled")
+	}
+	if have := stats["test_signer"].Anomalies; have != 3 {
+		t.Errorf("custom detector stats mismatch: have %v, want 3", have)
+	}
+	if stats[detectorRapidSigning].Config == nil {
+		t.Errorf("builtin detector config not reported")
+	}
+	// Registering a detector under a taken name must fail loudly
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate detector registration accepted")
+		}
+	}()
+	RegisterDetector(detectorRapidSigning, newRapidSigningDetector)
+}
+
+// Tests that the statistical detectors learn per signer baselines and flag both
+// signers departing from their peers and blocks departing from their signer's
+// own history, while leaving well behaved signers alone.
+func TestStatisticalDetectors(t *testing.T) {
+	signers := []common.Address{
+		common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		common.HexToAddress("0x3333333333333333333333333333333333333333"),
+		common.HexToAddress("0x4444444444444444444444444444444444444444"),
+	}
+	detector := NewAnomalyDetector(DefaultAnomalyDetectionConfig(), signers)
+
+	// Signer 3 seals empty blocks, signer 2 keeps sealing out of turn and signer
+	// 0 seals a block with ten times its usual gas at the end
+	type flag struct {
+		detector string
+		signer   common.Address
+		peers    bool
+	}
+	flagged := make(map[flag]string)
+
+	baseTime := time.Now()
+	for i := 0; i < 80; i++ {
+		signer := signers[i%len(signers)]
+		record := BlockRecord{
+			Number:    uint64(i + 1),
+			Signer:    signer,
+			Timestamp: baseTime.Add(time.Duration(i) * 15 * time.Second),
+			GasUsed:   uint64(1_000_000 + (i%5)*50_000),
+			GasLimit:  30_000_000,
+			TxCount:   40 + i%7,
+			InTurn:    signer != signers[2],
+			Delay:     -1,
+		}
+		if signer == signers[3] {
+			record.GasUsed, record.TxCount = 0, 0
+		}
+		if i == 76 {
+			record.GasUsed = 10_000_000
+		}
+		detector.AddRecord(record)
+
+		for _, anomaly := range detector.DetectAnomalies() {
+			if peers, ok := anomaly.Details["peers"].(bool); ok {
+				flagged[flag{anomaly.Detector, anomaly.Signer, peers}] = anomaly.Severity
+			}
+		}
+	}
+	want := []flag{
+		{"empty_blocks", signers[3], true},
+		{"tx_count", signers[3], true},
+		{"gas_used", signers[3], true},
+		{"out_of_turn", signers[2], true},
+		{"gas_used", signers[0], false},
+	}
+	for _, f := range want {
+		if _, ok := flagged[f]; !ok {
+			t.Errorf("missing anomaly: %+v", f)
+		}
+		delete(flagged, f)
 	}
-	if stats["block_history_size"] == nil {
-		t.Error("Stats should contain block_history_size field")
+	for f, severity := range flagged {
+		t.Errorf("unexpected %s anomaly: %+v", severity, f)
 	}
 }
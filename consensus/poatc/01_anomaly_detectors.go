@@ -0,0 +1,426 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Names of the builtin detectors.
+const (
+	detectorRapidSigning      = "rapid_signing"
+	detectorSuspiciousPattern = "suspicious_pattern"
+	detectorFrequency         = "frequency"
+	detectorTimestampDrift    = "timestamp_drift"
+	detectorMissingSigner     = "missing_signer"
+)
+
+func init() {
+	RegisterDetector(detectorRapidSigning, newRapidSigningDetector)
+	RegisterDetector(detectorSuspiciousPattern, newSuspiciousPatternDetector)
+	RegisterDetector(detectorFrequency, newFrequencyDetector)
+	RegisterDetector(detectorTimestampDrift, newTimestampDriftDetector)
+	RegisterDetector(detectorMissingSigner, newMissingSignerDetector)
+}
+
+// decodeSettings decodes detector specific settings on top of the defaults in v.
+func decodeSettings(name string, settings json.RawMessage, v interface{}) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(settings, v); err != nil {
+		return fmt.Errorf("invalid %s detector settings: %v", name, err)
+	}
+	return nil
+}
+
+// recordWindow is the recent block history a builtin detector analyzes.
+type recordWindow struct {
+	size    int
+	records []BlockRecord
+}
+
+// add appends a block to the window, dropping the oldest ones once the window
+// grew to twice its size.
+func (w *recordWindow) add(record BlockRecord) {
+	w.records = append(w.records, record)
+	if len(w.records) > w.size*2 {
+		w.records = w.records[len(w.records)-w.size:]
+	}
+}
+
+// head returns the number of the last block in the window.
+func (w *recordWindow) head() uint64 {
+	return w.records[len(w.records)-1].Number
+}
+
+// rapidSigningConfig tunes the rapid signing detector.
+type rapidSigningConfig struct {
+	MaxBlocksPerSigner int `json:"max_blocks_per_signer"` // Maximum blocks a signer can sign in the window
+}
+
+// rapidSigningDetector detects signers sealing too many of the recent blocks.
+type rapidSigningDetector struct {
+	config rapidSigningConfig
+	window recordWindow
+}
+
+func newRapidSigningDetector(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error) {
+	d := &rapidSigningDetector{
+		config: rapidSigningConfig{MaxBlocksPerSigner: config.MaxBlocksPerSigner},
+		window: recordWindow{size: config.PatternWindowSize},
+	}
+	return d, decodeSettings(detectorRapidSigning, settings, &d.config)
+}
+
+func (d *rapidSigningDetector) Name() string               { return detectorRapidSigning }
+func (d *rapidSigningDetector) Observe(record BlockRecord) { d.window.add(record) }
+func (d *rapidSigningDetector) Config() interface{}        { return d.config }
+
+// Evaluate detects if a signer is signing too many blocks in a short time
+func (d *rapidSigningDetector) Evaluate() []AnomalyResult {
+	var anomalies []AnomalyResult
+	if len(d.window.records) == 0 {
+		return anomalies
+	}
+	signerCounts := make(map[common.Address]int)
+
+	// Count blocks per signer in recent history
+	for _, record := range d.window.records {
+		signerCounts[record.Signer]++
+	}
+
+	// Check if any signer exceeds the threshold
+	for signer, count := range signerCounts {
+		if count > d.config.MaxBlocksPerSigner {
+			severity := "medium"
+			if count > d.config.MaxBlocksPerSigner*2 {
+				severity = "high"
+			}
+			if count > d.config.MaxBlocksPerSigner*3 {
+				severity = "critical"
+			}
+
+			anomalies = append(anomalies, AnomalyResult{
+				Type:     AnomalyRapidSigning,
+				Severity: severity,
+				Message: fmt.Sprintf("Signer %s has signed %d blocks in recent history (max: %d)",
+					signer.Hex(), count, d.config.MaxBlocksPerSigner),
+				Signer:      signer,
+				BlockNumber: d.window.head(),
+				Details: map[string]interface{}{
+					"blocks_signed": count,
+					"max_allowed":   d.config.MaxBlocksPerSigner,
+					"window_size":   len(d.window.records),
+				},
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// suspiciousPatternConfig tunes the suspicious pattern detector.
+type suspiciousPatternConfig struct {
+	Threshold int `json:"consecutive_block_threshold"` // Consecutive blocks by the same signer to trigger an alert
+}
+
+// suspiciousPatternDetector detects signers sealing long runs of consecutive
+// blocks.
+type suspiciousPatternDetector struct {
+	config suspiciousPatternConfig
+	window recordWindow
+}
+
+func newSuspiciousPatternDetector(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error) {
+	d := &suspiciousPatternDetector{
+		config: suspiciousPatternConfig{Threshold: config.SuspiciousThreshold},
+		window: recordWindow{size: config.PatternWindowSize},
+	}
+	return d, decodeSettings(detectorSuspiciousPattern, settings, &d.config)
+}
+
+func (d *suspiciousPatternDetector) Name() string               { return detectorSuspiciousPattern }
+func (d *suspiciousPatternDetector) Observe(record BlockRecord) { d.window.add(record) }
+func (d *suspiciousPatternDetector) Config() interface{}        { return d.config }
+
+// Evaluate detects suspicious signing patterns
+func (d *suspiciousPatternDetector) Evaluate() []AnomalyResult {
+	var anomalies []AnomalyResult
+
+	history := d.window.records
+	if len(history) == 0 || len(history) < d.config.Threshold {
+		return anomalies
+	}
+
+	// Check for consecutive blocks by the same signer
+	consecutiveCount := 1
+	currentSigner := history[len(history)-1].Signer
+
+	for i := len(history) - 2; i >= 0; i-- {
+		if history[i].Signer == currentSigner {
+			consecutiveCount++
+		} else {
+			break
+		}
+	}
+
+	// Debug logging
+	log.Debug("Suspicious pattern detection",
+		"consecutive_count", consecutiveCount,
+		"threshold", d.config.Threshold,
+		"current_signer", currentSigner.Hex())
+
+	if consecutiveCount >= d.config.Threshold {
+		severity := "low"
+		if consecutiveCount >= d.config.Threshold*2 {
+			severity = "medium"
+		}
+		if consecutiveCount >= d.config.Threshold*3 {
+			severity = "high"
+		}
+
+		anomalies = append(anomalies, AnomalyResult{
+			Type:     AnomalySuspiciousPattern,
+			Severity: severity,
+			Message: fmt.Sprintf("Signer %s has signed %d consecutive blocks",
+				currentSigner.Hex(), consecutiveCount),
+			Signer:      currentSigner,
+			BlockNumber: d.window.head(),
+			Details: map[string]interface{}{
+				"consecutive_blocks": consecutiveCount,
+				"threshold":          d.config.Threshold,
+			},
+		})
+	}
+
+	return anomalies
+}
+
+// frequencyConfig tunes the signer frequency detector.
+type frequencyConfig struct {
+	MaxFrequency float64 `json:"max_frequency"` // Maximum share of the recent blocks a signer may seal (0.0-1.0)
+	MinFrequency float64 `json:"min_frequency"` // Minimum share of the recent blocks a signer should seal (0.0-1.0)
+}
+
+// frequencyDetector detects signers sealing too large or too small a share of
+// the recent blocks.
+type frequencyDetector struct {
+	config frequencyConfig
+	window recordWindow
+}
+
+func newFrequencyDetector(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error) {
+	d := &frequencyDetector{
+		config: frequencyConfig{
+			MaxFrequency: config.MaxSignerFrequency,
+			MinFrequency: config.MinSignerFrequency,
+		},
+		window: recordWindow{size: config.PatternWindowSize},
+	}
+	return d, decodeSettings(detectorFrequency, settings, &d.config)
+}
+
+func (d *frequencyDetector) Name() string               { return detectorFrequency }
+func (d *frequencyDetector) Observe(record BlockRecord) { d.window.add(record) }
+func (d *frequencyDetector) Config() interface{}        { return d.config }
+
+// Evaluate detects if signers appear too frequently or too rarely
+func (d *frequencyDetector) Evaluate() []AnomalyResult {
+	var anomalies []AnomalyResult
+	if len(d.window.records) == 0 {
+		return anomalies
+	}
+	signerCounts := make(map[common.Address]int)
+	totalBlocks := len(d.window.records)
+
+	// Count blocks per signer
+	for _, record := range d.window.records {
+		signerCounts[record.Signer]++
+	}
+
+	// Check frequency for each signer
+	for _, signer := range d.window.records[totalBlocks-1].Signers {
+		count := signerCounts[signer]
+		frequency := float64(count) / float64(totalBlocks)
+
+		if frequency > d.config.MaxFrequency {
+			anomalies = append(anomalies, AnomalyResult{
+				Type:     AnomalyHighFrequency,
+				Severity: "medium",
+				Message: fmt.Sprintf("Signer %s appears too frequently: %.2f%% (max: %.2f%%)",
+					signer.Hex(), frequency*100, d.config.MaxFrequency*100),
+				Signer:      signer,
+				BlockNumber: d.window.head(),
+				Details: map[string]interface{}{
+					"frequency":     frequency,
+					"max_frequency": d.config.MaxFrequency,
+					"blocks_signed": count,
+					"total_blocks":  totalBlocks,
+				},
+			})
+		}
+
+		if frequency < d.config.MinFrequency && totalBlocks > 10 {
+			anomalies = append(anomalies, AnomalyResult{
+				Type:     AnomalyMissingSigner,
+				Severity: "low",
+				Message: fmt.Sprintf("Signer %s appears too rarely: %.2f%% (min: %.2f%%)",
+					signer.Hex(), frequency*100, d.config.MinFrequency*100),
+				Signer:      signer,
+				BlockNumber: d.window.head(),
+				Details: map[string]interface{}{
+					"frequency":     frequency,
+					"min_frequency": d.config.MinFrequency,
+					"blocks_signed": count,
+					"total_blocks":  totalBlocks,
+				},
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// timestampDriftConfig tunes the timestamp drift detector.
+type timestampDriftConfig struct {
+	MaxDrift int64 `json:"max_drift"` // Maximum gap between consecutive blocks in seconds
+}
+
+// timestampDriftDetector detects unusually large gaps between block timestamps.
+type timestampDriftDetector struct {
+	config timestampDriftConfig
+	window recordWindow
+}
+
+func newTimestampDriftDetector(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error) {
+	d := &timestampDriftDetector{
+		config: timestampDriftConfig{MaxDrift: config.MaxTimestampDrift},
+		window: recordWindow{size: config.PatternWindowSize},
+	}
+	return d, decodeSettings(detectorTimestampDrift, settings, &d.config)
+}
+
+func (d *timestampDriftDetector) Name() string               { return detectorTimestampDrift }
+func (d *timestampDriftDetector) Observe(record BlockRecord) { d.window.add(record) }
+func (d *timestampDriftDetector) Config() interface{}        { return d.config }
+
+// Evaluate detects unusual timestamp patterns
+func (d *timestampDriftDetector) Evaluate() []AnomalyResult {
+	var anomalies []AnomalyResult
+
+	history := d.window.records
+	if len(history) < 2 {
+		return anomalies
+	}
+
+	// Check timestamp differences between consecutive blocks
+	for i := 1; i < len(history); i++ {
+		prevTime := history[i-1].Timestamp
+		currTime := history[i].Timestamp
+		diff := currTime.Sub(prevTime)
+
+		// Check if timestamp difference is too large
+		if diff.Seconds() > float64(d.config.MaxDrift) {
+			anomalies = append(anomalies, AnomalyResult{
+				Type:     AnomalyTimestampDrift,
+				Severity: "medium",
+				Message: fmt.Sprintf("Large timestamp drift detected: %.2f seconds between blocks %d and %d",
+					diff.Seconds(), history[i-1].Number, history[i].Number),
+				BlockNumber: history[i].Number,
+				Details: map[string]interface{}{
+					"time_drift_seconds": diff.Seconds(),
+					"max_drift_seconds":  d.config.MaxDrift,
+					"prev_block":         history[i-1].Number,
+					"curr_block":         history[i].Number,
+				},
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// missingSignerConfig tunes the missing signer detector.
+type missingSignerConfig struct {
+	Window int `json:"window"` // Number of recent blocks every signer should have sealed one of
+}
+
+// missingSignerDetector detects authorized signers absent from the recent blocks.
+type missingSignerDetector struct {
+	config missingSignerConfig
+	window recordWindow
+}
+
+func newMissingSignerDetector(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error) {
+	d := &missingSignerDetector{
+		config: missingSignerConfig{Window: 10},
+		window: recordWindow{size: config.PatternWindowSize},
+	}
+	if err := decodeSettings(detectorMissingSigner, settings, &d.config); err != nil {
+		return nil, err
+	}
+	if d.config.Window > d.window.size {
+		d.window.size = d.config.Window
+	}
+	return d, nil
+}
+
+func (d *missingSignerDetector) Name() string               { return detectorMissingSigner }
+func (d *missingSignerDetector) Observe(record BlockRecord) { d.window.add(record) }
+func (d *missingSignerDetector) Config() interface{}        { return d.config }
+
+// Evaluate detects if expected signers are missing for too long
+func (d *missingSignerDetector) Evaluate() []AnomalyResult {
+	var anomalies []AnomalyResult
+
+	history := d.window.records
+	if d.config.Window <= 0 || len(history) < d.config.Window {
+		return anomalies // Need enough history
+	}
+
+	// Check the recent blocks for missing signers
+	recentSigners := make(map[common.Address]bool)
+	for i := len(history) - d.config.Window; i < len(history); i++ {
+		recentSigners[history[i].Signer] = true
+	}
+
+	// Check if any authorized signer is missing
+	signers := history[len(history)-1].Signers
+	for _, signer := range signers {
+		if !recentSigners[signer] {
+			anomalies = append(anomalies, AnomalyResult{
+				Type:     AnomalyMissingSigner,
+				Severity: "low",
+				Message: fmt.Sprintf("Signer %s has not signed any blocks in recent history",
+					signer.Hex()),
+				Signer:      signer,
+				BlockNumber: d.window.head(),
+				Details: map[string]interface{}{
+					"recent_blocks_checked": d.config.Window,
+					"total_signers":         len(signers),
+				},
+			})
+		}
+	}
+
+	return anomalies
+}
@@ -0,0 +1,290 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// madScale turns the median absolute deviation into a consistent estimator of
+// the standard deviation of normally distributed values.
+const madScale = 1.4826
+
+// blockMetric is a per block quantity a statistical detector keeps baselines of.
+type blockMetric struct {
+	name     string
+	anomaly  AnomalyType
+	minScale float64 // Smallest spread deviations are measured against, avoiding division by zero on constant baselines
+	history  bool    // Whether single blocks are compared against the signer's own history, or only its rate against peers
+
+	value func(record BlockRecord) (float64, bool) // Value of the metric for a block, false if unknown
+}
+
+// Metrics of the builtin statistical detectors.
+var blockMetrics = []*blockMetric{
+	{
+		name: "gas_used", anomaly: AnomalyGasUsage, minScale: 21000, history: true,
+		value: func(record BlockRecord) (float64, bool) {
+			return float64(record.GasUsed), true
+		},
+	},
+	{
+		name: "tx_count", anomaly: AnomalyTxCount, minScale: 1, history: true,
+		value: func(record BlockRecord) (float64, bool) {
+			return float64(record.TxCount), record.TxCount >= 0
+		},
+	},
+	{
+		name: "empty_blocks", anomaly: AnomalyEmptyBlocks, minScale: 0.05,
+		value: func(record BlockRecord) (float64, bool) {
+			return indicator(record.TxCount == 0), record.TxCount >= 0
+		},
+	},
+	{
+		name: "out_of_turn", anomaly: AnomalyOutOfTurn, minScale: 0.05,
+		value: func(record BlockRecord) (float64, bool) {
+			return indicator(!record.InTurn), true
+		},
+	},
+	{
+		name: "block_delay", anomaly: AnomalyBlockDelay, minScale: 1, history: true,
+		value: func(record BlockRecord) (float64, bool) {
+			return record.Delay.Seconds(), record.Delay >= 0
+		},
+	},
+}
+
+func init() {
+	for _, metric := range blockMetrics {
+		metric := metric
+		RegisterDetector(metric.name, func(config *AnomalyDetectionConfig, settings json.RawMessage) (Detector, error) {
+			return newStatisticalDetector(metric, settings)
+		})
+	}
+}
+
+// indicator converts a condition into a 0/1 sample, whose average is a rate.
+func indicator(cond bool) float64 {
+	if cond {
+		return 1
+	}
+	return 0
+}
+
+// ewma is an exponentially weighted moving average and variance of a metric.
+type ewma struct {
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	Samples  int     `json:"samples"`
+}
+
+// update folds a new sample into the moving average and variance.
+func (e *ewma) update(value float64, alpha float64) {
+	if e.Samples == 0 {
+		e.Mean = value
+	} else {
+		diff := value - e.Mean
+		incr := alpha * diff
+		e.Mean += incr
+		e.Variance = (1 - alpha) * (e.Variance + diff*incr)
+	}
+	e.Samples++
+}
+
+// statisticalConfig tunes a statistical detector.
+type statisticalConfig struct {
+	Alpha        float64 `json:"alpha"`         // Smoothing factor of the per signer baselines (0.0-1.0)
+	ZThreshold   float64 `json:"z_threshold"`   // Deviation from the signer's own baseline, in standard deviations, to flag a block
+	MADThreshold float64 `json:"mad_threshold"` // Deviation from the peers' median, in scaled MADs, to flag a signer
+	MinSamples   int     `json:"min_samples"`   // Blocks a signer must have sealed before its baseline is trusted
+}
+
+// deviation is a flagged departure of a signer from its expected behaviour.
+type deviation struct {
+	signer   common.Address
+	number   uint64
+	value    float64 // Value of the block or baseline of the signer
+	expected float64 // Baseline of the signer or median of its peers
+	score    float64 // Size of the deviation, in units of the spread
+	peers    bool    // Whether the deviation is from the peers rather than own history
+}
+
+// statisticalDetector keeps a rolling baseline of a block metric for every
+// signer, and flags blocks deviating from their signer's own history by a
+// z-score, as well as signers whose baseline deviates from their peers' by the
+// median absolute deviation.
+type statisticalDetector struct {
+	metric    *blockMetric
+	config    statisticalConfig
+	baselines map[common.Address]*ewma
+
+	last *BlockRecord // Last observed block with a known metric value
+	self *deviation   // Deviation of the last observed block from its signer's history
+}
+
+// newStatisticalDetector creates a statistical detector over the given metric.
+func newStatisticalDetector(metric *blockMetric, settings json.RawMessage) (Detector, error) {
+	d := &statisticalDetector{
+		metric: metric,
+		config: statisticalConfig{
+			Alpha:        0.1,
+			ZThreshold:   3,
+			MADThreshold: 3.5,
+			MinSamples:   10,
+		},
+		baselines: make(map[common.Address]*ewma),
+	}
+	if err := decodeSettings(metric.name, settings, &d.config); err != nil {
+		return nil, err
+	}
+	if d.config.Alpha <= 0 || d.config.Alpha > 1 {
+		return nil, fmt.Errorf("invalid %s detector settings: alpha %v not in (0, 1]", metric.name, d.config.Alpha)
+	}
+	return d, nil
+}
+
+func (d *statisticalDetector) Name() string        { return d.metric.name }
+func (d *statisticalDetector) Config() interface{} { return d.config }
+
+// Observe checks the block against its signer's baseline, then folds it in.
+func (d *statisticalDetector) Observe(record BlockRecord) {
+	value, ok := d.metric.value(record)
+	if !ok {
+		return
+	}
+	d.last, d.self = &record, nil
+
+	baseline := d.baselines[record.Signer]
+	if baseline == nil {
+		baseline = new(ewma)
+		d.baselines[record.Signer] = baseline
+	}
+	if d.metric.history && baseline.Samples >= d.config.MinSamples {
+		spread := math.Max(math.Sqrt(baseline.Variance), d.metric.minScale)
+		if score := math.Abs(value-baseline.Mean) / spread; score > d.config.ZThreshold {
+			d.self = &deviation{
+				signer:   record.Signer,
+				number:   record.Number,
+				value:    value,
+				expected: baseline.Mean,
+				score:    score,
+			}
+		}
+	}
+	baseline.update(value, d.config.Alpha)
+}
+
+// Evaluate reports the last block if it deviated from its signer's history, and
+// its signer if its baseline deviates from the ones of the other signers.
+func (d *statisticalDetector) Evaluate() []AnomalyResult {
+	var anomalies []AnomalyResult
+	if d.last == nil {
+		return anomalies
+	}
+	if d.self != nil {
+		anomalies = append(anomalies, d.result(d.self, d.config.ZThreshold))
+	}
+	if peer := d.peerDeviation(d.last); peer != nil {
+		anomalies = append(anomalies, d.result(peer, d.config.MADThreshold))
+	}
+	return anomalies
+}
+
+// peerDeviation compares the baseline of the block's signer against the median
+// baseline of all authorized signers with enough samples.
+func (d *statisticalDetector) peerDeviation(record *BlockRecord) *deviation {
+	own := d.baselines[record.Signer]
+	if own == nil || own.Samples < d.config.MinSamples {
+		return nil
+	}
+	var means []float64
+	for _, signer := range record.Signers {
+		if baseline := d.baselines[signer]; baseline != nil && baseline.Samples >= d.config.MinSamples {
+			means = append(means, baseline.Mean)
+		}
+	}
+	if len(means) < 3 {
+		return nil // Not enough peers for a meaningful comparison
+	}
+	median := medianOf(means)
+	for i, mean := range means {
+		means[i] = math.Abs(mean - median)
+	}
+	spread := math.Max(madScale*medianOf(means), d.metric.minScale)
+	score := math.Abs(own.Mean-median) / spread
+	if score <= d.config.MADThreshold {
+		return nil
+	}
+	return &deviation{
+		signer:   record.Signer,
+		number:   record.Number,
+		value:    own.Mean,
+		expected: median,
+		score:    score,
+		peers:    true,
+	}
+}
+
+// result converts a deviation into an anomaly, its severity growing with the
+// size of the deviation relative to the threshold.
+func (d *statisticalDetector) result(dev *deviation, threshold float64) AnomalyResult {
+	severity := "low"
+	switch {
+	case dev.score >= threshold*3:
+		severity = "critical"
+	case dev.score >= threshold*2:
+		severity = "high"
+	case dev.score >= threshold*1.5:
+		severity = "medium"
+	}
+	message := fmt.Sprintf("Signer %s %s %.2f deviates from its own baseline %.2f (score %.2f)",
+		dev.signer.Hex(), d.metric.name, dev.value, dev.expected, dev.score)
+	if dev.peers {
+		message = fmt.Sprintf("Signer %s %s baseline %.2f deviates from the peer median %.2f (score %.2f)",
+			dev.signer.Hex(), d.metric.name, dev.value, dev.expected, dev.score)
+	}
+	return AnomalyResult{
+		Type:        d.metric.anomaly,
+		Severity:    severity,
+		Message:     message,
+		Signer:      dev.signer,
+		BlockNumber: dev.number,
+		Details: map[string]interface{}{
+			"metric":    d.metric.name,
+			"value":     dev.value,
+			"expected":  dev.expected,
+			"score":     dev.score,
+			"threshold": threshold,
+			"peers":     dev.peers,
+		},
+	}
+}
+
+// medianOf returns the median of the values, reordering them.
+func medianOf(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
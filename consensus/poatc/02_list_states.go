@@ -0,0 +1,303 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ListState is the standing of an address in the local whitelist and blacklist.
+// An address in good standing is whitelisted, a suspended or banned one is
+// blacklisted, and the others are on neither list.
+type ListState string
+
+const (
+	ListProbation    ListState = "probation"     // Unproven, or recovering from a warning or suspension
+	ListGoodStanding ListState = "good_standing" // Whitelisted thanks to a high reputation
+	ListWarned       ListState = "warned"        // Reputation too low, suspended unless it recovers
+	ListSuspended    ListState = "suspended"     // Temporarily blacklisted
+	ListBanned       ListState = "banned"        // Blacklisted until an operator lifts it
+)
+
+// blacklisted returns whether addresses in the state are on the blacklist.
+func (s ListState) blacklisted() bool {
+	return s == ListSuspended || s == ListBanned
+}
+
+// listTransitions are the state changes the reputation of an address may
+// trigger on its own. A ban is only ever lifted by an operator.
+var listTransitions = map[ListState][]ListState{
+	ListProbation:    {ListGoodStanding, ListWarned},
+	ListGoodStanding: {ListProbation, ListWarned},
+	ListWarned:       {ListProbation, ListSuspended, ListBanned},
+	ListSuspended:    {ListProbation},
+}
+
+// manualListTransitions are the state changes an operator may make. Whitelisting
+// a blacklisted address is refused, it has to be removed from the blacklist first.
+var manualListTransitions = map[ListState][]ListState{
+	ListProbation:    {ListGoodStanding, ListBanned},
+	ListGoodStanding: {ListGoodStanding, ListProbation, ListBanned},
+	ListWarned:       {ListGoodStanding, ListBanned},
+	ListSuspended:    {ListProbation, ListBanned},
+	ListBanned:       {ListProbation, ListBanned},
+}
+
+// ListStanding is the state of an address in the whitelist/blacklist state
+// machine.
+type ListStanding struct {
+	State       ListState `json:"state"`
+	Since       uint64    `json:"since"`       // Block at which the address entered the state
+	Suspensions int       `json:"suspensions"` // Number of times the address was suspended
+	Manual      bool      `json:"manual"`      // Set by an operator, exempt from automatic transitions
+}
+
+// ListTransition is emitted whenever an address changes state.
+type ListTransition struct {
+	Address common.Address `json:"address"`
+	From    ListState      `json:"from"`
+	To      ListState      `json:"to"`
+	Number  uint64         `json:"number"` // Latest block seen when the transition happened
+	Reason  string         `json:"reason"`
+	Manual  bool           `json:"manual"`
+}
+
+// checkListTransition validates moving an address from its standing to a new
+// state. Automatic transitions never override the decision of an operator.
+func checkListTransition(standing *ListStanding, to ListState, manual bool) error {
+	allowed := listTransitions
+	if manual {
+		allowed = manualListTransitions
+	} else if standing.Manual {
+		return fmt.Errorf("%w: %s set by an operator", errInvalidListTransition, standing.State)
+	}
+	for _, state := range allowed[standing.State] {
+		if state == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", errInvalidListTransition, standing.State, to)
+}
+
+// SetIntegrationComponents sets the tracing system and the feed the state
+// transitions are reported to.
+func (wbm *WhitelistBlacklistManager) SetIntegrationComponents(tracingSystem *TracingSystem, feed *event.Feed) {
+	wbm.mutex.Lock()
+	defer wbm.mutex.Unlock()
+
+	wbm.tracingSystem = tracingSystem
+	wbm.feed = feed
+}
+
+// GetStanding returns the state of an address.
+func (wbm *WhitelistBlacklistManager) GetStanding(address common.Address) ListStanding {
+	wbm.mutex.Lock()
+	defer wbm.mutex.Unlock()
+
+	return *wbm.standing(address)
+}
+
+// GetStandings returns a copy of the states of all the known addresses.
+func (wbm *WhitelistBlacklistManager) GetStandings() map[common.Address]ListStanding {
+	wbm.mutex.Lock()
+	defer wbm.mutex.Unlock()
+
+	// Make sure the addresses only known from the lists are included
+	for addr := range wbm.whitelist {
+		wbm.standing(addr)
+	}
+	for addr := range wbm.blacklist {
+		wbm.standing(addr)
+	}
+	result := make(map[common.Address]ListStanding, len(wbm.standings))
+	for addr, standing := range wbm.standings {
+		result[addr] = *standing
+	}
+	return result
+}
+
+// UpdateStanding feeds the reputation of an address at the given block to the
+// state machine, moving the address between the lists if the score crossed a
+// threshold by more than the hysteresis band and the address dwelt long enough
+// in its current state. It returns the transition made, if any.
+func (wbm *WhitelistBlacklistManager) UpdateStanding(address common.Address, score, low, high float64, number uint64) *ListTransition {
+	wbm.mutex.Lock()
+	transition := wbm.updateStanding(address, score, low, high, number)
+	wbm.mutex.Unlock()
+
+	wbm.emit(transition)
+	return transition
+}
+
+// updateStanding implements UpdateStanding, the caller must hold the lock.
+func (wbm *WhitelistBlacklistManager) updateStanding(address common.Address, score, low, high float64, number uint64) *ListTransition {
+	if number > wbm.head {
+		wbm.head = number
+	}
+	standing := wbm.standing(address)
+	if standing.Manual {
+		return nil
+	}
+	var dwell uint64
+	if number > standing.Since {
+		dwell = number - standing.Since
+	}
+	band := wbm.config.HysteresisBand
+
+	var (
+		to     ListState
+		reason string
+	)
+	switch standing.State {
+	case ListProbation:
+		switch {
+		case score < low-band:
+			to, reason = ListWarned, fmt.Sprintf("Reputation %.2f below %.2f", score, low-band)
+		case score >= high+band && dwell >= wbm.config.ProbationBlocks:
+			to, reason = ListGoodStanding, fmt.Sprintf("Reputation %.2f above %.2f after %d blocks of probation", score, high+band, dwell)
+		}
+	case ListGoodStanding:
+		switch {
+		case score < low-band:
+			to, reason = ListWarned, fmt.Sprintf("Reputation %.2f below %.2f", score, low-band)
+		case score < high-band:
+			to, reason = ListProbation, fmt.Sprintf("Reputation %.2f below %.2f", score, high-band)
+		}
+	case ListWarned:
+		switch {
+		case score >= low+band:
+			to, reason = ListProbation, fmt.Sprintf("Reputation %.2f recovered above %.2f", score, low+band)
+		case score < low-band && dwell >= wbm.config.WarningBlocks:
+			to, reason = ListSuspended, fmt.Sprintf("Reputation %.2f below %.2f for %d blocks", score, low-band, dwell)
+			if standing.Suspensions >= wbm.config.MaxSuspensions {
+				to, reason = ListBanned, fmt.Sprintf("%s after %d suspensions", reason, standing.Suspensions)
+			}
+		}
+	case ListSuspended:
+		if score >= low+band && dwell >= wbm.config.SuspensionBlocks {
+			to, reason = ListProbation, fmt.Sprintf("Reputation %.2f recovered above %.2f after %d blocks of suspension", score, low+band, dwell)
+		}
+	}
+	if to == "" {
+		return nil
+	}
+	if err := checkListTransition(standing, to, false); err != nil {
+		log.Error("Rejected whitelist/blacklist transition", "address", address, "err", err)
+		return nil
+	}
+	// Don't push out entries of a full list, wait for a slot instead
+	if limit := wbm.config.MaxEntries; limit > 0 {
+		if _, ok := wbm.whitelist[address]; !ok && to == ListGoodStanding && len(wbm.whitelist) >= limit {
+			return nil
+		}
+		if _, ok := wbm.blacklist[address]; !ok && to.blacklisted() && len(wbm.blacklist) >= limit {
+			return nil
+		}
+	}
+	transition := wbm.move(address, to, false, reason)
+	wbm.saveToPersistence()
+	return transition
+}
+
+// standing returns the state of an address, deriving it from the lists if the
+// address has none yet, like entries persisted before the state machine existed.
+// The caller must hold the lock.
+func (wbm *WhitelistBlacklistManager) standing(address common.Address) *ListStanding {
+	if standing, ok := wbm.standings[address]; ok {
+		return standing
+	}
+	standing := &ListStanding{State: ListProbation, Since: wbm.head}
+	if entry, ok := wbm.blacklist[address]; ok {
+		standing.State, standing.Manual = ListSuspended, entry.AddedBy != (common.Address{})
+		if standing.Manual {
+			standing.State = ListBanned
+		}
+	} else if entry, ok := wbm.whitelist[address]; ok {
+		standing.State, standing.Manual = ListGoodStanding, entry.AddedBy != (common.Address{})
+	}
+	wbm.standings[address] = standing
+	return standing
+}
+
+// move sets the state of an address and brings the lists in line with it. Entries
+// already in the right list are kept, so callers may add them with their own
+// details beforehand. The transition must have been validated by the caller.
+func (wbm *WhitelistBlacklistManager) move(address common.Address, to ListState, manual bool, reason string) *ListTransition {
+	standing := wbm.standing(address)
+	from := standing.State
+
+	if to != ListGoodStanding {
+		delete(wbm.whitelist, address)
+	} else if _, ok := wbm.whitelist[address]; !ok {
+		wbm.whitelist[address] = WhitelistEntry{Address: address, AddedAt: wbm.now(), Reason: reason, IsActive: true}
+	}
+	if !to.blacklisted() {
+		delete(wbm.blacklist, address)
+	} else if _, ok := wbm.blacklist[address]; !ok {
+		wbm.blacklist[address] = BlacklistEntry{Address: address, AddedAt: wbm.now(), Reason: reason, IsActive: true}
+	}
+	if to == ListSuspended && from != ListSuspended {
+		standing.Suspensions++
+	}
+	standing.Manual = manual
+	if from == to {
+		return nil
+	}
+	standing.State, standing.Since = to, wbm.head
+	return &ListTransition{
+		Address: address,
+		From:    from,
+		To:      to,
+		Number:  wbm.head,
+		Reason:  reason,
+		Manual:  manual,
+	}
+}
+
+// emit reports state transitions to the logs, the tracing system and the
+// subscribers. It must be called without holding the lock.
+func (wbm *WhitelistBlacklistManager) emit(transitions ...*ListTransition) {
+	wbm.mutex.RLock()
+	tracingSystem, feed := wbm.tracingSystem, wbm.feed
+	wbm.mutex.RUnlock()
+
+	for _, transition := range transitions {
+		if transition == nil {
+			continue
+		}
+		log.Info("Whitelist/blacklist state changed", "address", transition.Address, "from", transition.From,
+			"to", transition.To, "manual", transition.Manual, "reason", transition.Reason)
+
+		if tracingSystem != nil {
+			tracingSystem.Trace(TraceEventWhitelistBlacklist, TraceLevelBasic, transition.Number, transition.Address,
+				fmt.Sprintf("Address %s moved from %s to %s", transition.Address.Hex(), transition.From, transition.To),
+				map[string]interface{}{
+					"from":   string(transition.From),
+					"to":     string(transition.To),
+					"reason": transition.Reason,
+					"manual": transition.Manual,
+				})
+		}
+		if feed != nil {
+			feed.Send(*transition)
+		}
+	}
+}
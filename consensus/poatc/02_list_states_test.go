@@ -0,0 +1,159 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// newTestListManager creates a whitelist/blacklist manager with short dwell
+// times and no persistence.
+func newTestListManager() *WhitelistBlacklistManager {
+	config := DefaultWhitelistBlacklistConfig()
+	config.EnableWhitelist, config.EnableBlacklist = true, true
+	config.PersistencePath = ""
+	config.ProbationBlocks = 10
+	config.WarningBlocks = 5
+	config.SuspensionBlocks = 20
+	config.MaxSuspensions = 1
+	return NewWhitelistBlacklistManager(config, nil)
+}
+
+// Tests that the reputation moves an address through the states only once it
+// crossed a threshold by more than the hysteresis band and dwelt long enough in
+// its state, and that the lists follow the states.
+func TestListStateMachine(t *testing.T) {
+	const low, high = 3.0, 7.0
+
+	manager := newTestListManager()
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	tests := []struct {
+		number uint64
+		score  float64
+		state  ListState
+	}{
+		{1, 7.2, ListProbation},     // Above the threshold, but within the band
+		{5, 8.0, ListProbation},     // Above the band, but still on probation
+		{11, 8.0, ListGoodStanding}, // Probation served
+		{12, 6.8, ListGoodStanding}, // Below the threshold, but within the band
+		{13, 7.6, ListGoodStanding}, // Back above the threshold
+		{14, 6.4, ListProbation},    // Below the band, whitelisting lost
+		{15, 2.4, ListWarned},       // Below the low band
+		{19, 2.0, ListWarned},       // Still within the warning period
+		{20, 2.0, ListSuspended},    // Warning period over
+		{30, 9.0, ListSuspended},    // Recovered, but still within the suspension
+		{40, 9.0, ListProbation},    // Suspension served
+		{41, 2.0, ListWarned},       // Relapse
+		{42, 3.2, ListWarned},       // Within the band, no flapping
+		{46, 2.0, ListBanned},       // Suspended once already, banned
+		{100, 10.0, ListBanned},     // Bans are only lifted by an operator
+	}
+	for i, tt := range tests {
+		manager.UpdateStanding(addr, tt.score, low, high, tt.number)
+		standing := manager.GetStanding(addr)
+		if standing.State != tt.state {
+			t.Fatalf("test %d: state mismatch: have %s, want %s", i, standing.State, tt.state)
+		}
+		if whitelisted := manager.IsWhitelisted(addr); whitelisted != (tt.state == ListGoodStanding) {
+			t.Errorf("test %d: whitelisted mismatch: have %v", i, whitelisted)
+		}
+		if blacklisted := manager.IsBlacklisted(addr); blacklisted != tt.state.blacklisted() {
+			t.Errorf("test %d: blacklisted mismatch: have %v", i, blacklisted)
+		}
+	}
+	if standing := manager.GetStanding(addr); standing.Suspensions != 1 || standing.Since != 46 {
+		t.Errorf("standing mismatch: have %+v", standing)
+	}
+	// Lifting the ban puts the address back on probation
+	if err := manager.RemoveFromBlacklist(addr); err != nil {
+		t.Fatalf("failed to lift ban: %v", err)
+	}
+	if standing := manager.GetStanding(addr); standing.State != ListProbation || standing.Manual {
+		t.Errorf("standing mismatch after lifting ban: have %+v", standing)
+	}
+}
+
+// Tests that operators can't contradict the state machine, and that the state
+// machine doesn't override the operators.
+func TestListStateManualTransitions(t *testing.T) {
+	const low, high = 3.0, 7.0
+
+	manager := newTestListManager()
+	feed := new(event.Feed)
+	manager.SetIntegrationComponents(nil, feed)
+
+	transitions := make(chan ListTransition, 16)
+	sub := feed.Subscribe(transitions)
+	defer sub.Unsubscribe()
+
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
+
+	// Suspend the address, which can't be whitelisted anymore
+	manager.UpdateStanding(addr, 2.0, low, high, 1)
+	manager.UpdateStanding(addr, 2.0, low, high, 6)
+	if err := manager.AddToWhitelist(addr, admin, "", nil); !errors.Is(err, errInvalidListTransition) {
+		t.Fatalf("whitelisting suspended address error mismatch: have %v, want %v", err, errInvalidListTransition)
+	}
+	// Operators may lift the suspension and whitelist the address then
+	if err := manager.RemoveFromBlacklist(addr); err != nil {
+		t.Fatalf("failed to lift suspension: %v", err)
+	}
+	if err := manager.AddToWhitelist(addr, admin, "trusted", nil); err != nil {
+		t.Fatalf("failed to whitelist: %v", err)
+	}
+	// A manually whitelisted address is not demoted by its reputation
+	manager.UpdateStanding(addr, 1.0, low, high, 7)
+	if standing := manager.GetStanding(addr); standing.State != ListGoodStanding || !standing.Manual {
+		t.Fatalf("manual standing overridden: have %+v", standing)
+	}
+	// Blacklisting bans the address regardless of its reputation
+	if err := manager.AddToBlacklist(addr, admin, "misbehaving", nil); err != nil {
+		t.Fatalf("failed to blacklist: %v", err)
+	}
+	manager.UpdateStanding(addr, 10.0, low, high, 100)
+	if manager.IsWhitelisted(addr) || !manager.IsBlacklisted(addr) {
+		t.Fatalf("address not banned")
+	}
+	want := []ListTransition{
+		{Address: addr, From: ListProbation, To: ListWarned, Number: 1},
+		{Address: addr, From: ListWarned, To: ListSuspended, Number: 6},
+		{Address: addr, From: ListSuspended, To: ListProbation, Number: 6},
+		{Address: addr, From: ListProbation, To: ListGoodStanding, Number: 6, Manual: true},
+		{Address: addr, From: ListGoodStanding, To: ListBanned, Number: 7, Manual: true},
+	}
+	for i, w := range want {
+		select {
+		case have := <-transitions:
+			if have.Address != w.Address || have.From != w.From || have.To != w.To || have.Number != w.Number || have.Manual != w.Manual {
+				t.Errorf("transition %d mismatch: have %+v, want %+v", i, have, w)
+			}
+		default:
+			t.Fatalf("transition %d missing", i)
+		}
+	}
+	select {
+	case have := <-transitions:
+		t.Errorf("unexpected transition: %+v", have)
+	default:
+	}
+}
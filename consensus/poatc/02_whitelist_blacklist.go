@@ -25,6 +25,8 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -34,6 +36,15 @@ type WhitelistBlacklistConfig struct {
 	EnableBlacklist bool   `json:"enable_blacklist"` // Enable blacklist checking
 	WhitelistMode   bool   `json:"whitelist_mode"`   // If true, only whitelisted signers can sign; if false, whitelist is just for monitoring
 	PersistencePath string `json:"persistence_path"` // Path to store whitelist/blacklist data
+
+	DefaultExpiration time.Duration `json:"default_expiration"` // Lifetime of reputation-forced blacklistings (0 = never expire)
+	MaxEntries        int           `json:"max_entries"`        // Maximum number of entries per list (0 = unlimited)
+
+	ProbationBlocks  uint64  `json:"probation_blocks"`  // Blocks on probation before an address may be whitelisted
+	WarningBlocks    uint64  `json:"warning_blocks"`    // Blocks a warned address gets to recover before it is suspended
+	SuspensionBlocks uint64  `json:"suspension_blocks"` // Blocks a suspended address stays blacklisted at least
+	MaxSuspensions   int     `json:"max_suspensions"`   // Suspensions after which the next one is a ban
+	HysteresisBand   float64 `json:"hysteresis_band"`   // Reputation margin around the thresholds before changing state
 }
 
 // DefaultWhitelistBlacklistConfig returns a default configuration
@@ -43,16 +54,33 @@ func DefaultWhitelistBlacklistConfig() *WhitelistBlacklistConfig {
 		EnableBlacklist: false, // Disabled by default
 		WhitelistMode:   false, // Monitoring mode by default
 		PersistencePath: "./whitelist_blacklist.json",
+
+		DefaultExpiration: 24 * time.Hour,
+		MaxEntries:        0,
+
+		ProbationBlocks:  100,
+		WarningBlocks:    100,
+		SuspensionBlocks: 1000,
+		MaxSuspensions:   3,
+		HysteresisBand:   0.5,
 	}
 }
 
-// WhitelistBlacklistManager handles whitelist and blacklist management
+// WhitelistBlacklistManager handles whitelist and blacklist management. The lists
+// are driven by a state machine tracking the standing of every address, so the
+// automatic management and the operators can't put an address in both lists.
 type WhitelistBlacklistManager struct {
 	config          *WhitelistBlacklistConfig
 	whitelist       map[common.Address]WhitelistEntry
 	blacklist       map[common.Address]BlacklistEntry
+	standings       map[common.Address]*ListStanding
+	head            uint64 // Latest block seen, at which the manual transitions happen
 	mutex           sync.RWMutex
 	persistencePath string
+	clock           mclock.Clock // Source of time of the entries and their expiry
+
+	tracingSystem *TracingSystem
+	feed          *event.Feed // Feed of the state transitions, if any
 }
 
 // WhitelistEntry represents an entry in the whitelist
@@ -79,12 +107,14 @@ type BlacklistEntry struct {
 type PersistenceData struct {
 	Whitelist   map[common.Address]WhitelistEntry `json:"whitelist"`
 	Blacklist   map[common.Address]BlacklistEntry `json:"blacklist"`
+	Standings   map[common.Address]*ListStanding  `json:"standings,omitempty"`
 	Config      *WhitelistBlacklistConfig         `json:"config"`
 	LastUpdated time.Time                         `json:"last_updated"`
 }
 
-// NewWhitelistBlacklistManager creates a new whitelist/blacklist manager
-func NewWhitelistBlacklistManager(config *WhitelistBlacklistConfig) *WhitelistBlacklistManager {
+// NewWhitelistBlacklistManager creates a new whitelist/blacklist manager, timing
+// the entries and their expiry with the given clock or the system clock if nil.
+func NewWhitelistBlacklistManager(config *WhitelistBlacklistConfig, clock mclock.Clock) *WhitelistBlacklistManager {
 	if config == nil {
 		config = DefaultWhitelistBlacklistConfig()
 	}
@@ -93,7 +123,9 @@ func NewWhitelistBlacklistManager(config *WhitelistBlacklistConfig) *WhitelistBl
 		config:          config,
 		whitelist:       make(map[common.Address]WhitelistEntry),
 		blacklist:       make(map[common.Address]BlacklistEntry),
+		standings:       make(map[common.Address]*ListStanding),
 		persistencePath: config.PersistencePath,
+		clock:           orSystemClock(clock),
 	}
 
 	// Load existing data if available
@@ -102,19 +134,34 @@ func NewWhitelistBlacklistManager(config *WhitelistBlacklistConfig) *WhitelistBl
 	return manager
 }
 
-// AddToWhitelist adds an address to the whitelist
+// now returns the current time of the manager's clock.
+func (wbm *WhitelistBlacklistManager) now() time.Time {
+	return wallTime(wbm.clock)
+}
+
+// AddToWhitelist adds an address to the whitelist, putting it in good standing
+// until an operator removes it. Blacklisted addresses can't be whitelisted.
 func (wbm *WhitelistBlacklistManager) AddToWhitelist(address common.Address, addedBy common.Address, reason string, expiresAt *time.Time) error {
 	wbm.mutex.Lock()
-	defer wbm.mutex.Unlock()
+	transition, err := wbm.addToWhitelist(address, addedBy, reason, expiresAt)
+	wbm.mutex.Unlock()
+
+	wbm.emit(transition)
+	return err
+}
 
-	// Check if address is in blacklist
-	if _, exists := wbm.blacklist[address]; exists {
-		return fmt.Errorf("address %s is in blacklist, cannot add to whitelist", address.Hex())
+// addToWhitelist implements AddToWhitelist, the caller must hold the lock.
+func (wbm *WhitelistBlacklistManager) addToWhitelist(address common.Address, addedBy common.Address, reason string, expiresAt *time.Time) (*ListTransition, error) {
+	if err := checkListTransition(wbm.standing(address), ListGoodStanding, true); err != nil {
+		return nil, fmt.Errorf("address %s cannot be whitelisted: %w", address.Hex(), err)
+	}
+	if _, exists := wbm.whitelist[address]; !exists && wbm.config.MaxEntries > 0 && len(wbm.whitelist) >= wbm.config.MaxEntries {
+		return nil, fmt.Errorf("whitelist is full (%d entries)", wbm.config.MaxEntries)
 	}
 
 	entry := WhitelistEntry{
 		Address:   address,
-		AddedAt:   time.Now(),
+		AddedAt:   wbm.now(),
 		AddedBy:   addedBy,
 		Reason:    reason,
 		IsActive:  true,
@@ -124,40 +171,65 @@ func (wbm *WhitelistBlacklistManager) AddToWhitelist(address common.Address, add
 	wbm.whitelist[address] = entry
 	log.Info("Address added to whitelist", "address", address.Hex(), "added_by", addedBy.Hex(), "reason", reason)
 
+	transition := wbm.move(address, ListGoodStanding, true, reason)
+
 	// Save to persistence
-	return wbm.saveToPersistence()
+	return transition, wbm.saveToPersistence()
 }
 
-// RemoveFromWhitelist removes an address from the whitelist
+// RemoveFromWhitelist removes an address from the whitelist, putting it back on
+// probation under the automatic management.
 func (wbm *WhitelistBlacklistManager) RemoveFromWhitelist(address common.Address) error {
 	wbm.mutex.Lock()
-	defer wbm.mutex.Unlock()
+	transition, err := wbm.removeFromWhitelist(address)
+	wbm.mutex.Unlock()
+
+	wbm.emit(transition)
+	return err
+}
 
+// removeFromWhitelist implements RemoveFromWhitelist, the caller must hold the lock.
+func (wbm *WhitelistBlacklistManager) removeFromWhitelist(address common.Address) (*ListTransition, error) {
 	if _, exists := wbm.whitelist[address]; !exists {
-		return fmt.Errorf("address %s not found in whitelist", address.Hex())
+		return nil, fmt.Errorf("address %s not found in whitelist", address.Hex())
+	}
+	if err := checkListTransition(wbm.standing(address), ListProbation, true); err != nil {
+		return nil, fmt.Errorf("address %s cannot be removed from whitelist: %w", address.Hex(), err)
 	}
-
-	delete(wbm.whitelist, address)
 	log.Info("Address removed from whitelist", "address", address.Hex())
 
+	transition := wbm.move(address, ListProbation, false, "Removed from whitelist")
+
 	// Save to persistence
-	return wbm.saveToPersistence()
+	return transition, wbm.saveToPersistence()
 }
 
-// AddToBlacklist adds an address to the blacklist
+// AddToBlacklist adds an address to the blacklist, banning it until an operator
+// removes it. The address is removed from the whitelist if needed.
 func (wbm *WhitelistBlacklistManager) AddToBlacklist(address common.Address, addedBy common.Address, reason string, expiresAt *time.Time) error {
 	wbm.mutex.Lock()
-	defer wbm.mutex.Unlock()
+	transition, err := wbm.addToBlacklist(address, addedBy, reason, expiresAt)
+	wbm.mutex.Unlock()
 
-	// Remove from whitelist if exists
+	wbm.emit(transition)
+	return err
+}
+
+// addToBlacklist implements AddToBlacklist, the caller must hold the lock.
+func (wbm *WhitelistBlacklistManager) addToBlacklist(address common.Address, addedBy common.Address, reason string, expiresAt *time.Time) (*ListTransition, error) {
+	if err := checkListTransition(wbm.standing(address), ListBanned, true); err != nil {
+		return nil, fmt.Errorf("address %s cannot be blacklisted: %w", address.Hex(), err)
+	}
+	if _, exists := wbm.blacklist[address]; !exists && wbm.config.MaxEntries > 0 && len(wbm.blacklist) >= wbm.config.MaxEntries {
+		return nil, fmt.Errorf("blacklist is full (%d entries)", wbm.config.MaxEntries)
+	}
 	if _, exists := wbm.whitelist[address]; exists {
-		delete(wbm.whitelist, address)
 		log.Info("Address removed from whitelist due to blacklist addition", "address", address.Hex())
 	}
 
 	entry := BlacklistEntry{
 		Address:   address,
-		AddedAt:   time.Now(),
+		AddedAt:   wbm.now(),
 		AddedBy:   addedBy,
 		Reason:    reason,
 		IsActive:  true,
@@ -167,24 +239,37 @@ func (wbm *WhitelistBlacklistManager) AddToBlacklist(address common.Address, add
 	wbm.blacklist[address] = entry
 	log.Info("Address added to blacklist", "address", address.Hex(), "added_by", addedBy.Hex(), "reason", reason)
 
+	transition := wbm.move(address, ListBanned, true, reason)
+
 	// Save to persistence
-	return wbm.saveToPersistence()
+	return transition, wbm.saveToPersistence()
 }
 
-// RemoveFromBlacklist removes an address from the blacklist
+// RemoveFromBlacklist removes an address from the blacklist, putting it back on
+// probation under the automatic management.
 func (wbm *WhitelistBlacklistManager) RemoveFromBlacklist(address common.Address) error {
 	wbm.mutex.Lock()
-	defer wbm.mutex.Unlock()
+	transition, err := wbm.removeFromBlacklist(address)
+	wbm.mutex.Unlock()
+
+	wbm.emit(transition)
+	return err
+}
 
+// removeFromBlacklist implements RemoveFromBlacklist, the caller must hold the lock.
+func (wbm *WhitelistBlacklistManager) removeFromBlacklist(address common.Address) (*ListTransition, error) {
 	if _, exists := wbm.blacklist[address]; !exists {
-		return fmt.Errorf("address %s not found in blacklist", address.Hex())
+		return nil, fmt.Errorf("address %s not found in blacklist", address.Hex())
+	}
+	if err := checkListTransition(wbm.standing(address), ListProbation, true); err != nil {
+		return nil, fmt.Errorf("address %s cannot be removed from blacklist: %w", address.Hex(), err)
 	}
-
-	delete(wbm.blacklist, address)
 	log.Info("Address removed from blacklist", "address", address.Hex())
 
+	transition := wbm.move(address, ListProbation, false, "Removed from blacklist")
+
 	// Save to persistence
-	return wbm.saveToPersistence()
+	return transition, wbm.saveToPersistence()
 }
 
 // IsWhitelisted checks if an address is in the whitelist
@@ -198,7 +283,7 @@ func (wbm *WhitelistBlacklistManager) IsWhitelisted(address common.Address) bool
 	}
 
 	// Check expiration
-	if entry.ExpiresAt != nil && time.Now().After(*entry.ExpiresAt) {
+	if entry.ExpiresAt != nil && wbm.now().After(*entry.ExpiresAt) {
 		return false
 	}
 
@@ -216,7 +301,7 @@ func (wbm *WhitelistBlacklistManager) IsBlacklisted(address common.Address) bool
 	}
 
 	// Check expiration
-	if entry.ExpiresAt != nil && time.Now().After(*entry.ExpiresAt) {
+	if entry.ExpiresAt != nil && wbm.now().After(*entry.ExpiresAt) {
 		return false
 	}
 
@@ -272,8 +357,29 @@ func (wbm *WhitelistBlacklistManager) GetBlacklist() map[common.Address]Blacklis
 	return result
 }
 
+// ListStats are the statistics of the whitelist and blacklist.
+type ListStats struct {
+	Config    ListStatsConfig `json:"config"`
+	Whitelist ListCounts      `json:"whitelist"`
+	Blacklist ListCounts      `json:"blacklist"`
+}
+
+// ListStatsConfig is the configuration the lists are enforced with.
+type ListStatsConfig struct {
+	EnableWhitelist bool `json:"enable_whitelist"`
+	EnableBlacklist bool `json:"enable_blacklist"`
+	WhitelistMode   bool `json:"whitelist_mode"`
+}
+
+// ListCounts are the number of entries in a list.
+type ListCounts struct {
+	Total   int `json:"total"`
+	Active  int `json:"active"`
+	Expired int `json:"expired"`
+}
+
 // GetStats returns statistics about whitelist and blacklist
-func (wbm *WhitelistBlacklistManager) GetStats() map[string]interface{} {
+func (wbm *WhitelistBlacklistManager) GetStats() *ListStats {
 	wbm.mutex.RLock()
 	defer wbm.mutex.RUnlock()
 
@@ -282,7 +388,7 @@ func (wbm *WhitelistBlacklistManager) GetStats() map[string]interface{} {
 	expiredWhitelist := 0
 	expiredBlacklist := 0
 
-	now := time.Now()
+	now := wbm.now()
 
 	for _, entry := range wbm.whitelist {
 		if entry.IsActive {
@@ -304,53 +410,54 @@ func (wbm *WhitelistBlacklistManager) GetStats() map[string]interface{} {
 		}
 	}
 
-	return map[string]interface{}{
-		"config": map[string]interface{}{
-			"enable_whitelist": wbm.config.EnableWhitelist,
-			"enable_blacklist": wbm.config.EnableBlacklist,
-			"whitelist_mode":   wbm.config.WhitelistMode,
+	return &ListStats{
+		Config: ListStatsConfig{
+			EnableWhitelist: wbm.config.EnableWhitelist,
+			EnableBlacklist: wbm.config.EnableBlacklist,
+			WhitelistMode:   wbm.config.WhitelistMode,
 		},
-		"whitelist": map[string]interface{}{
-			"total":   len(wbm.whitelist),
-			"active":  activeWhitelist,
-			"expired": expiredWhitelist,
+		Whitelist: ListCounts{
+			Total:   len(wbm.whitelist),
+			Active:  activeWhitelist,
+			Expired: expiredWhitelist,
 		},
-		"blacklist": map[string]interface{}{
-			"total":   len(wbm.blacklist),
-			"active":  activeBlacklist,
-			"expired": expiredBlacklist,
+		Blacklist: ListCounts{
+			Total:   len(wbm.blacklist),
+			Active:  activeBlacklist,
+			Expired: expiredBlacklist,
 		},
 	}
 }
 
-// CleanupExpiredEntries removes expired entries from both lists
+// CleanupExpiredEntries removes expired entries from both lists, putting their
+// addresses back on probation under the automatic management.
 func (wbm *WhitelistBlacklistManager) CleanupExpiredEntries() {
 	wbm.mutex.Lock()
-	defer wbm.mutex.Unlock()
 
-	now := time.Now()
-	cleaned := 0
+	now := wbm.now()
+	var transitions []*ListTransition
 
 	// Cleanup expired whitelist entries
 	for addr, entry := range wbm.whitelist {
 		if entry.ExpiresAt != nil && now.After(*entry.ExpiresAt) {
-			delete(wbm.whitelist, addr)
-			cleaned++
+			transitions = append(transitions, wbm.move(addr, ListProbation, false, "Whitelist entry expired"))
 		}
 	}
 
 	// Cleanup expired blacklist entries
 	for addr, entry := range wbm.blacklist {
 		if entry.ExpiresAt != nil && now.After(*entry.ExpiresAt) {
-			delete(wbm.blacklist, addr)
-			cleaned++
+			transitions = append(transitions, wbm.move(addr, ListProbation, false, "Blacklist entry expired"))
 		}
 	}
 
-	if cleaned > 0 {
-		log.Info("Cleaned up expired whitelist/blacklist entries", "count", cleaned)
+	if len(transitions) > 0 {
+		log.Info("Cleaned up expired whitelist/blacklist entries", "count", len(transitions))
 		wbm.saveToPersistence()
 	}
+	wbm.mutex.Unlock()
+
+	wbm.emit(transitions...)
 }
 
 // saveToPersistence saves the current state to disk
@@ -362,8 +469,9 @@ func (wbm *WhitelistBlacklistManager) saveToPersistence() error {
 	data := PersistenceData{
 		Whitelist:   wbm.whitelist,
 		Blacklist:   wbm.blacklist,
+		Standings:   wbm.standings,
 		Config:      wbm.config,
-		LastUpdated: time.Now(),
+		LastUpdated: wbm.now(),
 	}
 
 	jsonData, err := json.MarshalIndent(data, "", "  ")
@@ -411,11 +519,16 @@ func (wbm *WhitelistBlacklistManager) loadFromPersistence() error {
 		return fmt.Errorf("failed to unmarshal whitelist/blacklist data: %v", err)
 	}
 
-	// Update the manager's data
-	wbm.whitelist = data.Whitelist
-	wbm.blacklist = data.Blacklist
-	if data.Config != nil {
-		wbm.config = data.Config
+	// Update the manager's data. The configuration is deliberately not restored,
+	// it comes from the chain config and must be identical on all nodes.
+	if data.Whitelist != nil {
+		wbm.whitelist = data.Whitelist
+	}
+	if data.Blacklist != nil {
+		wbm.blacklist = data.Blacklist
+	}
+	if data.Standings != nil {
+		wbm.standings = data.Standings
 	}
 
 	log.Info("Loaded whitelist/blacklist from persistence",
@@ -445,3 +558,18 @@ func (wbm *WhitelistBlacklistManager) UpdateConfig(config *WhitelistBlacklistCon
 
 	return wbm.saveToPersistence()
 }
+
+// Names of the lists governed by signer votes.
+const (
+	listWhitelist = "whitelist"
+	listBlacklist = "blacklist"
+)
+
+// ListRules are the consensus parameters of the whitelist and blacklist voted
+// on by the signers. Unlike the local WhitelistBlacklistManager lists, the
+// voted lists live in the snapshot and are identical on every node.
+type ListRules struct {
+	StrictWhitelist bool   // Only whitelisted signers may seal while the whitelist is non-empty
+	VoteExpiry      uint64 // Blocks after which an unpassed list vote is discarded (0 = at the next epoch)
+	EntryExpiry     uint64 // Blocks after which a voted list entry expires (0 = never)
+}
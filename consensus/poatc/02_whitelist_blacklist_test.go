@@ -17,10 +17,17 @@
 package poatc
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestWhitelistBlacklistManagerBasic(t *testing.T) {
@@ -33,7 +40,7 @@ func TestWhitelistBlacklistManagerBasic(t *testing.T) {
 		PersistencePath: tempDir + "/whitelist_blacklist.json",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	// Test addresses
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
@@ -93,7 +100,7 @@ func TestWhitelistBlacklistManagerStrictMode(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	addr2 := common.HexToAddress("0x2345678901234567890123456789012345678901")
@@ -128,7 +135,7 @@ func TestWhitelistBlacklistManagerExpiration(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
@@ -158,6 +165,36 @@ func TestWhitelistBlacklistManagerExpiration(t *testing.T) {
 	}
 }
 
+// Tests that entries expire at the time set, as read off the manager's clock.
+func TestWhitelistBlacklistManagerExpirationClock(t *testing.T) {
+	config := &WhitelistBlacklistConfig{
+		EnableWhitelist: true,
+		EnableBlacklist: true,
+	}
+	clock := new(mclock.Simulated)
+	manager := NewWhitelistBlacklistManager(config, clock)
+
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
+
+	expiry := time.Unix(0, 0).Add(time.Hour)
+	if err := manager.AddToBlacklist(addr, admin, "Expiring entry", &expiry); err != nil {
+		t.Fatalf("Failed to add to blacklist: %v", err)
+	}
+	clock.Run(time.Hour)
+	if !manager.IsBlacklisted(addr) {
+		t.Fatal("Entry expired before its expiry time")
+	}
+	clock.Run(1)
+	if manager.IsBlacklisted(addr) {
+		t.Fatal("Entry not expired after its expiry time")
+	}
+	manager.CleanupExpiredEntries()
+	if stats := manager.GetStats(); stats.Blacklist.Total != 0 {
+		t.Errorf("Expired entry not cleaned up: %d entries left", stats.Blacklist.Total)
+	}
+}
+
 func TestWhitelistBlacklistManagerRemoval(t *testing.T) {
 	config := &WhitelistBlacklistConfig{
 		EnableWhitelist: true,
@@ -166,7 +203,7 @@ func TestWhitelistBlacklistManagerRemoval(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
@@ -220,7 +257,7 @@ func TestWhitelistBlacklistManagerBlacklistOverridesWhitelist(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
@@ -268,7 +305,7 @@ func TestWhitelistBlacklistManagerStats(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	addr2 := common.HexToAddress("0x2345678901234567890123456789012345678901")
@@ -282,45 +319,30 @@ func TestWhitelistBlacklistManagerStats(t *testing.T) {
 	stats := manager.GetStats()
 
 	// Check config
-	configData, ok := stats["config"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Stats should contain config")
-	}
-
-	if configData["enable_whitelist"] != true {
+	if !stats.Config.EnableWhitelist {
 		t.Error("Config should show whitelist enabled")
 	}
 
-	if configData["enable_blacklist"] != true {
+	if !stats.Config.EnableBlacklist {
 		t.Error("Config should show blacklist enabled")
 	}
 
 	// Check whitelist stats
-	whitelistData, ok := stats["whitelist"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Stats should contain whitelist data")
+	if stats.Whitelist.Total != 1 {
+		t.Errorf("Expected 1 whitelist entry, got %v", stats.Whitelist.Total)
 	}
 
-	if whitelistData["total"] != 1 {
-		t.Errorf("Expected 1 whitelist entry, got %v", whitelistData["total"])
-	}
-
-	if whitelistData["active"] != 1 {
-		t.Errorf("Expected 1 active whitelist entry, got %v", whitelistData["active"])
+	if stats.Whitelist.Active != 1 {
+		t.Errorf("Expected 1 active whitelist entry, got %v", stats.Whitelist.Active)
 	}
 
 	// Check blacklist stats
-	blacklistData, ok := stats["blacklist"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Stats should contain blacklist data")
+	if stats.Blacklist.Total != 1 {
+		t.Errorf("Expected 1 blacklist entry, got %v", stats.Blacklist.Total)
 	}
 
-	if blacklistData["total"] != 1 {
-		t.Errorf("Expected 1 blacklist entry, got %v", blacklistData["total"])
-	}
-
-	if blacklistData["active"] != 1 {
-		t.Errorf("Expected 1 active blacklist entry, got %v", blacklistData["active"])
+	if stats.Blacklist.Active != 1 {
+		t.Errorf("Expected 1 active blacklist entry, got %v", stats.Blacklist.Active)
 	}
 }
 
@@ -335,7 +357,7 @@ func TestWhitelistBlacklistManagerPersistence(t *testing.T) {
 	}
 
 	// Create first manager and add some entries
-	manager1 := NewWhitelistBlacklistManager(config)
+	manager1 := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	addr2 := common.HexToAddress("0x2345678901234567890123456789012345678901")
@@ -345,7 +367,7 @@ func TestWhitelistBlacklistManagerPersistence(t *testing.T) {
 	manager1.AddToBlacklist(addr2, admin, "Test blacklist entry", nil)
 
 	// Create second manager with same config (should load from persistence)
-	manager2 := NewWhitelistBlacklistManager(config)
+	manager2 := NewWhitelistBlacklistManager(config, nil)
 
 	// Check if data was loaded
 	if !manager2.IsWhitelisted(addr1) {
@@ -358,15 +380,13 @@ func TestWhitelistBlacklistManagerPersistence(t *testing.T) {
 
 	// Check stats
 	stats := manager2.GetStats()
-	whitelistData := stats["whitelist"].(map[string]interface{})
-	blacklistData := stats["blacklist"].(map[string]interface{})
 
-	if whitelistData["total"] != 1 {
-		t.Errorf("Expected 1 whitelist entry after loading, got %v", whitelistData["total"])
+	if stats.Whitelist.Total != 1 {
+		t.Errorf("Expected 1 whitelist entry after loading, got %v", stats.Whitelist.Total)
 	}
 
-	if blacklistData["total"] != 1 {
-		t.Errorf("Expected 1 blacklist entry after loading, got %v", blacklistData["total"])
+	if stats.Blacklist.Total != 1 {
+		t.Errorf("Expected 1 blacklist entry after loading, got %v", stats.Blacklist.Total)
 	}
 }
 
@@ -378,7 +398,7 @@ func TestWhitelistBlacklistManagerCleanupExpired(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	addr2 := common.HexToAddress("0x2345678901234567890123456789012345678901")
@@ -398,15 +418,13 @@ func TestWhitelistBlacklistManagerCleanupExpired(t *testing.T) {
 
 	// Check stats before cleanup
 	stats := manager.GetStats()
-	whitelistData := stats["whitelist"].(map[string]interface{})
-	blacklistData := stats["blacklist"].(map[string]interface{})
 
-	if whitelistData["total"] != 2 {
-		t.Errorf("Expected 2 whitelist entries before cleanup, got %v", whitelistData["total"])
+	if stats.Whitelist.Total != 2 {
+		t.Errorf("Expected 2 whitelist entries before cleanup, got %v", stats.Whitelist.Total)
 	}
 
-	if blacklistData["total"] != 2 {
-		t.Errorf("Expected 2 blacklist entries before cleanup, got %v", blacklistData["total"])
+	if stats.Blacklist.Total != 2 {
+		t.Errorf("Expected 2 blacklist entries before cleanup, got %v", stats.Blacklist.Total)
 	}
 
 	// Cleanup expired entries
@@ -414,15 +432,13 @@ func TestWhitelistBlacklistManagerCleanupExpired(t *testing.T) {
 
 	// Check stats after cleanup
 	stats = manager.GetStats()
-	whitelistData = stats["whitelist"].(map[string]interface{})
-	blacklistData = stats["blacklist"].(map[string]interface{})
 
-	if whitelistData["total"] != 1 {
-		t.Errorf("Expected 1 whitelist entry after cleanup, got %v", whitelistData["total"])
+	if stats.Whitelist.Total != 1 {
+		t.Errorf("Expected 1 whitelist entry after cleanup, got %v", stats.Whitelist.Total)
 	}
 
-	if blacklistData["total"] != 1 {
-		t.Errorf("Expected 1 blacklist entry after cleanup, got %v", blacklistData["total"])
+	if stats.Blacklist.Total != 1 {
+		t.Errorf("Expected 1 blacklist entry after cleanup, got %v", stats.Blacklist.Total)
 	}
 }
 
@@ -434,7 +450,7 @@ func TestWhitelistBlacklistManagerErrorHandling(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	addr1 := common.HexToAddress("0x1234567890123456789012345678901234567890")
 	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
@@ -466,7 +482,7 @@ func TestWhitelistBlacklistManagerConcurrentAccess(t *testing.T) {
 		PersistencePath: "",
 	}
 
-	manager := NewWhitelistBlacklistManager(config)
+	manager := NewWhitelistBlacklistManager(config, nil)
 
 	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
 
@@ -497,14 +513,140 @@ func TestWhitelistBlacklistManagerConcurrentAccess(t *testing.T) {
 
 	// Final stats should be clean
 	stats := manager.GetStats()
-	whitelistData := stats["whitelist"].(map[string]interface{})
-	blacklistData := stats["blacklist"].(map[string]interface{})
 
-	if whitelistData["total"] != 0 {
-		t.Errorf("Expected 0 whitelist entries after concurrent test, got %v", whitelistData["total"])
+	if stats.Whitelist.Total != 0 {
+		t.Errorf("Expected 0 whitelist entries after concurrent test, got %v", stats.Whitelist.Total)
+	}
+
+	if stats.Blacklist.Total != 0 {
+		t.Errorf("Expected 0 blacklist entries after concurrent test, got %v", stats.Blacklist.Total)
+	}
+}
+
+// Tests that the voted blacklist is enforced identically by every node, and
+// that list votes and entries expire after the configured number of blocks.
+func TestGovernedListVoting(t *testing.T) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C", "D"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	accounts.checkpoint(&types.Header{Extra: genesis.ExtraData}, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30}
+	config.Poatc = &params.PoatcConfig{
+		EnableWhitelistBlacklist: true,
+		WhitelistBlacklist: &params.PoatcWhitelistBlacklistConfig{
+			EnableExpiration: true,
+			VoteExpiry:       5,
+			EntryExpiry:      20,
+		},
+	}
+	genesis.Config = &config
+
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 23, func(i int, gen *core.BlockGen) {})
+
+	// A, B and C blacklist D in blocks 1-3 and A casts a lone whitelist vote in
+	// block 4. D seals block 23, right when its blacklisting expires.
+	votes := map[uint64]struct {
+		address common.Address
+		nonce   []byte
+	}{
+		1: {accounts.address("D"), nonceBlacklistAdd},
+		2: {accounts.address("D"), nonceBlacklistAdd},
+		3: {accounts.address("D"), nonceBlacklistAdd},
+		4: {accounts.address("A"), nonceWhitelistAdd},
+	}
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.ToBlock().Hash(), signers)
+	snap.setListRules(engine.listRules)
+
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		number := header.Number.Uint64()
+		if vote, ok := votes[number]; ok {
+			header.Coinbase = vote.address
+			copy(header.Nonce[:], vote.nonce)
+		}
+		signer := names[i%3]
+		if number == 23 {
+			signer = "D"
+		}
+		header.Extra = make([]byte, extraVanity+extraSeal)
+		header.Difficulty = diffInTurn // Ignored, we just need a valid number
+
+		accounts.sign(header, signer)
+		blocks[i] = block.WithSeal(header)
+
+		var err error
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("block %d: failed to apply header: %v", number, err)
+		}
+		switch number {
+		case 3:
+			if expiry, ok := snap.Blacklist[accounts.address("D")]; !ok || expiry != 23 {
+				t.Errorf("block %d: blacklist entry mismatch: have %d (%v), want 23", number, expiry, ok)
+			}
+			if len(snap.ListVotes) != 0 {
+				t.Errorf("block %d: passed votes not discarded: %d left", number, len(snap.ListVotes))
+			}
+		case 8:
+			if have := snap.WhitelistTally[accounts.address("A")].Votes; have != 1 {
+				t.Errorf("block %d: whitelist tally mismatch: have %d, want 1", number, have)
+			}
+		case 9:
+			if len(snap.ListVotes) != 0 || len(snap.WhitelistTally) != 0 {
+				t.Errorf("block %d: expired vote not discarded", number)
+			}
+		case 23:
+			if len(snap.Blacklist) != 0 {
+				t.Errorf("block %d: expired blacklist entry not discarded", number)
+			}
+		}
+	}
+	// Import the chain on an independent node and ensure it agrees
+	importer := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	importer.fakeDiff = true
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, importer, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	// Have D seal block 4 while blacklisted and ensure it's rejected
+	header := blocks[3].Header()
+	accounts.sign(header, "D")
+
+	rejected := []*types.Block{}
+	rejected = append(rejected, blocks[:3]...)
+	rejected = append(rejected, blocks[3].WithSeal(header))
+
+	importer = NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	importer.fakeDiff = true
+
+	chain, err = core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, importer, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
 	}
+	defer chain.Stop()
 
-	if blacklistData["total"] != 0 {
-		t.Errorf("Expected 0 blacklist entries after concurrent test, got %v", blacklistData["total"])
+	if _, err := chain.InsertChain(rejected); err != errBlacklistedSigner {
+		t.Fatalf("blacklisted signer error mismatch: have %v, want %v", err, errBlacklistedSigner)
 	}
 }
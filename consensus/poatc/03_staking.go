@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// stakeLength is the number of extra-data bytes a checkpoint uses for the stake
+// of every signer, a big endian uint256 amount of wei.
+const stakeLength = common.HashLength
+
+// StakingRules are the consensus parameters locating the signers' stakes in
+// chain state. Checkpoint blocks commit to the stakes found in the state of
+// their parent, after the signers, reputation scores and liveness, so snapshots
+// can weight elections by bonded value without access to state themselves.
+type StakingRules struct {
+	Contract   common.Address // Account holding the mapping(address => uint256) of stakes
+	Slot       common.Hash    // Storage slot of the stake mapping
+	TraceRoots bool           // Whether blocks commit to a trace root after the stakes
+}
+
+// stateReader is implemented by chains able to open historical states, such as
+// the full blockchain.
+type stateReader interface {
+	HasState(root common.Hash) bool
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// newStakingRules creates the staking rules for the chain, nil if the election
+// is not weighted by on-chain stakes.
+func newStakingRules(cfg *params.PoatcConfig, traceRoots bool) *StakingRules {
+	if cfg == nil || cfg.ValidatorSelection == nil || cfg.ValidatorSelection.Staking == nil {
+		return nil
+	}
+	staking := cfg.ValidatorSelection.Staking
+	return &StakingRules{
+		Contract:   staking.Contract,
+		Slot:       common.BigToHash(new(big.Int).SetUint64(staking.Slot)),
+		TraceRoots: traceRoots,
+	}
+}
+
+// stakeSlot returns the storage slot holding the stake of a signer, following
+// the Solidity layout of mappings.
+func (r *StakingRules) stakeSlot(signer common.Address) common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(signer[:], common.HashLength), r.Slot[:])
+}
+
+// readStakes reads the stakes of the given signers from the state the checkpoint
+// header builds on. Bodies are verified before their parent state is checked, so
+// a missing state is reported as a pruned ancestor for the block to be imported
+// as a side chain, reexecuting its ancestors first.
+func (r *StakingRules) readStakes(chain consensus.ChainHeaderReader, header *types.Header, signers []common.Address) ([]*big.Int, error) {
+	reader, ok := chain.(stateReader)
+	if !ok {
+		return nil, errStakesUnavailable
+	}
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	if !reader.HasState(parent.Root) {
+		return nil, consensus.ErrPrunedAncestor
+	}
+	statedb, err := reader.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	stakes := make([]*big.Int, len(signers))
+	for i, signer := range signers {
+		stakes[i] = statedb.GetState(r.Contract, r.stakeSlot(signer)).Big()
+	}
+	return stakes, nil
+}
+
+// encodeStakes encodes stakes into their checkpoint representation.
+func encodeStakes(stakes []*big.Int) []byte {
+	blob := make([]byte, 0, len(stakes)*stakeLength)
+	for _, stake := range stakes {
+		blob = append(blob, common.BigToHash(stake).Bytes()...)
+	}
+	return blob
+}
+
+// stakes extracts the stakes of the given number of signers committed to by a
+// checkpoint header, the last part of its checkpoint section.
+func (r *StakingRules) stakes(header *types.Header, signers int) []*big.Int {
+	end := len(header.Extra) - extraSeal
+	if r.TraceRoots {
+		end -= traceRootLength
+	}
+	start := end - signers*stakeLength
+	if start < extraVanity {
+		return nil
+	}
+	stakes := make([]*big.Int, signers)
+	for i := range stakes {
+		stakes[i] = new(big.Int).SetBytes(header.Extra[start+i*stakeLength : start+(i+1)*stakeLength])
+	}
+	return stakes
+}
+
+// updateStakes replaces the stakes of the snapshot with the ones committed to by
+// a checkpoint header. The header must be applied on top of the snapshot.
+func (s *Snapshot) updateStakes(header *types.Header) {
+	signers := s.signers()
+	stakes := s.staking.stakes(header, len(signers))
+	if stakes == nil {
+		return
+	}
+	s.Stakes = make(map[common.Address]*big.Int, len(signers))
+	for i, signer := range signers {
+		s.Stakes[signer] = stakes[i]
+	}
+}
+
+// setStakingRules sets the rules locating the stakes committed to by checkpoints.
+func (s *Snapshot) setStakingRules(rules *StakingRules) {
+	s.staking = rules
+}
@@ -18,19 +18,22 @@ package poatc
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/exp/slices"
 )
 
 // ValidatorSelectionConfig contains configuration for validator selection
 type ValidatorSelectionConfig struct {
 	EnableValidatorSelection bool          // Enable validator selection mechanism
 	SmallValidatorSetSize    int           // Size of small validator set
-	SelectionWindow          time.Duration // Time window for validator selection
+	SelectionInterval        uint64        // Number of blocks between small validator set re-elections
 	SelectionMethod          string        // "random", "stake", "reputation", "hybrid"
 	StakeWeight              float64       // Weight for stake-based selection (0.0-1.0)
 	ReputationWeight         float64       // Weight for reputation-based selection (0.0-1.0)
@@ -42,7 +45,7 @@ func DefaultValidatorSelectionConfig() *ValidatorSelectionConfig {
 	return &ValidatorSelectionConfig{
 		EnableValidatorSelection: true,
 		SmallValidatorSetSize:    3, // Select 3 validators from all validators
-		SelectionWindow:          1 * time.Hour,
+		SelectionInterval:        100, // Re-elect every 100 blocks
 		SelectionMethod:          "hybrid", // Use hybrid selection
 		StakeWeight:              0.4,      // 40% stake weight
 		ReputationWeight:         0.3,      // 30% reputation weight
@@ -62,12 +65,13 @@ type ValidatorInfo struct {
 
 // ValidatorSelectionManager handles validator selection logic
 type ValidatorSelectionManager struct {
-	config           *ValidatorSelectionConfig
-	allValidators    map[common.Address]*ValidatorInfo
-	smallValidatorSet []common.Address
-	lastSelection    time.Time
-	selectionHistory []ValidatorSelectionRecord
-	tracingSystem    *TracingSystem
+	config             *ValidatorSelectionConfig
+	allValidators      map[common.Address]*ValidatorInfo
+	smallValidatorSet  []common.Address
+	lastSelectionBlock uint64
+	selectionHistory   []ValidatorSelectionRecord
+	tracingSystem      *TracingSystem
+	clock              mclock.Clock // Source of time of the activity and selection records
 }
 
 // ValidatorSelectionRecord records a validator selection event
@@ -79,8 +83,9 @@ type ValidatorSelectionRecord struct {
 	SelectionSeed    []byte            `json:"selection_seed"`
 }
 
-// NewValidatorSelectionManager creates a new validator selection manager
-func NewValidatorSelectionManager(config *ValidatorSelectionConfig) *ValidatorSelectionManager {
+// NewValidatorSelectionManager creates a new validator selection manager, timing
+// its records with the given clock or the system clock if nil.
+func NewValidatorSelectionManager(config *ValidatorSelectionConfig, clock mclock.Clock) *ValidatorSelectionManager {
 	if config == nil {
 		config = DefaultValidatorSelectionConfig()
 	}
@@ -90,6 +95,7 @@ func NewValidatorSelectionManager(config *ValidatorSelectionConfig) *ValidatorSe
 		allValidators:    make(map[common.Address]*ValidatorInfo),
 		smallValidatorSet: make([]common.Address, 0),
 		selectionHistory: make([]ValidatorSelectionRecord, 0),
+		clock:            orSystemClock(clock),
 	}
 }
 
@@ -99,7 +105,7 @@ func (vsm *ValidatorSelectionManager) AddValidator(address common.Address, stake
 		Address:     address,
 		Stake:       stake,
 		Reputation:  reputation,
-		LastActive:  time.Now(),
+		LastActive:  wallTime(vsm.clock),
 		BlocksMined: 0,
 		IsActive:    true,
 	}
@@ -126,72 +132,59 @@ func (vsm *ValidatorSelectionManager) UpdateValidatorReputation(address common.A
 func (vsm *ValidatorSelectionManager) RecordBlockMining(address common.Address, blockNumber uint64) {
 	if validator, exists := vsm.allValidators[address]; exists {
 		validator.BlocksMined++
-		validator.LastActive = time.Now()
+		validator.LastActive = wallTime(vsm.clock)
 		log.Debug("Block mining recorded", "address", address.Hex(), "block", blockNumber, "total_blocks", validator.BlocksMined)
 	}
 }
 
-// SelectSmallValidatorSet selects a small set of validators from all validators
+// SelectSmallValidatorSet selects a small set of validators from all validators.
+// The set is re-elected once per selection interval, the block hash seeds the
+// election and the validators are considered in address order, so the same
+// inputs always yield the same set.
 func (vsm *ValidatorSelectionManager) SelectSmallValidatorSet(blockNumber uint64, blockHash common.Hash) ([]common.Address, error) {
 	if !vsm.config.EnableValidatorSelection {
 		// If validator selection is disabled, return all validators
-		allValidators := make([]common.Address, 0, len(vsm.allValidators))
-		for addr := range vsm.allValidators {
-			allValidators = append(allValidators, addr)
-		}
-		return allValidators, nil
+		return vsm.getActiveValidators(), nil
 	}
 
-	// Check if we need to reselect (based on time window or block interval)
-	if time.Since(vsm.lastSelection) < vsm.config.SelectionWindow && len(vsm.smallValidatorSet) > 0 {
+	// Check if we need to reselect (based on block interval)
+	if len(vsm.smallValidatorSet) > 0 && vsm.selectionRound(blockNumber) == vsm.selectionRound(vsm.lastSelectionBlock) {
 		log.Debug("Using existing small validator set", "size", len(vsm.smallValidatorSet))
 		return vsm.smallValidatorSet, nil
 	}
 
-	// Get active validators
+	// Gather the active validators along with their weights
 	activeValidators := vsm.getActiveValidators()
 	if len(activeValidators) == 0 {
 		return nil, fmt.Errorf("no active validators available")
 	}
-
-	// Determine selection size
-	selectionSize := vsm.config.SmallValidatorSetSize
-	if selectionSize > len(activeValidators) {
-		selectionSize = len(activeValidators)
-	}
-
-	// Select validators based on configured method
-	var selectedValidators []common.Address
-	var err error
-
-	switch vsm.config.SelectionMethod {
-	case "random":
-		selectedValidators, err = vsm.selectRandomValidators(activeValidators, selectionSize, blockNumber, blockHash)
-	case "stake":
-		selectedValidators, err = vsm.selectStakeBasedValidators(activeValidators, selectionSize, blockNumber, blockHash)
-	case "reputation":
-		selectedValidators, err = vsm.selectReputationBasedValidators(activeValidators, selectionSize, blockNumber, blockHash)
-	case "hybrid":
-		selectedValidators, err = vsm.selectHybridValidators(activeValidators, selectionSize, blockNumber, blockHash)
-	default:
-		return nil, fmt.Errorf("unknown selection method: %s", vsm.config.SelectionMethod)
+	candidates := make([]electionCandidate, 0, len(activeValidators))
+	for _, addr := range activeValidators {
+		validator := vsm.allValidators[addr]
+		candidates = append(candidates, electionCandidate{
+			Address:    addr,
+			Stake:      validator.Stake,
+			Reputation: fixedPoint(validator.Reputation).Uint64(),
+		})
 	}
+	seed := generateSelectionSeed(blockNumber, blockHash)
 
+	selectedValidators, err := electValidators(vsm.config, candidates, seed)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update small validator set
 	vsm.smallValidatorSet = selectedValidators
-	vsm.lastSelection = time.Now()
+	vsm.lastSelectionBlock = blockNumber
 
 	// Record selection
 	selectionRecord := ValidatorSelectionRecord{
-		BlockNumber:       blockNumber,
-		Timestamp:         time.Now(),
+		BlockNumber:        blockNumber,
+		Timestamp:          wallTime(vsm.clock),
 		SelectedValidators: selectedValidators,
-		SelectionMethod:   vsm.config.SelectionMethod,
-		SelectionSeed:     vsm.generateSelectionSeed(blockNumber, blockHash),
+		SelectionMethod:    vsm.config.SelectionMethod,
+		SelectionSeed:      seed,
 	}
 	vsm.selectionHistory = append(vsm.selectionHistory, selectionRecord)
 
@@ -200,16 +193,37 @@ func (vsm *ValidatorSelectionManager) SelectSmallValidatorSet(blockNumber uint64
 		vsm.selectionHistory = vsm.selectionHistory[len(vsm.selectionHistory)-100:]
 	}
 
-	log.Info("Small validator set selected", 
+	log.Info("Small validator set selected",
 		"method", vsm.config.SelectionMethod,
 		"size", len(selectedValidators),
 		"block", blockNumber,
 		"validators", selectedValidators)
 
+	if vsm.tracingSystem != nil {
+		validators := make([]string, len(selectedValidators))
+		for i, addr := range selectedValidators {
+			validators[i] = addr.Hex()
+		}
+		vsm.tracingSystem.Trace(TraceEventValidatorSelection, TraceLevelBasic, blockNumber, common.Address{},
+			fmt.Sprintf("Small validator set of %d selected from %d validators", len(selectedValidators), len(activeValidators)),
+			map[string]interface{}{
+				"validators":       validators,
+				"validators_count": len(activeValidators),
+				"selection_method": vsm.config.SelectionMethod,
+			})
+	}
 	return selectedValidators, nil
 }
 
-// getActiveValidators returns list of active validators
+// selectionRound returns the index of the selection interval a block falls in.
+func (vsm *ValidatorSelectionManager) selectionRound(blockNumber uint64) uint64 {
+	if vsm.config.SelectionInterval == 0 {
+		return blockNumber
+	}
+	return blockNumber / vsm.config.SelectionInterval
+}
+
+// getActiveValidators returns the active validators in ascending address order
 func (vsm *ValidatorSelectionManager) getActiveValidators() []common.Address {
 	var activeValidators []common.Address
 	for addr, validator := range vsm.allValidators {
@@ -217,240 +231,163 @@ func (vsm *ValidatorSelectionManager) getActiveValidators() []common.Address {
 			activeValidators = append(activeValidators, addr)
 		}
 	}
+	slices.SortFunc(activeValidators, common.Address.Cmp)
 	return activeValidators
 }
 
-// selectRandomValidators selects validators randomly
-func (vsm *ValidatorSelectionManager) selectRandomValidators(validators []common.Address, count int, blockNumber uint64, blockHash common.Hash) ([]common.Address, error) {
-	if count >= len(validators) {
-		return validators, nil
-	}
-
-	// Use block hash and number as seed for deterministic randomness
-	seed := vsm.generateSelectionSeed(blockNumber, blockHash)
-	
-	// Simple random selection using the seed
-	selected := make([]common.Address, 0, count)
-	used := make(map[common.Address]bool)
-	
-	for len(selected) < count {
-		// Use seed to select index
-		index := int(seed[len(selected)%len(seed)]) % len(validators)
-		validator := validators[index]
-		
-		if !used[validator] {
-			selected = append(selected, validator)
-			used[validator] = true
-		}
-	}
-
-	return selected, nil
+// selectionPrecision is the fixed point scale used to turn reputations and
+// selection weights into integers. Elections feed into the in-turn decision
+// of every node, so they must not depend on platform specific float rounding.
+const selectionPrecision = 1_000_000
+
+// electionCandidate is a validator taking part in a small validator set
+// election, together with the weights the election may take into account.
+type electionCandidate struct {
+	Address    common.Address
+	Stake      *big.Int // Stake of the validator, nil if unknown
+	Reputation uint64   // Reputation of the validator in selectionPrecision units, 0 if unknown
 }
 
-// selectStakeBasedValidators selects validators based on stake
-func (vsm *ValidatorSelectionManager) selectStakeBasedValidators(validators []common.Address, count int, blockNumber uint64, blockHash common.Hash) ([]common.Address, error) {
-	if count >= len(validators) {
-		return validators, nil
+// electValidators elects up to SmallValidatorSetSize validators from the given
+// candidates, which must be sorted by address. Every draw hashes the seed with
+// the draw index and picks a remaining candidate with probability proportional
+// to its weight. The election is a pure function of its inputs.
+func electValidators(config *ValidatorSelectionConfig, candidates []electionCandidate, seed []byte) ([]common.Address, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no active validators available")
 	}
-
-	// Calculate total stake
-	totalStake := big.NewInt(0)
-	for _, addr := range validators {
-		if validator, exists := vsm.allValidators[addr]; exists {
-			totalStake.Add(totalStake, validator.Stake)
-		}
+	weights, err := electionWeights(config, candidates)
+	if err != nil {
+		return nil, err
 	}
-
-	if totalStake.Cmp(big.NewInt(0)) == 0 {
-		// If no stake, fall back to random selection
-		return vsm.selectRandomValidators(validators, count, blockNumber, blockHash)
+	count := config.SmallValidatorSetSize
+	if count <= 0 || count >= len(candidates) {
+		selected := make([]common.Address, len(candidates))
+		for i, candidate := range candidates {
+			selected[i] = candidate.Address
+		}
+		return selected, nil
 	}
+	remaining := make([]electionCandidate, len(candidates))
+	copy(remaining, candidates)
 
-	// Weighted selection based on stake
 	selected := make([]common.Address, 0, count)
-	used := make(map[common.Address]bool)
-	seed := vsm.generateSelectionSeed(blockNumber, blockHash)
-
-	for len(selected) < count {
-		// Calculate cumulative weights
-		cumulative := big.NewInt(0)
-		target := new(big.Int).SetBytes(seed[len(selected)%len(seed):])
-		target.Mod(target, totalStake)
-
-		for _, addr := range validators {
-			if used[addr] {
-				continue
+	for draw := 0; len(selected) < count; draw++ {
+		total := new(big.Int)
+		for _, weight := range weights {
+			total.Add(total, weight)
+		}
+		// Without any weight left, every remaining candidate is equally likely
+		if total.Sign() == 0 {
+			for i := range weights {
+				weights[i] = big.NewInt(1)
 			}
-
-			if validator, exists := vsm.allValidators[addr]; exists {
-				cumulative.Add(cumulative, validator.Stake)
-				if cumulative.Cmp(target) >= 0 {
-					selected = append(selected, addr)
-					used[addr] = true
-					break
-				}
+			total.SetInt64(int64(len(weights)))
+		}
+		data := make([]byte, len(seed)+8)
+		copy(data, seed)
+		binary.BigEndian.PutUint64(data[len(seed):], uint64(draw))
+		digest := sha256.Sum256(data)
+		target := new(big.Int).SetBytes(digest[:])
+		target.Mod(target, total)
+
+		cumulative := new(big.Int)
+		for i, weight := range weights {
+			cumulative.Add(cumulative, weight)
+			if cumulative.Cmp(target) > 0 {
+				selected = append(selected, remaining[i].Address)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				weights = append(weights[:i], weights[i+1:]...)
+				break
 			}
 		}
 	}
-
 	return selected, nil
 }
 
-// selectReputationBasedValidators selects validators based on reputation
-func (vsm *ValidatorSelectionManager) selectReputationBasedValidators(validators []common.Address, count int, blockNumber uint64, blockHash common.Hash) ([]common.Address, error) {
-	if count >= len(validators) {
-		return validators, nil
-	}
-
-	// Calculate total reputation
-	totalReputation := 0.0
-	for _, addr := range validators {
-		if validator, exists := vsm.allValidators[addr]; exists {
-			totalReputation += validator.Reputation
+// electionWeights calculates the non-negative integer election weight of every
+// candidate according to the configured selection method.
+func electionWeights(config *ValidatorSelectionConfig, candidates []electionCandidate) ([]*big.Int, error) {
+	var (
+		weights     = make([]*big.Int, len(candidates))
+		stakes      = make([]*big.Int, len(candidates))
+		reputations = make([]*big.Int, len(candidates))
+		maxStake    = new(big.Int)
+		maxRep      = new(big.Int)
+	)
+	for i, candidate := range candidates {
+		stakes[i] = new(big.Int)
+		if candidate.Stake != nil && candidate.Stake.Sign() > 0 {
+			stakes[i].Set(candidate.Stake)
 		}
-	}
-
-	if totalReputation == 0 {
-		// If no reputation, fall back to random selection
-		return vsm.selectRandomValidators(validators, count, blockNumber, blockHash)
-	}
-
-	// Weighted selection based on reputation
-	selected := make([]common.Address, 0, count)
-	used := make(map[common.Address]bool)
-	seed := vsm.generateSelectionSeed(blockNumber, blockHash)
-
-	for len(selected) < count {
-		// Calculate cumulative weights
-		cumulative := 0.0
-		target := float64(seed[len(selected)%len(seed)]) / 255.0 * totalReputation
-
-		for _, addr := range validators {
-			if used[addr] {
-				continue
-			}
+		reputations[i] = new(big.Int).SetUint64(candidate.Reputation)
 
-			if validator, exists := vsm.allValidators[addr]; exists {
-				cumulative += validator.Reputation
-				if cumulative >= target {
-					selected = append(selected, addr)
-					used[addr] = true
-					break
-				}
-			}
+		if stakes[i].Cmp(maxStake) > 0 {
+			maxStake.Set(stakes[i])
 		}
-	}
-
-	return selected, nil
-}
-
-// selectHybridValidators selects validators using hybrid method (stake + reputation + random)
-func (vsm *ValidatorSelectionManager) selectHybridValidators(validators []common.Address, count int, blockNumber uint64, blockHash common.Hash) ([]common.Address, error) {
-	if count >= len(validators) {
-		return validators, nil
-	}
-
-	// Calculate hybrid scores for each validator
-	scores := make(map[common.Address]float64)
-	
-	// Normalize stake and reputation
-	maxStake := big.NewInt(0)
-	maxReputation := 0.0
-	
-	for _, addr := range validators {
-		if validator, exists := vsm.allValidators[addr]; exists {
-			if validator.Stake.Cmp(maxStake) > 0 {
-				maxStake.Set(validator.Stake)
-			}
-			if validator.Reputation > maxReputation {
-				maxReputation = validator.Reputation
-			}
+		if reputations[i].Cmp(maxRep) > 0 {
+			maxRep.Set(reputations[i])
 		}
 	}
-
-	// Calculate hybrid scores
-	for _, addr := range validators {
-		if validator, exists := vsm.allValidators[addr]; exists {
-			stakeScore := 0.0
-			if maxStake.Cmp(big.NewInt(0)) > 0 {
-				stakeScore = float64(validator.Stake.Uint64()) / float64(maxStake.Uint64())
-			}
-			
-			reputationScore := 0.0
-			if maxReputation > 0 {
-				reputationScore = validator.Reputation / maxReputation
-			}
-			
-			// Calculate hybrid score
-			hybridScore := vsm.config.StakeWeight*stakeScore + 
-						   vsm.config.ReputationWeight*reputationScore + 
-						   vsm.config.RandomWeight*0.5 // Random component
-			
-			scores[addr] = hybridScore
+	switch config.SelectionMethod {
+	case "random":
+		for i := range weights {
+			weights[i] = big.NewInt(1)
 		}
-	}
-
-	// Weighted selection based on hybrid scores
-	selected := make([]common.Address, 0, count)
-	used := make(map[common.Address]bool)
-	seed := vsm.generateSelectionSeed(blockNumber, blockHash)
-
-	// Calculate total score
-	totalScore := 0.0
-	for _, score := range scores {
-		totalScore += score
-	}
-
-	if totalScore == 0 {
-		// If no scores, fall back to random selection
-		return vsm.selectRandomValidators(validators, count, blockNumber, blockHash)
-	}
-
-	for len(selected) < count {
-		// Calculate cumulative weights
-		cumulative := 0.0
-		target := float64(seed[len(selected)%len(seed)]) / 255.0 * totalScore
-		
-		selectedInThisRound := false
-
-		for _, addr := range validators {
-			if used[addr] {
-				continue
+	case "stake":
+		copy(weights, stakes)
+	case "reputation":
+		copy(weights, reputations)
+	case "hybrid":
+		// Normalise stake and reputation against the best candidate, the random
+		// component contributes a constant half to every candidate.
+		var (
+			stakeWeight      = fixedPoint(config.StakeWeight)
+			reputationWeight = fixedPoint(config.ReputationWeight)
+			randomWeight     = fixedPoint(config.RandomWeight)
+			precision        = big.NewInt(selectionPrecision)
+		)
+		for i := range candidates {
+			weight := new(big.Int).Mul(randomWeight, big.NewInt(selectionPrecision/2))
+			if maxStake.Sign() > 0 {
+				score := new(big.Int).Mul(stakes[i], precision)
+				score.Div(score, maxStake)
+				weight.Add(weight, score.Mul(score, stakeWeight))
 			}
-
-			if score, exists := scores[addr]; exists {
-				cumulative += score
-				if cumulative >= target {
-					selected = append(selected, addr)
-					used[addr] = true
-					selectedInThisRound = true
-					break
-				}
+			if maxRep.Sign() > 0 {
+				score := new(big.Int).Mul(reputations[i], precision)
+				score.Div(score, maxRep)
+				weight.Add(weight, score.Mul(score, reputationWeight))
 			}
+			weights[i] = weight
 		}
-		
-		// If no validator was selected in this round, break to avoid infinite loop
-		if !selectedInThisRound {
-			break
-		}
+	default:
+		return nil, fmt.Errorf("unknown selection method: %s", config.SelectionMethod)
 	}
+	return weights, nil
+}
 
-	return selected, nil
+// fixedPoint converts a non-negative fraction into selectionPrecision units.
+func fixedPoint(value float64) *big.Int {
+	if value <= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).SetUint64(uint64(value * selectionPrecision))
 }
 
 // generateSelectionSeed generates a deterministic seed for selection
-func (vsm *ValidatorSelectionManager) generateSelectionSeed(blockNumber uint64, blockHash common.Hash) []byte {
+func generateSelectionSeed(blockNumber uint64, blockHash common.Hash) []byte {
 	// Combine block number and hash for seed
 	data := make([]byte, 40)
-	
+
 	// Add block number (8 bytes)
 	for i := 0; i < 8; i++ {
 		data[i] = byte(blockNumber >> (i * 8))
 	}
-	
+
 	// Add block hash (32 bytes)
 	copy(data[8:], blockHash[:])
-	
+
 	// Hash the combined data
 	hash := sha256.Sum256(data)
 	return hash[:]
@@ -471,8 +408,46 @@ func (vsm *ValidatorSelectionManager) GetSelectionHistory() []ValidatorSelection
 	return vsm.selectionHistory
 }
 
+// SelectionStats are the statistics of the validator selection.
+type SelectionStats struct {
+	Config     SelectionStatsConfig `json:"config"`
+	Validators SelectionValidators  `json:"validators"`
+	Totals     SelectionTotals      `json:"totals"`
+	Selection  SelectionState       `json:"selection"`
+}
+
+// SelectionStatsConfig is the configuration validators are elected with.
+type SelectionStatsConfig struct {
+	EnableValidatorSelection bool   `json:"enable_validator_selection"`
+	SmallValidatorSetSize    int    `json:"small_validator_set_size"`
+	SelectionMethod          string `json:"selection_method"`
+	SelectionInterval        uint64 `json:"selection_interval"`
+}
+
+// SelectionValidators are the number of validators taking part in elections.
+type SelectionValidators struct {
+	Total        int `json:"total"`
+	Active       int `json:"active"`
+	SmallSetSize int `json:"small_set_size"`
+}
+
+// SelectionTotals are the aggregated stake, reputation and blocks of the active
+// validators.
+type SelectionTotals struct {
+	Stake      *big.Int `json:"stake"`
+	Reputation float64  `json:"reputation"`
+	Blocks     int      `json:"blocks"`
+}
+
+// SelectionState is the outcome of the last election.
+type SelectionState struct {
+	LastSelectionBlock uint64           `json:"last_selection_block"`
+	HistoryCount       int              `json:"history_count"`
+	CurrentSet         []common.Address `json:"current_set"`
+}
+
 // GetStats returns statistics about validator selection
-func (vsm *ValidatorSelectionManager) GetStats() map[string]interface{} {
+func (vsm *ValidatorSelectionManager) GetStats() *SelectionStats {
 	activeCount := 0
 	totalStake := big.NewInt(0)
 	totalReputation := 0.0
@@ -487,27 +462,27 @@ func (vsm *ValidatorSelectionManager) GetStats() map[string]interface{} {
 		}
 	}
 
-	return map[string]interface{}{
-		"config": map[string]interface{}{
-			"enable_validator_selection": vsm.config.EnableValidatorSelection,
-			"small_validator_set_size":   vsm.config.SmallValidatorSetSize,
-			"selection_method":           vsm.config.SelectionMethod,
-			"selection_window":           vsm.config.SelectionWindow.String(),
+	return &SelectionStats{
+		Config: SelectionStatsConfig{
+			EnableValidatorSelection: vsm.config.EnableValidatorSelection,
+			SmallValidatorSetSize:    vsm.config.SmallValidatorSetSize,
+			SelectionMethod:          vsm.config.SelectionMethod,
+			SelectionInterval:        vsm.config.SelectionInterval,
 		},
-		"validators": map[string]interface{}{
-			"total":          len(vsm.allValidators),
-			"active":         activeCount,
-			"small_set_size": len(vsm.smallValidatorSet),
+		Validators: SelectionValidators{
+			Total:        len(vsm.allValidators),
+			Active:       activeCount,
+			SmallSetSize: len(vsm.smallValidatorSet),
 		},
-		"totals": map[string]interface{}{
-			"stake":      totalStake.String(),
-			"reputation": totalReputation,
-			"blocks":     totalBlocks,
+		Totals: SelectionTotals{
+			Stake:      totalStake,
+			Reputation: totalReputation,
+			Blocks:     totalBlocks,
 		},
-		"selection": map[string]interface{}{
-			"last_selection":    vsm.lastSelection,
-			"history_count":     len(vsm.selectionHistory),
-			"current_set":       vsm.smallValidatorSet,
+		Selection: SelectionState{
+			LastSelectionBlock: vsm.lastSelectionBlock,
+			HistoryCount:       len(vsm.selectionHistory),
+			CurrentSet:         append([]common.Address(nil), vsm.smallValidatorSet...),
 		},
 	}
 }
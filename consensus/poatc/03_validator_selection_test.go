@@ -21,6 +21,12 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestValidatorSelectionManagerBasic(t *testing.T) {
@@ -28,7 +34,7 @@ func TestValidatorSelectionManagerBasic(t *testing.T) {
 	config.SmallValidatorSetSize = 2
 	config.SelectionMethod = "random"
 	
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	// Add validators
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -76,7 +82,7 @@ func TestValidatorSelectionManagerStakeBased(t *testing.T) {
 	config.SmallValidatorSetSize = 2
 	config.SelectionMethod = "stake"
 	
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	// Add validators with different stakes
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -115,7 +121,7 @@ func TestValidatorSelectionManagerReputationBased(t *testing.T) {
 	config.SmallValidatorSetSize = 2
 	config.SelectionMethod = "reputation"
 	
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	// Add validators with different reputations
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -157,7 +163,7 @@ func TestValidatorSelectionManagerHybrid(t *testing.T) {
 	config.ReputationWeight = 0.3
 	config.RandomWeight = 0.3
 	
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	// Add validators with different combinations of stake and reputation
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -198,7 +204,7 @@ func TestValidatorSelectionManagerDeterministic(t *testing.T) {
 	config.SmallValidatorSetSize = 2
 	config.SelectionMethod = "random"
 	
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	// Add validators
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -239,7 +245,7 @@ func TestValidatorSelectionManagerStats(t *testing.T) {
 	config := DefaultValidatorSelectionConfig()
 	config.SmallValidatorSetSize = 2
 	
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	// Add validators
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -256,22 +262,16 @@ func TestValidatorSelectionManagerStats(t *testing.T) {
 	}
 	
 	// Check basic stats structure
-	_, ok := stats["config"].(map[string]interface{})
-	if !ok {
-		t.Fatalf("Config stats not found")
+	if stats.Config.SelectionMethod != config.SelectionMethod {
+		t.Fatalf("Expected selection method %s, got %s", config.SelectionMethod, stats.Config.SelectionMethod)
 	}
 	
-	validatorsStats, ok := stats["validators"].(map[string]interface{})
-	if !ok {
-		t.Fatalf("Validators stats not found")
+	if stats.Validators.Total != 2 {
+		t.Fatalf("Expected 2 total validators, got %v", stats.Validators.Total)
 	}
 	
-	if validatorsStats["total"] != 2 {
-		t.Fatalf("Expected 2 total validators, got %v", validatorsStats["total"])
-	}
-	
-	if validatorsStats["active"] != 2 {
-		t.Fatalf("Expected 2 active validators, got %v", validatorsStats["active"])
+	if stats.Validators.Active != 2 {
+		t.Fatalf("Expected 2 active validators, got %v", stats.Validators.Active)
 	}
 	
 	t.Logf("Stats: %+v", stats)
@@ -279,7 +279,7 @@ func TestValidatorSelectionManagerStats(t *testing.T) {
 
 func TestValidatorSelectionManagerUpdate(t *testing.T) {
 	config := DefaultValidatorSelectionConfig()
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
 	
@@ -313,7 +313,7 @@ func TestValidatorSelectionManagerUpdate(t *testing.T) {
 
 func TestValidatorSelectionManagerRecordBlockMining(t *testing.T) {
 	config := DefaultValidatorSelectionConfig()
-	vsm := NewValidatorSelectionManager(config)
+	vsm := NewValidatorSelectionManager(config, nil)
 	
 	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
 	
@@ -337,3 +337,269 @@ func TestValidatorSelectionManagerRecordBlockMining(t *testing.T) {
 	
 	t.Logf("Block mining recorded successfully: %d blocks", info.BlocksMined)
 }
+
+// Tests that independent nodes replaying the same chain reach identical in-turn
+// decisions, regardless of their local validator selection state or of how the
+// chain was imported.
+func TestValidatorSelectionReplayDeterministic(t *testing.T) {
+	// Create a chain of 6 signers electing a small set of 3 every 5 blocks
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C", "D", "E", "F"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	header := &types.Header{Extra: genesis.ExtraData}
+	accounts.checkpoint(header, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30}
+	config.Poatc = &params.PoatcConfig{
+		EnableValidatorSelection: true,
+		ValidatorSelection: &params.PoatcValidatorSelectionConfig{
+			EnableValidatorSelection: true,
+			SelectionMethod:          "hybrid",
+			SmallValidatorSetSize:    3,
+			ElectionInterval:         5,
+			StakeWeight:              0.4,
+			ReputationWeight:         0.3,
+			RandomWeight:             0.3,
+		},
+	}
+	genesis.Config = &config
+
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 40, func(i int, gen *core.BlockGen) {})
+
+	// Seal the blocks round-robin, tracking the expected difficulty through a
+	// snapshot replayed alongside
+	gblock := genesis.ToBlock()
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	snap := newSnapshot(engine.config, engine.signatures, 0, gblock.Hash(), signers)
+	snap.setSelection(engine.selectionConfig, nil)
+	snap.electSmallValidatorSet(0, gblock.Hash())
+
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Extra = make([]byte, extraVanity+extraSeal)
+		if header.Number.Uint64()%config.Clique.Epoch == 0 {
+			header.Extra = make([]byte, extraVanity+len(names)*common.AddressLength+extraSeal)
+			accounts.checkpoint(header, names)
+		}
+		signer := names[i%len(names)]
+		header.Difficulty = diffNoTurn
+		if snap.inturn(header.Number.Uint64(), accounts.address(signer)) {
+			header.Difficulty = diffInTurn
+		}
+		accounts.sign(header, signer)
+		blocks[i] = block.WithSeal(header)
+
+		var err error
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("block %d: failed to apply header: %v", i+1, err)
+		}
+	}
+	// Import the chain on independent nodes, each in differently sized batches
+	// and with a diverging local view of the validators
+	batches := []int{len(blocks), 1, 7}
+	decisions := make([][]bool, len(batches))
+
+	for n, batch := range batches {
+		engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+		chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("node %d: failed to create test chain: %v", n, err)
+		}
+		defer chain.Stop()
+
+		for i := 0; i < len(blocks); i += batch {
+			end := i + batch
+			if end > len(blocks) {
+				end = len(blocks)
+			}
+			if k, err := chain.InsertChain(blocks[i:end]); err != nil {
+				t.Fatalf("node %d: failed to import block %d: %v", n, i+k+1, err)
+			}
+			if engine.validatorSelectionManager != nil {
+				engine.validatorSelectionManager.UpdateValidatorReputation(signers[n], float64(10*n+i))
+				engine.validatorSelectionManager.UpdateValidatorStake(signers[n], big.NewInt(int64(1000*(n+i))))
+			}
+		}
+		for _, block := range blocks {
+			parent := chain.GetHeaderByHash(block.ParentHash())
+			snap, err := engine.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+			if err != nil {
+				t.Fatalf("node %d: failed to retrieve snapshot %d: %v", n, parent.Number, err)
+			}
+			for _, signer := range signers {
+				decisions[n] = append(decisions[n], snap.inturn(block.NumberU64(), signer))
+			}
+		}
+	}
+	for n := 1; n < len(decisions); n++ {
+		for i := range decisions[0] {
+			if decisions[n][i] != decisions[0][i] {
+				t.Fatalf("node %d: in-turn decision %d mismatch: have %v, want %v", n, i, decisions[n][i], decisions[0][i])
+			}
+		}
+	}
+}
+
+// Tests that checkpoints commit to the stakes held by the staking contract in
+// their parent state, that the election is weighted by them without overflowing
+// on wei amounts, and that importing nodes reject checkpoints misstating them.
+func TestStakeWeightedElection(t *testing.T) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	accounts.checkpoint(&types.Header{Extra: genesis.ExtraData}, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 3}
+	config.Poatc = &params.PoatcConfig{
+		EnableValidatorSelection: true,
+		ValidatorSelection: &params.PoatcValidatorSelectionConfig{
+			EnableValidatorSelection: true,
+			SelectionMethod:          "stake",
+			SmallValidatorSetSize:    1,
+			ElectionInterval:         3,
+			StakeWeight:              1,
+			Staking: &params.PoatcStakingConfig{
+				Contract: common.Address{0x10, 0x00},
+				Slot:     2,
+			},
+		},
+	}
+	genesis.Config = &config
+
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	// Allocate the stakes in the genesis, A's being well beyond 64 bits of wei
+	whale, _ := new(big.Int).SetString("1000000000000000000000000000000", 10)
+	stakes := map[common.Address]*big.Int{
+		accounts.address("A"): whale,
+		accounts.address("B"): big.NewInt(params.Ether),
+	}
+	storage := make(map[common.Hash]common.Hash)
+	for signer, stake := range stakes {
+		storage[engine.stakingRules.stakeSlot(signer)] = common.BigToHash(stake)
+	}
+	genesis.Alloc = types.GenesisAlloc{
+		config.Poatc.ValidatorSelection.Staking.Contract: {Code: []byte{0x00}, Storage: storage, Balance: new(big.Int)},
+	}
+	// Generate the blocks without stakes (the generator has no state access) and
+	// seal them round-robin, committing to the stakes at the checkpoint
+	generator := NewWithConfig(config.Clique, nil, rawdb.NewMemoryDatabase())
+	generator.fakeDiff = true
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, generator, 3, func(i int, gen *core.BlockGen) {})
+
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.ToBlock().Hash(), signers)
+	checkpoint := make([]*big.Int, len(signers))
+	for i, signer := range snap.signers() {
+		checkpoint[i] = new(big.Int)
+		if stake, ok := stakes[signer]; ok {
+			checkpoint[i].Set(stake)
+		}
+	}
+	seal := func(blocks []*types.Block, stakes []*big.Int) []*types.Block {
+		sealed := make([]*types.Block, len(blocks))
+		for i, block := range blocks {
+			header := block.Header()
+			if i > 0 {
+				header.ParentHash = sealed[i-1].Hash()
+			}
+			header.Extra = make([]byte, extraVanity+extraSeal)
+			if header.Number.Uint64()%config.Clique.Epoch == 0 {
+				header.Extra = make([]byte, extraVanity+len(names)*common.AddressLength+extraSeal)
+				accounts.checkpoint(header, names)
+				header.Extra = append(header.Extra[:len(header.Extra)-extraSeal], append(encodeStakes(stakes), make([]byte, extraSeal)...)...)
+			}
+			header.Difficulty = diffInTurn // Ignored, we just need a valid number
+
+			accounts.sign(header, names[i%len(names)])
+			sealed[i] = block.WithSeal(header)
+		}
+		return sealed
+	}
+	// Import the chain and ensure the checkpoint stakes drove the election
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(seal(blocks, checkpoint)); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	head := chain.CurrentBlock()
+	snap, err = engine.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	for _, signer := range signers {
+		want := new(big.Int)
+		if stake, ok := stakes[signer]; ok {
+			want = stake
+		}
+		if have := snap.Stakes[signer]; have == nil || have.Cmp(want) != 0 {
+			t.Errorf("stake of %x mismatch: have %v, want %v", signer, have, want)
+		}
+	}
+	if len(snap.SmallSet) != 1 || snap.SmallSet[0] != accounts.address("A") {
+		t.Errorf("small validator set mismatch: have %x, want [%x]", snap.SmallSet, accounts.address("A"))
+	}
+	// Ensure a locally sealed checkpoint reads the same stakes from state
+	header := &types.Header{ParentHash: head.ParentHash, Number: head.Number}
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare checkpoint: %v", err)
+	}
+	if have := engine.stakingRules.stakes(header, len(signers)); len(have) != len(checkpoint) || have[0].Cmp(checkpoint[0]) != 0 || have[1].Cmp(checkpoint[1]) != 0 {
+		t.Errorf("prepared stakes mismatch: have %v, want %v", have, checkpoint)
+	}
+	// Misstate the stakes and ensure the checkpoint is rejected
+	checkpoint[0], checkpoint[1] = checkpoint[1], checkpoint[0]
+
+	engine = NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	chain, err = core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(seal(blocks, checkpoint)); err != errMismatchingCheckpointStakes {
+		t.Fatalf("misstated stakes error mismatch: have %v, want %v", err, errMismatchingCheckpointStakes)
+	}
+	// Ensure a checkpoint on top of a missing state is reported as a pruned
+	// ancestor to be reimported as a side chain, not misverified
+	sealed := seal(blocks, checkpoint)
+	if err := engine.VerifyUncles(&prunedStateChain{chain}, sealed[len(sealed)-1]); err != consensus.ErrPrunedAncestor {
+		t.Fatalf("pruned state error mismatch: have %v, want %v", err, consensus.ErrPrunedAncestor)
+	}
+}
+
+// prunedStateChain is a blockchain missing the state of every block.
+type prunedStateChain struct {
+	*core.BlockChain
+}
+
+func (c *prunedStateChain) HasState(root common.Hash) bool { return false }
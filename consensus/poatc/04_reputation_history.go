@@ -0,0 +1,163 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxReputationHistoryPoints is the maximum number of points a reputation history
+// served over RPC may contain.
+const maxReputationHistoryPoints = 10_000
+
+// ReputationSnapshot is the local reputation of a validator as of a block, along
+// with the components the score is weighted from.
+type ReputationSnapshot struct {
+	Number    uint64    `json:"number"`    // Block the reputation is given at
+	UpdatedAt uint64    `json:"updatedAt"` // Block the reputation last changed at
+	Timestamp time.Time `json:"timestamp"` // Local time the reputation last changed at
+
+	Score       float64 `json:"score"`
+	BlockMining float64 `json:"blockMining"`
+	Uptime      float64 `json:"uptime"`
+	Consistency float64 `json:"consistency"`
+	Penalty     float64 `json:"penalty"`
+
+	BlocksMined int  `json:"blocksMined"`
+	Violations  int  `json:"violations"`
+	Active      bool `json:"active"`
+}
+
+// newReputationSnapshot takes a snapshot of a reputation score at a block.
+func newReputationSnapshot(score *ReputationScore, number uint64) *ReputationSnapshot {
+	return &ReputationSnapshot{
+		Number:      number,
+		UpdatedAt:   number,
+		Timestamp:   score.LastUpdate,
+		Score:       score.CurrentScore,
+		BlockMining: score.BlockMiningScore,
+		Uptime:      score.UptimeScore,
+		Consistency: score.ConsistencyScore,
+		Penalty:     score.PenaltyScore,
+		BlocksMined: score.TotalBlocksMined,
+		Violations:  score.ViolationCount,
+		Active:      score.IsActive,
+	}
+}
+
+// recordHistory stores the reputation of a validator as of the latest block
+// recorded, replacing any earlier change at the same block. The caller must
+// hold the lock.
+func (rs *ReputationSystem) recordHistory(address common.Address) {
+	score := rs.scores[address]
+	if rs.db == nil || score == nil {
+		return
+	}
+	blob, err := json.Marshal(newReputationSnapshot(score, rs.head))
+	if err != nil {
+		log.Error("Failed to encode reputation snapshot", "address", address, "err", err)
+		return
+	}
+	rawdb.WritePoatcReputationSnapshot(rs.db, address, rs.head, blob)
+}
+
+// reputationAt returns the reputation of a validator as of a block, which is the
+// latest one stored at or before it, or nil if none was recorded by then.
+func (rs *ReputationSystem) reputationAt(address common.Address, number uint64) *ReputationSnapshot {
+	if rs.db == nil {
+		return nil
+	}
+	updated, blob := rawdb.ReadPoatcReputationSnapshotAt(rs.db, address, number)
+	if blob == nil {
+		return nil
+	}
+	snap := new(ReputationSnapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		log.Error("Failed to decode reputation snapshot", "address", address, "number", updated, "err", err)
+		return nil
+	}
+	snap.Number, snap.UpdatedAt = number, updated
+	return snap
+}
+
+// GetReputationHistory returns the reputation of a validator at every step-th
+// block between from and to (inclusive). Reputations only change at some blocks,
+// every point holds the latest one as of its block. Blocks before the validator
+// was first recorded are skipped.
+func (rs *ReputationSystem) GetReputationHistory(address common.Address, from, to, step uint64) []*ReputationSnapshot {
+	if rs.db == nil || from > to {
+		return nil
+	}
+	if step == 0 {
+		step = 1
+	}
+	// Start from the reputation as of the first point, walking the changes after it
+	var (
+		history []*ReputationSnapshot
+		last    = rs.reputationAt(address, from)
+		start   = from
+		next    = from
+		done    bool
+	)
+	if last != nil {
+		start = last.UpdatedAt + 1
+	}
+	// fill adds the points before the given block, holding the latest reputation
+	fill := func(until uint64) {
+		for !done && next < until {
+			if last != nil {
+				point := *last
+				point.Number = next
+				history = append(history, &point)
+			}
+			if to-next < step {
+				done = true
+			}
+			next += step
+		}
+	}
+	rawdb.IteratePoatcReputationHistory(rs.db, address, start, func(number uint64, blob []byte) bool {
+		if number > to {
+			return false
+		}
+		fill(number)
+		if done {
+			return false
+		}
+		snap := new(ReputationSnapshot)
+		if err := json.Unmarshal(blob, snap); err != nil {
+			log.Error("Failed to decode reputation snapshot", "address", address, "number", number, "err", err)
+			return false
+		}
+		snap.UpdatedAt = number
+		last = snap
+		return true
+	})
+	fill(to)
+	if !done && last != nil {
+		// The last point falls on the upper bound of the range
+		point := *last
+		point.Number = next
+		history = append(history, &point)
+	}
+	return history
+}
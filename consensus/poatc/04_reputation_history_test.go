@@ -0,0 +1,164 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestReputationHistory(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		clock = new(mclock.Simulated)
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	)
+	rs := NewReputationSystem(DefaultReputationConfig(), db, clock)
+	rs.AddValidator(addr)
+	clock.Run(time.Minute)
+	rs.RecordBlockMining(addr, 3)
+	clock.Run(time.Minute)
+	rs.RecordBlockMining(addr, 7)
+
+	tests := []struct {
+		from, to, step uint64
+		numbers        []uint64 // Blocks of the points returned
+		updated        []uint64 // Blocks the reputations were last changed at
+		mined          []int    // Blocks mined as of every point
+	}{
+		// Every block, carrying the reputation forward between changes
+		{0, 8, 1, []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8}, []uint64{0, 0, 0, 3, 3, 3, 3, 7, 7}, []int{0, 0, 0, 1, 1, 1, 1, 2, 2}},
+		// Sampled blocks, a zero step standing for every block
+		{1, 10, 4, []uint64{1, 5, 9}, []uint64{0, 3, 7}, []int{0, 1, 2}},
+		{2, 4, 0, []uint64{2, 3, 4}, []uint64{0, 3, 3}, []int{0, 1, 1}},
+		// Ranges starting between or after the changes
+		{5, 6, 1, []uint64{5, 6}, []uint64{3, 3}, []int{1, 1}},
+		{100, 100, 1, []uint64{100}, []uint64{7}, []int{2}},
+		// Steps larger than the range
+		{0, 2, 1000, []uint64{0}, []uint64{0}, []int{0}},
+		// Invalid range
+		{5, 4, 1, nil, nil, nil},
+	}
+	for i, tt := range tests {
+		history := rs.GetReputationHistory(addr, tt.from, tt.to, tt.step)
+		if len(history) != len(tt.numbers) {
+			t.Errorf("test %d: history length mismatch: have %d, want %d", i, len(history), len(tt.numbers))
+			continue
+		}
+		for j, point := range history {
+			if point.Number != tt.numbers[j] || point.UpdatedAt != tt.updated[j] || point.BlocksMined != tt.mined[j] {
+				t.Errorf("test %d, point %d: have number %d, updated %d, mined %d, want %d, %d, %d",
+					i, j, point.Number, point.UpdatedAt, point.BlocksMined, tt.numbers[j], tt.updated[j], tt.mined[j])
+			}
+		}
+	}
+	// The latest point matches the live score
+	history := rs.GetReputationHistory(addr, 7, 7, 1)
+	score := rs.GetReputationScore(addr)
+	want := newReputationSnapshot(score, 7)
+	if !history[0].Timestamp.Equal(want.Timestamp) {
+		t.Errorf("latest snapshot time mismatch: have %v, want %v", history[0].Timestamp, want.Timestamp)
+	}
+	history[0].Timestamp = want.Timestamp
+	if !reflect.DeepEqual(history[0], want) {
+		t.Errorf("latest snapshot mismatch: have %+v, want %+v", history[0], want)
+	}
+	// Unknown validators have no history
+	if history := rs.GetReputationHistory(common.Address{0x22}, 0, 10, 1); len(history) != 0 {
+		t.Errorf("history of unknown validator returned: %v", history)
+	}
+}
+
+func TestReputationHistoryPersistence(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		clock = new(mclock.Simulated)
+		addr  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	)
+	rs := NewReputationSystem(DefaultReputationConfig(), db, clock)
+	rs.AddValidator(addr)
+	rs.RecordBlockMining(addr, 5)
+	want := rs.GetReputationHistory(addr, 0, 10, 1)
+
+	// A restarted system serves the same history and keeps extending it from
+	// the latest block mined
+	restarted := NewReputationSystem(DefaultReputationConfig(), db, clock)
+	if have := restarted.GetReputationHistory(addr, 0, 10, 1); !reflect.DeepEqual(have, want) {
+		t.Fatalf("history mismatch after restart: have %v, want %v", have, want)
+	}
+	restarted.RecordViolation(addr, 8, "test", "test violation")
+
+	history := restarted.GetReputationHistory(addr, 4, 8, 1)
+	if len(history) != 5 {
+		t.Fatalf("history length mismatch: have %d, want 5", len(history))
+	}
+	if history[0].UpdatedAt != 0 || history[1].UpdatedAt != 5 || history[4].UpdatedAt != 8 {
+		t.Errorf("history changes mismatch: have %d, %d, %d, want 0, 5, 8", history[0].UpdatedAt, history[1].UpdatedAt, history[4].UpdatedAt)
+	}
+	if history[3].Violations != 0 || history[4].Violations != 1 {
+		t.Errorf("violation count mismatch: have %d, %d, want 0, 1", history[3].Violations, history[4].Violations)
+	}
+}
+
+// Tests that the reputation as of a block is sought out among sparse changes,
+// without walking the history from its start.
+func TestReputationAt(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		addr    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		changes = []uint64{2, 3, 4, 10, 11, 500, 1000, 1001, 65536}
+	)
+	rs := NewReputationSystem(DefaultReputationConfig(), db, new(mclock.Simulated))
+	for i, number := range changes {
+		rawdb.WritePoatcReputationSnapshot(db, addr, number, []byte(fmt.Sprintf(`{"blocksMined":%d}`, i+1)))
+	}
+	rawdb.WritePoatcReputationSnapshot(db, common.Address{0x22}, 5, []byte(`{"blocksMined":100}`))
+
+	// Query the blocks around every change, and some way past the latest one
+	queries := []uint64{0, 1, 1 << 20}
+	for _, change := range changes {
+		queries = append(queries, change-1, change, change+1)
+	}
+	for _, number := range queries {
+		var (
+			want    uint64
+			mined   int
+			changed bool
+		)
+		for i, change := range changes {
+			if change <= number {
+				want, mined, changed = change, i+1, true
+			}
+		}
+		have := rs.reputationAt(addr, number)
+		if !changed {
+			if have != nil {
+				t.Fatalf("block %d: reputation returned before the first change: %+v", number, have)
+			}
+			continue
+		}
+		if have == nil || have.Number != number || have.UpdatedAt != want || have.BlocksMined != mined {
+			t.Fatalf("block %d: reputation mismatch: have %+v, want updated at %d with %d mined", number, have, want, mined)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ScoreCause is the reason a reputation score changed.
+type ScoreCause string
+
+const (
+	CauseJoined            ScoreCause = "joined"              // Validator added with the initial reputation
+	CauseBlockMined        ScoreCause = "block_mined"         // Reward for a sealed block
+	CauseViolation         ScoreCause = "violation"           // Violation recorded below the penalty threshold
+	CausePenalty           ScoreCause = "penalty"             // Penalty for a violation past the threshold
+	CauseUptime            ScoreCause = "uptime"              // Reward for the time spent online
+	CauseChainUptime       ScoreCause = "chain_uptime"        // Uptime derived from the in-turn slots sealed on chain
+	CauseConsistency       ScoreCause = "consistency"         // Regularity of the block mining intervals
+	CauseDecay             ScoreCause = "decay"               // Decay of the scores over time
+	CausePartialReset      ScoreCause = "partial_reset"       // Periodic halving of the accumulated scores
+	CauseNewValidatorBoost ScoreCause = "new_validator_boost" // Boost of validators in their first day
+	CauseVeteranPenalty    ScoreCause = "veteran_penalty"     // Penalty of validators older than 30 days
+	CauseComponentCap      ScoreCause = "component_cap"       // Component capped at its maximum
+	CauseScoreBounds       ScoreCause = "score_bounds"        // Score clamped into the allowed range
+	CauseInvalidScore      ScoreCause = "invalid_score"       // Non-finite score replaced by the initial reputation
+	CauseRecalculation     ScoreCause = "recalculation"       // Score rederived from its components
+)
+
+// ScoreComponent is the part of a reputation score a change applies to.
+type ScoreComponent string
+
+const (
+	ComponentBlockMining ScoreComponent = "blockMining"
+	ComponentUptime      ScoreComponent = "uptime"
+	ComponentConsistency ScoreComponent = "consistency"
+	ComponentPenalty     ScoreComponent = "penalty"
+	ComponentBounds      ScoreComponent = "bounds" // Part of the total not carried by the weighted components, such as clamping
+	ComponentTotal       ScoreComponent = "total"
+)
+
+// partialResetFactor is the part of the accumulated component scores kept by a
+// partial reset.
+const partialResetFactor = 0.5
+
+// ScoreDelta is a single change of the reputation of a validator, along with
+// its cause and the configuration parameter it was derived from.
+type ScoreDelta struct {
+	Number    uint64         `json:"number"`    // Block the change is filed under
+	Timestamp time.Time      `json:"timestamp"` // Local time of the change
+	Cause     ScoreCause     `json:"cause"`
+	Component ScoreComponent `json:"component"`
+	Parameter string         `json:"parameter,omitempty"` // Configuration parameter involved
+	Setting   string         `json:"setting,omitempty"`   // Value of the parameter when applied
+	Before    float64        `json:"before"`              // Component value before the change
+	After     float64        `json:"after"`               // Component value after the change
+	Impact    float64        `json:"impact"`              // Change of the total score caused
+	Detail    string         `json:"detail,omitempty"`
+}
+
+// ScoreContribution is the share of a component in the total reputation score.
+// The contributions of all components add up to the total score.
+type ScoreContribution struct {
+	Component    ScoreComponent `json:"component"`
+	Value        float64        `json:"value"`
+	Weight       float64        `json:"weight"`
+	Contribution float64        `json:"contribution"`
+}
+
+// ReputationExplanation accounts for the reputation of a validator at a block:
+// the weighted components it is made of and the changes that led to it from
+// the reputation before its latest change. The impacts of the changes add up
+// to the difference between the two scores.
+type ReputationExplanation struct {
+	Address       common.Address       `json:"address"`
+	Number        uint64               `json:"number"`
+	Reputation    *ReputationSnapshot  `json:"reputation"`         // Reputation as of the block
+	Previous      *ReputationSnapshot  `json:"previous,omitempty"` // Reputation before its latest change, if any
+	Contributions []*ScoreContribution `json:"contributions"`
+	Changes       []*ScoreDelta        `json:"changes"` // Changes at the block the reputation last changed at
+}
+
+// weight returns the factor a change of the component is carried over to the
+// total score with.
+func (rs *ReputationSystem) weight(component ScoreComponent) float64 {
+	switch component {
+	case ComponentBlockMining:
+		return rs.config.BlockMiningWeight
+	case ComponentUptime:
+		return rs.config.UptimeWeight
+	case ComponentConsistency:
+		return rs.config.ConsistencyWeight
+	case ComponentPenalty:
+		return -rs.config.PenaltyWeight
+	default:
+		return 1
+	}
+}
+
+// recordDelta files a change of a score component of a validator in the ledger
+// under the latest block recorded. Unchanged components are skipped. The caller
+// must hold the lock.
+func (rs *ReputationSystem) recordDelta(address common.Address, cause ScoreCause, component ScoreComponent, parameter string, setting interface{}, before, after float64, detail string) {
+	if before == after {
+		return
+	}
+	rs.appendLedger(address, &ScoreDelta{
+		Cause:     cause,
+		Component: component,
+		Parameter: parameter,
+		Setting:   fmt.Sprint(setting),
+		Before:    before,
+		After:     after,
+		Detail:    detail,
+	})
+}
+
+// ledgerAccount tracks how much of the total score of a validator the changes
+// filed in the ledger account for.
+type ledgerAccount struct {
+	score   float64 // Total score as of the latest calculation
+	bounds  float64 // Difference between the total score and the weighted sum of its components
+	pending float64 // Impacts of the changes filed since the latest calculation
+}
+
+// account returns the score accounting of a validator. The caller must hold
+// the lock.
+func (rs *ReputationSystem) account(address common.Address) *ledgerAccount {
+	account := rs.ledger[address]
+	if account == nil {
+		account = new(ledgerAccount)
+		rs.ledger[address] = account
+	}
+	return account
+}
+
+// weightedScore returns the weighted sum of the components of a score, before
+// it is clamped into the allowed range.
+func (rs *ReputationSystem) weightedScore(score *ReputationScore) float64 {
+	return rs.config.BlockMiningWeight*score.BlockMiningScore +
+		rs.config.UptimeWeight*score.UptimeScore +
+		rs.config.ConsistencyWeight*score.ConsistencyScore -
+		rs.config.PenaltyWeight*score.PenaltyScore
+}
+
+// appendLedger files a change in the ledger, accounting for its impact on the
+// total score until the next score calculation. The caller must hold the lock.
+func (rs *ReputationSystem) appendLedger(address common.Address, delta *ScoreDelta) {
+	if rs.db == nil {
+		return
+	}
+	delta.Number = rs.head
+	delta.Timestamp = rs.now()
+	delta.Impact = rs.weight(delta.Component) * (delta.After - delta.Before)
+	rs.account(address).pending += delta.Impact
+
+	blob, err := json.Marshal(delta)
+	if err != nil {
+		log.Error("Failed to encode reputation delta", "address", address, "err", err)
+		return
+	}
+	batch := rs.db.NewBatch()
+	rawdb.WritePoatcReputationDelta(batch, address, rs.head, rs.ledgerSeq, blob)
+	rawdb.WritePoatcReputationLedgerHead(batch, rs.ledgerSeq+1)
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to store reputation delta", "address", address, "err", err)
+		return
+	}
+	rs.ledgerSeq++
+}
+
+// reconcileLedger files the changes of a newly calculated total score which the
+// component changes don't account for: clamping into the allowed range, invalid
+// scores and scores not derived from the components, such as the initial
+// reputation. The caller must hold the lock.
+func (rs *ReputationSystem) reconcileLedger(address common.Address, raw float64, score *ReputationScore) {
+	if rs.db == nil {
+		return
+	}
+	account := rs.account(address)
+	if math.IsNaN(raw) || math.IsInf(raw, 0) {
+		raw = rs.config.InitialReputation
+		rs.appendLedger(address, &ScoreDelta{
+			Cause:     CauseInvalidScore,
+			Component: ComponentTotal,
+			Parameter: "InitialReputation",
+			Setting:   fmt.Sprint(rs.config.InitialReputation),
+			Before:    account.score + account.pending,
+			After:     raw,
+		})
+	}
+	// Changes of the clamping are filed as changes of its own component
+	bounds, parameter, setting := score.CurrentScore-raw, "MinReputation", rs.config.MinReputation
+	if bounds < 0 || (bounds == 0 && account.bounds < 0) {
+		parameter, setting = "MaxReputation", rs.config.MaxReputation
+	}
+	rs.recordDelta(address, CauseScoreBounds, ComponentBounds, parameter, setting, account.bounds, bounds,
+		fmt.Sprintf("weighted score %.4f", raw))
+	account.bounds = bounds
+
+	if expected := account.score + account.pending; math.Abs(score.CurrentScore-expected) > 1e-9 {
+		rs.appendLedger(address, &ScoreDelta{
+			Cause:     CauseRecalculation,
+			Component: ComponentTotal,
+			Before:    expected,
+			After:     score.CurrentScore,
+			Detail:    "score rederived from its weighted components",
+		})
+	}
+	account.score, account.pending = score.CurrentScore, 0
+}
+
+// readLedger retrieves the changes of a validator filed under a block, in the
+// order they were filed.
+func (rs *ReputationSystem) readLedger(address common.Address, number uint64) []*ScoreDelta {
+	var ledger []*ScoreDelta
+	rawdb.IteratePoatcReputationLedger(rs.db, address, number, func(seq uint64, blob []byte) bool {
+		delta := new(ScoreDelta)
+		if err := json.Unmarshal(blob, delta); err != nil {
+			log.Error("Failed to decode reputation delta", "address", address, "number", number, "seq", seq, "err", err)
+			return true
+		}
+		ledger = append(ledger, delta)
+		return true
+	})
+	return ledger
+}
+
+// ExplainReputation accounts for the reputation of a validator as of a block,
+// or returns nil if none was recorded by then.
+func (rs *ReputationSystem) ExplainReputation(address common.Address, number uint64) *ReputationExplanation {
+	reputation := rs.reputationAt(address, number)
+	if reputation == nil {
+		return nil
+	}
+
+	explanation := &ReputationExplanation{
+		Address:    address,
+		Number:     number,
+		Reputation: reputation,
+		Changes:    rs.readLedger(address, reputation.UpdatedAt),
+		Contributions: []*ScoreContribution{
+			rs.contribution(ComponentBlockMining, reputation.BlockMining),
+			rs.contribution(ComponentUptime, reputation.Uptime),
+			rs.contribution(ComponentConsistency, reputation.Consistency),
+			rs.contribution(ComponentPenalty, reputation.Penalty),
+		},
+	}
+	var weighted float64
+	for _, contribution := range explanation.Contributions {
+		weighted += contribution.Contribution
+	}
+	if bounds := reputation.Score - weighted; bounds != 0 {
+		explanation.Contributions = append(explanation.Contributions, rs.contribution(ComponentBounds, bounds))
+	}
+	if reputation.UpdatedAt > 0 {
+		explanation.Previous = rs.reputationAt(address, reputation.UpdatedAt-1)
+	}
+	return explanation
+}
+
+// contribution returns the share of a component value in the total score.
+func (rs *ReputationSystem) contribution(component ScoreComponent, value float64) *ScoreContribution {
+	weight := rs.weight(component)
+	return &ScoreContribution{
+		Component:    component,
+		Value:        value,
+		Weight:       weight,
+		Contribution: weight * value,
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// checkExplanation verifies that the changes of an explanation account for the
+// difference between the previous and the explained score, returning the
+// changes by cause.
+func checkExplanation(t *testing.T, explanation *ReputationExplanation) map[ScoreCause][]*ScoreDelta {
+	t.Helper()
+
+	if explanation == nil {
+		t.Fatalf("no explanation returned")
+	}
+	var previous, impact float64
+	if explanation.Previous != nil {
+		previous = explanation.Previous.Score
+	}
+	causes := make(map[ScoreCause][]*ScoreDelta)
+	for _, change := range explanation.Changes {
+		if change.Number != explanation.Reputation.UpdatedAt {
+			t.Errorf("change filed under block %d, want %d", change.Number, explanation.Reputation.UpdatedAt)
+		}
+		impact += change.Impact
+		causes[change.Cause] = append(causes[change.Cause], change)
+	}
+	if math.Abs(previous+impact-explanation.Reputation.Score) > 1e-9 {
+		t.Errorf("block %d: changes don't account for the score: %v%+v != %v", explanation.Number, previous, impact, explanation.Reputation.Score)
+	}
+	var total float64
+	for _, contribution := range explanation.Contributions {
+		total += contribution.Contribution
+	}
+	if math.Abs(total-explanation.Reputation.Score) > 1e-9 {
+		t.Errorf("block %d: contributions don't add up to the score: %v != %v", explanation.Number, total, explanation.Reputation.Score)
+	}
+	return causes
+}
+
+func TestReputationLedger(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		clock  = new(mclock.Simulated)
+		addr   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		config = DefaultReputationConfig()
+	)
+	config.PenaltyThreshold = 2
+
+	rs := NewReputationSystem(config, db, clock)
+	rs.AddValidator(addr)
+
+	// The initial reputation is explained by the validator joining
+	causes := checkExplanation(t, rs.ExplainReputation(addr, 0))
+	if joined := causes[CauseJoined]; len(joined) != 1 || joined[0].After != config.InitialReputation || joined[0].Parameter != "InitialReputation" {
+		t.Errorf("join mismatch: have %+v", joined)
+	}
+	// New validators are boosted on top of the block rewards
+	clock.Run(time.Minute)
+	rs.RecordBlockMining(addr, 3)
+
+	explanation := rs.ExplainReputation(addr, 4)
+	causes = checkExplanation(t, explanation)
+	if explanation.Reputation.UpdatedAt != 3 || explanation.Previous == nil || explanation.Previous.UpdatedAt != 0 {
+		t.Errorf("explained change mismatch: have %+v, previous %+v", explanation.Reputation, explanation.Previous)
+	}
+	if mined := causes[CauseBlockMined]; len(mined) != 1 || mined[0].Parameter != "BlockMiningReward" || mined[0].After-mined[0].Before != config.BlockMiningReward {
+		t.Errorf("block reward mismatch: have %+v", mined)
+	}
+	if boosts := causes[CauseNewValidatorBoost]; len(boosts) != 2 || boosts[0].Component != ComponentBlockMining || boosts[1].Component != ComponentUptime {
+		t.Errorf("boost mismatch: have %+v", boosts)
+	}
+	// Violations below the threshold are filed without an impact, the ones past
+	// it are penalized
+	rs.RecordViolation(addr, 5, "timestamp_drift", "drift")
+	causes = checkExplanation(t, rs.ExplainReputation(addr, 5))
+	if violations := causes[CauseViolation]; len(violations) != 1 || violations[0].Impact != 0 {
+		t.Errorf("violation mismatch: have %+v", violations)
+	}
+	rs.RecordViolation(addr, 6, "timestamp_drift", "drift")
+	causes = checkExplanation(t, rs.ExplainReputation(addr, 6))
+	if penalties := causes[CausePenalty]; len(penalties) != 1 || penalties[0].Detail != "timestamp_drift" ||
+		math.Abs(penalties[0].Impact+config.PenaltyWeight*config.PenaltyAmount) > 1e-9 {
+		t.Errorf("penalty mismatch: have %+v", penalties)
+	}
+	// Decay is filed for every component it shrinks
+	clock.Run(25 * time.Hour)
+	rs.RecordBlockMining(addr, 7)
+	rs.ApplyDecay(addr, 0.1)
+	causes = checkExplanation(t, rs.ExplainReputation(addr, 7))
+	if decays := causes[CauseDecay]; len(decays) == 0 || decays[0].Parameter != "ReputationDecayRate" || decays[0].Impact >= 0 {
+		t.Errorf("decay mismatch: have %+v", decays)
+	}
+	if boosts := causes[CauseNewValidatorBoost]; len(boosts) != 0 {
+		t.Errorf("boost applied after the first day: %+v", boosts)
+	}
+	// Unknown validators and blocks before the first record aren't explained
+	if explanation := rs.ExplainReputation(common.Address{0x22}, 7); explanation != nil {
+		t.Errorf("explanation of unknown validator returned: %+v", explanation)
+	}
+}
+
+func TestReputationLedgerBounds(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		addr   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		config = DefaultReputationConfig()
+	)
+	config.NewValidatorBoost = 0
+
+	rs := NewReputationSystem(config, db, new(mclock.Simulated))
+	rs.AddValidator(addr)
+	rs.RecordBlockMining(addr, 1)
+
+	// A single block reward falls below the minimum reputation
+	causes := checkExplanation(t, rs.ExplainReputation(addr, 1))
+	if bounds := causes[CauseScoreBounds]; len(bounds) != 1 || bounds[0].Parameter != "MinReputation" || bounds[0].Before != 0 || bounds[0].After <= 0 {
+		t.Errorf("bounds mismatch: have %+v", bounds)
+	}
+	// The initial reputation isn't derived from the components, replacing it is
+	// filed as a recalculation
+	if recalculations := causes[CauseRecalculation]; len(recalculations) != 1 || recalculations[0].After != config.MinReputation {
+		t.Errorf("recalculation mismatch: have %+v", recalculations)
+	}
+	// Restarted systems keep accounting from the stored scores
+	restarted := NewReputationSystem(config, db, new(mclock.Simulated))
+	restarted.RecordViolation(addr, 2, "test", "test violation")
+	causes = checkExplanation(t, restarted.ExplainReputation(addr, 2))
+	if len(causes[CauseRecalculation]) != 0 {
+		t.Errorf("unexpected recalculation after restart: %+v", causes[CauseRecalculation])
+	}
+}
+
+// Tests that every change is filed under its own key, and that recalculating a
+// score which doesn't change files nothing.
+func TestReputationLedgerRecalculation(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		clock  = new(mclock.Simulated)
+		addr   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		config = DefaultReputationConfig()
+	)
+	// Regular blocks earn a consistency above the component cap, and the uptime
+	// of the veteran validator is tracked on chain
+	config.ConsistencyReward = 2 * config.MaxComponentScore
+
+	rs := NewReputationSystem(config, db, clock)
+	rs.AddValidator(addr)
+	rs.SetUptime(addr, 0.5)
+	for number := uint64(1); number <= 3; number++ {
+		clock.Run(time.Minute)
+		rs.RecordBlockMining(addr, number)
+	}
+	clock.Run(31 * 24 * time.Hour)
+
+	recalculate := func() {
+		rs.mutex.Lock()
+		defer rs.mutex.Unlock()
+		rs.calculateTotalScore(addr)
+	}
+	for i := 0; rs.GetReputationScore(addr).BlockMiningScore > 0; i++ {
+		if i == 100 {
+			t.Fatalf("veteran penalty never drained the block mining score")
+		}
+		recalculate()
+	}
+	filed := len(rs.readLedger(addr, rs.head))
+	recalculate()
+	if changes := rs.readLedger(addr, rs.head); len(changes) != filed {
+		t.Errorf("unchanged score recalculation filed changes: %+v", changes[filed:])
+	}
+	// The changes are stored one per key, the sequence resuming after restarts
+	var seqs []uint64
+	rawdb.IteratePoatcReputationLedger(db, addr, rs.head, func(seq uint64, blob []byte) bool {
+		seqs = append(seqs, seq)
+		return true
+	})
+	if len(seqs) != filed {
+		t.Errorf("stored change count mismatch: have %d, want %d", len(seqs), filed)
+	}
+	for i := 1; i < len(seqs); i++ {
+		if seqs[i] <= seqs[i-1] {
+			t.Errorf("change %d stored out of order: seq %d after %d", i, seqs[i], seqs[i-1])
+		}
+	}
+	if restarted := NewReputationSystem(config, db, clock); restarted.ledgerSeq != rs.ledgerSeq {
+		t.Errorf("ledger sequence not resumed: have %d, want %d", restarted.ledgerSeq, rs.ledgerSeq)
+	}
+}
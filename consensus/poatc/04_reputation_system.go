@@ -20,12 +20,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -132,6 +135,15 @@ type ReputationEvent struct {
 	Description string         `json:"description"`
 }
 
+// ReputationChange is published whenever the reputation score of a validator
+// changes.
+type ReputationChange struct {
+	Address       common.Address `json:"address"`
+	PreviousScore float64        `json:"previous_score"`
+	CurrentScore  float64        `json:"current_score"`
+	Timestamp     time.Time      `json:"timestamp"`
+}
+
 // ReputationSystem manages validator reputation scores
 type ReputationSystem struct {
 	config     *ReputationConfig
@@ -139,11 +151,18 @@ type ReputationSystem struct {
 	scores     map[common.Address]*ReputationScore
 	events     []ReputationEvent
 	lastUpdate time.Time
+	clock      mclock.Clock // Source of time of the clock based scores
 	mutex      sync.RWMutex
 
 	// Performance tracking
 	blockTimes    map[common.Address][]time.Time // Track block mining times
 	uptimeTracker map[common.Address]*UptimeTracker
+	uptimes       map[common.Address]float64 // Uptimes derived from the missed slots tracked on chain, overriding the clock based ones
+	head          uint64                     // Latest block recorded, which score changes are filed under in the history
+	ledger        map[common.Address]*ledgerAccount // Score accounting of the changes filed in the ledger
+	ledgerSeq     uint64                            // Sequence number of the next change filed in the ledger
+
+	feed *event.Feed // Feed the score changes are published to, if any
 }
 
 // UptimeTracker tracks validator uptime
@@ -161,11 +180,13 @@ type OnlinePeriod struct {
 	End   time.Time `json:"end"`
 }
 
-// NewReputationSystem creates a new reputation system
-func NewReputationSystem(config *ReputationConfig, db ethdb.Database) *ReputationSystem {
+// NewReputationSystem creates a new reputation system, timing the scores with
+// the given clock or the system clock if nil.
+func NewReputationSystem(config *ReputationConfig, db ethdb.Database, clock mclock.Clock) *ReputationSystem {
 	if config == nil {
 		config = DefaultReputationConfig()
 	}
+	clock = orSystemClock(clock)
 
 	rs := &ReputationSystem{
 		config:        config,
@@ -174,7 +195,10 @@ func NewReputationSystem(config *ReputationConfig, db ethdb.Database) *Reputatio
 		events:        make([]ReputationEvent, 0),
 		blockTimes:    make(map[common.Address][]time.Time),
 		uptimeTracker: make(map[common.Address]*UptimeTracker),
-		lastUpdate:    time.Now(),
+		uptimes:       make(map[common.Address]float64),
+		lastUpdate:    wallTime(clock),
+		clock:         clock,
+		ledger:        make(map[common.Address]*ledgerAccount),
 	}
 
 	// Load existing data from database
@@ -183,13 +207,26 @@ func NewReputationSystem(config *ReputationConfig, db ethdb.Database) *Reputatio
 	return rs
 }
 
+// now returns the current time of the reputation system's clock.
+func (rs *ReputationSystem) now() time.Time {
+	return wallTime(rs.clock)
+}
+
+// SetFeed sets the feed the reputation score changes are published to.
+func (rs *ReputationSystem) SetFeed(feed *event.Feed) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	rs.feed = feed
+}
+
 // AddValidator adds a new validator to the reputation system
 func (rs *ReputationSystem) AddValidator(address common.Address) {
 	rs.mutex.Lock()
 	defer rs.mutex.Unlock()
 
 	if _, exists := rs.scores[address]; !exists {
-		now := time.Now()
+		now := rs.now()
 		rs.scores[address] = &ReputationScore{
 			Address:          address,
 			CurrentScore:     rs.config.InitialReputation,
@@ -219,6 +256,8 @@ func (rs *ReputationSystem) AddValidator(address common.Address) {
 		rs.blockTimes[address] = make([]time.Time, 0)
 
 		log.Info("Validator added to reputation system", "address", address.Hex(), "initial_score", rs.config.InitialReputation)
+		rs.recordDelta(address, CauseJoined, ComponentTotal, "InitialReputation", rs.config.InitialReputation, 0, rs.config.InitialReputation, "")
+		rs.recordHistory(address)
 		rs.saveToDatabase()
 	}
 }
@@ -228,19 +267,24 @@ func (rs *ReputationSystem) RecordBlockMining(address common.Address, blockNumbe
 	rs.mutex.Lock()
 	defer rs.mutex.Unlock()
 
+	if blockNumber > rs.head {
+		rs.head = blockNumber
+	}
 	if score, exists := rs.scores[address]; exists {
 		// Update block mining score with cap
 		newBlockMiningScore := score.BlockMiningScore + rs.config.BlockMiningReward
 		if newBlockMiningScore > rs.config.MaxComponentScore {
 			newBlockMiningScore = rs.config.MaxComponentScore
 		}
+		rs.recordDelta(address, CauseBlockMined, ComponentBlockMining, "BlockMiningReward", rs.config.BlockMiningReward,
+			score.BlockMiningScore, newBlockMiningScore, fmt.Sprintf("block %d", blockNumber))
 		score.BlockMiningScore = newBlockMiningScore
 		score.LastBlockMined = blockNumber
 		score.TotalBlocksMined++
-		score.LastUpdate = time.Now()
+		score.LastUpdate = rs.now()
 
 		// Track block mining time for consistency calculation
-		now := time.Now()
+		now := rs.now()
 		rs.blockTimes[address] = append(rs.blockTimes[address], now)
 
 		// Keep only recent block times (last 100 blocks)
@@ -283,12 +327,17 @@ func (rs *ReputationSystem) RecordViolation(address common.Address, blockNumber
 	rs.mutex.Lock()
 	defer rs.mutex.Unlock()
 
+	if blockNumber > rs.head {
+		rs.head = blockNumber
+	}
 	if score, exists := rs.scores[address]; exists {
 		score.ViolationCount++
-		score.LastUpdate = time.Now()
+		score.LastUpdate = rs.now()
 
 		// Apply penalty if threshold is reached
 		if score.ViolationCount >= rs.config.PenaltyThreshold {
+			rs.recordDelta(address, CausePenalty, ComponentPenalty, "PenaltyAmount", rs.config.PenaltyAmount,
+				score.PenaltyScore, score.PenaltyScore+rs.config.PenaltyAmount, violationType)
 			score.PenaltyScore += rs.config.PenaltyAmount
 
 			// Record penalty event
@@ -297,18 +346,27 @@ func (rs *ReputationSystem) RecordViolation(address common.Address, blockNumber
 				EventType:   "penalty",
 				ScoreChange: -rs.config.PenaltyAmount,
 				BlockNumber: blockNumber,
-				Timestamp:   time.Now(),
+				Timestamp:   rs.now(),
 				Description: fmt.Sprintf("Penalty applied: %s", description),
 			}
 			rs.events = append(rs.events, event)
 		} else {
+			rs.appendLedger(address, &ScoreDelta{
+				Cause:     CauseViolation,
+				Component: ComponentPenalty,
+				Parameter: "PenaltyThreshold",
+				Setting:   fmt.Sprint(rs.config.PenaltyThreshold),
+				Before:    score.PenaltyScore,
+				After:     score.PenaltyScore,
+				Detail:    fmt.Sprintf("%s, violation %d", violationType, score.ViolationCount),
+			})
 			// Record violation event
 			event := ReputationEvent{
 				Address:     address,
 				EventType:   "violation",
 				ScoreChange: 0,
 				BlockNumber: blockNumber,
-				Timestamp:   time.Now(),
+				Timestamp:   rs.now(),
 				Description: fmt.Sprintf("Violation: %s", description),
 			}
 			rs.events = append(rs.events, event)
@@ -328,7 +386,7 @@ func (rs *ReputationSystem) UpdateUptime(address common.Address) {
 	defer rs.mutex.Unlock()
 
 	if tracker, exists := rs.uptimeTracker[address]; exists {
-		now := time.Now()
+		now := rs.now()
 
 		if tracker.IsOnline {
 			// Update total uptime
@@ -342,6 +400,8 @@ func (rs *ReputationSystem) UpdateUptime(address common.Address) {
 				if newUptimeScore > rs.config.MaxComponentScore {
 					newUptimeScore = rs.config.MaxComponentScore
 				}
+				rs.recordDelta(address, CauseUptime, ComponentUptime, "UptimeReward", rs.config.UptimeReward,
+					score.UptimeScore, newUptimeScore, fmt.Sprintf("%.4f hours online", hours))
 				score.UptimeScore = newUptimeScore
 				score.UptimeHours += hours
 				score.LastUpdate = now
@@ -355,6 +415,23 @@ func (rs *ReputationSystem) UpdateUptime(address common.Address) {
 	}
 }
 
+// SetUptime sets the uptime score of a validator from the share of its in-turn
+// slots it sealed, as tracked by the voting snapshot. Unlike UpdateUptime it
+// doesn't depend on the local clock, so every node derives the same score, and
+// it takes precedence over the clock based adjustments from then on.
+func (rs *ReputationSystem) SetUptime(address common.Address, uptime float64) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	if old, ok := rs.uptimes[address]; ok && old == uptime {
+		return
+	}
+	rs.uptimes[address] = uptime
+	if _, exists := rs.scores[address]; exists {
+		rs.calculateTotalScore(address)
+	}
+}
+
 // MarkValidatorOffline marks a validator as offline
 func (rs *ReputationSystem) MarkValidatorOffline(address common.Address) {
 	rs.mutex.Lock()
@@ -368,7 +445,7 @@ func (rs *ReputationSystem) MarkValidatorOffline(address common.Address) {
 			if len(tracker.OnlinePeriods) > 0 {
 				lastPeriod := &tracker.OnlinePeriods[len(tracker.OnlinePeriods)-1]
 				if lastPeriod.End.IsZero() {
-					lastPeriod.End = time.Now()
+					lastPeriod.End = rs.now()
 				}
 			}
 
@@ -381,16 +458,24 @@ func (rs *ReputationSystem) MarkValidatorOffline(address common.Address) {
 func (rs *ReputationSystem) calculateTotalScore(address common.Address) {
 	if score, exists := rs.scores[address]; exists {
 		// Calculate consistency score based on block mining intervals
+		consistency := score.ConsistencyScore
 		rs.calculateConsistencyScore(address)
+		rs.recordDelta(address, CauseConsistency, ComponentConsistency, "ConsistencyReward", rs.config.ConsistencyReward,
+			consistency, score.ConsistencyScore, "")
 
 		// Apply fairness mechanisms
 		rs.applyFairnessMechanisms(address)
 
+		// Uptimes tracked on chain replace the clock based ones
+		if uptime, ok := rs.uptimes[address]; ok {
+			rs.recordDelta(address, CauseChainUptime, ComponentUptime, "MaxComponentScore", rs.config.MaxComponentScore,
+				score.UptimeScore, uptime*rs.config.MaxComponentScore, fmt.Sprintf("%.2f%% of in-turn slots sealed", uptime*100))
+			score.UptimeScore = uptime * rs.config.MaxComponentScore
+		}
+
 		// Calculate weighted total score
-		totalScore := rs.config.BlockMiningWeight*score.BlockMiningScore +
-			rs.config.UptimeWeight*score.UptimeScore +
-			rs.config.ConsistencyWeight*score.ConsistencyScore -
-			rs.config.PenaltyWeight*score.PenaltyScore
+		totalScore := rs.weightedScore(score)
+		raw := totalScore
 
 		// Check for NaN or infinite values
 		if math.IsNaN(totalScore) || math.IsInf(totalScore, 0) {
@@ -407,13 +492,28 @@ func (rs *ReputationSystem) calculateTotalScore(address common.Address) {
 
 		score.PreviousScore = score.CurrentScore
 		score.CurrentScore = totalScore
+		rs.reconcileLedger(address, raw, score)
+
+		if rs.feed != nil && score.CurrentScore != score.PreviousScore {
+			rs.feed.Send(ReputationChange{
+				Address:       address,
+				PreviousScore: score.PreviousScore,
+				CurrentScore:  score.CurrentScore,
+				Timestamp:     rs.now(),
+			})
+		}
+		rs.recordHistory(address)
 	}
 }
 
 // applyFairnessMechanisms applies fairness mechanisms to prevent score accumulation
 func (rs *ReputationSystem) applyFairnessMechanisms(address common.Address) {
 	if score, exists := rs.scores[address]; exists {
-		now := time.Now()
+		now := rs.now()
+
+		// Uptimes tracked on chain replace the clock based ones right after, so
+		// adjusting them here would only file changes undone by the replacement
+		_, chainUptime := rs.uptimes[address]
 
 		// 1. Check if it's time for a partial reset
 		if now.Sub(score.LastReset) >= rs.config.ResetInterval {
@@ -424,8 +524,13 @@ func (rs *ReputationSystem) applyFairnessMechanisms(address common.Address) {
 		if score.IsNewValidator && now.Sub(score.JoinTime) < 24*time.Hour {
 			// Give new validators a boost for first 24 hours
 			boost := rs.config.NewValidatorBoost
+			mining, uptime := score.BlockMiningScore, score.UptimeScore
 			score.BlockMiningScore = math.Min(score.BlockMiningScore+boost, rs.config.MaxComponentScore)
-			score.UptimeScore = math.Min(score.UptimeScore+boost, rs.config.MaxComponentScore)
+			rs.recordDelta(address, CauseNewValidatorBoost, ComponentBlockMining, "NewValidatorBoost", boost, mining, score.BlockMiningScore, "")
+			if !chainUptime {
+				score.UptimeScore = math.Min(score.UptimeScore+boost, rs.config.MaxComponentScore)
+				rs.recordDelta(address, CauseNewValidatorBoost, ComponentUptime, "NewValidatorBoost", boost, uptime, score.UptimeScore, "")
+			}
 		} else if now.Sub(score.JoinTime) >= 24*time.Hour {
 			score.IsNewValidator = false
 		}
@@ -434,14 +539,23 @@ func (rs *ReputationSystem) applyFairnessMechanisms(address common.Address) {
 		if now.Sub(score.JoinTime) > 30*24*time.Hour { // 30 days
 			penalty := rs.config.VeteranPenalty
 			score.VeteranPenalty = penalty
+			mining, uptime := score.BlockMiningScore, score.UptimeScore
 			score.BlockMiningScore = math.Max(score.BlockMiningScore-penalty, 0)
-			score.UptimeScore = math.Max(score.UptimeScore-penalty, 0)
+			rs.recordDelta(address, CauseVeteranPenalty, ComponentBlockMining, "VeteranPenalty", penalty, mining, score.BlockMiningScore, "")
+			if !chainUptime {
+				score.UptimeScore = math.Max(score.UptimeScore-penalty, 0)
+				rs.recordDelta(address, CauseVeteranPenalty, ComponentUptime, "VeteranPenalty", penalty, uptime, score.UptimeScore, "")
+			}
 		}
 
 		// 4. Ensure component scores don't exceed maximum
+		mining, uptime, consistency := score.BlockMiningScore, score.UptimeScore, score.ConsistencyScore
 		score.BlockMiningScore = math.Min(score.BlockMiningScore, rs.config.MaxComponentScore)
 		score.UptimeScore = math.Min(score.UptimeScore, rs.config.MaxComponentScore)
 		score.ConsistencyScore = math.Min(score.ConsistencyScore, rs.config.MaxComponentScore)
+		rs.recordDelta(address, CauseComponentCap, ComponentBlockMining, "MaxComponentScore", rs.config.MaxComponentScore, mining, score.BlockMiningScore, "")
+		rs.recordDelta(address, CauseComponentCap, ComponentUptime, "MaxComponentScore", rs.config.MaxComponentScore, uptime, score.UptimeScore, "")
+		rs.recordDelta(address, CauseComponentCap, ComponentConsistency, "MaxComponentScore", rs.config.MaxComponentScore, consistency, score.ConsistencyScore, "")
 	}
 }
 
@@ -449,14 +563,17 @@ func (rs *ReputationSystem) applyFairnessMechanisms(address common.Address) {
 func (rs *ReputationSystem) performPartialReset(address common.Address) {
 	if score, exists := rs.scores[address]; exists {
 		// Reset 50% of accumulated scores to prevent infinite accumulation
-		resetFactor := 0.5
+		mining, uptime, consistency := score.BlockMiningScore, score.UptimeScore, score.ConsistencyScore
+		score.BlockMiningScore *= partialResetFactor
+		score.UptimeScore *= partialResetFactor
+		score.ConsistencyScore *= partialResetFactor
 
-		score.BlockMiningScore *= resetFactor
-		score.UptimeScore *= resetFactor
-		score.ConsistencyScore *= resetFactor
+		rs.recordDelta(address, CausePartialReset, ComponentBlockMining, "ResetInterval", rs.config.ResetInterval, mining, score.BlockMiningScore, "")
+		rs.recordDelta(address, CausePartialReset, ComponentUptime, "ResetInterval", rs.config.ResetInterval, uptime, score.UptimeScore, "")
+		rs.recordDelta(address, CausePartialReset, ComponentConsistency, "ResetInterval", rs.config.ResetInterval, consistency, score.ConsistencyScore, "")
 
 		// Update reset time
-		score.LastReset = time.Now()
+		score.LastReset = rs.now()
 
 		log.Info("Partial score reset applied",
 			"address", address.Hex(),
@@ -499,7 +616,7 @@ func (rs *ReputationSystem) calculateConsistencyScore(address common.Address) {
 			if math.IsNaN(consistencyScore) || math.IsInf(consistencyScore, 0) {
 				consistencyScore = 0
 			}
-			score.ConsistencyScore = consistencyScore
+			score.ConsistencyScore = math.Min(consistencyScore, rs.config.MaxComponentScore)
 		} else {
 			score.ConsistencyScore = 0
 		}
@@ -511,15 +628,19 @@ func (rs *ReputationSystem) UpdateReputation() {
 	rs.mutex.Lock()
 	defer rs.mutex.Unlock()
 
-	now := time.Now()
+	now := rs.now()
 
 	// Apply decay factor and fairness mechanisms
 	for address, score := range rs.scores {
 		if now.Sub(score.LastUpdate) > rs.config.UpdateInterval {
 			// Apply stronger decay to prevent score accumulation
+			mining, uptime, consistency := score.BlockMiningScore, score.UptimeScore, score.ConsistencyScore
 			score.BlockMiningScore *= rs.config.DecayFactor
 			score.UptimeScore *= rs.config.DecayFactor
 			score.ConsistencyScore *= rs.config.DecayFactor
+			rs.recordDelta(address, CauseDecay, ComponentBlockMining, "DecayFactor", rs.config.DecayFactor, mining, score.BlockMiningScore, "")
+			rs.recordDelta(address, CauseDecay, ComponentUptime, "DecayFactor", rs.config.DecayFactor, uptime, score.UptimeScore, "")
+			rs.recordDelta(address, CauseDecay, ComponentConsistency, "DecayFactor", rs.config.DecayFactor, consistency, score.ConsistencyScore, "")
 			score.CurrentScore *= rs.config.DecayFactor
 			score.LastUpdate = now
 
@@ -588,33 +709,60 @@ func (rs *ReputationSystem) GetTopValidators(limit int) []*ReputationScore {
 	return scores
 }
 
+// ReputationStats are the statistics of the reputation system.
+type ReputationStats struct {
+	Config     ReputationStatsConfig `json:"config"`
+	Validators ReputationValidators  `json:"validators"`
+	Reputation ReputationSummary     `json:"reputation"`
+	Events     ReputationEventCounts `json:"events"`
+	LastUpdate time.Time             `json:"last_update"`
+}
+
+// ReputationStatsConfig is the configuration reputation is scored with.
+type ReputationStatsConfig struct {
+	EnableReputationSystem bool    `json:"enable_reputation_system"`
+	InitialReputation      float64 `json:"initial_reputation"`
+	MaxReputation          float64 `json:"max_reputation"`
+	MinReputation          float64 `json:"min_reputation"`
+	EvaluationWindow       string  `json:"evaluation_window"`
+	UpdateInterval         string  `json:"update_interval"`
+}
+
+// ReputationValidators are the number of validators being scored.
+type ReputationValidators struct {
+	Total  int `json:"total"`
+	Active int `json:"active"`
+}
+
+// ReputationSummary summarizes the scores of the active validators.
+type ReputationSummary struct {
+	Average float64 `json:"average"`
+	Highest float64 `json:"highest"`
+	Lowest  float64 `json:"lowest"`
+}
+
+// ReputationEventCounts are the number of reputation events recorded.
+type ReputationEventCounts struct {
+	Total int `json:"total"`
+}
+
 // GetReputationStats returns statistics about the reputation system
-func (rs *ReputationSystem) GetReputationStats() map[string]interface{} {
+func (rs *ReputationSystem) GetReputationStats() *ReputationStats {
 	rs.mutex.RLock()
 	defer rs.mutex.RUnlock()
 
-	stats := map[string]interface{}{
-		"config": map[string]interface{}{
-			"enable_reputation_system": rs.config.EnableReputationSystem,
-			"initial_reputation":       rs.config.InitialReputation,
-			"max_reputation":           rs.config.MaxReputation,
-			"min_reputation":           rs.config.MinReputation,
-			"evaluation_window":        rs.config.EvaluationWindow.String(),
-			"update_interval":          rs.config.UpdateInterval.String(),
+	stats := &ReputationStats{
+		Config: ReputationStatsConfig{
+			EnableReputationSystem: rs.config.EnableReputationSystem,
+			InitialReputation:      rs.config.InitialReputation,
+			MaxReputation:          rs.config.MaxReputation,
+			MinReputation:          rs.config.MinReputation,
+			EvaluationWindow:       rs.config.EvaluationWindow.String(),
+			UpdateInterval:         rs.config.UpdateInterval.String(),
 		},
-		"validators": map[string]interface{}{
-			"total":  len(rs.scores),
-			"active": 0,
-		},
-		"reputation": map[string]interface{}{
-			"average": 0.0,
-			"highest": 0.0,
-			"lowest":  0.0,
-		},
-		"events": map[string]interface{}{
-			"total": len(rs.events),
-		},
-		"last_update": rs.lastUpdate,
+		Validators: ReputationValidators{Total: len(rs.scores)},
+		Events:     ReputationEventCounts{Total: len(rs.events)},
+		LastUpdate: rs.lastUpdate,
 	}
 
 	// Calculate statistics
@@ -643,13 +791,13 @@ func (rs *ReputationSystem) GetReputationStats() map[string]interface{} {
 		}
 	}
 
-	stats["validators"].(map[string]interface{})["active"] = activeCount
+	stats.Validators.Active = activeCount
 
 	if activeCount > 0 {
-		stats["reputation"].(map[string]interface{})["average"] = totalScore / float64(activeCount)
+		stats.Reputation.Average = totalScore / float64(activeCount)
 	}
-	stats["reputation"].(map[string]interface{})["highest"] = highestScore
-	stats["reputation"].(map[string]interface{})["lowest"] = lowestScore
+	stats.Reputation.Highest = highestScore
+	stats.Reputation.Lowest = lowestScore
 
 	return stats
 }
@@ -707,10 +855,22 @@ func (rs *ReputationSystem) loadFromDatabase() {
 		return
 	}
 
+	rs.ledgerSeq = rawdb.ReadPoatcReputationLedgerHead(rs.db)
+
 	// Load scores
 	if scoresData, err := rs.db.Get(rawdb.ReputationScoresKey); err == nil {
 		json.Unmarshal(scoresData, &rs.scores)
 	}
+	// Resume attributing score changes after the latest block mined
+	for address, score := range rs.scores {
+		if score.LastBlockMined > rs.head {
+			rs.head = score.LastBlockMined
+		}
+		rs.ledger[address] = &ledgerAccount{
+			score:  score.CurrentScore,
+			bounds: score.CurrentScore - rs.weightedScore(score),
+		}
+	}
 
 	// Load events
 	if eventsData, err := rs.db.Get(rawdb.ReputationEventsKey); err == nil {
@@ -729,9 +889,13 @@ func (rs *ReputationSystem) ApplyDecay(address common.Address, decayFactor float
 		oldScore := score.CurrentScore
 		
 		// Apply decay to all component scores
+		mining, uptime, consistency := score.BlockMiningScore, score.UptimeScore, score.ConsistencyScore
 		score.BlockMiningScore *= (1.0 - decayFactor)
 		score.UptimeScore *= (1.0 - decayFactor)
 		score.ConsistencyScore *= (1.0 - decayFactor)
+		rs.recordDelta(address, CauseDecay, ComponentBlockMining, "ReputationDecayRate", decayFactor, mining, score.BlockMiningScore, "")
+		rs.recordDelta(address, CauseDecay, ComponentUptime, "ReputationDecayRate", decayFactor, uptime, score.UptimeScore, "")
+		rs.recordDelta(address, CauseDecay, ComponentConsistency, "ReputationDecayRate", decayFactor, consistency, score.ConsistencyScore, "")
 		
 		// Recalculate total score
 		rs.calculateTotalScore(address)
@@ -742,7 +906,7 @@ func (rs *ReputationSystem) ApplyDecay(address common.Address, decayFactor float
 			EventType:   "decay",
 			ScoreChange: score.CurrentScore - oldScore,
 			BlockNumber: 0, // Decay is not block-specific
-			Timestamp:   time.Now(),
+			Timestamp:   rs.now(),
 			Description: fmt.Sprintf("Reputation decay applied: factor %.4f", decayFactor),
 		}
 		rs.events = append(rs.events, event)
@@ -752,8 +916,88 @@ func (rs *ReputationSystem) ApplyDecay(address common.Address, decayFactor float
 			rs.events = rs.events[len(rs.events)-1000:]
 		}
 		
-		score.LastUpdate = time.Now()
+		score.LastUpdate = rs.now()
 		rs.saveToDatabase()
 	}
 }
 
+
+// reputationScoreLength is the number of extra-data bytes a checkpoint block
+// uses to commit to the consensus reputation of each signer.
+const reputationScoreLength = 8
+
+// ReputationRules are the consensus parameters of the reputation tracked in
+// the voting snapshot. Scores are fixed point integers with selectionPrecision
+// units per reputation point, so every node derives bit-identical values.
+type ReputationRules struct {
+	Initial           uint64 // Score of a newly authorized signer
+	Max               uint64 // Upper bound of a score
+	Min               uint64 // Lower bound of a score
+	BlockReward       uint64 // Score gained for every sealed block
+	ViolationPenalty  uint64 // Score lost for every recorded violation
+	DecayFactor       uint64 // Part of the score kept at every epoch transition
+	MaxTimestampDrift uint64 // Seconds a block may follow its parent without a drift violation
+	LowThreshold      uint64 // Score below which a signer is counted as poorly performing at epoch transitions
+}
+
+// newReputationRules converts the reputation and anomaly detection configs
+// into the fixed point consensus rules.
+func newReputationRules(config *ReputationConfig, maxTimestampDrift int64) *ReputationRules {
+	rules := &ReputationRules{
+		Initial:          fixedPoint(config.InitialReputation).Uint64(),
+		Max:              fixedPoint(config.MaxReputation).Uint64(),
+		Min:              fixedPoint(config.MinReputation).Uint64(),
+		BlockReward:      fixedPoint(config.BlockMiningReward).Uint64(),
+		ViolationPenalty: fixedPoint(config.PenaltyAmount).Uint64(),
+		DecayFactor:      fixedPoint(config.DecayFactor).Uint64(),
+		LowThreshold:     fixedPoint(config.LowReputationThreshold).Uint64(),
+	}
+	if maxTimestampDrift > 0 {
+		rules.MaxTimestampDrift = uint64(maxTimestampDrift)
+	}
+	return rules
+}
+
+// SignerReputation is the consensus reputation of a signer. It is derived only
+// from the contents of the blocks, so every node tracks the same values. Only
+// the score is committed to by checkpoint blocks, the remaining fields are
+// informational and restart from zero on nodes syncing from a checkpoint.
+type SignerReputation struct {
+	Score        uint64 `json:"score"`        // Reputation score in fixed point units
+	BlocksSigned uint64 `json:"blocksSigned"` // Number of blocks sealed since tracking started
+	LastSigned   uint64 `json:"lastSigned"`   // Number of the last block sealed
+	Violations   uint64 `json:"violations"`   // Number of violations recorded on chain
+	LowEpochs    uint64 `json:"lowEpochs"`    // Epoch transitions in a row the score was below the low threshold at
+}
+
+// Float returns the reputation score in reputation points.
+func (r SignerReputation) Float() float64 {
+	return float64(r.Score) / selectionPrecision
+}
+
+// reward increases the score by the given amount, capped at the maximum.
+func (r *SignerReputation) reward(rules *ReputationRules, amount uint64) {
+	r.Score += amount
+	if r.Score > rules.Max {
+		r.Score = rules.Max
+	}
+}
+
+// penalize decreases the score by the given amount, floored at the minimum.
+func (r *SignerReputation) penalize(rules *ReputationRules, amount uint64) {
+	if r.Score < rules.Min+amount {
+		r.Score = rules.Min
+		return
+	}
+	r.Score -= amount
+}
+
+// decay scales the score by the decay factor, floored at the minimum.
+func (r *SignerReputation) decay(rules *ReputationRules) {
+	score := new(big.Int).SetUint64(r.Score)
+	score.Mul(score, new(big.Int).SetUint64(rules.DecayFactor))
+	r.Score = score.Div(score, big.NewInt(selectionPrecision)).Uint64()
+	if r.Score < rules.Min {
+		r.Score = rules.Min
+	}
+}
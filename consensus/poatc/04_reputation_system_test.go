@@ -17,10 +17,17 @@
 package poatc
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestReputationSystemBasic(t *testing.T) {
@@ -28,7 +35,7 @@ func TestReputationSystemBasic(t *testing.T) {
 	config.BlockMiningReward = 0.1
 	config.UptimeReward = 0.05
 
-	rs := NewReputationSystem(config, nil)
+	rs := NewReputationSystem(config, nil, nil)
 
 	// Add validators
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -72,7 +79,7 @@ func TestReputationSystemViolations(t *testing.T) {
 	config.PenaltyThreshold = 2
 	config.PenaltyAmount = 0.5
 
-	rs := NewReputationSystem(config, nil)
+	rs := NewReputationSystem(config, nil, nil)
 
 	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
 	rs.AddValidator(addr)
@@ -114,38 +121,105 @@ func TestReputationSystemViolations(t *testing.T) {
 func TestReputationSystemUptime(t *testing.T) {
 	config := DefaultReputationConfig()
 	config.UptimeReward = 0.1
+	config.NewValidatorBoost = 0
 
-	rs := NewReputationSystem(config, nil)
+	clock := new(mclock.Simulated)
+	rs := NewReputationSystem(config, nil, clock)
 
 	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
 	rs.AddValidator(addr)
 
-	initialScore := rs.GetReputationScore(addr)
-	if initialScore == nil {
-		t.Fatalf("Failed to get initial score")
-	}
-
 	// Simulate uptime
-	rs.UpdateUptime(addr)
-	time.Sleep(100 * time.Millisecond) // Small delay
+	clock.Run(90 * time.Minute)
 	rs.UpdateUptime(addr)
 
-	// Check uptime score increased
 	score := rs.GetReputationScore(addr)
-	if score.UptimeScore <= initialScore.UptimeScore {
-		t.Fatalf("Uptime score should have increased")
+	if score.UptimeHours != 1.5 {
+		t.Errorf("uptime hours mismatch: have %v, want %v", score.UptimeHours, 1.5)
+	}
+	if want := 1.5 * config.UptimeReward; score.UptimeScore != want {
+		t.Errorf("uptime score mismatch: have %v, want %v", score.UptimeScore, want)
 	}
+}
+
+// Tests that scores decay once they weren't updated for longer than the update
+// interval.
+func TestReputationSystemDecay(t *testing.T) {
+	config := DefaultReputationConfig()
+	config.BlockMiningReward = 1
+	config.NewValidatorBoost = 0
 
-	t.Logf("Initial uptime score: %f", initialScore.UptimeScore)
-	t.Logf("Updated uptime score: %f", score.UptimeScore)
-	t.Logf("Uptime hours: %f", score.UptimeHours)
+	clock := new(mclock.Simulated)
+	rs := NewReputationSystem(config, nil, clock)
+
+	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	rs.AddValidator(addr)
+	rs.RecordBlockMining(addr, 1)
+
+	// Scores updated within the interval are kept
+	clock.Run(config.UpdateInterval)
+	rs.UpdateReputation()
+	if score := rs.GetReputationScore(addr); score.BlockMiningScore != 1 {
+		t.Fatalf("score decayed within the update interval: have %v, want %v", score.BlockMiningScore, 1)
+	}
+	// Scores left alone for longer decay
+	clock.Run(1)
+	rs.UpdateReputation()
+
+	score := rs.GetReputationScore(addr)
+	if score.BlockMiningScore != config.DecayFactor {
+		t.Errorf("decayed score mismatch: have %v, want %v", score.BlockMiningScore, config.DecayFactor)
+	}
+	if want := time.Unix(0, int64(clock.Now())); !score.LastUpdate.Equal(want) {
+		t.Errorf("update time mismatch: have %v, want %v", score.LastUpdate, want)
+	}
+}
+
+// Tests that accumulated scores are halved once per reset interval, and that
+// validators stop being new after a day.
+func TestReputationSystemPartialReset(t *testing.T) {
+	config := DefaultReputationConfig()
+	config.BlockMiningReward = 1
+	config.NewValidatorBoost = 0
+
+	clock := new(mclock.Simulated)
+	rs := NewReputationSystem(config, nil, clock)
+
+	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	rs.AddValidator(addr)
+	rs.RecordBlockMining(addr, 1)
+	rs.RecordBlockMining(addr, 2)
+
+	clock.Run(config.ResetInterval - 1)
+	rs.RecordBlockMining(addr, 3)
+	if score := rs.GetReputationScore(addr); score.BlockMiningScore != 3 || !score.LastReset.Equal(time.Unix(0, 0)) {
+		t.Fatalf("score reset early: have %v, reset at %v", score.BlockMiningScore, score.LastReset)
+	}
+	clock.Run(1)
+	rs.RecordBlockMining(addr, 4)
+
+	score := rs.GetReputationScore(addr)
+	if score.BlockMiningScore != 2 {
+		t.Errorf("reset score mismatch: have %v, want %v", score.BlockMiningScore, 2)
+	}
+	if want := time.Unix(0, int64(clock.Now())); !score.LastReset.Equal(want) {
+		t.Errorf("reset time mismatch: have %v, want %v", score.LastReset, want)
+	}
+	if score.IsNewValidator {
+		t.Errorf("validator still new after %v", config.ResetInterval)
+	}
+	// The next reset is a full interval away
+	rs.RecordBlockMining(addr, 5)
+	if score := rs.GetReputationScore(addr); score.BlockMiningScore != 3 {
+		t.Errorf("score reset twice: have %v, want %v", score.BlockMiningScore, 3)
+	}
 }
 
 func TestReputationSystemTopValidators(t *testing.T) {
 	config := DefaultReputationConfig()
 	config.BlockMiningReward = 0.1
 
-	rs := NewReputationSystem(config, nil)
+	rs := NewReputationSystem(config, nil, nil)
 
 	// Add validators
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -203,7 +277,7 @@ func TestReputationSystemTopValidators(t *testing.T) {
 func TestReputationSystemStats(t *testing.T) {
 	config := DefaultReputationConfig()
 
-	rs := NewReputationSystem(config, nil)
+	rs := NewReputationSystem(config, nil, nil)
 
 	// Add validators
 	addr1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -225,32 +299,21 @@ func TestReputationSystemStats(t *testing.T) {
 	}
 
 	// Check basic stats structure
-	_, ok := stats["config"].(map[string]interface{})
-	if !ok {
-		t.Fatalf("Config stats not found")
+	if !stats.Config.EnableReputationSystem {
+		t.Fatalf("Config stats should show the reputation system enabled")
 	}
 
-	validatorsStats, ok := stats["validators"].(map[string]interface{})
-	if !ok {
-		t.Fatalf("Validators stats not found")
+	if stats.Validators.Total != 2 {
+		t.Fatalf("Expected 2 total validators, got %v", stats.Validators.Total)
 	}
 
-	if validatorsStats["total"] != 2 {
-		t.Fatalf("Expected 2 total validators, got %v", validatorsStats["total"])
-	}
-
-	if validatorsStats["active"] != 2 {
-		t.Fatalf("Expected 2 active validators, got %v", validatorsStats["active"])
+	if stats.Validators.Active != 2 {
+		t.Fatalf("Expected 2 active validators, got %v", stats.Validators.Active)
 	}
 
 	// Check reputation stats
-	reputationStats, ok := stats["reputation"].(map[string]interface{})
-	if !ok {
-		t.Fatalf("Reputation stats not found")
-	}
-
-	if reputationStats["average"] == nil {
-		t.Fatalf("Average reputation should not be nil")
+	if stats.Reputation.Average == 0 {
+		t.Fatalf("Average reputation should not be zero")
 	}
 
 	t.Logf("Stats: %+v", stats)
@@ -259,7 +322,7 @@ func TestReputationSystemStats(t *testing.T) {
 func TestReputationSystemEvents(t *testing.T) {
 	config := DefaultReputationConfig()
 
-	rs := NewReputationSystem(config, nil)
+	rs := NewReputationSystem(config, nil, nil)
 
 	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
 	rs.AddValidator(addr)
@@ -306,7 +369,7 @@ func TestReputationSystemEvents(t *testing.T) {
 func TestReputationSystemOfflineTracking(t *testing.T) {
 	config := DefaultReputationConfig()
 
-	rs := NewReputationSystem(config, nil)
+	rs := NewReputationSystem(config, nil, nil)
 
 	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
 	rs.AddValidator(addr)
@@ -332,7 +395,7 @@ func TestReputationSystemPersistence(t *testing.T) {
 	config := DefaultReputationConfig()
 
 	// Create first reputation system
-	rs1 := NewReputationSystem(config, nil)
+	rs1 := NewReputationSystem(config, nil, nil)
 
 	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
 	rs1.AddValidator(addr)
@@ -349,3 +412,129 @@ func TestReputationSystemPersistence(t *testing.T) {
 	// In real system, persistence is handled by the main consensus engine
 	t.Log("Skipping persistence test with nil database - persistence is handled by main system")
 }
+
+// Tests that the consensus reputation is derived from the block contents alone,
+// committed to by checkpoint blocks and verified by importing nodes.
+func TestReputationCommittedAtCheckpoint(t *testing.T) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	accounts.checkpoint(&types.Header{Extra: genesis.ExtraData}, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 10}
+	config.Poatc = &params.PoatcConfig{
+		EnableReputationSystem: true,
+		Reputation: &params.PoatcReputationConfig{
+			EnableReputationSystem: true,
+			InitialReputation:      1.0,
+			MaxReputation:          10.0,
+			MinReputation:          0.1,
+			BlockMiningWeight:      0.4,
+			UptimeWeight:           0.3,
+			ConsistencyWeight:      0.2,
+			PenaltyWeight:          0.1,
+			DecayRate:              0.5,
+		},
+	}
+	genesis.Config = &config
+
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 12, func(i int, gen *core.BlockGen) {})
+
+	// Seal the blocks round-robin with block 5 sealed late, committing to the
+	// reputation tracked by a snapshot replayed alongside
+	gblock := genesis.ToBlock()
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	snap := newSnapshot(engine.config, engine.signatures, 0, gblock.Hash(), signers)
+	snap.Time = gblock.Time()
+	snap.setReputationRules(engine.reputationRules)
+	snap.seedReputation(nil)
+
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Time = snap.Time + 10
+		if header.Number.Uint64() == 5 {
+			header.Time += 100
+		}
+		signer := names[i%len(names)]
+
+		header.Extra = make([]byte, extraVanity)
+		if header.Number.Uint64()%config.Clique.Epoch == 0 {
+			header.Extra = append(header.Extra, engine.checkpointSection(snap, header.Number.Uint64(), header.Time, accounts.address(signer))...)
+		}
+		header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+		header.Difficulty = diffInTurn // Ignored, we just need a valid number
+
+		accounts.sign(header, signer)
+		blocks[i] = block.WithSeal(header)
+
+		var err error
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("block %d: failed to apply header: %v", i+1, err)
+		}
+	}
+	// Block 5 was sealed by B, which should have a violation recorded
+	if have := snap.Reputation[accounts.address("B")].Violations; have != 1 {
+		t.Errorf("violation count mismatch: have %d, want 1", have)
+	}
+	// C sealed blocks 3, 6, 9 and 12, with the scores halved at block 10
+	if have, want := snap.Reputation[accounts.address("C")].Score, uint64(750_000); have != want {
+		t.Errorf("score mismatch: have %d, want %d", have, want)
+	}
+	// Import the chain on an independent node and ensure it agrees
+	importer := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	importer.fakeDiff = true
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, importer, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	head, err := importer.snapshot(chain, blocks[len(blocks)-1].NumberU64(), blocks[len(blocks)-1].Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	for _, signer := range signers {
+		if head.Reputation[signer] != snap.Reputation[signer] {
+			t.Errorf("signer %x: reputation mismatch: have %+v, want %+v", signer, head.Reputation[signer], snap.Reputation[signer])
+		}
+	}
+	// Tamper with the committed scores of the checkpoint and ensure it's rejected
+	header := blocks[9].Header()
+	header.Extra[extraVanity+len(signers)*common.AddressLength] ^= 0x01
+	accounts.sign(header, names[9%len(names)])
+
+	tampered := []*types.Block{}
+	tampered = append(tampered, blocks[:9]...)
+	tampered = append(tampered, blocks[9].WithSeal(header))
+
+	importer = NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	importer.fakeDiff = true
+
+	chain, err = core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, importer, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(tampered); err != errMismatchingCheckpointReputation {
+		t.Fatalf("tampered checkpoint error mismatch: have %v, want %v", err, errMismatchingCheckpointReputation)
+	}
+}
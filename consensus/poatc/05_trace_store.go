@@ -0,0 +1,184 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// tracePruneInterval is the minimum time between two sweeps of the trace store
+// for events older than the trace retention.
+const tracePruneInterval = time.Minute
+
+// TraceFilter narrows down a trace event query. Block bounds are inclusive, a
+// zero ToBlock means no upper bound.
+type TraceFilter struct {
+	FromBlock uint64          `json:"fromBlock"`
+	ToBlock   uint64          `json:"toBlock"`
+	Address   *common.Address `json:"address"`
+	Offset    int             `json:"offset"` // Number of matching events to skip, for pagination
+}
+
+// traceQuery is a full trace event query: the filter along with the event type,
+// maximum level and page size.
+type traceQuery struct {
+	TraceFilter
+	Type  TraceEventType
+	Level TraceLevel
+	Limit int
+}
+
+// matches returns whether an event satisfies the query.
+func (q *traceQuery) matches(event *TraceEvent) bool {
+	if q.Type != "" && event.Type != q.Type {
+		return false
+	}
+	if q.Level != TraceLevelOff && event.Level > q.Level {
+		return false
+	}
+	if event.BlockNumber < q.FromBlock || (q.ToBlock != 0 && event.BlockNumber > q.ToBlock) {
+		return false
+	}
+	if q.Address != nil && event.Address != *q.Address {
+		return false
+	}
+	return true
+}
+
+// traceStore persists trace events in the node's key-value store, indexed by
+// block number, event type and address, so the audit trail survives restarts.
+type traceStore struct {
+	db        ethdb.Database
+	retention time.Duration // Age after which events are pruned (0 = keep forever)
+
+	next      uint64       // Sequence number of the next stored event
+	lastPrune time.Time    // Time of the last sweep for expired events
+	clock     mclock.Clock // Source of time of the sweeps
+	lock      sync.Mutex
+}
+
+// newTraceStore creates a trace store on top of the given database.
+func newTraceStore(db ethdb.Database, retention time.Duration, clock mclock.Clock) *traceStore {
+	return &traceStore{
+		db:        db,
+		retention: retention,
+		next:      rawdb.ReadPoatcTraceHead(db),
+		clock:     orSystemClock(clock),
+	}
+}
+
+// write stores a trace event, pruning expired events once in a while.
+func (s *traceStore) write(event TraceEvent) {
+	blob, err := json.Marshal(event)
+	if err != nil {
+		log.Error("Failed to encode trace event", "id", event.ID, "err", err)
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	batch := s.db.NewBatch()
+	rawdb.WritePoatcTraceEvent(batch, s.next, event.BlockNumber, string(event.Type), event.Address, blob)
+	rawdb.WritePoatcTraceHead(batch, s.next+1)
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to store trace event", "id", event.ID, "err", err)
+		return
+	}
+	s.next++
+
+	if now := wallTime(s.clock); s.retention > 0 && now.Sub(s.lastPrune) > tracePruneInterval {
+		s.prune(event.Timestamp.Add(-s.retention))
+		s.lastPrune = now
+	}
+}
+
+// prune deletes all events recorded before the given time. Events are stored
+// in the order they were recorded, so the sweep stops at the first newer one.
+func (s *traceStore) prune(before time.Time) int {
+	batch := s.db.NewBatch()
+
+	var pruned int
+	rawdb.IteratePoatcTraceEvents(s.db, func(seq uint64, blob []byte) bool {
+		var event TraceEvent
+		if err := json.Unmarshal(blob, &event); err != nil {
+			log.Error("Failed to decode trace event", "seq", seq, "err", err)
+			return false
+		}
+		if !event.Timestamp.Before(before) {
+			return false
+		}
+		rawdb.DeletePoatcTraceEvent(batch, seq, event.BlockNumber, string(event.Type), event.Address)
+		pruned++
+		return true
+	})
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to prune trace events", "err", err)
+		return 0
+	}
+	if pruned > 0 {
+		log.Debug("Pruned expired trace events", "count", pruned)
+	}
+	return pruned
+}
+
+// query retrieves the stored events matching a query, ordered by block number.
+// The most selective index the query allows is scanned.
+func (s *traceStore) query(q *traceQuery) []TraceEvent {
+	index := rawdb.PoatcTraceBlockIndex()
+	switch {
+	case q.Address != nil:
+		index = rawdb.PoatcTraceAddressIndex(*q.Address)
+	case q.Type != "":
+		index = rawdb.PoatcTraceTypeIndex(string(q.Type))
+	}
+	var (
+		events []TraceEvent
+		skip   = q.Offset
+	)
+	rawdb.IteratePoatcTraceIndex(s.db, index, q.FromBlock, func(number uint64, seq uint64) bool {
+		if q.ToBlock != 0 && number > q.ToBlock {
+			return false
+		}
+		blob := rawdb.ReadPoatcTraceEvent(s.db, seq)
+		if len(blob) == 0 {
+			return true // dangling index entry
+		}
+		var event TraceEvent
+		if err := json.Unmarshal(blob, &event); err != nil {
+			log.Error("Failed to decode trace event", "seq", seq, "err", err)
+			return true
+		}
+		if !q.matches(&event) {
+			return true
+		}
+		if skip > 0 {
+			skip--
+			return true
+		}
+		events = append(events, event)
+		return q.Limit <= 0 || len(events) < q.Limit
+	})
+	return events
+}
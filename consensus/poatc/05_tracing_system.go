@@ -5,10 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -40,6 +46,12 @@ const (
 	TraceEventValidatorSelection TraceEventType = "validator_selection"
 	TraceEventMerkleRoot         TraceEventType = "merkle_root"
 	TraceEventTimeDynamic        TraceEventType = "time_dynamic"
+	TraceEventVote               TraceEventType = "vote"
+	TraceEventAdminAction        TraceEventType = "admin_action"
+	TraceEventProposal           TraceEventType = "proposal"
+	TraceEventCandidate          TraceEventType = "candidate"
+	TraceEventFinality           TraceEventType = "finality"
+	TraceEventBlockTime          TraceEventType = "block_time"
 )
 
 // TraceEvent represents a single trace event with Merkle Tree support
@@ -104,18 +116,24 @@ type TracingSystem struct {
 	config      *TracingConfig
 	events      []TraceEvent
 	merkleTree  *MerkleTree
-	metrics     map[string]interface{}
+	metrics     TraceMetrics
 	mutex       sync.RWMutex
 	eventCount  int64
 	startTime   time.Time
 	currentRound uint64
+	store       *traceStore // Persistent event store, nil if persistence is disabled
+	feed        *event.Feed // Feed the recorded events are published to, if any
+	clock       mclock.Clock // Source of time of the events and the metrics
 }
 
-// NewTracingSystem creates a new tracing system
-func NewTracingSystem(config *TracingConfig) *TracingSystem {
+// NewTracingSystem creates a new tracing system, timing the events with the given
+// clock or the system clock if nil. If persistence is enabled and a database is
+// given, trace events are also written to the database.
+func NewTracingSystem(config *TracingConfig, db ethdb.Database, clock mclock.Clock) *TracingSystem {
 	if config == nil {
 		config = DefaultTracingConfig()
 	}
+	clock = orSystemClock(clock)
 
 	ts := &TracingSystem{
 		config:  config,
@@ -124,8 +142,12 @@ func NewTracingSystem(config *TracingConfig) *TracingSystem {
 			Leaves: make([]common.Hash, 0),
 			Events: make([]TraceEvent, 0),
 		},
-		metrics: make(map[string]interface{}),
-		startTime: time.Now(),
+		startTime: wallTime(clock),
+		clock:     clock,
+	}
+
+	if config.EnablePersistence && db != nil {
+		ts.store = newTraceStore(db, config.TraceRetention, clock)
 	}
 
 	// Initialize metrics
@@ -134,21 +156,47 @@ func NewTracingSystem(config *TracingConfig) *TracingSystem {
 	return ts
 }
 
-// initializeMetrics initializes the metrics map
+// now returns the current time of the tracing system's clock.
+func (ts *TracingSystem) now() time.Time {
+	return wallTime(ts.clock)
+}
+
+// TraceMetrics are the aggregated metrics of the traced events.
+type TraceMetrics struct {
+	TotalEvents          int64          `json:"total_events"`
+	EventsByType         map[string]int `json:"events_by_type"`
+	EventsByLevel        map[string]int `json:"events_by_level"`
+	MerkleTreesBuilt     int            `json:"merkle_trees_built"`
+	MerkleRootsGenerated int            `json:"merkle_roots_generated"`
+	AverageDuration      float64        `json:"average_duration"` // Milliseconds
+	MaxDuration          float64        `json:"max_duration"`     // Milliseconds
+	MinDuration          float64        `json:"min_duration"`     // Milliseconds
+	SystemUptime         time.Duration  `json:"system_uptime"`
+	LastEventTime        time.Time      `json:"last_event_time"`
+	EventsPerMinute      float64        `json:"events_per_minute"`
+	CurrentRound         uint64         `json:"current_round"`
+}
+
+// copy creates a deep copy of the metrics.
+func (m *TraceMetrics) copy() *TraceMetrics {
+	cpy := *m
+	cpy.EventsByType = make(map[string]int, len(m.EventsByType))
+	for k, v := range m.EventsByType {
+		cpy.EventsByType[k] = v
+	}
+	cpy.EventsByLevel = make(map[string]int, len(m.EventsByLevel))
+	for k, v := range m.EventsByLevel {
+		cpy.EventsByLevel[k] = v
+	}
+	return &cpy
+}
+
+// initializeMetrics initializes the metrics
 func (ts *TracingSystem) initializeMetrics() {
-	ts.metrics = map[string]interface{}{
-		"total_events":       0,
-		"events_by_type":     make(map[string]int),
-		"events_by_level":    make(map[string]int),
-		"merkle_trees_built": 0,
-		"merkle_roots_generated": 0,
-		"average_duration":   0.0,
-		"max_duration":       0.0,
-		"min_duration":       0.0,
-		"system_uptime":      time.Since(ts.startTime),
-		"last_event_time":    time.Time{},
-		"events_per_minute":  0.0,
-		"current_round":      0,
+	ts.metrics = TraceMetrics{
+		EventsByType:  make(map[string]int),
+		EventsByLevel: make(map[string]int),
+		SystemUptime:  ts.now().Sub(ts.startTime),
 	}
 }
 
@@ -164,7 +212,7 @@ func (ts *TracingSystem) Trace(eventType TraceEventType, level TraceLevel, block
 	event := TraceEvent{
 		ID:          fmt.Sprintf("%d-%d-%d", blockNumber, ts.currentRound, ts.eventCount),
 		Type:        eventType,
-		Timestamp:   time.Now(),
+		Timestamp:   ts.now(),
 		BlockNumber: blockNumber,
 		Round:       ts.currentRound,
 		Address:     address,
@@ -190,6 +238,16 @@ func (ts *TracingSystem) Trace(eventType TraceEventType, level TraceLevel, block
 		ts.events = ts.events[1:]
 	}
 
+	// Persist the event to the audit trail
+	if ts.store != nil {
+		ts.store.write(event)
+	}
+
+	// Publish the event to the subscribers
+	if ts.feed != nil {
+		ts.feed.Send(event)
+	}
+
 	// Update metrics
 	ts.updateMetrics(event)
 
@@ -218,7 +276,7 @@ func (ts *TracingSystem) TraceWithDuration(eventType TraceEventType, level Trace
 	event := TraceEvent{
 		ID:          fmt.Sprintf("%d-%d-%d", blockNumber, ts.currentRound, ts.eventCount),
 		Type:        eventType,
-		Timestamp:   time.Now(),
+		Timestamp:   ts.now(),
 		BlockNumber: blockNumber,
 		Round:       ts.currentRound,
 		Address:     address,
@@ -245,6 +303,16 @@ func (ts *TracingSystem) TraceWithDuration(eventType TraceEventType, level Trace
 		ts.events = ts.events[1:]
 	}
 
+	// Persist the event to the audit trail
+	if ts.store != nil {
+		ts.store.write(event)
+	}
+
+	// Publish the event to the subscribers
+	if ts.feed != nil {
+		ts.feed.Send(event)
+	}
+
 	// Update metrics
 	ts.updateMetrics(event)
 
@@ -264,6 +332,11 @@ func (ts *TracingSystem) TraceWithDuration(eventType TraceEventType, level Trace
 
 // calculateEventHash calculates SHA256 hash of an event
 func (ts *TracingSystem) calculateEventHash(event TraceEvent) common.Hash {
+	return traceEventHash(event)
+}
+
+// traceEventHash calculates SHA256 hash of an event
+func traceEventHash(event TraceEvent) common.Hash {
 	// Create a deterministic representation of the event
 	eventData := map[string]interface{}{
 		"id":           event.ID,
@@ -317,8 +390,8 @@ func (ts *TracingSystem) rebuildMerkleTree() {
 	ts.merkleTree.Root = ts.buildMerkleTree(leaves)
 
 	// Update metrics
-	ts.metrics["merkle_trees_built"] = ts.metrics["merkle_trees_built"].(int) + 1
-	ts.metrics["merkle_roots_generated"] = ts.metrics["merkle_roots_generated"].(int) + 1
+	ts.metrics.MerkleTreesBuilt++
+	ts.metrics.MerkleRootsGenerated++
 
 	log.Debug("Merkle Tree rebuilt", 
 		"events", len(ts.merkleTree.Events),
@@ -422,8 +495,31 @@ func (ts *TracingSystem) GetMerkleProof(event TraceEvent) ([]common.Hash, bool)
 	return proof, true
 }
 
+// merkleProofOf returns the Merkle proof for an event along with the position
+// of its leaf among the sorted leaves and the root the proof leads to.
+func (ts *TracingSystem) merkleProofOf(event TraceEvent) ([]common.Hash, int, common.Hash, bool) {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	eventHash := traceEventHash(event)
+	for i, leaf := range ts.merkleTree.Leaves {
+		if leaf == eventHash {
+			proof, index := merkleProof(i, ts.merkleTree.Leaves)
+			return proof, index, ts.merkleTree.Root.Hash, true
+		}
+	}
+	return nil, 0, common.Hash{}, false
+}
+
 // generateMerkleProof generates a Merkle proof for a given index
 func (ts *TracingSystem) generateMerkleProof(index int, leaves []common.Hash) []common.Hash {
+	proof, _ := merkleProof(index, leaves)
+	return proof
+}
+
+// merkleProof generates a Merkle proof for a given index, also returning the
+// position of the leaf among the sorted leaves the tree is built from.
+func merkleProof(index int, leaves []common.Hash) ([]common.Hash, int) {
 	var proof []common.Hash
 	
 	// Sort leaves for consistent ordering
@@ -443,7 +539,7 @@ func (ts *TracingSystem) generateMerkleProof(index int, leaves []common.Hash) []
 	}
 
 	if sortedIndex == -1 {
-		return proof
+		return proof, -1
 	}
 
 	// Generate proof by traversing the tree
@@ -475,7 +571,15 @@ func (ts *TracingSystem) generateMerkleProof(index int, leaves []common.Hash) []
 		currentLeaves = nextLevel
 	}
 
-	return proof
+	return proof, sortedIndex
+}
+
+// SetFeed sets the feed the recorded trace events are published to.
+func (ts *TracingSystem) SetFeed(feed *event.Feed) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.feed = feed
 }
 
 // SetCurrentRound sets the current round for tracing
@@ -484,7 +588,7 @@ func (ts *TracingSystem) SetCurrentRound(round uint64) {
 	defer ts.mutex.Unlock()
 
 	ts.currentRound = round
-	ts.metrics["current_round"] = round
+	ts.metrics.CurrentRound = round
 }
 
 // TraceRandomPOA traces random POA algorithm events
@@ -607,96 +711,138 @@ func (ts *TracingSystem) GetTraceEvents(eventType TraceEventType, level TraceLev
 	return filtered
 }
 
+// QueryTraceEvents returns trace events matching the filters. Events are served
+// from the persistent store ordered by block number if there is one, so they
+// cover the whole retention period rather than the recent in-memory events.
+func (ts *TracingSystem) QueryTraceEvents(eventType TraceEventType, level TraceLevel, limit int, filter *TraceFilter) []TraceEvent {
+	q := &traceQuery{Type: eventType, Level: level, Limit: limit}
+	if filter != nil {
+		q.TraceFilter = *filter
+	}
+	if ts.store != nil {
+		return ts.store.query(q)
+	}
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	var filtered []TraceEvent
+	for _, event := range ts.events {
+		if !q.matches(&event) {
+			continue
+		}
+		if q.Offset > 0 {
+			q.Offset--
+			continue
+		}
+		filtered = append(filtered, event)
+		if limit > 0 && len(filtered) >= limit {
+			break
+		}
+	}
+	return filtered
+}
+
 // GetTraceMetrics returns current trace metrics
-func (ts *TracingSystem) GetTraceMetrics() map[string]interface{} {
+func (ts *TracingSystem) GetTraceMetrics() *TraceMetrics {
 	ts.mutex.RLock()
 	defer ts.mutex.RUnlock()
 
 	// Return a copy to avoid race conditions
-	metricsCopy := make(map[string]interface{})
-	for k, v := range ts.metrics {
-		metricsCopy[k] = v
-	}
+	return ts.metrics.copy()
+}
 
-	return metricsCopy
+// TraceStats are the statistics of the tracing system.
+type TraceStats struct {
+	Config           TraceStatsConfig `json:"config"`
+	CurrentEvents    int              `json:"current_events"`
+	TotalEvents      int64            `json:"total_events"`
+	SystemUptime     string           `json:"system_uptime"`
+	CurrentRound     uint64           `json:"current_round"`
+	MerkleRoot       common.Hash      `json:"merkle_root"`
+	MerkleTreeEvents int              `json:"merkle_tree_events"`
+	Persistent       bool             `json:"persistent"`
+	Metrics          *TraceMetrics    `json:"metrics"`
+}
+
+// TraceStatsConfig is the configuration events are traced with.
+type TraceStatsConfig struct {
+	EnableTracing     bool       `json:"enable_tracing"`
+	TraceLevel        TraceLevel `json:"trace_level"`
+	MaxTraceEvents    int        `json:"max_trace_events"`
+	TraceRetention    string     `json:"trace_retention"`
+	EnableMerkleTree  bool       `json:"enable_merkle_tree"`
+	EnablePersistence bool       `json:"enable_persistence"`
+	EnableMetrics     bool       `json:"enable_metrics"`
+	MerkleRootInBlock bool       `json:"merkle_root_in_block"`
 }
 
 // GetTraceStats returns trace statistics
-func (ts *TracingSystem) GetTraceStats() map[string]interface{} {
+func (ts *TracingSystem) GetTraceStats() *TraceStats {
 	ts.mutex.RLock()
 	defer ts.mutex.RUnlock()
 
-	stats := map[string]interface{}{
-		"config": map[string]interface{}{
-			"enable_tracing":        ts.config.EnableTracing,
-			"trace_level":           ts.config.TraceLevel,
-			"max_trace_events":      ts.config.MaxTraceEvents,
-			"trace_retention":       ts.config.TraceRetention.String(),
-			"enable_merkle_tree":    ts.config.EnableMerkleTree,
-			"enable_persistence":    ts.config.EnablePersistence,
-			"enable_metrics":        ts.config.EnableMetrics,
-			"merkle_root_in_block":  ts.config.MerkleRootInBlock,
+	return &TraceStats{
+		Config: TraceStatsConfig{
+			EnableTracing:     ts.config.EnableTracing,
+			TraceLevel:        ts.config.TraceLevel,
+			MaxTraceEvents:    ts.config.MaxTraceEvents,
+			TraceRetention:    ts.config.TraceRetention.String(),
+			EnableMerkleTree:  ts.config.EnableMerkleTree,
+			EnablePersistence: ts.config.EnablePersistence,
+			EnableMetrics:     ts.config.EnableMetrics,
+			MerkleRootInBlock: ts.config.MerkleRootInBlock,
 		},
-		"current_events":     len(ts.events),
-		"total_events":       ts.eventCount,
-		"system_uptime":      time.Since(ts.startTime).String(),
-		"current_round":      ts.currentRound,
-		"merkle_root":        ts.GetMerkleRoot().Hex(),
-		"merkle_tree_events": len(ts.merkleTree.Events),
-		"metrics":            ts.metrics,
+		CurrentEvents:    len(ts.events),
+		TotalEvents:      ts.eventCount,
+		SystemUptime:     ts.now().Sub(ts.startTime).String(),
+		CurrentRound:     ts.currentRound,
+		MerkleRoot:       ts.GetMerkleRoot(),
+		MerkleTreeEvents: len(ts.merkleTree.Events),
+		Persistent:       ts.store != nil,
+		Metrics:          ts.metrics.copy(),
 	}
-
-	return stats
 }
 
 // updateMetrics updates the metrics based on the new event
 func (ts *TracingSystem) updateMetrics(event TraceEvent) {
 	// Update total events
-	ts.metrics["total_events"] = ts.eventCount
+	ts.metrics.TotalEvents = ts.eventCount
 
-	// Update events by type
-	eventsByType := ts.metrics["events_by_type"].(map[string]int)
-	eventsByType[string(event.Type)]++
-
-	// Update events by level
-	eventsByLevel := ts.metrics["events_by_level"].(map[string]int)
-	levelName := fmt.Sprintf("level_%d", event.Level)
-	eventsByLevel[levelName]++
+	// Update events by type and level
+	ts.metrics.EventsByType[string(event.Type)]++
+	ts.metrics.EventsByLevel[fmt.Sprintf("level_%d", event.Level)]++
 
 	// Update duration metrics
 	if event.Duration > 0 {
-		currentMax := ts.metrics["max_duration"].(float64)
-		currentMin := ts.metrics["min_duration"].(float64)
-		
 		durationMs := float64(event.Duration.Milliseconds())
-		
-		if durationMs > currentMax {
-			ts.metrics["max_duration"] = durationMs
+
+		if durationMs > ts.metrics.MaxDuration {
+			ts.metrics.MaxDuration = durationMs
 		}
-		if currentMin == 0 || durationMs < currentMin {
-			ts.metrics["min_duration"] = durationMs
+		if ts.metrics.MinDuration == 0 || durationMs < ts.metrics.MinDuration {
+			ts.metrics.MinDuration = durationMs
 		}
 
 		// Update average duration
 		totalEvents := float64(ts.eventCount)
-		currentAvg := ts.metrics["average_duration"].(float64)
-		ts.metrics["average_duration"] = (currentAvg*(totalEvents-1) + durationMs) / totalEvents
+		ts.metrics.AverageDuration = (ts.metrics.AverageDuration*(totalEvents-1) + durationMs) / totalEvents
 	}
 
 	// Update system uptime
-	ts.metrics["system_uptime"] = time.Since(ts.startTime)
+	ts.metrics.SystemUptime = ts.now().Sub(ts.startTime)
 
 	// Update last event time
-	ts.metrics["last_event_time"] = event.Timestamp
+	ts.metrics.LastEventTime = event.Timestamp
 
 	// Update events per minute
-	uptimeMinutes := time.Since(ts.startTime).Minutes()
+	uptimeMinutes := ts.now().Sub(ts.startTime).Minutes()
 	if uptimeMinutes > 0 {
-		ts.metrics["events_per_minute"] = float64(ts.eventCount) / uptimeMinutes
+		ts.metrics.EventsPerMinute = float64(ts.eventCount) / uptimeMinutes
 	}
 }
 
-// ClearTraceEvents clears all trace events
+// ClearTraceEvents clears all in-memory trace events, the persisted audit trail
+// is left untouched
 func (ts *TracingSystem) ClearTraceEvents() {
 	ts.mutex.Lock()
 	defer ts.mutex.Unlock()
@@ -718,7 +864,7 @@ func (ts *TracingSystem) ExportTraceEvents() ([]byte, error) {
 	defer ts.mutex.RUnlock()
 
 	export := map[string]interface{}{
-		"export_time":  time.Now(),
+		"export_time":  ts.now(),
 		"config":       ts.config,
 		"events":       ts.events,
 		"merkle_tree":  ts.merkleTree,
@@ -782,3 +928,125 @@ func (ts *TracingSystem) EnableTracing(enable bool) {
 	ts.config.EnableTracing = enable
 	log.Info("Tracing enabled/disabled", "enabled", enable)
 }
+
+// traceRootLength is the size of the trace Merkle root a block commits to in its
+// extra-data, right before the seal.
+const traceRootLength = common.HashLength
+
+// blockTraceEvents returns the consensus trace events of a block sealed by
+// signer on top of snap, including the double signing evidence it carries, if
+// any. The evidence is passed in rather than parsed from the header, as blocks
+// being prepared don't have their extra-data complete yet. The events only
+// depend on the chain, so unlike the local trace every node derives the very
+// same events and leaf hashes.
+func blockTraceEvents(snap *Snapshot, header *types.Header, signer common.Address, evidence *Evidence) []TraceEvent {
+	number := header.Number.Uint64()
+
+	var events []TraceEvent
+	add := func(eventType TraceEventType, address common.Address, message string, data map[string]interface{}) {
+		event := TraceEvent{
+			ID:          fmt.Sprintf("%d-%d", number, len(events)),
+			Type:        eventType,
+			Timestamp:   time.Unix(int64(header.Time), 0).UTC(),
+			BlockNumber: number,
+			Round:       number,
+			Address:     address,
+			Message:     message,
+			Data:        data,
+			Level:       TraceLevelBasic,
+		}
+		event.Hash = traceEventHash(event)
+		events = append(events, event)
+	}
+	// Data values are strings only, so the events survive a JSON round trip
+	// without changing their hash
+	inturn, _ := snap.inturnSigner(number)
+	add(TraceEventLeaderSelection, inturn, "In-turn signer selected", map[string]interface{}{
+		"parent":         snap.Hash.Hex(),
+		"small_set_size": strconv.Itoa(len(snap.SmallSet)),
+	})
+	add(TraceEventBlockSigning, signer, "Block sealed", map[string]interface{}{
+		"difficulty": header.Difficulty.String(),
+		"inturn":     strconv.FormatBool(inturn == signer),
+	})
+	if header.Coinbase != (common.Address{}) {
+		vote := map[string]interface{}{
+			"address": header.Coinbase.Hex(),
+			"nonce":   hexutil.Encode(header.Nonce[:]),
+		}
+		if list, authorize, ok := snap.listVote(header.Nonce); ok {
+			vote["list"] = list
+			vote["add"] = strconv.FormatBool(authorize)
+		}
+		add(TraceEventVote, signer, "Vote cast", vote)
+	}
+	if evidence != nil {
+		offender, _ := ecrecover(evidence.First, snap.sigcache)
+		add(TraceEventAccusation, offender, "Double signing slashed", map[string]interface{}{
+			"height": evidence.First.Number.String(),
+			"first":  evidence.First.Hash().Hex(),
+			"second": evidence.Second.Hash().Hex(),
+		})
+	}
+	if snap.reputationRules != nil {
+		next := snap.copy()
+		next.updateReputation(number, header.Time, signer)
+
+		old, updated := snap.Reputation[signer], next.Reputation[signer]
+		add(TraceEventReputationUpdate, signer, "Reputation updated", map[string]interface{}{
+			"old_score":  strconv.FormatUint(old.Score, 10),
+			"new_score":  strconv.FormatUint(updated.Score, 10),
+			"violations": strconv.FormatUint(updated.Violations, 10),
+		})
+	}
+	return events
+}
+
+// blockTraceRoot returns the Merkle root of a block's consensus trace events.
+func blockTraceRoot(events []TraceEvent) common.Hash {
+	leaves := make([]common.Hash, len(events))
+	for i, event := range events {
+		leaves[i] = event.Hash
+	}
+	return merkleRoot(leaves)
+}
+
+// merkleRoot returns the root of the Merkle tree built from the sorted leaves,
+// the same way the tracing system builds its own tree.
+func merkleRoot(leaves []common.Hash) common.Hash {
+	if len(leaves) == 0 {
+		return common.Hash{}
+	}
+	level := make([]common.Hash, len(leaves))
+	copy(level, leaves)
+	sort.Slice(level, func(i, j int) bool {
+		return level[i].Hex() < level[j].Hex()
+	})
+	for len(level) > 1 {
+		// If odd number of leaves, duplicate the last one
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([]common.Hash, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, sha256.Sum256(append(level[i].Bytes(), level[i+1].Bytes()...)))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// verifyMerkleProof verifies that leaf sits at the given position among the
+// sorted leaves of the Merkle tree with the given root.
+func verifyMerkleProof(leaf common.Hash, index int, proof []common.Hash, root common.Hash) bool {
+	hash := leaf
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			hash = sha256.Sum256(append(hash.Bytes(), sibling.Bytes()...))
+		} else {
+			hash = sha256.Sum256(append(sibling.Bytes(), hash.Bytes()...))
+		}
+		index /= 2
+	}
+	return hash == root
+}
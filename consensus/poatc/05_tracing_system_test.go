@@ -0,0 +1,227 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that blocks commit to the Merkle root of their consensus trace events,
+// that importing nodes verify it, and that the events are provable against the
+// block header.
+func TestTraceRootCommittedInBlock(t *testing.T) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	accounts.checkpoint(&types.Header{Extra: genesis.ExtraData}, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30}
+	config.Poatc = &params.PoatcConfig{
+		EnableTracingSystem: true,
+		Tracing: &params.PoatcTracingConfig{
+			EnableTracing:     true,
+			TraceLevel:        int(TraceLevelDetailed),
+			EnableMerkleTree:  true,
+			MerkleRootInBlock: true,
+		},
+	}
+	genesis.Config = &config
+
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 6, func(i int, gen *core.BlockGen) {})
+
+	// Seal the blocks round-robin with a vote in block 2, committing to the
+	// trace root derived from a snapshot replayed alongside
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.ToBlock().Hash(), signers)
+
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		if header.Number.Uint64() == 2 {
+			header.Coinbase = common.Address{0xff}
+			copy(header.Nonce[:], nonceAuthVote)
+		}
+		signer := names[i%len(names)]
+		header.Difficulty = diffInTurn // Ignored, we just need a valid number
+
+		root := blockTraceRoot(blockTraceEvents(snap, header, accounts.address(signer), nil))
+		header.Extra = make([]byte, extraVanity)
+		header.Extra = append(header.Extra, root[:]...)
+		header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+
+		accounts.sign(header, signer)
+		blocks[i] = block.WithSeal(header)
+
+		var err error
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("block %d: failed to apply header: %v", i+1, err)
+		}
+	}
+	// Import the chain on an independent node and prove an event of the vote
+	importer := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	importer.fakeDiff = true
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, importer, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	api := &API{chain: chain, poatc: importer}
+
+	number := rpc.BlockNumber(2)
+	trace, err := api.GetBlockTrace(&number)
+	if err != nil {
+		t.Fatalf("failed to retrieve block trace: %v", err)
+	}
+	if trace.Hash != blocks[1].Hash() || trace.Root != traceRoot(blocks[1].Header()) {
+		t.Fatalf("block trace anchor mismatch: have %x/%x, want %x", trace.Hash, trace.Root, blocks[1].Hash())
+	}
+	var vote *TraceEvent
+	for i := range trace.Events {
+		if trace.Events[i].Type == TraceEventVote {
+			vote = &trace.Events[i]
+		}
+	}
+	if vote == nil {
+		t.Fatalf("vote event missing from block trace: %+v", trace.Events)
+	}
+	// Round trip the event through JSON as an RPC client would
+	blob, err := json.Marshal(vote)
+	if err != nil {
+		t.Fatalf("failed to encode event: %v", err)
+	}
+	var event TraceEvent
+	if err := json.Unmarshal(blob, &event); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	proof, err := api.GetMerkleProof(event)
+	if err != nil {
+		t.Fatalf("failed to retrieve proof: %v", err)
+	}
+	if proof.Hash != blocks[1].Hash() {
+		t.Errorf("proof anchor mismatch: have %x, want %x", proof.Hash, blocks[1].Hash())
+	}
+	if !verifyMerkleProof(proof.Leaf, proof.Index, proof.Proof, traceRoot(blocks[1].Header())) {
+		t.Errorf("proof does not lead to the committed trace root")
+	}
+	// Tamper with the committed root and ensure it's rejected
+	header := blocks[3].Header()
+	header.Extra[extraVanity] ^= 0x01
+	accounts.sign(header, names[3%len(names)])
+
+	tampered := []*types.Block{}
+	tampered = append(tampered, blocks[:3]...)
+	tampered = append(tampered, blocks[3].WithSeal(header))
+
+	importer = NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	importer.fakeDiff = true
+
+	chain, err = core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, importer, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(tampered); err != errMismatchingTraceRoot {
+		t.Fatalf("tampered trace root error mismatch: have %v, want %v", err, errMismatchingTraceRoot)
+	}
+}
+
+// Tests that trace events are persisted across restarts, can be queried by
+// block range, type and address with pagination, and are pruned by retention.
+func TestTraceStore(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	config := DefaultTracingConfig()
+	config.TraceRetention = time.Hour
+
+	var (
+		alice = common.Address{0xa}
+		bob   = common.Address{0xb}
+	)
+	ts := NewTracingSystem(config, db, nil)
+	for number := uint64(1); number <= 10; number++ {
+		ts.Trace(TraceEventBlockSigning, TraceLevelBasic, number, alice, "signed", nil)
+		ts.Trace(TraceEventVote, TraceLevelBasic, number, bob, "voted", nil)
+	}
+	ts.ClearTraceEvents()
+
+	// Restart the tracing system and ensure the audit trail survived
+	ts = NewTracingSystem(config, db, nil)
+	if have := len(ts.QueryTraceEvents("", TraceLevelOff, 0, nil)); have != 20 {
+		t.Fatalf("persisted event count mismatch: have %d, want 20", have)
+	}
+	ts.Trace(TraceEventVote, TraceLevelBasic, 11, bob, "voted", nil)
+
+	tests := []struct {
+		eventType TraceEventType
+		limit     int
+		filter    *TraceFilter
+		want      []uint64 // Block numbers of the expected events
+	}{
+		{"", 0, &TraceFilter{FromBlock: 3, ToBlock: 4}, []uint64{3, 3, 4, 4}},
+		{TraceEventVote, 0, &TraceFilter{FromBlock: 9}, []uint64{9, 10, 11}},
+		{"", 3, &TraceFilter{Address: &alice, Offset: 2}, []uint64{3, 4, 5}},
+		{TraceEventBlockSigning, 0, &TraceFilter{Address: &bob}, nil},
+	}
+	for i, tt := range tests {
+		events := ts.QueryTraceEvents(tt.eventType, TraceLevelOff, tt.limit, tt.filter)
+		if len(events) != len(tt.want) {
+			t.Errorf("test %d: event count mismatch: have %d, want %d", i, len(events), len(tt.want))
+			continue
+		}
+		for j, event := range events {
+			if event.BlockNumber != tt.want[j] {
+				t.Errorf("test %d, event %d: block mismatch: have %d, want %d", i, j, event.BlockNumber, tt.want[j])
+			}
+		}
+	}
+	// Age out everything but the last event and ensure the indexes follow
+	if pruned := ts.store.prune(time.Now().Add(time.Hour)); pruned != 21 {
+		t.Fatalf("pruned event count mismatch: have %d, want 21", pruned)
+	}
+	if events := ts.QueryTraceEvents(TraceEventVote, TraceLevelOff, 0, &TraceFilter{Address: &bob}); len(events) != 0 {
+		t.Errorf("pruned events still indexed: %d", len(events))
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BlockTimeRules are the consensus parameters of the dynamic block time. Like
+// the EIP-1559 base fee, the period a block has to keep from its parent is
+// derived from the gas the parent used: a parent at the gas target keeps the
+// base period, fuller parents shorten it down to the minimum at the gas limit,
+// and emptier ones stretch it up to the maximum for empty blocks. Every node
+// thus agrees on the time at which the next block becomes valid.
+type BlockTimeRules struct {
+	Base uint64 // Period after a block using exactly the gas target, in seconds
+	Min  uint64 // Period after a block using its whole gas limit, in seconds
+	Max  uint64 // Period after an empty block, in seconds
+}
+
+// newBlockTimeRules creates the dynamic block time rules for the chain, or nil if
+// blocks follow the fixed clique period. Enforcing the block time changes which
+// timestamps are valid, so the chain config has to explicitly opt in. Bounds
+// left out of the config default to the base period.
+func newBlockTimeRules(cfg *params.PoatcConfig, clique *params.CliqueConfig) *BlockTimeRules {
+	if cfg == nil || !cfg.EnableTimeDynamic || cfg.TimeDynamic == nil {
+		return nil
+	}
+	tc := cfg.TimeDynamic
+	if !tc.EnableDynamicBlockTime || !tc.BlockTimeInHeader {
+		return nil
+	}
+	rules := &BlockTimeRules{Base: tc.BaseBlockTime, Min: tc.MinBlockTime, Max: tc.MaxBlockTime}
+	if rules.Base == 0 {
+		rules.Base = clique.Period
+	}
+	if rules.Min == 0 || rules.Min > rules.Base {
+		rules.Min = rules.Base
+	}
+	if rules.Max < rules.Base {
+		rules.Max = rules.Base
+	}
+	return rules
+}
+
+// period returns the number of seconds the child of a block has to keep from it.
+func (r *BlockTimeRules) period(parent *types.Header) uint64 {
+	target := parent.GasLimit / params.DefaultElasticityMultiplier
+	switch {
+	case target == 0 || parent.GasUsed == target:
+		return r.Base
+	case parent.GasUsed > target:
+		excess := parent.GasUsed - target
+		if excess > target {
+			excess = target
+		}
+		return r.Base - mulDiv(r.Base-r.Min, excess, target)
+	default:
+		return r.Base + mulDiv(r.Max-r.Base, target-parent.GasUsed, target)
+	}
+}
+
+// mulDiv returns a*b/c without overflowing the intermediate product. The caller
+// must ensure b <= c, so the result fits into 64 bits.
+func mulDiv(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	quo, _ := bits.Div64(hi, lo, c)
+	return quo
+}
+
+// period returns the number of seconds the child of a block has to keep from it,
+// the fixed clique period unless the chain enforces a dynamic block time.
+func (c *POATC) period(parent *types.Header) uint64 {
+	if c.blockTimeRules == nil {
+		return c.config.Period
+	}
+	return c.blockTimeRules.period(parent)
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the period of a block is derived from the gas used by its parent
+// and bounded by the configured minimum and maximum.
+func TestBlockTimePeriod(t *testing.T) {
+	rules := &BlockTimeRules{Base: 15, Min: 5, Max: 60}
+
+	tests := []struct {
+		gasUsed  uint64
+		gasLimit uint64
+		period   uint64
+	}{
+		{0, 30_000_000, 60},                         // Empty parent
+		{7_500_000, 30_000_000, 37},                 // Half the target
+		{15_000_000, 30_000_000, 15},                // At the target
+		{22_500_000, 30_000_000, 10},                // Halfway to the limit
+		{30_000_000, 30_000_000, 5},                 // Full parent
+		{0, 0, 15},                                  // No gas target
+		{math.MaxUint64 - 1, math.MaxUint64 - 1, 5}, // No overflow on huge limits
+	}
+	for i, tt := range tests {
+		parent := &types.Header{GasUsed: tt.gasUsed, GasLimit: tt.gasLimit}
+		if have := rules.period(parent); have != tt.period {
+			t.Errorf("test %d: period mismatch: have %d, want %d", i, have, tt.period)
+		}
+	}
+}
+
+// Tests that the dynamic block time is only enforced if the chain config opts
+// in, and that missing bounds default to the base period.
+func TestBlockTimeRulesConfig(t *testing.T) {
+	clique := &params.CliqueConfig{Period: 10, Epoch: 30000}
+	config := func(inHeader bool, base, min, max uint64) *params.PoatcConfig {
+		return &params.PoatcConfig{
+			EnableTimeDynamic: true,
+			TimeDynamic: &params.PoatcTimeDynamicConfig{
+				EnableDynamicBlockTime: true,
+				BlockTimeInHeader:      inHeader,
+				BaseBlockTime:          base,
+				MinBlockTime:           min,
+				MaxBlockTime:           max,
+			},
+		}
+	}
+	if rules := newBlockTimeRules(nil, clique); rules != nil {
+		t.Errorf("rules created without config: %+v", rules)
+	}
+	if rules := newBlockTimeRules(config(false, 15, 5, 60), clique); rules != nil {
+		t.Errorf("rules created without opting in: %+v", rules)
+	}
+	if rules := newBlockTimeRules(config(true, 15, 5, 60), clique); *rules != (BlockTimeRules{Base: 15, Min: 5, Max: 60}) {
+		t.Errorf("rules mismatch: have %+v", rules)
+	}
+	if rules := newBlockTimeRules(config(true, 0, 0, 0), clique); *rules != (BlockTimeRules{Base: 10, Min: 10, Max: 10}) {
+		t.Errorf("default rules mismatch: have %+v", rules)
+	}
+}
+
+// Tests that headers sealed before the period derived from their parent are
+// rejected.
+func TestBlockTimeVerification(t *testing.T) {
+	engine := NewWithConfig(&params.CliqueConfig{Period: 15, Epoch: 30000}, &params.PoatcConfig{
+		EnableTimeDynamic: true,
+		TimeDynamic: &params.PoatcTimeDynamicConfig{
+			EnableDynamicBlockTime: true,
+			BlockTimeInHeader:      true,
+			MinBlockTime:           5,
+			MaxBlockTime:           60,
+		},
+	}, nil)
+
+	parent := &types.Header{Number: big.NewInt(1), Time: 1000, GasLimit: 30_000_000}
+	header := &types.Header{Number: big.NewInt(2), ParentHash: parent.Hash(), Time: 1059}
+	if err := engine.verifyCascadingFields(nil, header, []*types.Header{parent}); !errors.Is(err, errInvalidTimestamp) {
+		t.Errorf("early header after empty parent error mismatch: have %v, want %v", err, errInvalidTimestamp)
+	}
+	// Without the rules, the fixed period would have been kept
+	engine.blockTimeRules = nil
+	if have := engine.period(parent); have != 15 {
+		t.Errorf("fixed period mismatch: have %d, want %d", have, 15)
+	}
+}
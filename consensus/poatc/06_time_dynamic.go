@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -70,6 +71,7 @@ func DefaultTimeDynamicConfig() *TimeDynamicConfig {
 // TimeDynamicManager manages all time dynamic mechanisms
 type TimeDynamicManager struct {
 	config                  *TimeDynamicConfig
+	clock                   mclock.Clock // Source of time of the update intervals and the decay
 	mutex                   sync.RWMutex
 	
 	// Dynamic Block Time
@@ -106,24 +108,33 @@ type TransactionMetrics struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
-// NewTimeDynamicManager creates a new time dynamic manager
-func NewTimeDynamicManager(config *TimeDynamicConfig) *TimeDynamicManager {
+// NewTimeDynamicManager creates a new time dynamic manager, timing the updates
+// with the given clock or the system clock if nil.
+func NewTimeDynamicManager(config *TimeDynamicConfig, clock mclock.Clock) *TimeDynamicManager {
 	if config == nil {
 		config = DefaultTimeDynamicConfig()
 	}
+	clock = orSystemClock(clock)
+	now := wallTime(clock)
 	
 	return &TimeDynamicManager{
 		config:                  config,
+		clock:                   clock,
 		currentBlockTime:        config.BaseBlockTime,
 		recentTxCounts:          make([]int, 0),
-		lastBlockTimeUpdate:     time.Now(),
-		lastValidatorSelection:  time.Now(),
+		lastBlockTimeUpdate:     now,
+		lastValidatorSelection:  now,
 		validatorSelectionCount: 0,
-		lastReputationDecay:     time.Now(),
+		lastReputationDecay:     now,
 		decayHistory:            make([]DecayRecord, 0),
 	}
 }
 
+// now returns the current time of the manager's clock.
+func (tdm *TimeDynamicManager) now() time.Time {
+	return wallTime(tdm.clock)
+}
+
 // SetIntegrationComponents sets the integration components
 func (tdm *TimeDynamicManager) SetIntegrationComponents(
 	vsm *ValidatorSelectionManager,
@@ -205,7 +216,7 @@ func (tdm *TimeDynamicManager) calculateDynamicBlockTime() {
 	if math.Abs(float64(newBlockTime-tdm.currentBlockTime)) > float64(time.Second) {
 		oldBlockTime := tdm.currentBlockTime
 		tdm.currentBlockTime = newBlockTime
-		tdm.lastBlockTimeUpdate = time.Now()
+		tdm.lastBlockTimeUpdate = tdm.now()
 		
 		log.Info("Dynamic block time updated",
 			"old_time", oldBlockTime,
@@ -216,7 +227,7 @@ func (tdm *TimeDynamicManager) calculateDynamicBlockTime() {
 		
 		// Trace the event
 		if tdm.tracingSystem != nil {
-			tdm.tracingSystem.Trace(TraceEventTimeDynamic, TraceLevelDetailed, 0, common.Address{},
+			tdm.tracingSystem.Trace(TraceEventBlockTime, TraceLevelDetailed, 0, common.Address{},
 				"Dynamic block time updated",
 				map[string]interface{}{
 					"old_block_time":    oldBlockTime.Seconds(),
@@ -254,6 +265,32 @@ func (tdm *TimeDynamicManager) GetCurrentBlockTime() time.Duration {
 	return tdm.currentBlockTime
 }
 
+// SetBlockTime records the block time the chain enforces after the given block,
+// overriding the local estimate.
+func (tdm *TimeDynamicManager) SetBlockTime(number uint64, blockTime time.Duration) {
+	tdm.mutex.Lock()
+	defer tdm.mutex.Unlock()
+
+	if blockTime == tdm.currentBlockTime {
+		return
+	}
+	oldBlockTime := tdm.currentBlockTime
+	tdm.currentBlockTime = blockTime
+	tdm.lastBlockTimeUpdate = tdm.now()
+
+	log.Debug("Enforced block time changed", "number", number, "old_time", oldBlockTime, "new_time", blockTime)
+
+	if tdm.tracingSystem != nil {
+		tdm.tracingSystem.Trace(TraceEventBlockTime, TraceLevelDetailed, number, common.Address{},
+			"Enforced block time changed",
+			map[string]interface{}{
+				"old_block_time": oldBlockTime.Seconds(),
+				"new_block_time": blockTime.Seconds(),
+				"change_reason":  "parent_gas_usage",
+			})
+	}
+}
+
 // ===== Dynamic Validator Selection =====
 
 // ShouldUpdateValidatorSelection checks if it's time to update validator selection
@@ -265,7 +302,7 @@ func (tdm *TimeDynamicManager) ShouldUpdateValidatorSelection() bool {
 	tdm.mutex.RLock()
 	defer tdm.mutex.RUnlock()
 	
-	return time.Since(tdm.lastValidatorSelection) >= tdm.config.ValidatorSelectionInterval
+	return tdm.now().Sub(tdm.lastValidatorSelection) >= tdm.config.ValidatorSelectionInterval
 }
 
 // UpdateValidatorSelection triggers a validator selection update
@@ -284,7 +321,7 @@ func (tdm *TimeDynamicManager) UpdateValidatorSelection(blockNumber uint64, bloc
 		return err
 	}
 	
-	tdm.lastValidatorSelection = time.Now()
+	tdm.lastValidatorSelection = tdm.now()
 	tdm.validatorSelectionCount++
 	
 	log.Info("Dynamic validator selection updated",
@@ -317,7 +354,7 @@ func (tdm *TimeDynamicManager) ShouldApplyReputationDecay() bool {
 	tdm.mutex.RLock()
 	defer tdm.mutex.RUnlock()
 	
-	return time.Since(tdm.lastReputationDecay) >= tdm.config.ReputationUpdateInterval
+	return tdm.now().Sub(tdm.lastReputationDecay) >= tdm.config.ReputationUpdateInterval
 }
 
 // ApplyReputationDecay applies real-time reputation decay
@@ -329,7 +366,7 @@ func (tdm *TimeDynamicManager) ApplyReputationDecay() error {
 	tdm.mutex.Lock()
 	defer tdm.mutex.Unlock()
 	
-	now := time.Now()
+	now := tdm.now()
 	timeSinceLastDecay := now.Sub(tdm.lastReputationDecay)
 	
 	// Calculate decay factor based on time elapsed
@@ -396,48 +433,89 @@ func (tdm *TimeDynamicManager) ApplyReputationDecay() error {
 
 // ===== Statistics and Monitoring =====
 
+// TimeDynamicStats are the statistics of the time dynamic mechanisms.
+type TimeDynamicStats struct {
+	Config                    TimeDynamicStatsConfig `json:"config"`
+	DynamicBlockTime          BlockTimeStats         `json:"dynamic_block_time"`
+	DynamicValidatorSelection SelectionTimingStats   `json:"dynamic_validator_selection"`
+	DynamicReputationDecay    DecayStats             `json:"dynamic_reputation_decay"`
+}
+
+// TimeDynamicStatsConfig are the time dynamic mechanisms enabled.
+type TimeDynamicStatsConfig struct {
+	EnableDynamicBlockTime          bool `json:"enable_dynamic_block_time"`
+	EnableDynamicValidatorSelection bool `json:"enable_dynamic_validator_selection"`
+	EnableDynamicReputationDecay    bool `json:"enable_dynamic_reputation_decay"`
+}
+
+// BlockTimeStats are the statistics of the dynamic block time, in seconds.
+type BlockTimeStats struct {
+	CurrentBlockTime float64   `json:"current_block_time"`
+	BaseBlockTime    float64   `json:"base_block_time"`
+	MinBlockTime     float64   `json:"min_block_time"`
+	MaxBlockTime     float64   `json:"max_block_time"`
+	LastUpdate       time.Time `json:"last_update"`
+	RecentTxCount    int       `json:"recent_tx_count"`
+	AvgTxCount       float64   `json:"avg_tx_count,omitempty"`
+	RecentTxCounts   []int     `json:"recent_tx_counts,omitempty"`
+}
+
+// SelectionTimingStats are the statistics of the timed validator selection.
+type SelectionTimingStats struct {
+	SelectionCount    int       `json:"selection_count"`
+	LastSelection     time.Time `json:"last_selection"`
+	SelectionInterval string    `json:"selection_interval"`
+}
+
+// DecayStats are the statistics of the timed reputation decay.
+type DecayStats struct {
+	LastDecay         time.Time `json:"last_decay"`
+	DecayRate         float64   `json:"decay_rate"`
+	UpdateInterval    string    `json:"update_interval"`
+	DecayHistoryCount int       `json:"decay_history_count"`
+}
+
 // GetTimeDynamicStats returns statistics about time dynamic mechanisms
-func (tdm *TimeDynamicManager) GetTimeDynamicStats() map[string]interface{} {
+func (tdm *TimeDynamicManager) GetTimeDynamicStats() *TimeDynamicStats {
 	tdm.mutex.RLock()
 	defer tdm.mutex.RUnlock()
-	
-	stats := map[string]interface{}{
-		"config": map[string]interface{}{
-			"enable_dynamic_block_time":            tdm.config.EnableDynamicBlockTime,
-			"enable_dynamic_validator_selection":   tdm.config.EnableDynamicValidatorSelection,
-			"enable_dynamic_reputation_decay":      tdm.config.EnableDynamicReputationDecay,
+
+	stats := &TimeDynamicStats{
+		Config: TimeDynamicStatsConfig{
+			EnableDynamicBlockTime:          tdm.config.EnableDynamicBlockTime,
+			EnableDynamicValidatorSelection: tdm.config.EnableDynamicValidatorSelection,
+			EnableDynamicReputationDecay:    tdm.config.EnableDynamicReputationDecay,
 		},
-		"dynamic_block_time": map[string]interface{}{
-			"current_block_time":    tdm.currentBlockTime.Seconds(),
-			"base_block_time":       tdm.config.BaseBlockTime.Seconds(),
-			"min_block_time":        tdm.config.MinBlockTime.Seconds(),
-			"max_block_time":        tdm.config.MaxBlockTime.Seconds(),
-			"last_update":           tdm.lastBlockTimeUpdate,
-			"recent_tx_count":       len(tdm.recentTxCounts),
+		DynamicBlockTime: BlockTimeStats{
+			CurrentBlockTime: tdm.currentBlockTime.Seconds(),
+			BaseBlockTime:    tdm.config.BaseBlockTime.Seconds(),
+			MinBlockTime:     tdm.config.MinBlockTime.Seconds(),
+			MaxBlockTime:     tdm.config.MaxBlockTime.Seconds(),
+			LastUpdate:       tdm.lastBlockTimeUpdate,
+			RecentTxCount:    len(tdm.recentTxCounts),
 		},
-		"dynamic_validator_selection": map[string]interface{}{
-			"selection_count":     tdm.validatorSelectionCount,
-			"last_selection":      tdm.lastValidatorSelection,
-			"selection_interval":  tdm.config.ValidatorSelectionInterval.String(),
+		DynamicValidatorSelection: SelectionTimingStats{
+			SelectionCount:    tdm.validatorSelectionCount,
+			LastSelection:     tdm.lastValidatorSelection,
+			SelectionInterval: tdm.config.ValidatorSelectionInterval.String(),
 		},
-		"dynamic_reputation_decay": map[string]interface{}{
-			"last_decay":          tdm.lastReputationDecay,
-			"decay_rate":          tdm.config.ReputationDecayRate,
-			"update_interval":     tdm.config.ReputationUpdateInterval.String(),
-			"decay_history_count": len(tdm.decayHistory),
+		DynamicReputationDecay: DecayStats{
+			LastDecay:         tdm.lastReputationDecay,
+			DecayRate:         tdm.config.ReputationDecayRate,
+			UpdateInterval:    tdm.config.ReputationUpdateInterval.String(),
+			DecayHistoryCount: len(tdm.decayHistory),
 		},
 	}
-	
+
 	// Add recent transaction counts if available
 	if len(tdm.recentTxCounts) > 0 {
 		totalTx := 0
 		for _, count := range tdm.recentTxCounts {
 			totalTx += count
 		}
-		stats["dynamic_block_time"].(map[string]interface{})["avg_tx_count"] = float64(totalTx) / float64(len(tdm.recentTxCounts))
-		stats["dynamic_block_time"].(map[string]interface{})["recent_tx_counts"] = tdm.recentTxCounts
+		stats.DynamicBlockTime.AvgTxCount = float64(totalTx) / float64(len(tdm.recentTxCounts))
+		stats.DynamicBlockTime.RecentTxCounts = append([]int(nil), tdm.recentTxCounts...)
 	}
-	
 	return stats
 }
 
@@ -8,11 +8,12 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 )
 
 func TestTimeDynamicManagerBasic(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	if tdm == nil {
 		t.Fatal("Failed to create TimeDynamicManager")
@@ -31,7 +32,7 @@ func TestDynamicBlockTime(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
 	config.TxThresholdHigh = 50
 	config.TxThresholdLow = 5
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	// Test high transaction volume (should decrease block time)
 	for i := 0; i < 5; i++ {
@@ -47,7 +48,7 @@ func TestDynamicBlockTime(t *testing.T) {
 	t.Logf("High tx volume: block time changed from %v to %v", config.BaseBlockTime, newBlockTime)
 
 	// Reset and test no transaction volume (should stay at base block time)
-	tdm = NewTimeDynamicManager(config)
+	tdm = NewTimeDynamicManager(config, nil)
 	for i := 0; i < 5; i++ {
 		tdm.UpdateTransactionCount(0) // No transactions
 	}
@@ -61,7 +62,7 @@ func TestDynamicBlockTime(t *testing.T) {
 	t.Logf("No tx volume: block time changed from %v to %v (should remain at base)", config.BaseBlockTime, newBlockTime)
 
 	// Test very low transaction volume (should slightly increase block time)
-	tdm = NewTimeDynamicManager(config)
+	tdm = NewTimeDynamicManager(config, nil)
 	for i := 0; i < 5; i++ {
 		tdm.UpdateTransactionCount(1) // Very low transaction count
 	}
@@ -72,49 +73,95 @@ func TestDynamicBlockTime(t *testing.T) {
 
 func TestDynamicValidatorSelection(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
-	config.ValidatorSelectionInterval = 100 * time.Millisecond // Short interval for testing
-	tdm := NewTimeDynamicManager(config)
+	config.ValidatorSelectionInterval = 10 * time.Minute
+	clock := new(mclock.Simulated)
+	tdm := NewTimeDynamicManager(config, clock)
 
-	// Initially should not need update
+	// Should not need an update before the interval elapsed
+	clock.Run(config.ValidatorSelectionInterval - 1)
 	if tdm.ShouldUpdateValidatorSelection() {
-		t.Fatal("Should not need validator selection update initially")
+		t.Fatal("Should not need validator selection update before the interval")
 	}
-
-	// Wait for interval
-	time.Sleep(150 * time.Millisecond)
-
 	// Now should need update
+	clock.Run(1)
 	if !tdm.ShouldUpdateValidatorSelection() {
 		t.Fatal("Should need validator selection update after interval")
 	}
-
-	t.Logf("Validator selection timing works correctly")
 }
 
 func TestDynamicReputationDecay(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
-	config.ReputationUpdateInterval = 100 * time.Millisecond // Short interval for testing
-	tdm := NewTimeDynamicManager(config)
+	config.ReputationUpdateInterval = time.Minute
+	clock := new(mclock.Simulated)
+	tdm := NewTimeDynamicManager(config, clock)
 
-	// Initially should not need decay
+	// Should not need decay before the interval elapsed
+	clock.Run(config.ReputationUpdateInterval - 1)
 	if tdm.ShouldApplyReputationDecay() {
-		t.Fatal("Should not need reputation decay initially")
+		t.Fatal("Should not need reputation decay before the interval")
 	}
-
-	// Wait for interval
-	time.Sleep(150 * time.Millisecond)
-
 	// Now should need decay
+	clock.Run(1)
 	if !tdm.ShouldApplyReputationDecay() {
 		t.Fatal("Should need reputation decay after interval")
 	}
+}
 
-	t.Logf("Reputation decay timing works correctly")
+// Tests that the reputation decay is proportional to the time elapsed since the
+// previous one, and bounded by the minimum retention.
+func TestDynamicReputationDecayAmount(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		score   float64 // Block mining score left of the initial 1.0
+	}{
+		{2 * time.Hour, 0.9},      // 5% per hour
+		{30 * time.Minute, 0.975}, // Partial hours
+		{20 * time.Hour, 0.5},     // Minimum 50% retention
+	}
+	for i, tt := range tests {
+		clock := new(mclock.Simulated)
+
+		repConfig := DefaultReputationConfig()
+		repConfig.BlockMiningReward = 1
+		repConfig.NewValidatorBoost = 0
+		rs := NewReputationSystem(repConfig, nil, clock)
+
+		addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
+		rs.AddValidator(addr)
+		rs.RecordBlockMining(addr, 1)
+
+		config := DefaultTimeDynamicConfig()
+		config.ReputationDecayRate = 0.05
+		tdm := NewTimeDynamicManager(config, clock)
+		tdm.SetIntegrationComponents(nil, rs, nil)
+
+		clock.Run(tt.elapsed)
+		if err := tdm.ApplyReputationDecay(); err != nil {
+			t.Fatalf("test %d: failed to apply decay: %v", i, err)
+		}
+		if have := rs.GetReputationScore(addr).BlockMiningScore; have != tt.score {
+			t.Errorf("test %d: block mining score mismatch: have %v, want %v", i, have, tt.score)
+		}
+		history := tdm.GetDecayHistory(0)
+		if len(history) != 1 {
+			t.Fatalf("test %d: decay history length mismatch: have %d, want 1", i, len(history))
+		}
+		if want := time.Unix(0, 0).Add(tt.elapsed); !history[0].Timestamp.Equal(want) {
+			t.Errorf("test %d: decay time mismatch: have %v, want %v", i, history[0].Timestamp, want)
+		}
+		// Decaying again right away leaves the score unchanged
+		if err := tdm.ApplyReputationDecay(); err != nil {
+			t.Fatalf("test %d: failed to apply decay: %v", i, err)
+		}
+		if have := rs.GetReputationScore(addr).BlockMiningScore; have != tt.score {
+			t.Errorf("test %d: score decayed twice: have %v, want %v", i, have, tt.score)
+		}
+	}
 }
 
 func TestTimeDynamicStats(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	// Update some transaction counts
 	tdm.UpdateTransactionCount(10)
@@ -127,22 +174,12 @@ func TestTimeDynamicStats(t *testing.T) {
 	}
 
 	// Check required fields
-	configStats, ok := stats["config"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Config stats not found")
-	}
-
-	if !configStats["enable_dynamic_block_time"].(bool) {
+	if !stats.Config.EnableDynamicBlockTime {
 		t.Error("Dynamic block time should be enabled")
 	}
 
-	blockTimeStats, ok := stats["dynamic_block_time"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Block time stats not found")
-	}
-
-	if blockTimeStats["recent_tx_count"].(int) != 3 {
-		t.Errorf("Expected 3 recent tx counts, got %v", blockTimeStats["recent_tx_count"])
+	if stats.DynamicBlockTime.RecentTxCount != 3 {
+		t.Errorf("Expected 3 recent tx counts, got %v", stats.DynamicBlockTime.RecentTxCount)
 	}
 
 	t.Logf("Time dynamic stats: %+v", stats)
@@ -150,7 +187,7 @@ func TestTimeDynamicStats(t *testing.T) {
 
 func TestDecayHistory(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	// Initially no decay history
 	history := tdm.GetDecayHistory(10)
@@ -192,7 +229,7 @@ func TestDecayHistory(t *testing.T) {
 
 func TestTimeDynamicConfigUpdate(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	// Check initial config
 	if !tdm.config.EnableDynamicBlockTime {
@@ -227,7 +264,7 @@ func TestBlockTimeChangeReason(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
 	config.TxThresholdHigh = 50
 	config.TxThresholdLow = 5
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	// Test different transaction volumes
 	testCases := []struct {
@@ -256,16 +293,16 @@ func TestTimeDynamicIntegration(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
 	config.ValidatorSelectionInterval = 50 * time.Millisecond
 	config.ReputationUpdateInterval = 50 * time.Millisecond
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	// Create mock validator selection manager
-	vsm := NewValidatorSelectionManager(DefaultValidatorSelectionConfig())
+	vsm := NewValidatorSelectionManager(DefaultValidatorSelectionConfig(), nil)
 	
 	// Create mock reputation system
-	rs := NewReputationSystem(DefaultReputationConfig(), nil)
+	rs := NewReputationSystem(DefaultReputationConfig(), nil, nil)
 	
 	// Create mock tracing system
-	ts := NewTracingSystem(DefaultTracingConfig())
+	ts := NewTracingSystem(DefaultTracingConfig(), nil, nil)
 
 	// Set integration components
 	tdm.SetIntegrationComponents(vsm, rs, ts)
@@ -0,0 +1,165 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Evidence proves that a signer sealed two different headers at the same
+// height on top of the same parent. Signers include evidence in the extra-data
+// of non-checkpoint blocks, right after the vanity, and every node slashes the
+// offender when applying the block to its voting snapshot.
+type Evidence struct {
+	First  *types.Header `json:"first"`
+	Second *types.Header `json:"second"`
+}
+
+// SlashingRules are the consensus parameters of double signing slashing.
+type SlashingRules struct {
+	Cooldown   uint64 // Blocks before a slashed signer may be voted back in
+	TraceRoots bool   // Whether blocks commit to a trace root after the evidence
+}
+
+// newSlashingRules creates the slashing rules for the chain. Evidence extends
+// the extra-data of non-checkpoint blocks, so it's only accepted if the chain
+// config explicitly opts in, and nil is returned otherwise.
+func newSlashingRules(cfg *params.PoatcConfig, clique *params.CliqueConfig, traceRoots bool) *SlashingRules {
+	if cfg == nil || !cfg.EnableSlashing {
+		return nil
+	}
+	rules := &SlashingRules{Cooldown: clique.Epoch, TraceRoots: traceRoots}
+	if cfg.SlashingCooldown > 0 {
+		rules.Cooldown = cfg.SlashingCooldown
+	}
+	return rules
+}
+
+// evidence extracts the double signing evidence included in a header, if any.
+func (r *SlashingRules) evidence(config *params.CliqueConfig, header *types.Header) (*Evidence, error) {
+	number := header.Number.Uint64()
+	if number%config.Epoch == 0 {
+		return nil, nil // checkpoints carry the signer list instead
+	}
+	end := len(header.Extra) - extraSeal
+	if r.TraceRoots {
+		end -= traceRootLength
+	}
+	if end < extraVanity {
+		return nil, errInvalidEvidence // truncated extra-data, don't mistake it for no evidence
+	}
+	if end == extraVanity {
+		return nil, nil
+	}
+	return decodeEvidence(header.Extra[extraVanity:end])
+}
+
+// decodeEvidence decodes RLP encoded double signing evidence.
+func decodeEvidence(blob []byte) (*Evidence, error) {
+	evidence := new(Evidence)
+	if err := rlp.DecodeBytes(blob, evidence); err != nil {
+		return nil, errInvalidEvidence
+	}
+	if err := evidence.sanityCheck(); err != nil {
+		return nil, err
+	}
+	return evidence, nil
+}
+
+// sanityCheck ensures both headers of the evidence can be seal hashed. Evidence
+// comes from peers, so headers with a truncated extra-data or with fields that
+// have no place in a proof-of-authority header are rejected before hashing.
+func (e *Evidence) sanityCheck() error {
+	for _, header := range []*types.Header{e.First, e.Second} {
+		if header == nil || header.Number == nil {
+			return errInvalidEvidence
+		}
+		if len(header.Extra) < extraVanity+extraSeal {
+			return errInvalidEvidence
+		}
+		if header.WithdrawalsHash != nil || header.ExcessBlobGas != nil || header.BlobGasUsed != nil || header.ParentBeaconRoot != nil {
+			return errInvalidEvidence
+		}
+	}
+	return nil
+}
+
+// verifyEvidence checks that evidence included in the block at the given number
+// proves a current signer sealed two different children of the same parent at
+// an earlier height, returning the offender.
+func (s *Snapshot) verifyEvidence(evidence *Evidence, number uint64) (common.Address, error) {
+	if err := evidence.sanityCheck(); err != nil {
+		return common.Address{}, err
+	}
+	height := evidence.First.Number.Uint64()
+	if height != evidence.Second.Number.Uint64() || height == 0 || height >= number {
+		return common.Address{}, errInvalidEvidence
+	}
+	// Sealing a height again on another parent is how honest signers follow a
+	// reorg, only competing children of the same parent are equivocation
+	if evidence.First.ParentHash != evidence.Second.ParentHash {
+		return common.Address{}, errInvalidEvidence
+	}
+	if SealHash(evidence.First) == SealHash(evidence.Second) {
+		return common.Address{}, errInvalidEvidence
+	}
+	first, err := ecrecover(evidence.First, s.sigcache)
+	if err != nil {
+		return common.Address{}, errInvalidEvidence
+	}
+	second, err := ecrecover(evidence.Second, s.sigcache)
+	if err != nil || first != second {
+		return common.Address{}, errInvalidEvidence
+	}
+	// Only current signers can be slashed, and never twice for offences that
+	// predate their previous slashing
+	if _, ok := s.Signers[first]; !ok || len(s.Signers) == 1 {
+		return common.Address{}, errInvalidEvidence
+	}
+	if slashed, ok := s.Slashed[first]; ok && height <= slashed {
+		return common.Address{}, errInvalidEvidence
+	}
+	return first, nil
+}
+
+// slash deauthorizes a signer proven to have double signed, recording the block
+// to enforce the cooldown and reputation floor should it be voted back in.
+func (s *Snapshot) slash(offender common.Address, number uint64) {
+	s.removeSigner(offender, number)
+	if s.Slashed == nil {
+		s.Slashed = make(map[common.Address]uint64)
+	}
+	s.Slashed[offender] = number
+}
+
+// coolingDown returns whether a signer was slashed too recently to be voted
+// back in at the given block.
+func (s *Snapshot) coolingDown(address common.Address, number uint64) bool {
+	if s.slashing == nil {
+		return false
+	}
+	slashed, ok := s.Slashed[address]
+	return ok && number < slashed+s.slashing.Cooldown
+}
+
+// setSlashingRules sets the double signing slashing rules of the snapshot.
+func (s *Snapshot) setSlashingRules(rules *SlashingRules) {
+	s.slashing = rules
+}
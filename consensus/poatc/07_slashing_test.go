@@ -0,0 +1,431 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that a signer sealing two blocks at the same height is detected while
+// importing a fork, and that including the evidence slashes it on every node:
+// it's removed from the signers, can't be voted back in during the cooldown,
+// and restarts from the reputation floor afterwards.
+func TestDoubleSigningSlashed(t *testing.T) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	accounts.checkpoint(&types.Header{Extra: genesis.ExtraData}, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30}
+	config.Poatc = &params.PoatcConfig{
+		EnableReputationSystem: true,
+		EnableSlashing:         true,
+		SlashingCooldown:       5,
+		Reputation: &params.PoatcReputationConfig{
+			EnableReputationSystem: true,
+			InitialReputation:      1.0,
+			MaxReputation:          10.0,
+			MinReputation:          0.1,
+			BlockMiningWeight:      0.4,
+			UptimeWeight:           0.3,
+			ConsistencyWeight:      0.2,
+			PenaltyWeight:          0.1,
+		},
+	}
+	genesis.Config = &config
+
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 10, func(i int, gen *core.BlockGen) {})
+
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.ToBlock().Hash(), signers)
+	snap.setReputationRules(engine.reputationRules)
+	snap.seedReputation(nil)
+	snap.setSlashingRules(engine.slashingRules)
+
+	// seal re-signs the i-th block on top of the previous one, carrying the given
+	// vote and evidence, and applies it to the replayed snapshot
+	seal := func(i int, signer string, vote common.Address, evidence *Evidence) {
+		header := blocks[i].Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		if vote != (common.Address{}) {
+			header.Coinbase = vote
+			copy(header.Nonce[:], nonceAuthVote)
+		}
+		header.Extra = make([]byte, extraVanity)
+		if evidence != nil {
+			blob, err := rlp.EncodeToBytes(evidence)
+			if err != nil {
+				t.Fatalf("failed to encode evidence: %v", err)
+			}
+			header.Extra = append(header.Extra, blob...)
+		}
+		header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+		header.Difficulty = diffInTurn // Ignored, we just need a valid number
+
+		accounts.sign(header, signer)
+		blocks[i] = blocks[i].WithSeal(header)
+
+		var err error
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("block %d: failed to apply header: %v", i+1, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		seal(i, names[i], common.Address{}, nil)
+	}
+	// B seals a competing block 2 too, which an importing node sees on sync
+	fork := blocks[1].Header()
+	fork.Time++
+	accounts.sign(fork, "B")
+
+	importer := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	importer.fakeDiff = true
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, importer, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if _, err := chain.InsertChain([]*types.Block{blocks[0], blocks[1].WithSeal(fork)}); err != nil {
+		t.Fatalf("failed to import fork: %v", err)
+	}
+	api := &API{chain: chain, poatc: importer}
+
+	pending := api.PendingEvidence()
+	if len(pending) != 1 {
+		t.Fatalf("pending evidence count mismatch: have %d, want 1", len(pending))
+	}
+	// Include the evidence in block 4, then have A and C vote B back in both
+	// during and after the cooldown
+	seal(3, "A", common.Address{}, pending[0])
+	if _, ok := snap.Signers[accounts.address("B")]; ok {
+		t.Fatalf("slashed signer still authorized")
+	}
+	seal(4, "C", common.Address{}, nil)
+	seal(5, "A", accounts.address("B"), nil)
+	seal(6, "C", accounts.address("B"), nil)
+	if len(snap.Tally) != 0 {
+		t.Errorf("votes counted during cooldown: %v", snap.Tally)
+	}
+	seal(7, "A", common.Address{}, nil)
+	seal(8, "C", accounts.address("B"), nil)
+	seal(9, "A", accounts.address("B"), nil)
+
+	if _, ok := snap.Signers[accounts.address("B")]; !ok {
+		t.Fatalf("signer not voted back in after cooldown")
+	}
+	if have, want := snap.Reputation[accounts.address("B")].Score, engine.reputationRules.Min; have != want {
+		t.Errorf("reputation of slashed signer mismatch: have %d, want %d", have, want)
+	}
+	// Import the rest of the chain on the detecting node and ensure it agrees
+	if _, err := chain.InsertChain(blocks[3:]); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	head, err := importer.snapshot(chain, blocks[9].NumberU64(), blocks[9].Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	if have := head.Slashed[accounts.address("B")]; have != 4 {
+		t.Errorf("slashing block mismatch: have %d, want 4", have)
+	}
+	if head.Reputation[accounts.address("B")] != snap.Reputation[accounts.address("B")] {
+		t.Errorf("reputation mismatch: have %+v, want %+v", head.Reputation[accounts.address("B")], snap.Reputation[accounts.address("B")])
+	}
+	// Replaying the evidence can't slash B again for the same offence
+	if err := api.SubmitEvidence(pending[0].First, pending[0].Second); err != errInvalidEvidence {
+		t.Errorf("replayed evidence error mismatch: have %v, want %v", err, errInvalidEvidence)
+	}
+}
+
+// Tests that evidence carrying headers which can't be seal hashed is rejected
+// instead of crashing the node hashing them.
+func TestMalformedEvidenceRejected(t *testing.T) {
+	valid := func() *types.Header {
+		return &types.Header{
+			Number:     big.NewInt(1),
+			Difficulty: diffInTurn,
+			BaseFee:    big.NewInt(params.InitialBaseFee),
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+	}
+	hash, blobGas := common.Hash{0x01}, uint64(1)
+
+	tests := []struct {
+		name   string
+		mutate func(header *types.Header)
+	}{
+		{"short extra-data", func(header *types.Header) { header.Extra = header.Extra[:extraSeal-1] }},
+		{"extra-data without vanity", func(header *types.Header) { header.Extra = header.Extra[:extraSeal] }},
+		{"withdrawals hash", func(header *types.Header) { header.WithdrawalsHash = &hash }},
+		{"excess blob gas", func(header *types.Header) { header.ExcessBlobGas = &blobGas }},
+		{"blob gas used", func(header *types.Header) { header.BlobGasUsed = &blobGas }},
+		{"parent beacon root", func(header *types.Header) { header.ParentBeaconRoot = &hash }},
+	}
+	config := &params.CliqueConfig{Period: 1, Epoch: 30}
+	rules := &SlashingRules{Cooldown: config.Epoch}
+	snap := newSnapshot(config, lru.NewCache[common.Hash, common.Address](inmemorySignatures), 0, common.Hash{}, []common.Address{{0x01}, {0x02}})
+
+	for _, tt := range tests {
+		malformed := valid()
+		tt.mutate(malformed)
+
+		evidence := &Evidence{First: malformed, Second: valid()}
+		blob, err := rlp.EncodeToBytes(evidence)
+		if err != nil {
+			t.Fatalf("%s: failed to encode evidence: %v", tt.name, err)
+		}
+		if _, err := decodeEvidence(blob); err != errInvalidEvidence {
+			t.Errorf("%s: decoding error mismatch: have %v, want %v", tt.name, err, errInvalidEvidence)
+		}
+		// Blocks carrying the evidence are rejected on every path
+		header := &types.Header{
+			Number: big.NewInt(2),
+			Extra:  append(append(make([]byte, extraVanity), blob...), make([]byte, extraSeal)...),
+		}
+		if _, err := rules.evidence(config, header); err != errInvalidEvidence {
+			t.Errorf("%s: extraction error mismatch: have %v, want %v", tt.name, err, errInvalidEvidence)
+		}
+		if _, err := snap.verifyEvidence(evidence, 2); err != errInvalidEvidence {
+			t.Errorf("%s: verification error mismatch: have %v, want %v", tt.name, err, errInvalidEvidence)
+		}
+	}
+	// Extra-data too short to hold the trace root isn't mistaken for no evidence
+	header := &types.Header{Number: big.NewInt(2), Extra: make([]byte, extraVanity+extraSeal)}
+	rules.TraceRoots = true
+	if _, err := rules.evidence(config, header); err != errInvalidEvidence {
+		t.Errorf("truncated extra-data error mismatch: have %v, want %v", err, errInvalidEvidence)
+	}
+}
+
+// Tests that chains which don't opt in to slashing keep rejecting non-checkpoint
+// blocks with data between the vanity and the seal, evidence or not.
+func TestEvidenceRequiresSlashing(t *testing.T) {
+	config := &params.CliqueConfig{Period: 1, Epoch: 30}
+	for _, poatcConfig := range []*params.PoatcConfig{nil, {EnableReputationSystem: true}} {
+		engine := NewWithConfig(config, poatcConfig, rawdb.NewMemoryDatabase())
+		if engine.slashingRules != nil {
+			t.Fatalf("slashing enabled without opting in: %+v", poatcConfig)
+		}
+		evidence := &Evidence{
+			First:  &types.Header{Number: big.NewInt(1), Difficulty: diffInTurn, Extra: make([]byte, extraVanity+extraSeal)},
+			Second: &types.Header{Number: big.NewInt(1), Difficulty: diffNoTurn, Extra: make([]byte, extraVanity+extraSeal)},
+		}
+		blob, err := rlp.EncodeToBytes(evidence)
+		if err != nil {
+			t.Fatalf("failed to encode evidence: %v", err)
+		}
+		header := &types.Header{
+			Number: big.NewInt(2),
+			Extra:  append(append(make([]byte, extraVanity), blob...), make([]byte, extraSeal)...),
+		}
+		if err := engine.verifyHeader(nil, header, nil); err != errExtraSigners {
+			t.Errorf("header with evidence error mismatch: have %v, want %v", err, errExtraSigners)
+		}
+		api := &API{poatc: engine}
+		if err := api.SubmitEvidence(evidence.First, evidence.Second); err == nil {
+			t.Errorf("evidence accepted without slashing")
+		}
+	}
+}
+
+// Tests that a signer sealing a height again on another parent, as it does when
+// following a reorg, is neither reported nor slashable, while competing children
+// of the same parent still are.
+func TestReorgResealNotSlashable(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	config := &params.CliqueConfig{Period: 1, Epoch: 30}
+	engine := NewWithConfig(config, &params.PoatcConfig{EnableSlashing: true}, rawdb.NewMemoryDatabase())
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	snap := newSnapshot(engine.config, engine.signatures, 2, common.Hash{}, signers)
+
+	// seal creates a block 2 sealed by B on top of the given parent
+	seal := func(parent common.Hash, time uint64) *types.Header {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(2),
+			Time:       time,
+			Difficulty: diffNoTurn,
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		accounts.sign(header, "B")
+		return header
+	}
+	original := seal(common.Hash{0x01}, 10)
+	resealed := seal(common.Hash{0x02}, 11)
+	competing := seal(common.Hash{0x02}, 12)
+
+	if _, err := snap.verifyEvidence(&Evidence{First: original, Second: resealed}, 3); err != errInvalidEvidence {
+		t.Errorf("reorg reseal error mismatch: have %v, want %v", err, errInvalidEvidence)
+	}
+	offender, err := snap.verifyEvidence(&Evidence{First: resealed, Second: competing}, 3)
+	if err != nil {
+		t.Fatalf("failed to verify evidence: %v", err)
+	}
+	if offender != accounts.address("B") {
+		t.Errorf("offender mismatch: have %x, want %x", offender, accounts.address("B"))
+	}
+	// Only the competing children are reported by the detecting node
+	engine.detectDoubleSigning(original, offender)
+	engine.detectDoubleSigning(resealed, offender)
+	if pending := engine.pendingEvidence(snap, 3); pending != nil {
+		t.Fatalf("reorg reseal reported as double signing: %+v", pending)
+	}
+	engine.detectDoubleSigning(competing, offender)
+	if pending := engine.pendingEvidence(snap, 3); pending == nil || pending.First.ParentHash != pending.Second.ParentHash {
+		t.Errorf("competing children not reported: %+v", pending)
+	}
+}
+
+// Tests that blocks including double signing evidence on chains committing to
+// trace roots account for the accusation in the root they are prepared with, so
+// every node importing them derives the same root.
+func TestSlashingWithTraceRoots(t *testing.T) {
+	accounts := newTesterAccountPool()
+	names := []string{"A", "B", "C"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	accounts.checkpoint(&types.Header{Extra: genesis.ExtraData}, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30}
+	config.Poatc = &params.PoatcConfig{
+		EnableSlashing:      true,
+		EnableTracingSystem: true,
+		Tracing: &params.PoatcTracingConfig{
+			EnableTracing:     true,
+			EnableMerkleTree:  true,
+			MerkleRootInBlock: true,
+		},
+	}
+	genesis.Config = &config
+
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.fakeDiff = true
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 4, func(i int, gen *core.BlockGen) {})
+
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.ToBlock().Hash(), signers)
+	engine.configureSnapshot(snap)
+
+	// Seal the first blocks round-robin, committing to their trace roots
+	snaps := []*Snapshot{snap}
+	for i := 0; i < 3; i++ {
+		header := blocks[i].Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Difficulty = diffInTurn // Ignored, we just need a valid number
+
+		root := blockTraceRoot(blockTraceEvents(snap, header, accounts.address(names[i]), nil))
+		header.Extra = append(make([]byte, extraVanity), root[:]...)
+		header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+		accounts.sign(header, names[i])
+		blocks[i] = blocks[i].WithSeal(header)
+
+		var err error
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("block %d: failed to apply header: %v", i+1, err)
+		}
+		snaps = append(snaps, snap)
+	}
+	// B seals a competing block 2 too, which the node sees on sync
+	fork := blocks[1].Header()
+	fork.Time++
+	root := blockTraceRoot(blockTraceEvents(snaps[1], fork, accounts.address("B"), nil))
+	copy(fork.Extra[extraVanity:], root[:])
+	accounts.sign(fork, "B")
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if _, err := chain.InsertChain([]*types.Block{blocks[0], blocks[1].WithSeal(fork)}); err != nil {
+		t.Fatalf("failed to import fork: %v", err)
+	}
+	evidence := engine.pendingEvidence(snap, 4)
+	if evidence == nil {
+		t.Fatalf("double signing not detected")
+	}
+	// A prepares block 4 including the evidence, which must import cleanly
+	engine.Authorize(accounts.address("A"), nil)
+
+	header := blocks[3].Header()
+	header.ParentHash = blocks[2].Hash()
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare block: %v", err)
+	}
+	accounts.sign(header, "A")
+
+	events := blockTraceEvents(snap, header, accounts.address("A"), evidence)
+	if last := events[len(events)-1]; last.Type != TraceEventAccusation || last.Address != accounts.address("B") {
+		t.Fatalf("accusation event missing: %+v", events)
+	}
+	if have, want := traceRoot(header), blockTraceRoot(events); have != want {
+		t.Fatalf("prepared trace root mismatch: have %x, want %x", have, want)
+	}
+	if _, err := chain.InsertChain([]*types.Block{blocks[3].WithSeal(header)}); err != nil {
+		t.Fatalf("failed to import slashing block: %v", err)
+	}
+	head, err := engine.snapshot(chain, 4, header.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	if have := head.Slashed[accounts.address("B")]; have != 4 {
+		t.Errorf("slashing block mismatch: have %d, want 4", have)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// livenessEntryLength is the number of extra-data bytes a checkpoint block uses
+// to commit to the missed slot accounting of each signer: its slots, missed
+// slots and streak as big endian uint64s.
+const livenessEntryLength = 3 * 8
+
+// LivenessRules are the parameters of the missed slot tracking in the voting
+// snapshot. Every block assigns its in-turn slot to the signer picked by the
+// deterministic schedule, so every node counts the same missed slots no matter
+// when it imports the block.
+type LivenessRules struct {
+	MaxMissedSlots uint64 // Consecutive missed slots before the engine proposes to drop a signer (0 = never)
+}
+
+// newLivenessRules creates the liveness rules for the chain, or nil if the chain
+// config doesn't opt into missed slot tracking.
+func newLivenessRules(cfg *params.PoatcConfig) *LivenessRules {
+	if cfg == nil || !cfg.EnableLivenessTracking {
+		return nil
+	}
+	return &LivenessRules{MaxMissedSlots: cfg.MaxMissedSlots}
+}
+
+// SignerLiveness is the missed slot accounting of a signer. It is derived from
+// the blocks only and committed to by checkpoints after the reputation scores,
+// so nodes syncing from a checkpoint carry on with the same counters.
+type SignerLiveness struct {
+	Slots  uint64 `json:"slots"`  // In-turn slots assigned to the signer
+	Missed uint64 `json:"missed"` // In-turn slots sealed by another signer
+	Streak uint64 `json:"streak"` // Slots missed in a row since the signer last sealed a block
+}
+
+// Uptime returns the share of its in-turn slots the signer sealed, one if it
+// wasn't assigned any yet.
+func (l SignerLiveness) Uptime() float64 {
+	if l.Slots == 0 {
+		return 1
+	}
+	return float64(l.Slots-l.Missed) / float64(l.Slots)
+}
+
+// setLivenessRules sets the missed slot tracking rules of the snapshot.
+func (s *Snapshot) setLivenessRules(rules *LivenessRules) {
+	s.liveness = rules
+	if rules != nil && s.Liveness == nil {
+		s.Liveness = make(map[common.Address]SignerLiveness)
+	}
+}
+
+// trackLiveness accounts the in-turn slot of a block sealed by signer on top of
+// the given parent. The snapshot must still be the one of the parent block when
+// called. Sealing any block proves the signer alive and ends its streak.
+func (s *Snapshot) trackLiveness(number uint64, parent common.Hash, signer common.Address) {
+	if expected, ok := s.inturnSignerAt(number, parent); ok {
+		liveness := s.Liveness[expected]
+		liveness.Slots++
+		if expected != signer {
+			liveness.Missed++
+			liveness.Streak++
+		}
+		s.Liveness[expected] = liveness
+	}
+	// Signers are only tracked once in turn, like in snapshots seeded from a
+	// checkpoint, which never carry empty entries
+	if liveness, ok := s.Liveness[signer]; ok && liveness.Streak > 0 {
+		liveness.Streak = 0
+		s.Liveness[signer] = liveness
+	}
+}
+
+// encodeLiveness encodes the missed slot accounting of the signers in ascending
+// signer order, as committed to by checkpoint blocks.
+func (s *Snapshot) encodeLiveness() []byte {
+	signers := s.signers()
+	blob := make([]byte, 0, len(signers)*livenessEntryLength)
+	for _, signer := range signers {
+		liveness := s.Liveness[signer]
+		blob = binary.BigEndian.AppendUint64(blob, liveness.Slots)
+		blob = binary.BigEndian.AppendUint64(blob, liveness.Missed)
+		blob = binary.BigEndian.AppendUint64(blob, liveness.Streak)
+	}
+	return blob
+}
+
+// seedLiveness sets the missed slot accounting of the signers to the one
+// committed to by a checkpoint. Signers without any slot accounted are left
+// untracked, like on nodes that applied every block.
+func (s *Snapshot) seedLiveness(blob []byte) {
+	for i, signer := range s.signers() {
+		if len(blob) < (i+1)*livenessEntryLength {
+			return
+		}
+		entry := blob[i*livenessEntryLength:]
+		liveness := SignerLiveness{
+			Slots:  binary.BigEndian.Uint64(entry),
+			Missed: binary.BigEndian.Uint64(entry[8:]),
+			Streak: binary.BigEndian.Uint64(entry[16:]),
+		}
+		if liveness != (SignerLiveness{}) {
+			s.Liveness[signer] = liveness
+		}
+	}
+}
+
+// unresponsive returns whether a signer missed enough slots in a row to be
+// proposed for removal.
+func (s *Snapshot) unresponsive(signer common.Address) bool {
+	if s.liveness == nil || s.liveness.MaxMissedSlots == 0 {
+		return false
+	}
+	if _, ok := s.Signers[signer]; !ok {
+		return false
+	}
+	return s.Liveness[signer].Streak >= s.liveness.MaxMissedSlots
+}
+
+// updateUptimeScores feeds the uptime of the signers tracked by the snapshot to
+// the local reputation system.
+func (c *POATC) updateUptimeScores(snap *Snapshot) {
+	if snap.liveness == nil || c.reputationSystem == nil {
+		return
+	}
+	for _, address := range snap.signers() {
+		c.reputationSystem.SetUptime(address, snap.Liveness[address].Uptime())
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the missed slots of a signer that stopped sealing are tracked in
+// the snapshot, the same whether the blocks are applied at once or one by one,
+// and that the engine proposes to drop it until it seals again.
+func TestLivenessTracking(t *testing.T) {
+	accounts := newTesterAccountPool()
+	engine := NewWithConfig(&params.CliqueConfig{Period: 1, Epoch: 30000}, &params.PoatcConfig{
+		EnableLivenessTracking: true,
+		MaxMissedSlots:         2,
+	}, nil)
+	engine.Authorize(accounts.address("A"), nil)
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	genesis := &types.Header{Number: big.NewInt(0), Time: 100}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.Hash(), signers)
+	engine.configureSnapshot(snap)
+
+	// seal signs a block on top of the parent, in turn if the signer is expected
+	seal := func(parent *types.Header, replay *Snapshot, name string) *types.Header {
+		number := parent.Number.Uint64() + 1
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			ParentHash: parent.Hash(),
+			Time:       parent.Time + 1,
+			Extra:      make([]byte, extraVanity+extraSeal),
+			Difficulty: diffNoTurn,
+		}
+		if expected, _ := replay.inturnSigner(number); expected == accounts.address(name) {
+			header.Difficulty = diffInTurn
+		}
+		accounts.sign(header, name)
+		return header
+	}
+	// A and B take turns sealing until C missed enough of its slots in a row
+	var (
+		headers []*types.Header
+		replay  = snap
+		parent  = genesis
+		slots   = make(map[common.Address]uint64)
+		missed  = make(map[common.Address]uint64)
+	)
+	for i := 0; replay.Liveness[accounts.address("C")].Streak < 2; i++ {
+		if i == 100 {
+			t.Fatalf("signer C was never in turn")
+		}
+		name := []string{"A", "B"}[i%2]
+		header := seal(parent, replay, name)
+
+		expected, _ := replay.inturnSigner(header.Number.Uint64())
+		slots[expected]++
+		if expected != accounts.address(name) {
+			missed[expected]++
+		}
+		var err error
+		if replay, err = replay.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("failed to apply block %d: %v", header.Number, err)
+		}
+		headers, parent = append(headers, header), header
+	}
+	batch, err := snap.apply(headers)
+	if err != nil {
+		t.Fatalf("failed to apply blocks: %v", err)
+	}
+	for _, signer := range signers {
+		have, want := batch.Liveness[signer], replay.Liveness[signer]
+		if have != want {
+			t.Errorf("signer %x liveness mismatch between batch and single applies: have %+v, want %+v", signer, have, want)
+		}
+		if have.Slots != slots[signer] || have.Missed != missed[signer] {
+			t.Errorf("signer %x slots mismatch: have %d/%d missed, want %d/%d", signer, have.Missed, have.Slots, missed[signer], slots[signer])
+		}
+	}
+	if c := batch.Liveness[accounts.address("C")]; c.Uptime() != 0 || c.Missed != c.Slots {
+		t.Errorf("absent signer liveness mismatch: have %+v, uptime %v", c, c.Uptime())
+	}
+	// The uptime of the signers feeds their local reputation
+	engine.reputationSystem = NewReputationSystem(nil, nil, nil)
+	for _, signer := range signers {
+		engine.reputationSystem.AddValidator(signer)
+	}
+	engine.updateUptimeScores(batch)
+	for _, signer := range signers {
+		want := batch.Liveness[signer].Uptime() * engine.reputationSystem.config.MaxComponentScore
+		if have := engine.reputationSystem.GetReputationScore(signer).UptimeScore; have != want {
+			t.Errorf("signer %x uptime score mismatch: have %v, want %v", signer, have, want)
+		}
+	}
+	// The unresponsive signer is proposed for removal, until it seals again
+	engine.rotateSigners(nil, batch, batch.Number+1)
+	if authorize, ok := engine.proposals[accounts.address("C")]; !ok || authorize {
+		t.Fatalf("unresponsive signer not proposed for removal: %v", engine.proposals)
+	}
+	header := seal(parent, batch, "C")
+	if batch, err = batch.apply([]*types.Header{header}); err != nil {
+		t.Fatalf("failed to apply block %d: %v", header.Number, err)
+	}
+	if streak := batch.Liveness[accounts.address("C")].Streak; streak != 0 {
+		t.Errorf("streak not reset by sealing: have %d", streak)
+	}
+	engine.rotateSigners(nil, batch, batch.Number+1)
+	if _, ok := engine.proposals[accounts.address("C")]; ok || len(engine.autoProposals) != 0 {
+		t.Errorf("proposal not withdrawn after sealing: %v", engine.proposals)
+	}
+}
+
+// Tests that the engine never overrides nor withdraws proposals of the user.
+func TestLivenessKeepsUserProposals(t *testing.T) {
+	accounts := newTesterAccountPool()
+	engine := NewWithConfig(&params.CliqueConfig{Period: 1, Epoch: 30000}, &params.PoatcConfig{
+		EnableLivenessTracking: true,
+		MaxMissedSlots:         1,
+	}, nil)
+	signers := []common.Address{accounts.address("A"), accounts.address("B")}
+	snap := newSnapshot(engine.config, engine.signatures, 0, common.Hash{}, signers)
+	engine.configureSnapshot(snap)
+
+	snap.Liveness[accounts.address("B")] = SignerLiveness{Slots: 1, Missed: 1, Streak: 1}
+	engine.proposals[accounts.address("B")] = true
+
+	engine.rotateSigners(nil, snap, 1)
+	if authorize := engine.proposals[accounts.address("B")]; !authorize || len(engine.autoProposals) != 0 {
+		t.Errorf("user proposal overridden: %v", engine.proposals)
+	}
+	snap.Liveness[accounts.address("B")] = SignerLiveness{Slots: 2, Missed: 1}
+	engine.rotateSigners(nil, snap, 2)
+	if _, ok := engine.proposals[accounts.address("B")]; !ok {
+		t.Errorf("user proposal withdrawn")
+	}
+}
+
+// Tests that checkpoints commit to the missed slot accounting of the signers,
+// so nodes syncing from a checkpoint carry on with the same liveness.
+func TestLivenessCommittedAtCheckpoint(t *testing.T) {
+	accounts := newTesterAccountPool()
+	engine := NewWithConfig(&params.CliqueConfig{Period: 1, Epoch: 6}, &params.PoatcConfig{
+		EnableLivenessTracking: true,
+		MaxMissedSlots:         2,
+	}, nil)
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	genesis := &types.Header{Number: big.NewInt(0), Time: 100}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.Hash(), signers)
+	engine.configureSnapshot(snap)
+
+	// A and B take turns sealing up to a checkpoint after C missed a slot
+	parent := genesis
+	for i := 0; parent.Number.Uint64()%engine.config.Epoch != 0 || snap.Liveness[accounts.address("C")].Missed == 0; i++ {
+		if i == 100 {
+			t.Fatalf("signer C was never in turn")
+		}
+		number := parent.Number.Uint64() + 1
+		name := []string{"A", "B"}[i%2]
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			ParentHash: parent.Hash(),
+			Time:       parent.Time + 1,
+			Extra:      make([]byte, extraVanity),
+			Difficulty: diffNoTurn,
+		}
+		if number%engine.config.Epoch == 0 {
+			header.Extra = append(header.Extra, engine.checkpointSection(snap, number, header.Time, accounts.address(name))...)
+		}
+		header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+		accounts.sign(header, name)
+
+		var err error
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("failed to apply block %d: %v", number, err)
+		}
+		parent = header
+	}
+	// A snapshot trusting the checkpoint should carry the same accounting
+	synced := engine.checkpointSnapshot(parent)
+	if len(synced.Liveness) != len(snap.Liveness) {
+		t.Errorf("tracked signer count mismatch: have %d, want %d", len(synced.Liveness), len(snap.Liveness))
+	}
+	for _, signer := range signers {
+		if have, want := synced.Liveness[signer], snap.Liveness[signer]; have != want {
+			t.Errorf("signer %x liveness mismatch: have %+v, want %+v", signer, have, want)
+		}
+	}
+}
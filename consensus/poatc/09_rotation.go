@@ -0,0 +1,217 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Reasons for the engine to push a proposal on its own.
+const (
+	rotationMissedSlots   = "missed_slots"   // Signer missed too many in-turn slots in a row
+	rotationLowReputation = "low_reputation" // Signer stayed below the low reputation threshold for too long
+	rotationStandby       = "standby"        // Standby candidate refilling the signer set
+	rotationCandidate     = "candidate"      // Registered candidate that passed its probation refilling the signer set
+)
+
+// RotationPolicy is the signer rotation policy of the chain. It only decides on
+// proposals, which are still voted on like the ones of the user, but it only
+// reads the voting snapshot, so all signers following it vote the same way.
+type RotationPolicy struct {
+	LowReputationEpochs uint64           // Epochs in a row a signer may stay below the low reputation threshold (0 = forever)
+	Standby             []common.Address // Candidates authorized in order to refill the signer set
+	TargetSigners       int              // Number of signers to refill the set up to
+}
+
+// newRotationPolicy creates the rotation policy of the chain, or nil if the
+// chain config doesn't opt into signer rotation.
+func newRotationPolicy(cfg *params.PoatcConfig) *RotationPolicy {
+	if cfg == nil || cfg.Rotation == nil || !cfg.Rotation.EnableRotation {
+		return nil
+	}
+	return &RotationPolicy{
+		LowReputationEpochs: cfg.Rotation.LowReputationEpochs,
+		Standby:             cfg.Rotation.StandbySigners,
+		TargetSigners:       cfg.Rotation.TargetSigners,
+	}
+}
+
+// lowReputation returns whether a signer stayed below the low reputation
+// threshold for enough epochs to be proposed for removal.
+func (p *RotationPolicy) lowReputation(snap *Snapshot, signer common.Address) bool {
+	if p.LowReputationEpochs == 0 || snap.reputationRules == nil {
+		return false
+	}
+	return snap.Reputation[signer].LowEpochs >= p.LowReputationEpochs
+}
+
+// autoProposal is a proposal the engine pushes on its own.
+type autoProposal struct {
+	Authorize bool
+	Reason    string
+}
+
+// rotationProposals returns the proposals the engine should push on top of the
+// given snapshot, keyed by the account voted on.
+func (c *POATC) rotationProposals(chain consensus.ChainHeaderReader, snap *Snapshot) map[common.Address]autoProposal {
+	proposals := make(map[common.Address]autoProposal)
+	for _, signer := range snap.signers() {
+		switch {
+		case snap.unresponsive(signer):
+			proposals[signer] = autoProposal{Authorize: false, Reason: rotationMissedSlots}
+		case c.rotation != nil && c.rotation.lowReputation(snap, signer):
+			proposals[signer] = autoProposal{Authorize: false, Reason: rotationLowReputation}
+		}
+	}
+	if c.rotation == nil {
+		return proposals
+	}
+	// Refill the signer set from the standby candidates, in order
+	number := snap.Number + 1
+	for _, candidate := range c.rotation.Standby {
+		if len(snap.Signers)+countAuthorizations(proposals) >= c.rotation.TargetSigners {
+			break
+		}
+		if !snap.validVote(candidate, true) || snap.coolingDown(candidate, number) || snap.checkLists(candidate, number) != nil {
+			continue
+		}
+		proposals[candidate] = autoProposal{Authorize: true, Reason: rotationStandby}
+	}
+	// Refill the remaining slots with the best registered candidates
+	if c.registry == nil || chain == nil || len(snap.Signers)+countAuthorizations(proposals) >= c.rotation.TargetSigners {
+		return proposals
+	}
+	header := chain.GetHeader(snap.Hash, snap.Number)
+	if header == nil {
+		return proposals
+	}
+	candidates, err := c.registry.promotable(chain, header)
+	if err != nil {
+		log.Debug("Failed to read signer candidates", "number", snap.Number, "err", err)
+		return proposals
+	}
+	for _, candidate := range candidates {
+		if len(snap.Signers)+countAuthorizations(proposals) >= c.rotation.TargetSigners {
+			break
+		}
+		if _, ok := proposals[candidate.Address]; ok {
+			continue
+		}
+		if !snap.validVote(candidate.Address, true) || snap.coolingDown(candidate.Address, number) || snap.checkLists(candidate.Address, number) != nil {
+			continue
+		}
+		proposals[candidate.Address] = autoProposal{Authorize: true, Reason: rotationCandidate}
+	}
+	return proposals
+}
+
+// countAuthorizations returns the number of proposals to authorize a signer.
+func countAuthorizations(proposals map[common.Address]autoProposal) int {
+	var count int
+	for _, proposal := range proposals {
+		if proposal.Authorize {
+			count++
+		}
+	}
+	return count
+}
+
+// rotateSigners updates the proposals the engine pushes on its own to the ones
+// called for by the given snapshot: new ones are added, and the ones no longer
+// called for are dropped, either because they passed or were withdrawn.
+// Proposals of the user are left alone, and signers never propose to drop
+// themselves. The chain is only needed to promote registered candidates.
+func (c *POATC) rotateSigners(chain consensus.ChainHeaderReader, snap *Snapshot, number uint64) {
+	if snap.liveness == nil && c.rotation == nil {
+		return
+	}
+	proposals := c.rotationProposals(chain, snap)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for address, proposal := range c.autoProposals {
+		if wanted, ok := proposals[address]; ok && wanted == proposal {
+			continue
+		}
+		delete(c.proposals, address)
+		delete(c.autoProposals, address)
+
+		action := "withdrawn"
+		if !snap.validVote(address, proposal.Authorize) {
+			action = "passed"
+		}
+		log.Info("Ended signer rotation proposal", "address", address, "authorize", proposal.Authorize, "reason", proposal.Reason, "outcome", action)
+		c.traceRotation(snap, number, address, proposal, action)
+	}
+	for _, address := range sortedAddresses(proposals) {
+		proposal := proposals[address]
+		if address == c.signer {
+			continue
+		}
+		if _, ok := c.proposals[address]; ok {
+			continue
+		}
+		c.proposals[address] = proposal.Authorize
+		c.autoProposals[address] = proposal
+
+		log.Info("Proposed signer rotation", "address", address, "authorize", proposal.Authorize, "reason", proposal.Reason)
+		c.traceRotation(snap, number, address, proposal, "proposed")
+	}
+}
+
+// traceRotation traces a rotation decision of the engine about an account.
+func (c *POATC) traceRotation(snap *Snapshot, number uint64, address common.Address, proposal autoProposal, action string) {
+	if c.tracingSystem == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"action":    action,
+		"authorize": proposal.Authorize,
+		"reason":    proposal.Reason,
+	}
+	switch proposal.Reason {
+	case rotationMissedSlots:
+		data["missed"] = snap.Liveness[address].Streak
+	case rotationLowReputation:
+		data["score"] = snap.Reputation[address].Float()
+		data["low_epochs"] = snap.Reputation[address].LowEpochs
+	}
+	message := "Proposal to drop signer " + action
+	switch {
+	case proposal.Reason == rotationCandidate:
+		message = "Proposal to authorize registered candidate " + action
+	case proposal.Authorize:
+		message = "Proposal to authorize standby signer " + action
+	}
+	c.tracingSystem.Trace(TraceEventProposal, TraceLevelBasic, number, address, message, data)
+}
+
+// sortedAddresses returns the accounts of the proposals in ascending order.
+func sortedAddresses(proposals map[common.Address]autoProposal) []common.Address {
+	addresses := make([]common.Address, 0, len(proposals))
+	for address := range proposals {
+		addresses = append(addresses, address)
+	}
+	slices.SortFunc(addresses, common.Address.Cmp)
+	return addresses
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that epoch transitions count how long signers stay below the low
+// reputation threshold.
+func TestLowReputationEpochs(t *testing.T) {
+	rules := &ReputationRules{
+		Initial:      selectionPrecision,
+		Max:          10 * selectionPrecision,
+		Min:          selectionPrecision / 10,
+		DecayFactor:  selectionPrecision,
+		LowThreshold: 3 * selectionPrecision,
+	}
+	var (
+		good = common.Address{0x01}
+		bad  = common.Address{0x02}
+	)
+	snap := newSnapshot(&params.CliqueConfig{Epoch: 10}, nil, 0, common.Hash{}, []common.Address{good, bad})
+	snap.setReputationRules(rules)
+	snap.Reputation[good] = SignerReputation{Score: 5 * selectionPrecision}
+	snap.Reputation[bad] = SignerReputation{Score: selectionPrecision}
+
+	for epoch := uint64(1); epoch <= 3; epoch++ {
+		snap.updateReputation(epoch*10, 0, good)
+	}
+	if have := snap.Reputation[good].LowEpochs; have != 0 {
+		t.Errorf("good signer low epochs mismatch: have %d, want 0", have)
+	}
+	if have := snap.Reputation[bad].LowEpochs; have != 3 {
+		t.Errorf("bad signer low epochs mismatch: have %d, want 3", have)
+	}
+	// Climbing back above the threshold restarts the count
+	snap.Reputation[bad] = SignerReputation{Score: 4 * selectionPrecision, LowEpochs: 3}
+	snap.updateReputation(40, 0, good)
+	if have := snap.Reputation[bad].LowEpochs; have != 0 {
+		t.Errorf("recovered signer low epochs mismatch: have %d, want 0", have)
+	}
+}
+
+// Tests that the engine proposes to drop signers with a chronically low
+// reputation, refills the signer set from the standby candidates and traces
+// every decision.
+func TestSignerRotation(t *testing.T) {
+	var (
+		self     = common.Address{0x01}
+		bad      = common.Address{0x02}
+		standby1 = common.Address{0x03}
+		standby2 = common.Address{0x04}
+	)
+	engine := NewWithConfig(&params.CliqueConfig{Period: 1, Epoch: 10}, &params.PoatcConfig{
+		EnableReputationSystem: true,
+		EnableSlashing:         true,
+		Rotation: &params.PoatcRotationConfig{
+			EnableRotation:      true,
+			LowReputationEpochs: 2,
+			StandbySigners:      []common.Address{standby1, standby2},
+			TargetSigners:       2,
+		},
+	}, nil)
+	engine.Authorize(self, nil)
+	engine.tracingSystem = NewTracingSystem(DefaultTracingConfig(), nil, nil)
+
+	snap := newSnapshot(engine.config, engine.signatures, 20, common.Hash{}, []common.Address{self, bad})
+	engine.configureSnapshot(snap)
+	snap.seedReputation(nil)
+
+	// Nothing to do while the signers are fine and the set is full
+	engine.rotateSigners(nil, snap, 21)
+	if len(engine.proposals) != 0 {
+		t.Fatalf("unexpected proposals: %v", engine.proposals)
+	}
+	// A chronically bad signer is proposed for removal, but never the local one
+	for _, address := range []common.Address{self, bad} {
+		reputation := snap.Reputation[address]
+		reputation.LowEpochs = 2
+		snap.Reputation[address] = reputation
+	}
+	engine.rotateSigners(nil, snap, 21)
+	if authorize, ok := engine.proposals[bad]; !ok || authorize || len(engine.proposals) != 1 {
+		t.Fatalf("bad signer not proposed for removal alone: %v", engine.proposals)
+	}
+	// Once dropped, the first standby candidate is proposed to refill the set
+	snap.removeSigner(bad, 22)
+	snap.Number = 22
+	engine.rotateSigners(nil, snap, 23)
+	if _, ok := engine.proposals[bad]; ok {
+		t.Errorf("passed proposal not dropped: %v", engine.proposals)
+	}
+	if authorize, ok := engine.proposals[standby1]; !ok || !authorize || len(engine.proposals) != 1 {
+		t.Fatalf("standby signer not proposed alone: %v", engine.proposals)
+	}
+	// Standby candidates that can't be voted in are skipped
+	snap.Slashed = map[common.Address]uint64{standby1: 22}
+	engine.rotateSigners(nil, snap, 23)
+	if _, ok := engine.proposals[standby2]; !ok || len(engine.proposals) != 1 {
+		t.Fatalf("next standby signer not proposed alone: %v", engine.proposals)
+	}
+	events := engine.tracingSystem.GetTraceEvents(TraceEventProposal, TraceLevelVerbose, 0)
+	want := []struct {
+		address common.Address
+		action  string
+		reason  string
+	}{
+		{bad, "proposed", rotationLowReputation},
+		{bad, "passed", rotationLowReputation},
+		{standby1, "proposed", rotationStandby},
+		{standby1, "withdrawn", rotationStandby},
+		{standby2, "proposed", rotationStandby},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("trace events mismatch: have %d, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.Address != want[i].address || event.Data["action"] != want[i].action || event.Data["reason"] != want[i].reason {
+			t.Errorf("trace event %d mismatch: have %x %v %v, want %x %s %s", i, event.Address, event.Data["action"], event.Data["reason"], want[i].address, want[i].action, want[i].reason)
+		}
+	}
+}
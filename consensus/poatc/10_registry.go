@@ -0,0 +1,403 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"bytes"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// RegistryABI is the interface of the signer candidate registry. Candidates call
+// it with plain transactions to the registry account, which the engine executes
+// while finalizing the block they are included in, so any ABI tooling may be
+// used to register.
+const RegistryABI = `[
+	{"type":"function","name":"register","stateMutability":"payable","inputs":[{"name":"enode","type":"string"}],"outputs":[]},
+	{"type":"function","name":"attest","stateMutability":"nonpayable","inputs":[{"name":"number","type":"uint256"},{"name":"hash","type":"bytes32"}],"outputs":[]},
+	{"type":"function","name":"withdraw","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+]`
+
+// registryABI is the parsed interface of the candidate registry.
+var registryABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(RegistryABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// maxEnodeLength is the maximum length of the enode URL of a candidate, enough
+// for a v4 URL with an IPv6 endpoint and a distinct discovery port. It bounds the
+// storage slots a registration writes.
+const maxEnodeLength = 256
+
+// defaultAttestationWindow is the number of blocks an attested block may lag
+// behind the block including the attestation, if not configured.
+const defaultAttestationWindow = 32
+
+// Fields of a candidate record, consecutive storage slots from its base slot.
+// The enode URL follows its length, 32 bytes per slot.
+const (
+	candidateDeposit = iota
+	candidateRegistered
+	candidateAttestations
+	candidateLastAttested
+	candidateIndex // Position in the candidate list, plus one
+	candidateEnodeLength
+	candidateEnode
+)
+
+// RegistryRules are the consensus parameters of the signer candidate registry.
+// The registry is kept in the storage of its account: a list of the candidate
+// addresses in slot 0, laid out as a Solidity dynamic array, and a record per
+// candidate based at the slot a Solidity mapping in slot 1 would use.
+type RegistryRules struct {
+	Address           common.Address // Account holding the registry and the deposits
+	MinDeposit        *big.Int       // Wei a candidate deposits to register
+	AttestationWindow uint64         // Blocks an attested block may lag behind the attestation
+	Probation         uint64         // Attestations a candidate needs before it may be promoted
+}
+
+// newRegistryRules creates the registry rules for the chain, nil if the chain
+// has no candidate registry.
+func newRegistryRules(cfg *params.PoatcConfig) *RegistryRules {
+	if cfg == nil || cfg.Registry == nil {
+		return nil
+	}
+	rules := &RegistryRules{
+		Address:           cfg.Registry.Address,
+		MinDeposit:        new(big.Int),
+		AttestationWindow: cfg.Registry.AttestationWindow,
+		Probation:         cfg.Registry.ProbationAttestations,
+	}
+	if cfg.Registry.MinDeposit != nil {
+		rules.MinDeposit.Set(cfg.Registry.MinDeposit)
+	}
+	if rules.AttestationWindow == 0 {
+		rules.AttestationWindow = defaultAttestationWindow
+	}
+	return rules
+}
+
+// Candidate is a signer candidate registered in the registry.
+type Candidate struct {
+	Address      common.Address `json:"address"`
+	Enode        string         `json:"enode"`        // Node the candidate signs with once promoted
+	Deposit      *big.Int       `json:"deposit"`      // Wei deposited, refunded on withdrawal
+	Registered   uint64         `json:"registered"`   // Block the candidate registered in
+	Attestations uint64         `json:"attestations"` // Blocks attested on time, its probation reputation
+	LastAttested uint64         `json:"lastAttested"` // Number of the last attested block
+}
+
+// registryStore is the storage the registry is read from.
+type registryStore interface {
+	GetState(addr common.Address, hash common.Hash) common.Hash
+}
+
+// listSlot returns the storage slot of the i-th candidate address.
+func (r *RegistryRules) listSlot(i uint64) common.Hash {
+	base := new(big.Int).SetBytes(crypto.Keccak256(common.Hash{}.Bytes()))
+	return common.BigToHash(base.Add(base, new(big.Int).SetUint64(i)))
+}
+
+// recordSlot returns the storage slot of a field of a candidate record.
+func (r *RegistryRules) recordSlot(candidate common.Address, field uint64) common.Hash {
+	base := new(big.Int).SetBytes(crypto.Keccak256(common.LeftPadBytes(candidate[:], common.HashLength), common.BigToHash(common.Big1).Bytes()))
+	return common.BigToHash(base.Add(base, new(big.Int).SetUint64(field)))
+}
+
+// field reads a numeric field of a candidate record.
+func (r *RegistryRules) field(db registryStore, candidate common.Address, field uint64) *big.Int {
+	return db.GetState(r.Address, r.recordSlot(candidate, field)).Big()
+}
+
+// setField writes a numeric field of a candidate record.
+func (r *RegistryRules) setField(db *state.StateDB, candidate common.Address, field uint64, value *big.Int) {
+	db.SetState(r.Address, r.recordSlot(candidate, field), common.BigToHash(value))
+}
+
+// candidate reads the record of a candidate, nil if it isn't registered.
+func (r *RegistryRules) candidate(db registryStore, address common.Address) *Candidate {
+	if r.field(db, address, candidateIndex).Sign() == 0 {
+		return nil
+	}
+	length := r.field(db, address, candidateEnodeLength).Uint64()
+	url := make([]byte, 0, length)
+	for i := uint64(0); uint64(len(url)) < length; i++ {
+		url = append(url, db.GetState(r.Address, r.recordSlot(address, candidateEnode+i)).Bytes()...)
+	}
+	return &Candidate{
+		Address:      address,
+		Enode:        string(url[:length]),
+		Deposit:      r.field(db, address, candidateDeposit),
+		Registered:   r.field(db, address, candidateRegistered).Uint64(),
+		Attestations: r.field(db, address, candidateAttestations).Uint64(),
+		LastAttested: r.field(db, address, candidateLastAttested).Uint64(),
+	}
+}
+
+// candidates reads the records of all registered candidates, in registration
+// order.
+func (r *RegistryRules) candidates(db registryStore) []*Candidate {
+	count := db.GetState(r.Address, common.Hash{}).Big().Uint64()
+	candidates := make([]*Candidate, 0, count)
+	for i := uint64(0); i < count; i++ {
+		address := common.BytesToAddress(db.GetState(r.Address, r.listSlot(i)).Bytes())
+		candidates = append(candidates, r.candidate(db, address))
+	}
+	return candidates
+}
+
+// add registers a candidate, appending it to the candidate list.
+func (r *RegistryRules) add(db *state.StateDB, candidate *Candidate) {
+	count := db.GetState(r.Address, common.Hash{}).Big().Uint64()
+	db.SetState(r.Address, r.listSlot(count), common.BytesToHash(candidate.Address[:]))
+	db.SetState(r.Address, common.Hash{}, common.BigToHash(new(big.Int).SetUint64(count+1)))
+
+	r.setField(db, candidate.Address, candidateIndex, new(big.Int).SetUint64(count+1))
+	r.setField(db, candidate.Address, candidateDeposit, candidate.Deposit)
+	r.setField(db, candidate.Address, candidateRegistered, new(big.Int).SetUint64(candidate.Registered))
+	r.setField(db, candidate.Address, candidateEnodeLength, big.NewInt(int64(len(candidate.Enode))))
+	for i := 0; i*common.HashLength < len(candidate.Enode); i++ {
+		chunk := make([]byte, common.HashLength)
+		copy(chunk, candidate.Enode[i*common.HashLength:])
+		db.SetState(r.Address, r.recordSlot(candidate.Address, candidateEnode+uint64(i)), common.BytesToHash(chunk))
+	}
+}
+
+// remove unregisters a candidate, moving the last one of the candidate list in
+// its place and clearing its record.
+func (r *RegistryRules) remove(db *state.StateDB, address common.Address) {
+	count := db.GetState(r.Address, common.Hash{}).Big().Uint64()
+	index := r.field(db, address, candidateIndex).Uint64()
+
+	if index != count {
+		last := common.BytesToAddress(db.GetState(r.Address, r.listSlot(count-1)).Bytes())
+		db.SetState(r.Address, r.listSlot(index-1), common.BytesToHash(last[:]))
+		r.setField(db, last, candidateIndex, new(big.Int).SetUint64(index))
+	}
+	db.SetState(r.Address, r.listSlot(count-1), common.Hash{})
+	db.SetState(r.Address, common.Hash{}, common.BigToHash(new(big.Int).SetUint64(count-1)))
+
+	length := r.field(db, address, candidateEnodeLength).Uint64()
+	for i := uint64(0); i*common.HashLength < length; i++ {
+		db.SetState(r.Address, r.recordSlot(address, candidateEnode+i), common.Hash{})
+	}
+	for field := uint64(candidateDeposit); field < candidateEnode; field++ {
+		db.SetState(r.Address, r.recordSlot(address, field), common.Hash{})
+	}
+}
+
+// transfer moves wei out of the registry account.
+func (r *RegistryRules) transfer(db *state.StateDB, to common.Address, amount *big.Int) {
+	if amount.Sign() == 0 {
+		return
+	}
+	value := uint256.MustFromBig(amount)
+	db.SubBalance(r.Address, value)
+	db.AddBalance(to, value)
+}
+
+// execute runs a registry call sent by an account in a block.
+func (r *RegistryRules) execute(chain consensus.ChainHeaderReader, header *types.Header, db *state.StateDB, from common.Address, tx *types.Transaction) (string, error) {
+	data := tx.Data()
+	if len(data) < 4 {
+		return "", errUnknownRegistryCall
+	}
+	method, err := registryABI.MethodById(data[:4])
+	if err != nil {
+		return "", errUnknownRegistryCall
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return method.Name, err
+	}
+	if method.Name != "register" && tx.Value().Sign() > 0 {
+		return method.Name, errRegistryNotPayable
+	}
+	switch method.Name {
+	case "register":
+		if r.candidate(db, from) != nil {
+			return method.Name, errAlreadyRegistered
+		}
+		if tx.Value().Cmp(r.MinDeposit) < 0 {
+			return method.Name, errInsufficientDeposit
+		}
+		url := args[0].(string)
+		if len(url) > maxEnodeLength {
+			return method.Name, errEnodeTooLong
+		}
+		if _, err := enode.ParseV4(url); err != nil {
+			return method.Name, err
+		}
+		r.add(db, &Candidate{
+			Address:    from,
+			Enode:      url,
+			Deposit:    tx.Value(),
+			Registered: header.Number.Uint64(),
+		})
+
+	case "attest":
+		candidate := r.candidate(db, from)
+		if candidate == nil {
+			return method.Name, errNotCandidate
+		}
+		number, hash := args[0].(*big.Int), common.Hash(args[1].([32]byte))
+		if !number.IsUint64() || number.Uint64() <= candidate.LastAttested || !r.ancestor(chain, header, number.Uint64(), hash) {
+			return method.Name, errInvalidAttestation
+		}
+		r.setField(db, from, candidateAttestations, new(big.Int).SetUint64(candidate.Attestations+1))
+		r.setField(db, from, candidateLastAttested, number)
+
+	case "withdraw":
+		candidate := r.candidate(db, from)
+		if candidate == nil {
+			return method.Name, errNotCandidate
+		}
+		r.remove(db, from)
+		r.transfer(db, from, candidate.Deposit)
+	}
+	return method.Name, nil
+}
+
+// ancestor returns whether the given block is an ancestor of the header within
+// the attestation window.
+func (r *RegistryRules) ancestor(chain consensus.ChainHeaderReader, header *types.Header, number uint64, hash common.Hash) bool {
+	current := header.Number.Uint64()
+	if number >= current || current-number > r.AttestationWindow {
+		return false
+	}
+	parent := header
+	for parent.Number.Uint64() > number {
+		if parent = chain.GetHeader(parent.ParentHash, parent.Number.Uint64()-1); parent == nil {
+			return false
+		}
+	}
+	return parent.Hash() == hash
+}
+
+// processRegistry executes the registry calls of the transactions in a block,
+// tracing the executed ones if record is set. The value sent along calls that
+// fail is refunded, so the registry only ever holds the deposits of the
+// candidates.
+func (c *POATC) processRegistry(chain consensus.ChainHeaderReader, header *types.Header, db *state.StateDB, txs []*types.Transaction, record bool) {
+	r := c.registry
+	if r == nil || db.GetCodeSize(r.Address) != 0 {
+		return
+	}
+	var (
+		number = header.Number.Uint64()
+		signer = types.MakeSigner(chain.Config(), header.Number, header.Time)
+	)
+	for _, tx := range txs {
+		if tx.To() == nil || *tx.To() != r.Address {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		// Keep the registry account from being deleted as empty with its storage
+		if db.GetNonce(r.Address) == 0 {
+			db.SetNonce(r.Address, 1)
+		}
+		method, err := r.execute(chain, header, db, from, tx)
+		if err != nil {
+			r.transfer(db, from, tx.Value())
+			log.Debug("Rejected registry call", "number", number, "tx", tx.Hash(), "from", from, "method", method, "err", err)
+			continue
+		}
+		if method == "attest" || c.tracingSystem == nil || !record {
+			continue
+		}
+		c.tracingSystem.Trace(TraceEventCandidate, TraceLevelBasic, number, from,
+			"Candidate "+method+" executed", map[string]interface{}{
+				"method": method,
+				"tx":     tx.Hash().Hex(),
+				"value":  tx.Value().String(),
+			})
+	}
+}
+
+// registryState opens the state the registry is read from after the given
+// block.
+func registryState(chain consensus.ChainHeaderReader, header *types.Header) (*state.StateDB, error) {
+	reader, ok := chain.(stateReader)
+	if !ok {
+		return nil, errRegistryUnavailable
+	}
+	return reader.StateAt(header.Root)
+}
+
+// promotable returns the candidates that passed their probation after the given
+// block, best first: by attestations, then deposit, then address.
+func (r *RegistryRules) promotable(chain consensus.ChainHeaderReader, header *types.Header) ([]*Candidate, error) {
+	db, err := registryState(chain, header)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Candidate
+	for _, candidate := range r.candidates(db) {
+		if candidate.Attestations >= r.Probation {
+			candidates = append(candidates, candidate)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b *Candidate) int {
+		if a.Attestations != b.Attestations {
+			if a.Attestations > b.Attestations {
+				return -1
+			}
+			return 1
+		}
+		if cmp := b.Deposit.Cmp(a.Deposit); cmp != 0 {
+			return cmp
+		}
+		return bytes.Compare(a.Address[:], b.Address[:])
+	})
+	return candidates, nil
+}
+
+// PackRegister packs the call data registering as a candidate signing with the
+// given node. The deposit is sent as the value of the transaction.
+func PackRegister(url string) ([]byte, error) {
+	return registryABI.Pack("register", url)
+}
+
+// PackAttest packs the call data attesting the block with the given number and
+// hash on behalf of a candidate.
+func PackAttest(number uint64, hash common.Hash) ([]byte, error) {
+	return registryABI.Pack("attest", new(big.Int).SetUint64(number), hash)
+}
+
+// PackWithdraw packs the call data unregistering a candidate and refunding its
+// deposit.
+func PackWithdraw() ([]byte, error) {
+	return registryABI.Pack("withdraw")
+}
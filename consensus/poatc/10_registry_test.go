@@ -0,0 +1,260 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// registryChain is a chain of empty headers serving a single state, enough to
+// execute registry calls and read the candidates back.
+type registryChain struct {
+	headers []*types.Header
+	state   *state.StateDB
+}
+
+func (c *registryChain) Config() *params.ChainConfig        { return params.TestChainConfig }
+func (c *registryChain) CurrentHeader() *types.Header       { return c.headers[len(c.headers)-1] }
+func (c *registryChain) GetTd(common.Hash, uint64) *big.Int { return nil }
+
+func (c *registryChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+func (c *registryChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func (c *registryChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+func (c *registryChain) HasState(common.Hash) bool { return true }
+
+func (c *registryChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return c.state, nil
+}
+
+// Tests that the registry executes registrations, attestations and withdrawals
+// included in blocks, refunds the value of rejected calls and that rotation
+// promotes the candidates that passed their probation.
+func TestCandidateRegistry(t *testing.T) {
+	var (
+		registry = common.Address{0xee}
+		deposit  = big.NewInt(params.Ether)
+		funds    = new(big.Int).Mul(deposit, big.NewInt(10))
+		self     = common.Address{0x01}
+	)
+	engine := NewWithConfig(&params.CliqueConfig{Period: 1, Epoch: 30000}, &params.PoatcConfig{
+		Rotation: &params.PoatcRotationConfig{EnableRotation: true, TargetSigners: 3},
+		Registry: &params.PoatcRegistryConfig{
+			Address:               registry,
+			MinDeposit:            deposit,
+			AttestationWindow:     2,
+			ProbationAttestations: 2,
+		},
+	}, rawdb.NewMemoryDatabase())
+	engine.tracingSystem = NewTracingSystem(DefaultTracingConfig(), nil, nil)
+
+	db, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	genesis := &types.Header{Number: new(big.Int), Extra: make([]byte, extraVanity+common.AddressLength+extraSeal)}
+	copy(genesis.Extra[extraVanity:], self[:])
+	chain := &registryChain{headers: []*types.Header{genesis}, state: db}
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	addrs := make([]common.Address, 2)
+	urls := make([]string, 2)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		urls[i] = enode.NewV4(&keys[i].PublicKey, net.ParseIP("127.0.0.1"), 30303, 30303).String()
+		db.AddBalance(addrs[i], uint256.MustFromBig(funds))
+	}
+	nonces := make(map[int]uint64)
+	call := func(from int, value *big.Int, data []byte, err error) *types.Transaction {
+		if err != nil {
+			t.Fatalf("failed to pack registry call: %v", err)
+		}
+		if value == nil {
+			value = new(big.Int)
+		}
+		tx := types.MustSignNewTx(keys[from], types.LatestSigner(chain.Config()), &types.LegacyTx{
+			Nonce: nonces[from],
+			To:    &registry,
+			Value: value,
+			Gas:   100000,
+			Data:  data,
+		})
+		nonces[from]++
+		return tx
+	}
+	// include executes the registry calls in a new sealed block, after the value
+	// of the transactions was transferred to the registry
+	include := func(txs ...*types.Transaction) *types.Header {
+		parent := chain.CurrentHeader()
+		header := &types.Header{
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			ParentHash: parent.Hash(),
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		sig, _ := crypto.Sign(SealHash(header).Bytes(), keys[0])
+		copy(header.Extra[extraVanity:], sig)
+
+		for _, tx := range txs {
+			from, _ := types.Sender(types.LatestSigner(chain.Config()), tx)
+			db.SubBalance(from, uint256.MustFromBig(tx.Value()))
+			db.AddBalance(registry, uint256.MustFromBig(tx.Value()))
+		}
+		engine.Finalize(chain, header, db, txs, nil, nil)
+		chain.headers = append(chain.headers, header)
+		return header
+	}
+	register := func(from int, value *big.Int) *types.Transaction {
+		data, err := PackRegister(urls[from])
+		return call(from, value, data, err)
+	}
+	attest := func(from int, header *types.Header) *types.Transaction {
+		data, err := PackAttest(header.Number.Uint64(), header.Hash())
+		return call(from, nil, data, err)
+	}
+	withdraw := func(from int) *types.Transaction {
+		data, err := PackWithdraw()
+		return call(from, nil, data, err)
+	}
+	balance := func(address common.Address) *big.Int {
+		return db.GetBalance(address).ToBig()
+	}
+	// Registrations need the minimum deposit and a valid node, and happen once
+	badURL, _ := PackRegister("enode://invalid")
+	longURL, _ := PackRegister(urls[1] + "?padding=" + strings.Repeat("a", maxEnodeLength))
+	pre, txs := db.Copy(), []*types.Transaction{
+		register(0, deposit),
+		register(0, deposit),
+		register(1, new(big.Int).Sub(deposit, common.Big1)),
+		call(1, deposit, badURL, nil),
+		call(1, deposit, longURL, nil),
+	}
+	first := include(txs...)
+	candidates := engine.registry.candidates(db)
+	if len(candidates) != 1 {
+		t.Fatalf("candidate count mismatch: have %d, want 1", len(candidates))
+	}
+	// Reexecuting a block, such as to trace it, doesn't record it again
+	events := engine.tracingSystem.GetTraceEvents(TraceEventCandidate, TraceLevelVerbose, 0)
+	engine.Finalize(chain, first, pre, txs, nil, nil)
+	if candidates := engine.registry.candidates(pre); len(candidates) != 1 {
+		t.Errorf("reexecuted candidate count mismatch: have %d, want 1", len(candidates))
+	}
+	if have := engine.tracingSystem.GetTraceEvents(TraceEventCandidate, TraceLevelVerbose, 0); len(have) != len(events) {
+		t.Errorf("reexecution traced again: have %d events, want %d", len(have), len(events))
+	}
+	if c := candidates[0]; c.Address != addrs[0] || c.Enode != urls[0] || c.Deposit.Cmp(deposit) != 0 || c.Registered != 1 {
+		t.Errorf("candidate mismatch: have %+v", c)
+	}
+	if have := balance(registry); have.Cmp(deposit) != 0 {
+		t.Errorf("registry balance mismatch: have %v, want %v", have, deposit)
+	}
+	if have, want := balance(addrs[1]), funds; have.Cmp(want) != 0 {
+		t.Errorf("rejected deposits not refunded: have %v, want %v", have, want)
+	}
+	if nonce := db.GetNonce(registry); nonce == 0 {
+		t.Errorf("registry account left empty")
+	}
+	// Attestations must be of unseen recent ancestors, by candidates only
+	stale, _ := PackAttest(0, genesis.Hash())
+	second := include(
+		attest(0, first),
+		attest(0, first),
+		attest(1, first),
+		call(0, common.Big1, stale, nil),
+		register(1, new(big.Int).Mul(deposit, common.Big2)),
+	)
+	include(attest(0, &types.Header{Number: second.Number, ParentHash: common.Hash{0x01}}))
+	include()
+	include(attest(0, second), attest(1, second))
+
+	candidates = engine.registry.candidates(db)
+	if len(candidates) != 2 {
+		t.Fatalf("candidate count mismatch: have %d, want 2", len(candidates))
+	}
+	if c := candidates[0]; c.Attestations != 1 || c.LastAttested != 1 {
+		t.Errorf("first candidate attestations mismatch: have %d up to %d, want 1 up to 1", c.Attestations, c.LastAttested)
+	}
+	if c := candidates[1]; c.Address != addrs[1] || c.Attestations != 0 {
+		t.Errorf("second candidate attestations mismatch: have %+v", c)
+	}
+	// Only candidates that passed their probation are promoted, best first
+	third := include(attest(0, chain.CurrentHeader()), attest(1, chain.CurrentHeader()))
+	include(attest(1, third))
+
+	snap := newSnapshot(engine.config, engine.signatures, chain.CurrentHeader().Number.Uint64(), chain.CurrentHeader().Hash(), []common.Address{self})
+	engine.configureSnapshot(snap)
+	engine.rotateSigners(chain, snap, snap.Number+1)
+	if proposal, ok := engine.autoProposals[addrs[1]]; !ok || !proposal.Authorize || proposal.Reason != rotationCandidate || len(engine.autoProposals) != 2 {
+		t.Fatalf("candidates not promoted: %v", engine.autoProposals)
+	}
+	engine.rotation.TargetSigners = 2
+	engine.rotateSigners(chain, snap, snap.Number+1)
+	if _, ok := engine.autoProposals[addrs[1]]; !ok || len(engine.autoProposals) != 1 {
+		t.Fatalf("best candidate not promoted alone: %v", engine.autoProposals)
+	}
+	// Withdrawals refund the deposits and clear the registry
+	include(withdraw(0), withdraw(0))
+	if candidates = engine.registry.candidates(db); len(candidates) != 1 || candidates[0].Address != addrs[1] {
+		t.Fatalf("candidates mismatch after withdrawal: %v", candidates)
+	}
+	include(withdraw(1))
+	if candidates = engine.registry.candidates(db); len(candidates) != 0 {
+		t.Fatalf("candidates left after withdrawals: %v", candidates)
+	}
+	if have := balance(registry); have.Sign() != 0 {
+		t.Errorf("deposits left in the registry: %v", have)
+	}
+	for _, address := range addrs {
+		if have, want := balance(address), funds; have.Cmp(want) != 0 {
+			t.Errorf("account %x balance mismatch: have %v, want %v", address, have, want)
+		}
+	}
+	events = engine.tracingSystem.GetTraceEvents(TraceEventCandidate, TraceLevelVerbose, 0)
+	if len(events) != 4 {
+		t.Errorf("candidate trace events mismatch: have %d, want 4", len(events))
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// defaultFinalityWindow is the number of blocks behind or ahead of the head
+// signers may attest, if not configured.
+const defaultFinalityWindow = 64
+
+// ErrInvalidAttestation is returned if an attestation is invalid regardless of
+// the local view of the chain, such as one not signed by a signer of the block.
+var ErrInvalidAttestation = errors.New("invalid attestation")
+
+// attestationPrefix domain separates attestation signatures from the ones over
+// headers and admin requests.
+var attestationPrefix = []byte("\x19POATC attestation:\n")
+
+// Attestation is the signature of a signer over a block it considers canonical.
+// Attestations are exchanged outside of blocks, and a block is final as soon as
+// more than two thirds of its signers attested it.
+type Attestation struct {
+	Number    uint64        `json:"number"`
+	Hash      common.Hash   `json:"hash"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// attestationDataLength is the length of the data signers sign to attest a block.
+var attestationDataLength = len(attestationPrefix) + common.HashLength + 8 + common.HashLength
+
+// attestationData returns the data signers sign to attest a block of a chain.
+// The signature is over the keccak256 hash of the data, without any prefix.
+func attestationData(chainID *big.Int, number uint64, hash common.Hash) []byte {
+	data := make([]byte, 0, attestationDataLength)
+	data = append(data, attestationPrefix...)
+	data = append(data, common.BigToHash(chainID).Bytes()...)
+	data = binary.BigEndian.AppendUint64(data, number)
+	return append(data, hash.Bytes()...)
+}
+
+// ParseAttestationData returns the chain, number and hash of the block attested
+// by signing the given data, allowing signers to check what they attest.
+func ParseAttestationData(data []byte) (*big.Int, uint64, common.Hash, error) {
+	if len(data) != attestationDataLength || !bytes.HasPrefix(data, attestationPrefix) {
+		return nil, 0, common.Hash{}, errInvalidAttestationData
+	}
+	data = data[len(attestationPrefix):]
+	chainID := new(big.Int).SetBytes(data[:common.HashLength])
+	number := binary.BigEndian.Uint64(data[common.HashLength:])
+	return chainID, number, common.BytesToHash(data[common.HashLength+8:]), nil
+}
+
+// SignAttestation attests a block of the given chain with a private key.
+func SignAttestation(key *ecdsa.PrivateKey, chainID *big.Int, header *types.Header) (*Attestation, error) {
+	number := header.Number.Uint64()
+	sig, err := crypto.Sign(crypto.Keccak256(attestationData(chainID, number, header.Hash())), key)
+	if err != nil {
+		return nil, err
+	}
+	return &Attestation{Number: number, Hash: header.Hash(), Signature: sig}, nil
+}
+
+// ID returns the unique identifier of the attestation, telling apart the ones
+// of different signers over the same block.
+func (a *Attestation) ID() common.Hash {
+	return crypto.Keccak256Hash(a.Hash[:], a.Signature)
+}
+
+// Signer recovers the account that signed the attestation on the given chain.
+func (a *Attestation) Signer(chainID *big.Int) (common.Address, error) {
+	if len(a.Signature) != crypto.SignatureLength {
+		return common.Address{}, errInvalidAttestationSignature
+	}
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(attestationData(chainID, a.Number, a.Hash)), a.Signature)
+	if err != nil {
+		return common.Address{}, errInvalidAttestationSignature
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// finalityStore is implemented by chains persisting the finalized block, such as
+// the full blockchain, which serves it through the finalized block tag.
+type finalityStore interface {
+	CurrentFinalBlock() *types.Header
+	SetFinalized(header *types.Header)
+}
+
+// FinalityGadget collects the attestations of the signers over recent blocks
+// and finalizes the blocks attested by a supermajority of them. Finality is a
+// local decision: nodes missing attestations merely finalize later.
+type FinalityGadget struct {
+	window uint64 // Blocks around the head attestations are accepted for
+
+	votes     map[common.Hash]map[common.Address][]byte // Signatures of the signers, per attested block
+	numbers   map[common.Hash]uint64                    // Numbers of the attested blocks
+	finalized *types.Header                             // Finalized block, if the chain doesn't persist it
+
+	feed event.Feed // Feed of newly accepted attestations, to be broadcast
+	lock sync.Mutex // Protects the votes and the finalized block
+}
+
+// newFinalityGadget creates the finality gadget of the chain, nil if the chain
+// config doesn't opt into fast finality.
+func newFinalityGadget(cfg *params.PoatcConfig) *FinalityGadget {
+	if cfg == nil || !cfg.EnableFinality {
+		return nil
+	}
+	window := cfg.FinalityWindow
+	if window == 0 {
+		window = defaultFinalityWindow
+	}
+	return &FinalityGadget{
+		window:  window,
+		votes:   make(map[common.Hash]map[common.Address][]byte),
+		numbers: make(map[common.Hash]uint64),
+	}
+}
+
+// finalizedHeader returns the finalized block, nil if none is.
+func (g *FinalityGadget) finalizedHeader(chain consensus.ChainHeaderReader) *types.Header {
+	if store, ok := chain.(finalityStore); ok {
+		return store.CurrentFinalBlock()
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.finalized
+}
+
+// prune drops the attestations of the blocks fallen out of the window behind
+// the head. The caller must hold the lock.
+func (g *FinalityGadget) prune(head uint64) {
+	for hash, number := range g.numbers {
+		if number+g.window < head {
+			delete(g.votes, hash)
+			delete(g.numbers, hash)
+		}
+	}
+}
+
+// finalize marks a block as final, dropping the attestations of the blocks up
+// to it.
+func (g *FinalityGadget) finalize(chain consensus.ChainHeaderReader, header *types.Header) {
+	if store, ok := chain.(finalityStore); ok {
+		store.SetFinalized(header)
+	} else {
+		g.finalized = header
+	}
+	for hash, number := range g.numbers {
+		if number <= header.Number.Uint64() {
+			delete(g.votes, hash)
+			delete(g.numbers, hash)
+		}
+	}
+}
+
+// FinalityEnabled returns whether the chain finalizes blocks attested by a
+// supermajority of the signers.
+func (c *POATC) FinalityEnabled() bool {
+	return c.finality != nil
+}
+
+// SubscribeAttestations subscribes to the attestations accepted by the engine,
+// both local and remote ones, each delivered once.
+func (c *POATC) SubscribeAttestations(ch chan<- *Attestation) event.Subscription {
+	if c.finality == nil {
+		return event.NewSubscription(func(quit <-chan struct{}) error { <-quit; return nil })
+	}
+	return c.finality.feed.Subscribe(ch)
+}
+
+// Attest signs an attestation of the given block with the local signing
+// credentials and accepts it. It returns nil if the local signer isn't
+// authorized to attest the block. Attest is invoked on every new head, so the
+// attestations fallen behind it are dropped first, even without a local signer.
+func (c *POATC) Attest(chain consensus.ChainHeaderReader, header *types.Header) (*Attestation, error) {
+	if c.finality == nil {
+		return nil, errFinalityDisabled
+	}
+	c.finality.lock.Lock()
+	c.finality.prune(chain.CurrentHeader().Number.Uint64())
+	c.finality.lock.Unlock()
+
+	c.lock.RLock()
+	signer, signFn := c.signer, c.signFn
+	c.lock.RUnlock()
+
+	if signFn == nil {
+		return nil, nil
+	}
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		return nil, nil
+	}
+	sig, err := signFn(accounts.Account{Address: signer}, accounts.MimetypePoatcAttestation, attestationData(chain.Config().ChainID, number, header.Hash()))
+	if err != nil {
+		return nil, err
+	}
+	attestation := &Attestation{Number: number, Hash: header.Hash(), Signature: sig}
+	if err := c.AddAttestation(chain, attestation); err != nil {
+		return nil, err
+	}
+	return attestation, nil
+}
+
+// KnownAttestation returns whether an attestation was already accepted, allowing
+// attestations relayed by several peers to only be verified once.
+func (c *POATC) KnownAttestation(attestation *Attestation) bool {
+	g := c.finality
+	if g == nil {
+		return false
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, sig := range g.votes[attestation.Hash] {
+		if bytes.Equal(sig, attestation.Signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAttestation verifies an attestation and counts it towards the finality of
+// the attested block, finalizing it once more than two thirds of the signers of
+// its snapshot attested it.
+func (c *POATC) AddAttestation(chain consensus.ChainHeaderReader, attestation *Attestation) error {
+	g := c.finality
+	if g == nil {
+		return errFinalityDisabled
+	}
+	header := chain.GetHeader(attestation.Hash, attestation.Number)
+	if header == nil {
+		return errUnknownBlock
+	}
+	if final := g.finalizedHeader(chain); final != nil && attestation.Number <= final.Number.Uint64() {
+		return errStaleAttestation
+	}
+	// Only attestations around the head are kept, bounding the votes tracked
+	head := chain.CurrentHeader().Number.Uint64()
+	if head > attestation.Number+g.window {
+		return errStaleAttestation
+	}
+	if attestation.Number > head+g.window {
+		return errFutureAttestation
+	}
+	signer, err := attestation.Signer(chain.Config().ChainID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidAttestation, err)
+	}
+	snap, err := c.snapshot(chain, attestation.Number, attestation.Hash, nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		return fmt.Errorf("%w: %w", ErrInvalidAttestation, errUnauthorizedAttestation)
+	}
+	g.lock.Lock()
+	g.prune(head)
+
+	votes := g.votes[attestation.Hash]
+	if votes == nil {
+		votes = make(map[common.Address][]byte)
+		g.votes[attestation.Hash] = votes
+		g.numbers[attestation.Hash] = attestation.Number
+	}
+	if _, ok := votes[signer]; ok {
+		g.lock.Unlock()
+		return nil
+	}
+	votes[signer] = attestation.Signature
+
+	// Only blocks of the canonical chain are finalized, attestations of the
+	// other ones are kept in case they become canonical
+	final := 3*len(votes) > 2*len(snap.Signers)
+	if final {
+		if canonical := chain.GetHeaderByNumber(attestation.Number); canonical == nil || canonical.Hash() != attestation.Hash {
+			final = false
+		}
+	}
+	if final {
+		g.finalize(chain, header)
+	}
+	attested := len(votes)
+	g.lock.Unlock()
+
+	g.feed.Send(attestation)
+
+	if final {
+		log.Info("Finalized block", "number", attestation.Number, "hash", attestation.Hash, "attestations", attested, "signers", len(snap.Signers))
+		if c.tracingSystem != nil {
+			c.tracingSystem.Trace(TraceEventFinality, TraceLevelBasic, attestation.Number, signer,
+				"Block finalized", map[string]interface{}{
+					"hash":         attestation.Hash.Hex(),
+					"attestations": attested,
+					"signers":      len(snap.Signers),
+				})
+		}
+	}
+	return nil
+}
+
+// verifyFinality checks that a header doesn't fork the chain off before the
+// finalized block. Headers at or below the finalized block must be canonical,
+// and so must be the parent of the ones right above it.
+func (c *POATC) verifyFinality(chain consensus.ChainHeaderReader, header *types.Header) error {
+	if c.finality == nil {
+		return nil
+	}
+	final := c.finality.finalizedHeader(chain)
+	if final == nil {
+		return nil
+	}
+	number := header.Number.Uint64()
+	if number <= final.Number.Uint64() {
+		if canonical := chain.GetHeaderByNumber(number); canonical != nil && canonical.Hash() != header.Hash() {
+			return errFinalizedConflict
+		}
+		return nil
+	}
+	if number-1 <= final.Number.Uint64() {
+		if canonical := chain.GetHeaderByNumber(number - 1); canonical != nil && canonical.Hash() != header.ParentHash {
+			return errFinalizedConflict
+		}
+	}
+	return nil
+}
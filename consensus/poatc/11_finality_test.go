@@ -0,0 +1,192 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that blocks are finalized once more than two thirds of their signers
+// attested them, that the finalized block is served by the chain and that the
+// chain can't fork off before it anymore.
+func TestFinality(t *testing.T) {
+	pool := newTesterAccountPool()
+	names := []string{"A", "B", "C", "D"}
+
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	pool.checkpoint(&types.Header{Extra: genesis.ExtraData}, names)
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30000}
+	config.Poatc = &params.PoatcConfig{EnableFinality: true, FinalityWindow: 3}
+	genesis.Config = &config
+
+	// Generate a chain sealed by the signers in a round-robin
+	generator := NewWithConfig(config.Clique, nil, rawdb.NewMemoryDatabase())
+	generator.fakeDiff = true
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, generator, 5, func(i int, gen *core.BlockGen) {})
+
+	replay := newSnapshot(config.Clique, generator.signatures, 0, genesis.ToBlock().Hash(), []common.Address{
+		pool.address("A"), pool.address("B"), pool.address("C"), pool.address("D"),
+	})
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Extra = make([]byte, extraVanity+extraSeal)
+		signer := names[i%len(names)]
+		header.Difficulty = diffNoTurn
+		if replay.inturn(header.Number.Uint64(), pool.address(signer)) {
+			header.Difficulty = diffInTurn
+		}
+		pool.sign(header, signer)
+		blocks[i] = block.WithSeal(header)
+
+		var err error
+		if replay, err = replay.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("block %d: failed to apply header: %v", i+1, err)
+		}
+	}
+	engine := NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	attestations := make(chan *Attestation, 16)
+	sub := engine.SubscribeAttestations(attestations)
+	defer sub.Unsubscribe()
+
+	attest := func(name string, header *types.Header) error {
+		pool.address(name) // Ensure the key exists
+		attestation, err := SignAttestation(pool.accounts[name], config.ChainID, header)
+		if err != nil {
+			t.Fatalf("failed to sign attestation: %v", err)
+		}
+		return engine.AddAttestation(chain, attestation)
+	}
+	// Two of four signers aren't a supermajority, nor are duplicate attestations
+	target := blocks[1].Header()
+	for _, name := range []string{"A", "B", "A"} {
+		if err := attest(name, target); err != nil {
+			t.Fatalf("signer %s: failed to attest: %v", name, err)
+		}
+	}
+	if final := chain.CurrentFinalBlock(); final != nil {
+		t.Fatalf("block finalized early: %d", final.Number)
+	}
+	if err := attest("E", target); !errors.Is(err, errUnauthorizedAttestation) {
+		t.Errorf("attestation of a non-signer error mismatch: have %v, want %v", err, errUnauthorizedAttestation)
+	}
+	if err := attest("C", target); err != nil {
+		t.Fatalf("failed to attest: %v", err)
+	}
+	if final := chain.CurrentFinalBlock(); final == nil || final.Hash() != target.Hash() {
+		t.Fatalf("block not finalized: have %v, want %d", final, target.Number)
+	}
+	if len(attestations) != 3 {
+		t.Errorf("broadcast attestations mismatch: have %d, want 3", len(attestations))
+	}
+	// Attestations of final, far behind or unknown blocks are rejected
+	if err := attest("D", target); !errors.Is(err, errStaleAttestation) {
+		t.Errorf("attestation of a final block error mismatch: have %v, want %v", err, errStaleAttestation)
+	}
+	if err := attest("A", blocks[2].Header()); err != nil {
+		t.Fatalf("failed to attest: %v", err)
+	}
+	engine.finality.window = 1
+	if err := attest("D", blocks[2].Header()); !errors.Is(err, errStaleAttestation) {
+		t.Errorf("attestation out of window error mismatch: have %v, want %v", err, errStaleAttestation)
+	}
+	// Votes of blocks fallen out of the window are dropped on the next head
+	if _, err := engine.Attest(chain, blocks[4].Header()); err != nil {
+		t.Fatalf("failed to process head: %v", err)
+	}
+	if len(engine.finality.votes) != 0 || len(engine.finality.numbers) != 0 {
+		t.Errorf("votes out of window not pruned: have %d blocks", len(engine.finality.votes))
+	}
+	if err := attest("D", &types.Header{Number: big.NewInt(4)}); !errors.Is(err, errUnknownBlock) {
+		t.Errorf("attestation of an unknown block error mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+	// The local signer attests blocks it is a signer of, signing through the
+	// wallet of its account like when sealing
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(pool.accounts["D"], "")
+	if err != nil {
+		t.Fatalf("failed to import signer key: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock signer: %v", err)
+	}
+	var mimeTypes []string
+	engine.Authorize(account.Address, func(account accounts.Account, mimeType string, message []byte) ([]byte, error) {
+		mimeTypes = append(mimeTypes, mimeType)
+		return ks.Wallets()[0].SignData(account, mimeType, message)
+	})
+	head := blocks[4].Header()
+	attestation, err := engine.Attest(chain, head)
+	if err != nil || attestation == nil {
+		t.Fatalf("failed to attest locally: %v", err)
+	}
+	if len(mimeTypes) != 1 || mimeTypes[0] != accounts.MimetypePoatcAttestation {
+		t.Errorf("signing mimetype mismatch: have %v, want [%s]", mimeTypes, accounts.MimetypePoatcAttestation)
+	}
+	chainID, number, hash, err := ParseAttestationData(attestationData(config.ChainID, head.Number.Uint64(), head.Hash()))
+	if err != nil || chainID.Cmp(config.ChainID) != 0 || number != head.Number.Uint64() || hash != head.Hash() {
+		t.Errorf("parsed attestation mismatch: have %v/%d/%x, want %v/%d/%x (%v)", chainID, number, hash, config.ChainID, head.Number, head.Hash(), err)
+	}
+	if _, _, _, err := ParseAttestationData([]byte("not an attestation")); !errors.Is(err, errInvalidAttestationData) {
+		t.Errorf("invalid attestation data error mismatch: have %v, want %v", err, errInvalidAttestationData)
+	}
+	if signer, err := attestation.Signer(config.ChainID); err != nil || signer != pool.address("D") {
+		t.Errorf("local attestation signer mismatch: have %x, want %x (%v)", signer, pool.address("D"), err)
+	}
+	// Headers forking off before the finalized block are rejected
+	fork := blocks[1].Header()
+	fork.Extra = append([]byte{0x01}, fork.Extra[1:]...)
+	if err := engine.verifyFinality(chain, fork); !errors.Is(err, errFinalizedConflict) {
+		t.Errorf("conflicting header error mismatch: have %v, want %v", err, errFinalizedConflict)
+	}
+	child := blocks[2].Header()
+	child.ParentHash = fork.Hash()
+	if err := engine.verifyFinality(chain, child); !errors.Is(err, errFinalizedConflict) {
+		t.Errorf("conflicting child error mismatch: have %v, want %v", err, errFinalizedConflict)
+	}
+	for _, block := range blocks {
+		if err := engine.verifyFinality(chain, block.Header()); err != nil {
+			t.Errorf("block %d: canonical header rejected: %v", block.NumberU64(), err)
+		}
+	}
+}
@@ -0,0 +1,557 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// AdminNamespace is the RPC namespace of the mutating POATC methods. It is only
+// served over IPC and the JWT authenticated endpoints.
+const AdminNamespace = "poatcadmin"
+
+// AdminRequest is the authorization of an admin call by a current signer. The
+// signer signs the call's method, its JSON encoded arguments and the replay
+// protection fields as the EIP-712 typed data returned by AdminTypedData.
+type AdminRequest struct {
+	Params    string        `json:"params"`    // JSON object of the call arguments, exactly as signed
+	Nonce     uint64        `json:"nonce"`     // Must exceed the last nonce the signer used
+	Deadline  uint64        `json:"deadline"`  // Unix time after which the authorization expires
+	Signature hexutil.Bytes `json:"signature"` // EIP-712 signature of the call by a current signer
+}
+
+// AdminTypedData returns the EIP-712 typed data a signer signs to authorize a
+// call of the given admin method, such as "addToBlacklist", on the given chain.
+func AdminTypedData(chainID *big.Int, method string, req *AdminRequest) apitypes.TypedData {
+	if chainID == nil {
+		chainID = new(big.Int)
+	}
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"AdminAction": {
+				{Name: "method", Type: "string"},
+				{Name: "params", Type: "string"},
+				{Name: "nonce", Type: "uint64"},
+				{Name: "deadline", Type: "uint64"},
+			},
+		},
+		PrimaryType: "AdminAction",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "POATC",
+			Version: "1",
+			ChainId: (*math.HexOrDecimal256)(chainID),
+		},
+		Message: apitypes.TypedDataMessage{
+			"method":   AdminNamespace + "_" + method,
+			"params":   req.Params,
+			"nonce":    new(big.Int).SetUint64(req.Nonce),
+			"deadline": new(big.Int).SetUint64(req.Deadline),
+		},
+	}
+}
+
+// SignAdminRequest authorizes a call of the given admin method with the given
+// arguments by the signer owning the key.
+func SignAdminRequest(key *ecdsa.PrivateKey, chainID *big.Int, method string, args interface{}, nonce uint64, deadline uint64) (*AdminRequest, error) {
+	params, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	req := &AdminRequest{
+		Params:   string(params),
+		Nonce:    nonce,
+		Deadline: deadline,
+	}
+	hash, _, err := apitypes.TypedDataAndHash(AdminTypedData(chainID, method, req))
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27 // Match the eth_signTypedData convention
+	req.Signature = sig
+
+	return req, nil
+}
+
+// AdminAPI is a user facing RPC API to change the POATC subsystems of a node.
+// Every call must be authorized by a current signer, who is recorded in a trace
+// event as the actor.
+type AdminAPI struct {
+	chain consensus.ChainHeaderReader
+	poatc *POATC
+}
+
+// authorize verifies that the request authorizes a call of the method by a
+// current signer, consumes its nonce and decodes its arguments into args. The
+// verified actor is returned and recorded as a trace event.
+func (api *AdminAPI) authorize(method string, req *AdminRequest, args interface{}) (common.Address, error) {
+	if req.Deadline < uint64(api.poatc.now().Unix()) {
+		return common.Address{}, errExpiredAdminRequest
+	}
+	hash, _, err := apitypes.TypedDataAndHash(AdminTypedData(api.chain.Config().ChainID, method, req))
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(req.Signature) != crypto.SignatureLength {
+		return common.Address{}, errInvalidAdminSignature
+	}
+	sig := common.CopyBytes(req.Signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, errInvalidAdminSignature
+	}
+	actor := crypto.PubkeyToAddress(*pubkey)
+
+	// Only the signers authorized at the current head may administer the node
+	header := api.chain.CurrentHeader()
+	snap, err := api.poatc.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, ok := snap.Signers[actor]; !ok {
+		return common.Address{}, errUnauthorizedAdmin
+	}
+	if req.Params != "" {
+		dec := json.NewDecoder(bytes.NewReader([]byte(req.Params)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(args); err != nil {
+			return common.Address{}, fmt.Errorf("invalid %s params: %v", method, err)
+		}
+	}
+	// Consume the nonce before acting, so the request can't be replayed
+	api.poatc.adminLock.Lock()
+	defer api.poatc.adminLock.Unlock()
+
+	if req.Nonce <= rawdb.ReadPoatcAdminNonce(api.poatc.db, actor) {
+		return common.Address{}, errUsedAdminNonce
+	}
+	rawdb.WritePoatcAdminNonce(api.poatc.db, actor, req.Nonce)
+
+	if api.poatc.tracingSystem != nil {
+		api.poatc.tracingSystem.Trace(TraceEventAdminAction, TraceLevelBasic, header.Number.Uint64(), actor,
+			fmt.Sprintf("Admin action %s by %s", method, actor.Hex()),
+			map[string]interface{}{
+				"method": AdminNamespace + "_" + method,
+				"params": req.Params,
+				"nonce":  req.Nonce,
+			})
+	}
+	return actor, nil
+}
+
+// ===== Whitelist/Blacklist =====
+
+// listArgs are the arguments of the list admin methods.
+type listArgs struct {
+	Address common.Address `json:"address"`
+	Reason  string         `json:"reason,omitempty"`
+}
+
+// AddToWhitelist adds an address to the whitelist on behalf of the actor.
+// Params: {"address", "reason"}.
+func (api *AdminAPI) AddToWhitelist(req AdminRequest) error {
+	var args listArgs
+	actor, err := api.authorize("addToWhitelist", &req, &args)
+	if err != nil {
+		return err
+	}
+	if api.poatc.whitelistBlacklistManager == nil {
+		return fmt.Errorf("whitelist/blacklist manager not initialized")
+	}
+	return api.poatc.whitelistBlacklistManager.AddToWhitelist(args.Address, actor, args.Reason, nil)
+}
+
+// RemoveFromWhitelist removes an address from the whitelist. Params: {"address"}.
+func (api *AdminAPI) RemoveFromWhitelist(req AdminRequest) error {
+	var args listArgs
+	if _, err := api.authorize("removeFromWhitelist", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.whitelistBlacklistManager == nil {
+		return fmt.Errorf("whitelist/blacklist manager not initialized")
+	}
+	return api.poatc.whitelistBlacklistManager.RemoveFromWhitelist(args.Address)
+}
+
+// AddToBlacklist adds an address to the blacklist on behalf of the actor.
+// Params: {"address", "reason"}.
+func (api *AdminAPI) AddToBlacklist(req AdminRequest) error {
+	var args listArgs
+	actor, err := api.authorize("addToBlacklist", &req, &args)
+	if err != nil {
+		return err
+	}
+	if api.poatc.whitelistBlacklistManager == nil {
+		return fmt.Errorf("whitelist/blacklist manager not initialized")
+	}
+	return api.poatc.whitelistBlacklistManager.AddToBlacklist(args.Address, actor, args.Reason, nil)
+}
+
+// RemoveFromBlacklist removes an address from the blacklist. Params: {"address"}.
+func (api *AdminAPI) RemoveFromBlacklist(req AdminRequest) error {
+	var args listArgs
+	if _, err := api.authorize("removeFromBlacklist", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.whitelistBlacklistManager == nil {
+		return fmt.Errorf("whitelist/blacklist manager not initialized")
+	}
+	return api.poatc.whitelistBlacklistManager.RemoveFromBlacklist(args.Address)
+}
+
+// CleanupExpiredEntries removes expired entries from whitelist and blacklist.
+// Params: {}.
+func (api *AdminAPI) CleanupExpiredEntries(req AdminRequest) error {
+	if _, err := api.authorize("cleanupExpiredEntries", &req, &struct{}{}); err != nil {
+		return err
+	}
+	if api.poatc.whitelistBlacklistManager == nil {
+		return fmt.Errorf("whitelist/blacklist manager not initialized")
+	}
+	api.poatc.whitelistBlacklistManager.CleanupExpiredEntries()
+	return nil
+}
+
+// ForceReputationBasedWhitelistBlacklist whitelists the validators with a high
+// reputation and blacklists the ones with a low reputation, on behalf of the
+// actor. Params: {}.
+func (api *AdminAPI) ForceReputationBasedWhitelistBlacklist(req AdminRequest) error {
+	actor, err := api.authorize("forceReputationBasedWhitelistBlacklist", &req, &struct{}{})
+	if err != nil {
+		return err
+	}
+	if api.poatc.reputationSystem == nil || api.poatc.whitelistBlacklistManager == nil {
+		return fmt.Errorf("reputation system or whitelist/blacklist manager not initialized")
+	}
+	// Get all validators and their reputation scores
+	topValidators := api.poatc.reputationSystem.GetTopValidators(0)
+	config := api.poatc.reputationSystem.config
+
+	for _, validator := range topValidators {
+		// Check if should be blacklisted
+		if validator.CurrentScore < config.LowReputationThreshold {
+			if !api.poatc.whitelistBlacklistManager.IsBlacklisted(validator.Address) {
+				var expiresAt *time.Time
+				if expiry := api.poatc.whitelistBlacklistManager.config.DefaultExpiration; expiry > 0 {
+					t := api.poatc.whitelistBlacklistManager.now().Add(expiry)
+					expiresAt = &t
+				}
+				api.poatc.whitelistBlacklistManager.AddToBlacklist(validator.Address, actor,
+					fmt.Sprintf("Force blacklisted due to low reputation: %.2f", validator.CurrentScore), expiresAt)
+			}
+		}
+		// Check if should be whitelisted
+		if validator.CurrentScore >= config.HighReputationThreshold {
+			if !api.poatc.whitelistBlacklistManager.IsWhitelisted(validator.Address) {
+				api.poatc.whitelistBlacklistManager.AddToWhitelist(validator.Address, actor,
+					fmt.Sprintf("Force whitelisted due to high reputation: %.2f", validator.CurrentScore), nil)
+			}
+		}
+	}
+	return nil
+}
+
+// ===== Validator Selection =====
+
+// validatorArgs are the arguments of the validator selection admin methods.
+type validatorArgs struct {
+	Address    common.Address `json:"address"`
+	Stake      *big.Int       `json:"stake,omitempty"`
+	Reputation float64        `json:"reputation,omitempty"`
+}
+
+// AddValidator adds a new validator to the selection system.
+// Params: {"address", "stake", "reputation"}.
+func (api *AdminAPI) AddValidator(req AdminRequest) error {
+	var args validatorArgs
+	if _, err := api.authorize("addValidator", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.validatorSelectionManager == nil {
+		return fmt.Errorf("validator selection manager not initialized")
+	}
+	if args.Stake == nil {
+		args.Stake = new(big.Int)
+	}
+	api.poatc.validatorSelectionManager.AddValidator(args.Address, args.Stake, args.Reputation)
+	return nil
+}
+
+// UpdateValidatorStake updates a validator's stake. Chains reading the stakes
+// from state don't accept stakes set through the API. Params: {"address", "stake"}.
+func (api *AdminAPI) UpdateValidatorStake(req AdminRequest) error {
+	var args validatorArgs
+	if _, err := api.authorize("updateValidatorStake", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.validatorSelectionManager == nil {
+		return fmt.Errorf("validator selection manager not initialized")
+	}
+	if api.poatc.stakingRules != nil {
+		return fmt.Errorf("stakes are read from staking contract %s", api.poatc.stakingRules.Contract.Hex())
+	}
+	if args.Stake == nil {
+		return fmt.Errorf("missing stake")
+	}
+	api.poatc.validatorSelectionManager.UpdateValidatorStake(args.Address, args.Stake)
+	return nil
+}
+
+// UpdateValidatorReputation updates a validator's selection reputation.
+// Params: {"address", "reputation"}.
+func (api *AdminAPI) UpdateValidatorReputation(req AdminRequest) error {
+	var args validatorArgs
+	if _, err := api.authorize("updateValidatorReputation", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.validatorSelectionManager == nil {
+		return fmt.Errorf("validator selection manager not initialized")
+	}
+	api.poatc.validatorSelectionManager.UpdateValidatorReputation(args.Address, args.Reputation)
+	return nil
+}
+
+// blockArgs identify the block an admin method acts on.
+type blockArgs struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// ForceValidatorSelection forces a new validator selection seeded by the block.
+// Params: {"number", "hash"}.
+func (api *AdminAPI) ForceValidatorSelection(req AdminRequest) ([]common.Address, error) {
+	var args blockArgs
+	if _, err := api.authorize("forceValidatorSelection", &req, &args); err != nil {
+		return nil, err
+	}
+	if api.poatc.validatorSelectionManager == nil {
+		return nil, fmt.Errorf("validator selection manager not initialized")
+	}
+	return api.poatc.validatorSelectionManager.SelectSmallValidatorSet(args.Number, args.Hash)
+}
+
+// ===== Reputation System =====
+
+// violationArgs are the arguments of RecordViolation.
+type violationArgs struct {
+	Address       common.Address `json:"address"`
+	Number        uint64         `json:"number"`
+	ViolationType string         `json:"violationType"`
+	Description   string         `json:"description"`
+}
+
+// RecordViolation records a violation by a validator.
+// Params: {"address", "number", "violationType", "description"}.
+func (api *AdminAPI) RecordViolation(req AdminRequest) error {
+	var args violationArgs
+	if _, err := api.authorize("recordViolation", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.reputationSystem == nil {
+		return fmt.Errorf("reputation system not initialized")
+	}
+	api.poatc.reputationSystem.RecordViolation(args.Address, args.Number, args.ViolationType, args.Description)
+	return nil
+}
+
+// UpdateReputation manually updates reputation scores. Params: {}.
+func (api *AdminAPI) UpdateReputation(req AdminRequest) error {
+	if _, err := api.authorize("updateReputation", &req, &struct{}{}); err != nil {
+		return err
+	}
+	if api.poatc.reputationSystem == nil {
+		return fmt.Errorf("reputation system not initialized")
+	}
+	api.poatc.reputationSystem.UpdateReputation()
+	return nil
+}
+
+// addressArgs are the arguments of admin methods acting on a single validator.
+type addressArgs struct {
+	Address common.Address `json:"address"`
+}
+
+// MarkValidatorOffline marks a validator as offline. Params: {"address"}.
+func (api *AdminAPI) MarkValidatorOffline(req AdminRequest) error {
+	var args addressArgs
+	if _, err := api.authorize("markValidatorOffline", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.reputationSystem == nil {
+		return fmt.Errorf("reputation system not initialized")
+	}
+	api.poatc.reputationSystem.MarkValidatorOffline(args.Address)
+	return nil
+}
+
+// UpdateValidatorUptime updates the uptime for a validator. Params: {"address"}.
+func (api *AdminAPI) UpdateValidatorUptime(req AdminRequest) error {
+	var args addressArgs
+	if _, err := api.authorize("updateValidatorUptime", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.reputationSystem == nil {
+		return fmt.Errorf("reputation system not initialized")
+	}
+	api.poatc.reputationSystem.UpdateUptime(args.Address)
+	return nil
+}
+
+// ForcePartialReset forces a partial reset for a specific validator.
+// Params: {"address"}.
+func (api *AdminAPI) ForcePartialReset(req AdminRequest) error {
+	var args addressArgs
+	if _, err := api.authorize("forcePartialReset", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.reputationSystem == nil {
+		return fmt.Errorf("reputation system not enabled")
+	}
+	// This would need to be implemented in the reputation system
+	// For now, we'll just trigger a reputation update
+	api.poatc.reputationSystem.UpdateReputation()
+	return nil
+}
+
+// ===== Tracing System =====
+
+// ClearTraceEvents clears all in-memory trace events and the Merkle Tree. The
+// clearing itself is traced before, so it's only kept in the persisted audit
+// trail. Params: {}.
+func (api *AdminAPI) ClearTraceEvents(req AdminRequest) error {
+	if _, err := api.authorize("clearTraceEvents", &req, &struct{}{}); err != nil {
+		return err
+	}
+	if api.poatc.tracingSystem == nil {
+		return fmt.Errorf("tracing system not enabled")
+	}
+	api.poatc.tracingSystem.ClearTraceEvents()
+	return nil
+}
+
+// SetTraceLevel sets the trace level. Params: {"level"}.
+func (api *AdminAPI) SetTraceLevel(req AdminRequest) error {
+	var args struct {
+		Level int `json:"level"`
+	}
+	if _, err := api.authorize("setTraceLevel", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.tracingSystem == nil {
+		return fmt.Errorf("tracing system not enabled")
+	}
+	if args.Level < 0 || args.Level > 3 {
+		return fmt.Errorf("invalid trace level: %d (must be 0-3)", args.Level)
+	}
+	api.poatc.tracingSystem.SetTraceLevel(TraceLevel(args.Level))
+	return nil
+}
+
+// EnableTracing enables or disables tracing. Params: {"enable"}.
+func (api *AdminAPI) EnableTracing(req AdminRequest) error {
+	var args struct {
+		Enable bool `json:"enable"`
+	}
+	if _, err := api.authorize("enableTracing", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.tracingSystem == nil {
+		return fmt.Errorf("tracing system not enabled")
+	}
+	api.poatc.tracingSystem.EnableTracing(args.Enable)
+	return nil
+}
+
+// ===== Time Dynamic System =====
+
+// UpdateTransactionCount manually feeds a transaction count to the dynamic
+// block time. Params: {"txCount"}.
+func (api *AdminAPI) UpdateTransactionCount(req AdminRequest) error {
+	var args struct {
+		TxCount int `json:"txCount"`
+	}
+	if _, err := api.authorize("updateTransactionCount", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.timeDynamicManager == nil {
+		return fmt.Errorf("time dynamic system not enabled")
+	}
+	api.poatc.timeDynamicManager.UpdateTransactionCount(args.TxCount)
+	return nil
+}
+
+// TriggerValidatorSelection manually triggers a timed validator selection seeded
+// by the block. Params: {"number", "hash"}.
+func (api *AdminAPI) TriggerValidatorSelection(req AdminRequest) error {
+	var args blockArgs
+	if _, err := api.authorize("triggerValidatorSelection", &req, &args); err != nil {
+		return err
+	}
+	if api.poatc.timeDynamicManager == nil {
+		return fmt.Errorf("time dynamic system not enabled")
+	}
+	return api.poatc.timeDynamicManager.UpdateValidatorSelection(args.Number, args.Hash)
+}
+
+// TriggerReputationDecay manually triggers reputation decay. Params: {}.
+func (api *AdminAPI) TriggerReputationDecay(req AdminRequest) error {
+	if _, err := api.authorize("triggerReputationDecay", &req, &struct{}{}); err != nil {
+		return err
+	}
+	if api.poatc.timeDynamicManager == nil {
+		return fmt.Errorf("time dynamic system not enabled")
+	}
+	return api.poatc.timeDynamicManager.ApplyReputationDecay()
+}
+
+// UpdateTimeDynamicConfig replaces the time dynamic configuration. Params: the
+// TimeDynamicConfig.
+func (api *AdminAPI) UpdateTimeDynamicConfig(req AdminRequest) error {
+	config := new(TimeDynamicConfig)
+	if _, err := api.authorize("updateTimeDynamicConfig", &req, config); err != nil {
+		return err
+	}
+	if api.poatc.timeDynamicManager == nil {
+		return fmt.Errorf("time dynamic system not enabled")
+	}
+	api.poatc.timeDynamicManager.UpdateConfig(config)
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newTestAdminAPI creates a chain authorizing signer "A" of the account pool and
+// an admin API over it, with an in-memory whitelist/blacklist.
+func newTestAdminAPI(t *testing.T, accounts *testerAccountPool) *AdminAPI {
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	accounts.checkpoint(&types.Header{Extra: genesis.ExtraData}, []string{"A"})
+
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30}
+	genesis.Config = &config
+
+	db := rawdb.NewMemoryDatabase()
+	engine := NewWithConfig(config.Clique, nil, db)
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	lists := DefaultWhitelistBlacklistConfig()
+	lists.PersistencePath = ""
+	engine.whitelistBlacklistManager = NewWhitelistBlacklistManager(lists, nil)
+
+	return &AdminAPI{chain: chain, poatc: engine}
+}
+
+// Tests that admin calls are only executed if signed by a current signer with a
+// fresh nonce before their deadline, and are attributed to the signer.
+func TestAdminAuthorization(t *testing.T) {
+	accounts := newTesterAccountPool()
+	api := newTestAdminAPI(t, accounts)
+	chainID := api.chain.Config().ChainID
+
+	target := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	args := map[string]interface{}{"address": target, "reason": "spam"}
+	deadline := uint64(time.Now().Add(time.Minute).Unix())
+
+	sign := func(signer string, method string, args interface{}, nonce uint64, deadline uint64) AdminRequest {
+		t.Helper()
+		req, err := SignAdminRequest(accounts.accounts[signer], chainID, method, args, nonce, deadline)
+		if err != nil {
+			t.Fatalf("failed to sign admin request: %v", err)
+		}
+		return *req
+	}
+	// A request signed by the current signer is executed on its behalf
+	req := sign("A", "addToBlacklist", args, 1, deadline)
+	if err := api.AddToBlacklist(req); err != nil {
+		t.Fatalf("failed to blacklist by signer: %v", err)
+	}
+	entry, ok := api.poatc.whitelistBlacklistManager.GetBlacklist()[target]
+	if !ok || entry.AddedBy != accounts.address("A") || entry.Reason != "spam" {
+		t.Errorf("blacklist entry mismatch: have %+v, added by %x", entry, accounts.address("A"))
+	}
+	// Replays and requests not signed by the current signers are rejected
+	if err := api.AddToBlacklist(req); !errors.Is(err, errUsedAdminNonce) {
+		t.Errorf("replayed request error mismatch: have %v, want %v", err, errUsedAdminNonce)
+	}
+	accounts.address("B")
+	if err := api.RemoveFromBlacklist(sign("B", "removeFromBlacklist", args, 2, deadline)); !errors.Is(err, errUnauthorizedAdmin) {
+		t.Errorf("non-signer request error mismatch: have %v, want %v", err, errUnauthorizedAdmin)
+	}
+	// Expired requests, requests signed for other methods and unknown arguments are rejected
+	if err := api.RemoveFromBlacklist(sign("A", "removeFromBlacklist", args, 3, uint64(time.Now().Add(-time.Minute).Unix()))); !errors.Is(err, errExpiredAdminRequest) {
+		t.Errorf("expired request error mismatch: have %v, want %v", err, errExpiredAdminRequest)
+	}
+	if err := api.RemoveFromBlacklist(sign("A", "addToBlacklist", args, 4, deadline)); !errors.Is(err, errUnauthorizedAdmin) {
+		t.Errorf("request for other method error mismatch: have %v, want %v", err, errUnauthorizedAdmin)
+	}
+	unknown := map[string]interface{}{"address": target, "addedBy": target}
+	if err := api.RemoveFromBlacklist(sign("A", "removeFromBlacklist", unknown, 5, deadline)); err == nil {
+		t.Errorf("request with unknown arguments executed")
+	}
+	if !api.poatc.whitelistBlacklistManager.IsBlacklisted(target) {
+		t.Errorf("rejected request removed blacklist entry")
+	}
+	// Nonces only have to exceed the last used one
+	if err := api.RemoveFromBlacklist(sign("A", "removeFromBlacklist", args, 100, deadline)); err != nil {
+		t.Fatalf("failed to remove from blacklist by signer: %v", err)
+	}
+	if api.poatc.whitelistBlacklistManager.IsBlacklisted(target) {
+		t.Errorf("blacklist entry not removed")
+	}
+	if nonce := rawdb.ReadPoatcAdminNonce(api.poatc.db, accounts.address("A")); nonce != 100 {
+		t.Errorf("stored nonce mismatch: have %d, want %d", nonce, 100)
+	}
+}
+
+// Tests that the executed admin calls are traced along with their actor.
+func TestAdminActionTraced(t *testing.T) {
+	accounts := newTesterAccountPool()
+	api := newTestAdminAPI(t, accounts)
+
+	// Subsystems are started by the first snapshot retrieval
+	header := api.chain.CurrentHeader()
+	if _, err := api.poatc.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil); err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	req, err := SignAdminRequest(accounts.accounts["A"], api.chain.Config().ChainID, "setTraceLevel",
+		map[string]int{"level": int(TraceLevelDetailed)}, 1, uint64(time.Now().Add(time.Minute).Unix()))
+	if err != nil {
+		t.Fatalf("failed to sign admin request: %v", err)
+	}
+	if err := api.SetTraceLevel(*req); err != nil {
+		t.Fatalf("failed to set trace level: %v", err)
+	}
+	events := api.poatc.tracingSystem.GetTraceEvents(TraceEventAdminAction, TraceLevelVerbose, 0)
+	if len(events) != 1 {
+		t.Fatalf("admin trace events mismatch: have %d, want 1", len(events))
+	}
+	if events[0].Address != accounts.address("A") || events[0].Data["method"] != "poatcadmin_setTraceLevel" {
+		t.Errorf("admin trace event mismatch: have %+v", events[0])
+	}
+}
@@ -19,7 +19,6 @@ package poatc
 import (
 	"encoding/json"
 	"fmt"
-	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -114,6 +113,7 @@ func (api *API) Propose(address common.Address, auth bool) {
 	defer api.poatc.lock.Unlock()
 
 	api.poatc.proposals[address] = auth
+	delete(api.poatc.autoProposals, address)
 }
 
 // Discard drops a currently running proposal, stopping the signer from casting
@@ -123,62 +123,174 @@ func (api *API) Discard(address common.Address) {
 	defer api.poatc.lock.Unlock()
 
 	delete(api.poatc.proposals, address)
+	delete(api.poatc.autoProposals, address)
 }
 
-type status struct {
+// SubmitEvidence queues evidence that a signer sealed two different headers at
+// the same height. The evidence is verified against the current head and then
+// included by the local signer in one of its blocks, slashing the offender.
+func (api *API) SubmitEvidence(first *types.Header, second *types.Header) error {
+	if api.poatc.slashingRules == nil {
+		return fmt.Errorf("slashing is not enabled on chain")
+	}
+	evidence := &Evidence{First: first, Second: second}
+	if err := evidence.sanityCheck(); err != nil {
+		return err
+	}
+	snap, err := api.GetSnapshot(nil)
+	if err != nil {
+		return err
+	}
+	offender, err := snap.verifyEvidence(evidence, snap.Number+1)
+	if err != nil {
+		return err
+	}
+	api.poatc.addEvidence(sealKey{Number: first.Number.Uint64(), Parent: first.ParentHash, Signer: offender}, evidence)
+	return nil
+}
+
+// PendingEvidence returns the double signing evidence waiting to be included
+// in a block by the local signer.
+func (api *API) PendingEvidence() []*Evidence {
+	api.poatc.lock.RLock()
+	defer api.poatc.lock.RUnlock()
+
+	evidence := make([]*Evidence, 0, len(api.poatc.evidence))
+	for _, ev := range api.poatc.evidence {
+		evidence = append(evidence, ev)
+	}
+	return evidence
+}
+
+// ListProposals returns the current whitelist/blacklist proposals the node tries
+// to uphold and vote on, keyed by list name.
+func (api *API) ListProposals() map[string]map[common.Address]bool {
+	api.poatc.lock.RLock()
+	defer api.poatc.lock.RUnlock()
+
+	proposals := map[string]map[common.Address]bool{
+		listWhitelist: make(map[common.Address]bool),
+		listBlacklist: make(map[common.Address]bool),
+	}
+	for proposal, add := range api.poatc.listProposals {
+		proposals[proposal.List][proposal.Address] = add
+	}
+	return proposals
+}
+
+// ProposeList injects a new whitelist/blacklist proposal that the signer will
+// attempt to push through.
+func (api *API) ProposeList(list string, address common.Address, add bool) error {
+	if api.poatc.listRules == nil {
+		return fmt.Errorf("whitelist/blacklist is not governed on chain")
+	}
+	if list != listWhitelist && list != listBlacklist {
+		return fmt.Errorf("unknown list %q", list)
+	}
+	api.poatc.lock.Lock()
+	defer api.poatc.lock.Unlock()
+
+	api.poatc.listProposals[listProposal{List: list, Address: address}] = add
+	return nil
+}
+
+// DiscardList drops a currently running whitelist/blacklist proposal, stopping
+// the signer from casting further votes (either for or against).
+func (api *API) DiscardList(list string, address common.Address) {
+	api.poatc.lock.Lock()
+	defer api.poatc.lock.Unlock()
+
+	delete(api.poatc.listProposals, listProposal{List: list, Address: address})
+}
+
+// SealerStatus is the sealing activity of the signers over the recent blocks.
+type SealerStatus struct {
 	InturnPercent float64                `json:"inturnPercent"`
 	SigningStatus map[common.Address]int `json:"sealerActivity"`
 	NumBlocks     uint64                 `json:"numBlocks"`
 }
 
-// Status returns the status of the last N blocks,
-// - the number of active signers,
-// - the number of signers,
+// statusBlocks is the number of recent blocks covered by Status.
+const statusBlocks = 64
+
+// Status returns the status of the last 64 blocks,
+// - the number of blocks sealed by each signer,
 // - the percentage of in-turn blocks
-func (api *API) Status() (*status, error) {
-	var (
-		numBlocks = uint64(64)
-		header    = api.chain.CurrentHeader()
-		diff      = uint64(0)
-		optimals  = 0
-	)
-	snap, err := api.poatc.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+//
+// Deprecated: use SignerReport, which covers any range and reports more.
+func (api *API) Status() (*SealerStatus, error) {
+	head := api.chain.CurrentHeader().Number.Uint64()
+	if head == 0 {
+		return nil, fmt.Errorf("no blocks sealed yet")
+	}
+	from := uint64(1)
+	if head > statusBlocks {
+		from = head - statusBlocks + 1
+	}
+	report, err := api.signerReport(from, head)
 	if err != nil {
 		return nil, err
 	}
-	var (
-		signers = snap.signers()
-		end     = header.Number.Uint64()
-		start   = end - numBlocks
-	)
-	if numBlocks > end {
-		start = 1
-		numBlocks = end - start
-	}
-	signStatus := make(map[common.Address]int)
-	for _, s := range signers {
-		signStatus[s] = 0
-	}
-	for n := start; n < end; n++ {
-		h := api.chain.GetHeaderByNumber(n)
-		if h == nil {
-			return nil, fmt.Errorf("missing block %d", n)
-		}
-		if h.Difficulty.Cmp(diffInTurn) == 0 {
-			optimals++
-		}
-		diff += h.Difficulty.Uint64()
-		sealer, err := api.poatc.Author(h)
-		if err != nil {
-			return nil, err
+	status := &SealerStatus{
+		InturnPercent: report.InturnPercent,
+		SigningStatus: make(map[common.Address]int),
+		NumBlocks:     report.Blocks,
+	}
+	for signer, activity := range report.Signers {
+		status.SigningStatus[signer] = int(activity.Sealed)
+	}
+	return status, nil
+}
+
+// maxSignerReportBlocks is the maximum number of blocks a signer report served
+// over RPC may cover, larger ranges can be reported offline by the CLI.
+const maxSignerReportBlocks = 100_000
+
+// SignerReport returns the sealing activity of every signer between the given
+// blocks (inclusive): blocks sealed in and out of turn, missed in-turn slots,
+// seal delays, longest absence and anomaly and violation counts.
+func (api *API) SignerReport(from rpc.BlockNumber, to rpc.BlockNumber) (*SignerReport, error) {
+	head := api.chain.CurrentHeader().Number.Uint64()
+
+	start, end := head, head
+	if from >= 0 {
+		start = uint64(from.Int64())
+	}
+	if to >= 0 {
+		end = uint64(to.Int64())
+	}
+	if start == 0 {
+		start = 1 // The genesis block isn't sealed
+	}
+	if end > head {
+		return nil, errUnknownBlock
+	}
+	if end >= start && end-start >= maxSignerReportBlocks {
+		return nil, fmt.Errorf("block range too large: %d blocks, max %d", end-start+1, maxSignerReportBlocks)
+	}
+	return api.signerReport(start, end)
+}
+
+// signerReport builds the signer report of the canonical blocks in [from, to].
+func (api *API) signerReport(from, to uint64) (*SignerReport, error) {
+	if from == 0 || from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	parent := api.chain.GetHeaderByNumber(from - 1)
+	if parent == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.poatc.snapshot(api.chain, from-1, parent.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	header := func(number uint64) (*types.Header, error) {
+		if header := api.chain.GetHeaderByNumber(number); header != nil {
+			return header, nil
 		}
-		signStatus[sealer]++
+		return nil, fmt.Errorf("missing block %d", number)
 	}
-	return &status{
-		InturnPercent: float64(100*optimals) / float64(numBlocks),
-		SigningStatus: signStatus,
-		NumBlocks:     numBlocks,
-	}, nil
+	return buildSignerReport(snap, parent, header, from, to, api.poatc.reportAnomalyConfig())
 }
 
 type blockNumberOrHashOrRLP struct {
@@ -237,11 +349,9 @@ func (api *API) GetSigner(rlpOrBlockNr *blockNumberOrHashOrRLP) (common.Address,
 }
 
 // GetAnomalyStats returns statistics about detected anomalies
-func (api *API) GetAnomalyStats() (map[string]interface{}, error) {
+func (api *API) GetAnomalyStats() (*AnomalyStats, error) {
 	if api.poatc.anomalyDetector == nil {
-		return map[string]interface{}{
-			"error": "anomaly detector not initialized",
-		}, nil
+		return nil, fmt.Errorf("anomaly detector not initialized")
 	}
 
 	return api.poatc.anomalyDetector.GetAnomalyStats(), nil
@@ -267,17 +377,74 @@ func (api *API) GetAnomalyConfig() (*AnomalyDetectionConfig, error) {
 
 // Whitelist/Blacklist API endpoints
 
+// GovernedLists are the whitelist and blacklist voted on by the signers at a
+// given block, mapping each entry to its expiry block (0 = never).
+type GovernedLists struct {
+	Number    uint64                    `json:"number"`
+	Hash      common.Hash               `json:"hash"`
+	Whitelist map[common.Address]uint64 `json:"whitelist"`
+	Blacklist map[common.Address]uint64 `json:"blacklist"`
+}
+
+// GetGovernedLists returns the voted whitelist and blacklist at the specified
+// block (or the current head if none requested). Unlike the local lists, these
+// are part of the voting snapshot and identical on every node.
+func (api *API) GetGovernedLists(number *rpc.BlockNumber) (*GovernedLists, error) {
+	if api.poatc.listRules == nil {
+		return nil, fmt.Errorf("whitelist/blacklist is not governed on chain")
+	}
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return &GovernedLists{
+		Number:    snap.Number,
+		Hash:      snap.Hash,
+		Whitelist: copyEntries(snap.Whitelist),
+		Blacklist: copyEntries(snap.Blacklist),
+	}, nil
+}
+
+// GetCandidates returns the signer candidates registered at the specified block
+// (or the current head if none requested), in registration order.
+func (api *API) GetCandidates(number *rpc.BlockNumber) ([]*Candidate, error) {
+	if api.poatc.registry == nil {
+		return nil, fmt.Errorf("no signer candidate registry configured")
+	}
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	db, err := registryState(api.chain, header)
+	if err != nil {
+		return nil, err
+	}
+	return api.poatc.registry.candidates(db), nil
+}
+
 // GetWhitelistBlacklistStats returns statistics about whitelist and blacklist
-func (api *API) GetWhitelistBlacklistStats() (map[string]interface{}, error) {
+func (api *API) GetWhitelistBlacklistStats() (*ListStats, error) {
 	if api.poatc.whitelistBlacklistManager == nil {
-		return map[string]interface{}{
-			"error": "whitelist/blacklist manager not initialized",
-		}, nil
+		return nil, fmt.Errorf("whitelist/blacklist manager not initialized")
 	}
 
 	return api.poatc.whitelistBlacklistManager.GetStats(), nil
 }
 
+// GetListStandings returns the whitelist/blacklist state of every address known
+// to the local state machine.
+func (api *API) GetListStandings() (map[common.Address]ListStanding, error) {
+	if api.poatc.whitelistBlacklistManager == nil {
+		return nil, fmt.Errorf("whitelist/blacklist manager not initialized")
+	}
+	return api.poatc.whitelistBlacklistManager.GetStandings(), nil
+}
+
 // GetWhitelist returns the current whitelist
 func (api *API) GetWhitelist() (map[string]WhitelistEntry, error) {
 	if api.poatc.whitelistBlacklistManager == nil {
@@ -306,42 +473,6 @@ func (api *API) GetBlacklist() (map[string]BlacklistEntry, error) {
 	return result, nil
 }
 
-// AddToWhitelist adds an address to the whitelist
-func (api *API) AddToWhitelist(address common.Address, addedBy common.Address, reason string) error {
-	if api.poatc.whitelistBlacklistManager == nil {
-		return fmt.Errorf("whitelist/blacklist manager not initialized")
-	}
-
-	return api.poatc.whitelistBlacklistManager.AddToWhitelist(address, addedBy, reason, nil)
-}
-
-// RemoveFromWhitelist removes an address from the whitelist
-func (api *API) RemoveFromWhitelist(address common.Address) error {
-	if api.poatc.whitelistBlacklistManager == nil {
-		return fmt.Errorf("whitelist/blacklist manager not initialized")
-	}
-
-	return api.poatc.whitelistBlacklistManager.RemoveFromWhitelist(address)
-}
-
-// AddToBlacklist adds an address to the blacklist
-func (api *API) AddToBlacklist(address common.Address, addedBy common.Address, reason string) error {
-	if api.poatc.whitelistBlacklistManager == nil {
-		return fmt.Errorf("whitelist/blacklist manager not initialized")
-	}
-
-	return api.poatc.whitelistBlacklistManager.AddToBlacklist(address, addedBy, reason, nil)
-}
-
-// RemoveFromBlacklist removes an address from the blacklist
-func (api *API) RemoveFromBlacklist(address common.Address) error {
-	if api.poatc.whitelistBlacklistManager == nil {
-		return fmt.Errorf("whitelist/blacklist manager not initialized")
-	}
-
-	return api.poatc.whitelistBlacklistManager.RemoveFromBlacklist(address)
-}
-
 // IsWhitelisted checks if an address is in the whitelist
 func (api *API) IsWhitelisted(address common.Address) (bool, error) {
 	if api.poatc.whitelistBlacklistManager == nil {
@@ -360,34 +491,28 @@ func (api *API) IsBlacklisted(address common.Address) (bool, error) {
 	return api.poatc.whitelistBlacklistManager.IsBlacklisted(address), nil
 }
 
-// ValidateSigner validates if a signer is allowed to sign
-func (api *API) ValidateSigner(address common.Address) (bool, string, error) {
-	if api.poatc.whitelistBlacklistManager == nil {
-		return false, "", fmt.Errorf("whitelist/blacklist manager not initialized")
-	}
-
-	valid, reason := api.poatc.whitelistBlacklistManager.ValidateSigner(address)
-	return valid, reason, nil
+// SignerValidation is the verdict of the whitelist and blacklist on a signer.
+type SignerValidation struct {
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason"`
 }
 
-// CleanupExpiredEntries removes expired entries from whitelist and blacklist
-func (api *API) CleanupExpiredEntries() error {
+// ValidateSigner validates if a signer is allowed to sign
+func (api *API) ValidateSigner(address common.Address) (*SignerValidation, error) {
 	if api.poatc.whitelistBlacklistManager == nil {
-		return fmt.Errorf("whitelist/blacklist manager not initialized")
+		return nil, fmt.Errorf("whitelist/blacklist manager not initialized")
 	}
 
-	api.poatc.whitelistBlacklistManager.CleanupExpiredEntries()
-	return nil
+	valid, reason := api.poatc.whitelistBlacklistManager.ValidateSigner(address)
+	return &SignerValidation{Valid: valid, Reason: reason}, nil
 }
 
 // ===== Validator Selection API =====
 
 // GetValidatorSelectionStats returns statistics about validator selection
-func (api *API) GetValidatorSelectionStats() (map[string]interface{}, error) {
+func (api *API) GetValidatorSelectionStats() (*SelectionStats, error) {
 	if api.poatc.validatorSelectionManager == nil {
-		return map[string]interface{}{
-			"error": "validator selection manager not initialized",
-		}, nil
+		return nil, fmt.Errorf("validator selection manager not initialized")
 	}
 
 	return api.poatc.validatorSelectionManager.GetStats(), nil
@@ -416,36 +541,6 @@ func (api *API) GetValidatorInfo(address common.Address) (*ValidatorInfo, error)
 	return info, nil
 }
 
-// AddValidator adds a new validator to the selection system
-func (api *API) AddValidator(address common.Address, stake *big.Int, reputation float64) error {
-	if api.poatc.validatorSelectionManager == nil {
-		return fmt.Errorf("validator selection manager not initialized")
-	}
-
-	api.poatc.validatorSelectionManager.AddValidator(address, stake, reputation)
-	return nil
-}
-
-// UpdateValidatorStake updates a validator's stake
-func (api *API) UpdateValidatorStake(address common.Address, stake *big.Int) error {
-	if api.poatc.validatorSelectionManager == nil {
-		return fmt.Errorf("validator selection manager not initialized")
-	}
-
-	api.poatc.validatorSelectionManager.UpdateValidatorStake(address, stake)
-	return nil
-}
-
-// UpdateValidatorReputation updates a validator's reputation
-func (api *API) UpdateValidatorReputation(address common.Address, reputation float64) error {
-	if api.poatc.validatorSelectionManager == nil {
-		return fmt.Errorf("validator selection manager not initialized")
-	}
-
-	api.poatc.validatorSelectionManager.UpdateValidatorReputation(address, reputation)
-	return nil
-}
-
 // GetSelectionHistory returns the validator selection history
 func (api *API) GetSelectionHistory() ([]ValidatorSelectionRecord, error) {
 	if api.poatc.validatorSelectionManager == nil {
@@ -455,40 +550,53 @@ func (api *API) GetSelectionHistory() ([]ValidatorSelectionRecord, error) {
 	return api.poatc.validatorSelectionManager.GetSelectionHistory(), nil
 }
 
-// ForceValidatorSelection forces a new validator selection
-func (api *API) ForceValidatorSelection(blockNumber uint64, blockHash common.Hash) ([]common.Address, error) {
-	if api.poatc.validatorSelectionManager == nil {
-		return nil, fmt.Errorf("validator selection manager not initialized")
-	}
-
-	return api.poatc.validatorSelectionManager.SelectSmallValidatorSet(blockNumber, blockHash)
-}
-
 // ===== Reputation System API =====
 
 // GetReputationStats returns statistics about the reputation system
-func (api *API) GetReputationStats() (map[string]interface{}, error) {
+func (api *API) GetReputationStats() (*ReputationStats, error) {
 	if api.poatc.reputationSystem == nil {
-		return map[string]interface{}{
-			"error": "reputation system not initialized",
-		}, nil
+		return nil, fmt.Errorf("reputation system not initialized")
 	}
 
 	return api.poatc.reputationSystem.GetReputationStats(), nil
 }
 
-// GetReputationScore returns the reputation score for a specific validator
-func (api *API) GetReputationScore(address common.Address) (*ReputationScore, error) {
-	if api.poatc.reputationSystem == nil {
-		return nil, fmt.Errorf("reputation system not initialized")
-	}
+// ChainReputation is the consensus reputation of a signer at a given block, as
+// derived from the chain and committed to by checkpoint blocks.
+type ChainReputation struct {
+	Address      common.Address `json:"address"`
+	Number       uint64         `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	Score        float64        `json:"score"`
+	BlocksSigned uint64         `json:"blocksSigned"`
+	LastSigned   uint64         `json:"lastSigned"`
+	Violations   uint64         `json:"violations"`
+}
 
-	score := api.poatc.reputationSystem.GetReputationScore(address)
-	if score == nil {
+// GetReputationScore returns the consensus reputation score of a signer at the
+// specified block (or the current head if none requested). The score is part
+// of the voting snapshot, so every node returns the same answer.
+func (api *API) GetReputationScore(address common.Address, number *rpc.BlockNumber) (*ChainReputation, error) {
+	if api.poatc.reputationRules == nil {
+		return nil, fmt.Errorf("reputation is not tracked on chain")
+	}
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	reputation, ok := snap.Reputation[address]
+	if !ok {
 		return nil, fmt.Errorf("validator not found in reputation system")
 	}
-
-	return score, nil
+	return &ChainReputation{
+		Address:      address,
+		Number:       snap.Number,
+		Hash:         snap.Hash,
+		Score:        reputation.Float(),
+		BlocksSigned: reputation.BlocksSigned,
+		LastSigned:   reputation.LastSigned,
+		Violations:   reputation.Violations,
+	}, nil
 }
 
 // GetTopValidators returns validators sorted by reputation score
@@ -509,124 +617,140 @@ func (api *API) GetReputationEvents(limit int) ([]ReputationEvent, error) {
 	return api.poatc.reputationSystem.GetReputationEvents(limit), nil
 }
 
-// RecordViolation records a violation by a validator
-func (api *API) RecordViolation(address common.Address, blockNumber uint64, violationType string, description string) error {
+// GetReputationHistory returns the local reputation of a validator, broken down
+// into its mining, uptime, consistency and penalty components, at every step-th
+// block between the given blocks (inclusive).
+func (api *API) GetReputationHistory(address common.Address, from rpc.BlockNumber, to rpc.BlockNumber, step uint64) ([]*ReputationSnapshot, error) {
 	if api.poatc.reputationSystem == nil {
-		return fmt.Errorf("reputation system not initialized")
+		return nil, fmt.Errorf("reputation system not initialized")
 	}
+	head := api.chain.CurrentHeader().Number.Uint64()
 
-	api.poatc.reputationSystem.RecordViolation(address, blockNumber, violationType, description)
-	return nil
+	start, end := head, head
+	if from >= 0 {
+		start = uint64(from.Int64())
+	}
+	if to >= 0 {
+		end = uint64(to.Int64())
+	}
+	if end > head {
+		return nil, errUnknownBlock
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid block range %d-%d", start, end)
+	}
+	if step == 0 {
+		step = 1
+	}
+	if points := (end-start)/step + 1; points > maxReputationHistoryPoints {
+		return nil, fmt.Errorf("too many history points: %d, max %d", points, maxReputationHistoryPoints)
+	}
+	return api.poatc.reputationSystem.GetReputationHistory(address, start, end, step), nil
 }
 
-// UpdateReputation manually updates reputation scores
-func (api *API) UpdateReputation() error {
+// ExplainReputation accounts for the local reputation of a validator at the
+// specified block (or the current head if none requested): the weighted
+// components of the score and every change that led to it from the previous
+// score, with its cause and the configuration parameter involved.
+func (api *API) ExplainReputation(address common.Address, number *rpc.BlockNumber) (*ReputationExplanation, error) {
 	if api.poatc.reputationSystem == nil {
-		return fmt.Errorf("reputation system not initialized")
+		return nil, fmt.Errorf("reputation system not initialized")
 	}
+	head := api.chain.CurrentHeader().Number.Uint64()
 
-	api.poatc.reputationSystem.UpdateReputation()
-	return nil
+	block := head
+	if number != nil && *number >= 0 {
+		block = uint64(number.Int64())
+	}
+	if block > head {
+		return nil, errUnknownBlock
+	}
+	explanation := api.poatc.reputationSystem.ExplainReputation(address, block)
+	if explanation == nil {
+		return nil, fmt.Errorf("no reputation recorded for validator at block %d", block)
+	}
+	return explanation, nil
 }
 
-// MarkValidatorOffline marks a validator as offline
-func (api *API) MarkValidatorOffline(address common.Address) error {
-	if api.poatc.reputationSystem == nil {
-		return fmt.Errorf("reputation system not initialized")
-	}
+// ===== Integration Management API =====
 
-	api.poatc.reputationSystem.MarkValidatorOffline(address)
-	return nil
+// IntegrationStatus reports which subsystems are running and which of them feed
+// into each other.
+type IntegrationStatus struct {
+	ReputationSystem   SubsystemStatus  `json:"reputation_system"`
+	ValidatorSelection SubsystemStatus  `json:"validator_selection"`
+	AnomalyDetection   SubsystemStatus  `json:"anomaly_detection"`
+	WhitelistBlacklist SubsystemStatus  `json:"whitelist_blacklist"`
+	Integrations       IntegrationLinks `json:"integrations"`
 }
 
-// UpdateValidatorUptime updates the uptime for a validator
-func (api *API) UpdateValidatorUptime(address common.Address) error {
-	if api.poatc.reputationSystem == nil {
-		return fmt.Errorf("reputation system not initialized")
-	}
-
-	api.poatc.reputationSystem.UpdateUptime(address)
-	return nil
+// SubsystemStatus reports whether a subsystem is running. Subsystems that can't
+// be disabled once initialized are enabled whenever initialized.
+type SubsystemStatus struct {
+	Initialized bool `json:"initialized"`
+	Enabled     bool `json:"enabled"`
 }
 
-// ===== Integration Management API =====
+// IntegrationLinks reports which subsystems feed into each other.
+type IntegrationLinks struct {
+	ReputationToValidatorSelection bool `json:"reputation_to_validator_selection"`
+	AnomalyDetectionToReputation   bool `json:"anomaly_detection_to_reputation"`
+	ReputationToWhitelistBlacklist bool `json:"reputation_to_whitelist_blacklist"`
+}
 
 // GetIntegrationStatus returns the status of all system integrations
-func (api *API) GetIntegrationStatus() (map[string]interface{}, error) {
-	status := map[string]interface{}{
-		"reputation_system": map[string]interface{}{
-			"initialized": api.poatc.reputationSystem != nil,
-			"enabled":     api.poatc.reputationSystem != nil && api.poatc.reputationSystem.config.EnableReputationSystem,
-		},
-		"validator_selection": map[string]interface{}{
-			"initialized": api.poatc.validatorSelectionManager != nil,
-		},
-		"anomaly_detection": map[string]interface{}{
-			"initialized": api.poatc.anomalyDetector != nil,
-		},
-		"whitelist_blacklist": map[string]interface{}{
-			"initialized": api.poatc.whitelistBlacklistManager != nil,
+func (api *API) GetIntegrationStatus() (*IntegrationStatus, error) {
+	var (
+		reputation = api.poatc.reputationSystem != nil
+		selection  = api.poatc.validatorSelectionManager != nil
+		anomaly    = api.poatc.anomalyDetector != nil
+		lists      = api.poatc.whitelistBlacklistManager != nil
+	)
+	return &IntegrationStatus{
+		ReputationSystem: SubsystemStatus{
+			Initialized: reputation,
+			Enabled:     reputation && api.poatc.reputationSystem.config.EnableReputationSystem,
 		},
-		"integrations": map[string]interface{}{
-			"reputation_to_validator_selection": api.poatc.reputationSystem != nil && api.poatc.validatorSelectionManager != nil,
-			"anomaly_detection_to_reputation":   api.poatc.anomalyDetector != nil && api.poatc.reputationSystem != nil,
-			"reputation_to_whitelist_blacklist": api.poatc.reputationSystem != nil && api.poatc.whitelistBlacklistManager != nil,
+		ValidatorSelection: SubsystemStatus{Initialized: selection, Enabled: selection},
+		AnomalyDetection:   SubsystemStatus{Initialized: anomaly, Enabled: anomaly},
+		WhitelistBlacklist: SubsystemStatus{Initialized: lists, Enabled: lists},
+		Integrations: IntegrationLinks{
+			ReputationToValidatorSelection: reputation && selection,
+			AnomalyDetectionToReputation:   anomaly && reputation,
+			ReputationToWhitelistBlacklist: reputation && lists,
 		},
-	}
-
-	return status, nil
+	}, nil
 }
 
-// ForceReputationBasedWhitelistBlacklist forces whitelist/blacklist management based on current reputation scores
-func (api *API) ForceReputationBasedWhitelistBlacklist() error {
-	if api.poatc.reputationSystem == nil || api.poatc.whitelistBlacklistManager == nil {
-		return fmt.Errorf("reputation system or whitelist/blacklist manager not initialized")
-	}
-
-	// Get all validators and their reputation scores
-	topValidators := api.poatc.reputationSystem.GetTopValidators(0) // Get all validators
-
-	config := api.poatc.reputationSystem.config
-	processed := 0
+// ReputationRecommendations are the validators whose reputation suggests they
+// should be whitelisted or blacklisted.
+type ReputationRecommendations struct {
+	Thresholds      ReputationThresholds `json:"thresholds"`
+	Recommendations ListRecommendations  `json:"recommendations"`
+}
 
-	for _, validator := range topValidators {
-		// Check if should be blacklisted
-		if validator.CurrentScore < config.LowReputationThreshold {
-			if !api.poatc.whitelistBlacklistManager.IsBlacklisted(validator.Address) {
-				expiresAt := time.Now().Add(24 * time.Hour)
-				err := api.poatc.whitelistBlacklistManager.AddToBlacklist(
-					validator.Address,
-					common.Address{}, // System address
-					fmt.Sprintf("Force blacklisted due to low reputation: %.2f", validator.CurrentScore),
-					&expiresAt,
-				)
-				if err == nil {
-					processed++
-				}
-			}
-		}
+// ReputationThresholds are the scores above which validators are recommended
+// for the whitelist, and below which for the blacklist.
+type ReputationThresholds struct {
+	HighReputation float64 `json:"high_reputation"`
+	LowReputation  float64 `json:"low_reputation"`
+}
 
-		// Check if should be whitelisted
-		if validator.CurrentScore >= config.HighReputationThreshold {
-			if !api.poatc.whitelistBlacklistManager.IsWhitelisted(validator.Address) {
-				err := api.poatc.whitelistBlacklistManager.AddToWhitelist(
-					validator.Address,
-					common.Address{}, // System address
-					fmt.Sprintf("Force whitelisted due to high reputation: %.2f", validator.CurrentScore),
-					nil, // No expiration
-				)
-				if err == nil {
-					processed++
-				}
-			}
-		}
-	}
+// ListRecommendations are the validators recommended for each list.
+type ListRecommendations struct {
+	Whitelist []ListRecommendation `json:"whitelist"`
+	Blacklist []ListRecommendation `json:"blacklist"`
+}
 
-	return nil
+// ListRecommendation is a validator recommended for a list.
+type ListRecommendation struct {
+	Address    common.Address `json:"address"`
+	Reputation float64        `json:"reputation"`
+	Reason     string         `json:"reason"`
 }
 
 // GetReputationBasedRecommendations returns recommendations for whitelist/blacklist based on reputation
-func (api *API) GetReputationBasedRecommendations() (map[string]interface{}, error) {
+func (api *API) GetReputationBasedRecommendations() (*ReputationRecommendations, error) {
 	if api.poatc.reputationSystem == nil {
 		return nil, fmt.Errorf("reputation system not initialized")
 	}
@@ -634,65 +758,72 @@ func (api *API) GetReputationBasedRecommendations() (map[string]interface{}, err
 	topValidators := api.poatc.reputationSystem.GetTopValidators(0)
 	config := api.poatc.reputationSystem.config
 
-	recommendations := map[string]interface{}{
-		"thresholds": map[string]interface{}{
-			"high_reputation": config.HighReputationThreshold,
-			"low_reputation":  config.LowReputationThreshold,
+	recommendations := &ReputationRecommendations{
+		Thresholds: ReputationThresholds{
+			HighReputation: config.HighReputationThreshold,
+			LowReputation:  config.LowReputationThreshold,
 		},
-		"recommendations": map[string]interface{}{
-			"whitelist": []map[string]interface{}{},
-			"blacklist": []map[string]interface{}{},
+		Recommendations: ListRecommendations{
+			Whitelist: []ListRecommendation{},
+			Blacklist: []ListRecommendation{},
 		},
 	}
 
-	whitelistRecs := []map[string]interface{}{}
-	blacklistRecs := []map[string]interface{}{}
-
 	for _, validator := range topValidators {
 		if validator.CurrentScore >= config.HighReputationThreshold {
-			whitelistRecs = append(whitelistRecs, map[string]interface{}{
-				"address":    validator.Address.Hex(),
-				"reputation": validator.CurrentScore,
-				"reason":     fmt.Sprintf("High reputation score: %.2f", validator.CurrentScore),
+			recommendations.Recommendations.Whitelist = append(recommendations.Recommendations.Whitelist, ListRecommendation{
+				Address:    validator.Address,
+				Reputation: validator.CurrentScore,
+				Reason:     fmt.Sprintf("High reputation score: %.2f", validator.CurrentScore),
 			})
 		} else if validator.CurrentScore < config.LowReputationThreshold {
-			blacklistRecs = append(blacklistRecs, map[string]interface{}{
-				"address":    validator.Address.Hex(),
-				"reputation": validator.CurrentScore,
-				"reason":     fmt.Sprintf("Low reputation score: %.2f", validator.CurrentScore),
+			recommendations.Recommendations.Blacklist = append(recommendations.Recommendations.Blacklist, ListRecommendation{
+				Address:    validator.Address,
+				Reputation: validator.CurrentScore,
+				Reason:     fmt.Sprintf("Low reputation score: %.2f", validator.CurrentScore),
 			})
 		}
 	}
-
-	recommendations["recommendations"].(map[string]interface{})["whitelist"] = whitelistRecs
-	recommendations["recommendations"].(map[string]interface{})["blacklist"] = blacklistRecs
-
 	return recommendations, nil
 }
 
 // ===== Fairness Management API =====
 
+// FairnessStats are the parameters and population of the fairness mechanisms
+// of the reputation system.
+type FairnessStats struct {
+	MaxComponentScore  float64 `json:"max_component_score"`
+	ResetIntervalHours float64 `json:"reset_interval_hours"`
+	NewValidatorBoost  float64 `json:"new_validator_boost"`
+	VeteranPenalty     float64 `json:"veteran_penalty"`
+	DecayFactor        float64 `json:"decay_factor"`
+	TotalValidators    int     `json:"total_validators"`
+	NewValidators      int     `json:"new_validators"`
+	VeteranValidators  int     `json:"veteran_validators"`
+}
+
 // GetFairnessStats returns statistics about fairness mechanisms
-func (api *API) GetFairnessStats() (map[string]interface{}, error) {
+func (api *API) GetFairnessStats() (*FairnessStats, error) {
 	if api.poatc.reputationSystem == nil {
 		return nil, fmt.Errorf("reputation system not enabled")
 	}
 
-	stats := make(map[string]interface{})
 	config := api.poatc.reputationSystem.config
 
 	// Configuration
-	stats["max_component_score"] = config.MaxComponentScore
-	stats["reset_interval_hours"] = config.ResetInterval.Hours()
-	stats["new_validator_boost"] = config.NewValidatorBoost
-	stats["veteran_penalty"] = config.VeteranPenalty
-	stats["decay_factor"] = config.DecayFactor
+	stats := &FairnessStats{
+		MaxComponentScore:  config.MaxComponentScore,
+		ResetIntervalHours: config.ResetInterval.Hours(),
+		NewValidatorBoost:  config.NewValidatorBoost,
+		VeteranPenalty:     config.VeteranPenalty,
+		DecayFactor:        config.DecayFactor,
+	}
 
 	// Validator statistics
 	allValidators := api.poatc.reputationSystem.GetAllValidators()
 	newValidators := 0
 	veteranValidators := 0
-	now := time.Now()
+	now := api.poatc.reputationSystem.now()
 
 	for _, validator := range allValidators {
 		score := api.poatc.reputationSystem.GetReputationScore(validator)
@@ -706,28 +837,35 @@ func (api *API) GetFairnessStats() (map[string]interface{}, error) {
 		}
 	}
 
-	stats["total_validators"] = len(allValidators)
-	stats["new_validators"] = newValidators
-	stats["veteran_validators"] = veteranValidators
+	stats.TotalValidators = len(allValidators)
+	stats.NewValidators = newValidators
+	stats.VeteranValidators = veteranValidators
 
 	return stats, nil
 }
 
-// ForcePartialReset forces a partial reset for a specific validator
-func (api *API) ForcePartialReset(address common.Address) error {
-	if api.poatc.reputationSystem == nil {
-		return fmt.Errorf("reputation system not enabled")
-	}
-
-	// This would need to be implemented in the reputation system
-	// For now, we'll just trigger a reputation update
-	api.poatc.reputationSystem.UpdateReputation()
-
-	return nil
+// ValidatorFairness is the standing of a validator with regard to the fairness
+// mechanisms of the reputation system.
+type ValidatorFairness struct {
+	Address          common.Address `json:"address"`
+	JoinTime         time.Time      `json:"join_time"`
+	LastReset        time.Time      `json:"last_reset"`
+	IsNewValidator   bool           `json:"is_new_validator"`
+	VeteranPenalty   float64        `json:"veteran_penalty"`
+	DaysSinceJoin    float64        `json:"days_since_join"`
+	HoursSinceReset  float64        `json:"hours_since_reset"`
+	BlockMiningScore float64        `json:"block_mining_score"`
+	UptimeScore      float64        `json:"uptime_score"`
+	ConsistencyScore float64        `json:"consistency_score"`
+	PenaltyScore     float64        `json:"penalty_score"`
+	CurrentScore     float64        `json:"current_score"`
+	IsAtMaxComponent bool           `json:"is_at_max_component"`
+	NeedsReset       bool           `json:"needs_reset"`
+	IsVeteran        bool           `json:"is_veteran"`
 }
 
 // GetValidatorFairnessInfo returns fairness information for a specific validator
-func (api *API) GetValidatorFairnessInfo(address common.Address) (map[string]interface{}, error) {
+func (api *API) GetValidatorFairnessInfo(address common.Address) (*ValidatorFairness, error) {
 	if api.poatc.reputationSystem == nil {
 		return nil, fmt.Errorf("reputation system not enabled")
 	}
@@ -737,42 +875,40 @@ func (api *API) GetValidatorFairnessInfo(address common.Address) (map[string]int
 		return nil, fmt.Errorf("validator not found")
 	}
 
-	info := make(map[string]interface{})
-	now := time.Now()
-
-	info["address"] = address.Hex()
-	info["join_time"] = score.JoinTime
-	info["last_reset"] = score.LastReset
-	info["is_new_validator"] = score.IsNewValidator
-	info["veteran_penalty"] = score.VeteranPenalty
-
-	// Calculate time-based info
-	info["days_since_join"] = now.Sub(score.JoinTime).Hours() / 24
-	info["hours_since_reset"] = now.Sub(score.LastReset).Hours()
-
-	// Component scores
-	info["block_mining_score"] = score.BlockMiningScore
-	info["uptime_score"] = score.UptimeScore
-	info["consistency_score"] = score.ConsistencyScore
-	info["penalty_score"] = score.PenaltyScore
-	info["current_score"] = score.CurrentScore
-
-	// Fairness status
+	now := api.poatc.reputationSystem.now()
 	config := api.poatc.reputationSystem.config
-	info["is_at_max_component"] = score.BlockMiningScore >= config.MaxComponentScore ||
-		score.UptimeScore >= config.MaxComponentScore ||
-		score.ConsistencyScore >= config.MaxComponentScore
-
-	info["needs_reset"] = now.Sub(score.LastReset) >= config.ResetInterval
-	info["is_veteran"] = now.Sub(score.JoinTime) > 30*24*time.Hour
 
-	return info, nil
+	return &ValidatorFairness{
+		Address:        address,
+		JoinTime:       score.JoinTime,
+		LastReset:      score.LastReset,
+		IsNewValidator: score.IsNewValidator,
+		VeteranPenalty: score.VeteranPenalty,
+
+		// Time-based info
+		DaysSinceJoin:   now.Sub(score.JoinTime).Hours() / 24,
+		HoursSinceReset: now.Sub(score.LastReset).Hours(),
+
+		// Component scores
+		BlockMiningScore: score.BlockMiningScore,
+		UptimeScore:      score.UptimeScore,
+		ConsistencyScore: score.ConsistencyScore,
+		PenaltyScore:     score.PenaltyScore,
+		CurrentScore:     score.CurrentScore,
+
+		// Fairness status
+		IsAtMaxComponent: score.BlockMiningScore >= config.MaxComponentScore ||
+			score.UptimeScore >= config.MaxComponentScore ||
+			score.ConsistencyScore >= config.MaxComponentScore,
+		NeedsReset: now.Sub(score.LastReset) >= config.ResetInterval,
+		IsVeteran:  now.Sub(score.JoinTime) > 30*24*time.Hour,
+	}, nil
 }
 
 // ===== Tracing System API =====
 
 // GetTracingStats returns statistics about the tracing system
-func (api *API) GetTracingStats() (map[string]interface{}, error) {
+func (api *API) GetTracingStats() (*TraceStats, error) {
 	if api.poatc.tracingSystem == nil {
 		return nil, fmt.Errorf("tracing system not enabled")
 	}
@@ -780,8 +916,10 @@ func (api *API) GetTracingStats() (map[string]interface{}, error) {
 	return api.poatc.tracingSystem.GetTraceStats(), nil
 }
 
-// GetTraceEvents returns trace events with optional filtering
-func (api *API) GetTraceEvents(eventType string, level int, limit int) ([]TraceEvent, error) {
+// GetTraceEvents returns trace events with optional filtering. The optional
+// filter narrows the events down by block range and address and pages through
+// them, covering the whole persisted audit trail if persistence is enabled.
+func (api *API) GetTraceEvents(eventType string, level int, limit int, filter *TraceFilter) ([]TraceEvent, error) {
 	if api.poatc.tracingSystem == nil {
 		return nil, fmt.Errorf("tracing system not enabled")
 	}
@@ -798,16 +936,16 @@ func (api *API) GetTraceEvents(eventType string, level int, limit int) ([]TraceE
 		traceLevel = TraceLevelOff
 	}
 
-	return api.poatc.tracingSystem.GetTraceEvents(traceEventType, traceLevel, limit), nil
+	return api.poatc.tracingSystem.QueryTraceEvents(traceEventType, traceLevel, limit, filter), nil
 }
 
 // GetMerkleRoot returns the current Merkle root of the tracing system
-func (api *API) GetMerkleRoot() (string, error) {
+func (api *API) GetMerkleRoot() (common.Hash, error) {
 	if api.poatc.tracingSystem == nil {
-		return "", fmt.Errorf("tracing system not enabled")
+		return common.Hash{}, fmt.Errorf("tracing system not enabled")
 	}
 
-	return api.poatc.tracingSystem.GetMerkleRoot().Hex(), nil
+	return api.poatc.tracingSystem.GetMerkleRoot(), nil
 }
 
 // VerifyEventInMerkleTree verifies if an event is in the Merkle Tree
@@ -819,24 +957,105 @@ func (api *API) VerifyEventInMerkleTree(event TraceEvent) (bool, error) {
 	return api.poatc.tracingSystem.VerifyEventInMerkleTree(event), nil
 }
 
-// GetMerkleProof returns the Merkle proof for an event
-func (api *API) GetMerkleProof(event TraceEvent) ([]string, bool, error) {
+// TraceProof is a Merkle proof of a trace event. Proofs of consensus trace
+// events lead to the trace root committed to by the block with the given hash,
+// proofs of local trace events to the root of the node's in-memory tree.
+type TraceProof struct {
+	Number uint64        `json:"number"`
+	Hash   common.Hash   `json:"hash,omitempty"`
+	Root   common.Hash   `json:"root"`
+	Leaf   common.Hash   `json:"leaf"`
+	Index  int           `json:"index"` // Position of the leaf among the sorted leaves
+	Proof  []common.Hash `json:"proof"`
+}
+
+// Verify reports whether the proof leads from its leaf to its root.
+func (p *TraceProof) Verify() bool {
+	return verifyMerkleProof(p.Leaf, p.Index, p.Proof, p.Root)
+}
+
+// GetMerkleProof returns the Merkle proof for an event. If the chain commits
+// trace roots and the event is one of its block's consensus events, the proof
+// is anchored in the block header, otherwise the local tree is used.
+func (api *API) GetMerkleProof(event TraceEvent) (*TraceProof, error) {
+	if api.poatc.traceRoots && event.BlockNumber > 0 {
+		trace, err := api.blockTrace(event.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		leaf := traceEventHash(event)
+		leaves := make([]common.Hash, len(trace.Events))
+		for i, event := range trace.Events {
+			leaves[i] = event.Hash
+		}
+		for i := range leaves {
+			if leaves[i] == leaf {
+				proof, index := merkleProof(i, leaves)
+				return &TraceProof{Number: trace.Number, Hash: trace.Hash, Root: trace.Root, Leaf: leaf, Index: index, Proof: proof}, nil
+			}
+		}
+	}
 	if api.poatc.tracingSystem == nil {
-		return nil, false, fmt.Errorf("tracing system not enabled")
+		return nil, fmt.Errorf("tracing system not enabled")
 	}
-
-	proof, found := api.poatc.tracingSystem.GetMerkleProof(event)
+	proof, index, root, found := api.poatc.tracingSystem.merkleProofOf(event)
 	if !found {
-		return nil, false, nil
+		return nil, fmt.Errorf("trace event not found")
 	}
+	return &TraceProof{Number: event.BlockNumber, Root: root, Leaf: traceEventHash(event), Index: index, Proof: proof}, nil
+}
 
-	// Convert proof to string array
-	proofStrings := make([]string, len(proof))
-	for i, hash := range proof {
-		proofStrings[i] = hash.Hex()
+// BlockTrace is the consensus trace of a block together with the Merkle root
+// the block commits to in its header.
+type BlockTrace struct {
+	Number uint64       `json:"number"`
+	Hash   common.Hash  `json:"hash"`
+	Root   common.Hash  `json:"root"`
+	Events []TraceEvent `json:"events"`
+}
+
+// GetBlockTrace returns the consensus trace events of the specified block (or
+// the current head if none requested) and the trace root it commits to. The
+// events are rederived from the chain, so they are identical on every node.
+func (api *API) GetBlockTrace(number *rpc.BlockNumber) (*BlockTrace, error) {
+	if number == nil || *number == rpc.LatestBlockNumber {
+		return api.blockTrace(api.chain.CurrentHeader().Number.Uint64())
 	}
+	return api.blockTrace(uint64(number.Int64()))
+}
 
-	return proofStrings, true, nil
+// blockTrace rederives the consensus trace events of a canonical block.
+func (api *API) blockTrace(number uint64) (*BlockTrace, error) {
+	if !api.poatc.traceRoots {
+		return nil, fmt.Errorf("trace roots are not committed on chain")
+	}
+	if number == 0 {
+		return nil, fmt.Errorf("genesis block has no trace")
+	}
+	header := api.chain.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.poatc.snapshot(api.chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ecrecover(header, api.poatc.signatures)
+	if err != nil {
+		return nil, err
+	}
+	var evidence *Evidence
+	if snap.slashing != nil {
+		if evidence, err = snap.slashing.evidence(snap.config, header); err != nil {
+			return nil, err
+		}
+	}
+	return &BlockTrace{
+		Number: number,
+		Hash:   header.Hash(),
+		Root:   traceRoot(header),
+		Events: blockTraceEvents(snap, header, signer, evidence),
+	}, nil
 }
 
 // ExportTraceEvents exports all trace events and Merkle Tree to JSON
@@ -853,42 +1072,8 @@ func (api *API) ExportTraceEvents() (string, error) {
 	return string(data), nil
 }
 
-// ClearTraceEvents clears all trace events and Merkle Tree
-func (api *API) ClearTraceEvents() error {
-	if api.poatc.tracingSystem == nil {
-		return fmt.Errorf("tracing system not enabled")
-	}
-
-	api.poatc.tracingSystem.ClearTraceEvents()
-	return nil
-}
-
-// SetTraceLevel sets the trace level
-func (api *API) SetTraceLevel(level int) error {
-	if api.poatc.tracingSystem == nil {
-		return fmt.Errorf("tracing system not enabled")
-	}
-
-	if level < 0 || level > 3 {
-		return fmt.Errorf("invalid trace level: %d (must be 0-3)", level)
-	}
-
-	api.poatc.tracingSystem.SetTraceLevel(TraceLevel(level))
-	return nil
-}
-
-// EnableTracing enables or disables tracing
-func (api *API) EnableTracing(enable bool) error {
-	if api.poatc.tracingSystem == nil {
-		return fmt.Errorf("tracing system not enabled")
-	}
-
-	api.poatc.tracingSystem.EnableTracing(enable)
-	return nil
-}
-
 // GetTraceMetrics returns current trace metrics
-func (api *API) GetTraceMetrics() (map[string]interface{}, error) {
+func (api *API) GetTraceMetrics() (*TraceMetrics, error) {
 	if api.poatc.tracingSystem == nil {
 		return nil, fmt.Errorf("tracing system not enabled")
 	}
@@ -899,7 +1084,7 @@ func (api *API) GetTraceMetrics() (map[string]interface{}, error) {
 // ===== Time Dynamic System API =====
 
 // GetTimeDynamicStats returns statistics about time dynamic mechanisms
-func (api *API) GetTimeDynamicStats() (map[string]interface{}, error) {
+func (api *API) GetTimeDynamicStats() (*TimeDynamicStats, error) {
 	if api.poatc.timeDynamicManager == nil {
 		return nil, fmt.Errorf("time dynamic system not enabled")
 	}
@@ -914,33 +1099,6 @@ func (api *API) GetCurrentBlockTime() (float64, error) {
 	return api.poatc.timeDynamicManager.GetCurrentBlockTime().Seconds(), nil
 }
 
-// UpdateTransactionCount manually updates transaction count for testing
-func (api *API) UpdateTransactionCount(txCount int) error {
-	if api.poatc.timeDynamicManager == nil {
-		return fmt.Errorf("time dynamic system not enabled")
-	}
-	api.poatc.timeDynamicManager.UpdateTransactionCount(txCount)
-	return nil
-}
-
-// TriggerValidatorSelection manually triggers validator selection update
-func (api *API) TriggerValidatorSelection(blockNumber uint64, blockHash string) error {
-	if api.poatc.timeDynamicManager == nil {
-		return fmt.Errorf("time dynamic system not enabled")
-	}
-
-	hash := common.HexToHash(blockHash)
-	return api.poatc.timeDynamicManager.UpdateValidatorSelection(blockNumber, hash)
-}
-
-// TriggerReputationDecay manually triggers reputation decay
-func (api *API) TriggerReputationDecay() error {
-	if api.poatc.timeDynamicManager == nil {
-		return fmt.Errorf("time dynamic system not enabled")
-	}
-	return api.poatc.timeDynamicManager.ApplyReputationDecay()
-}
-
 // GetDecayHistory returns the recent decay history
 func (api *API) GetDecayHistory(limit int) ([]DecayRecord, error) {
 	if api.poatc.timeDynamicManager == nil {
@@ -949,15 +1107,6 @@ func (api *API) GetDecayHistory(limit int) ([]DecayRecord, error) {
 	return api.poatc.timeDynamicManager.GetDecayHistory(limit), nil
 }
 
-// UpdateTimeDynamicConfig updates the time dynamic configuration
-func (api *API) UpdateTimeDynamicConfig(config *TimeDynamicConfig) error {
-	if api.poatc.timeDynamicManager == nil {
-		return fmt.Errorf("time dynamic system not enabled")
-	}
-	api.poatc.timeDynamicManager.UpdateConfig(config)
-	return nil
-}
-
 // GetTimeDynamicConfig returns the current time dynamic configuration
 func (api *API) GetTimeDynamicConfig() (*TimeDynamicConfig, error) {
 	if api.poatc.timeDynamicManager == nil {
@@ -0,0 +1,41 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// orSystemClock returns the given clock, or the system clock if none is given.
+func orSystemClock(clock mclock.Clock) mclock.Clock {
+	if clock == nil {
+		return mclock.System{}
+	}
+	return clock
+}
+
+// wallTime returns the wall time read off a clock. The system clock reads the
+// current time. Any other clock, such as a simulated one, is taken to count the
+// time elapsed since the Unix epoch, so it can be started at any point in time.
+func wallTime(clock mclock.Clock) time.Time {
+	if _, ok := clock.(mclock.System); ok {
+		return time.Now()
+	}
+	return time.Unix(0, int64(clock.Now()))
+}
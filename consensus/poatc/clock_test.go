@@ -0,0 +1,57 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that simulated clocks read the time since the Unix epoch, while the
+// system clock reads the current time.
+func TestWallTime(t *testing.T) {
+	clock := new(mclock.Simulated)
+	clock.Run(1_700_000_000 * time.Second)
+	if have, want := wallTime(clock), time.Unix(1_700_000_000, 0); !have.Equal(want) {
+		t.Errorf("simulated time mismatch: have %v, want %v", have, want)
+	}
+	before := time.Now()
+	if have := wallTime(orSystemClock(nil)); have.Before(before) || have.After(time.Now()) {
+		t.Errorf("system time %v out of range", have)
+	}
+}
+
+// Tests that the engine verifies and prepares blocks against its own clock.
+func TestEngineClock(t *testing.T) {
+	engine := New(&params.CliqueConfig{Period: 5, Epoch: 30000}, nil)
+
+	clock := new(mclock.Simulated)
+	clock.Run(1000 * time.Second)
+	engine.SetClock(clock)
+
+	header := &types.Header{Number: big.NewInt(1), Time: 1001}
+	if err := engine.verifyHeader(nil, header, nil); !errors.Is(err, consensus.ErrFutureBlock) {
+		t.Errorf("future block error mismatch: have %v, want %v", err, consensus.ErrFutureBlock)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// This file translates the genesis "poatc" chain config section into the
+// configuration structs of the individual subsystems. Every conversion starts
+// from the subsystem defaults and only overrides what the chain config sets,
+// so partially specified genesis files keep working.
+
+// anomalyDetectionConfig returns the anomaly detection config for the chain.
+func anomalyDetectionConfig(cfg *params.PoatcConfig) *AnomalyDetectionConfig {
+	config := DefaultAnomalyDetectionConfig()
+	if cfg == nil || cfg.AnomalyDetection == nil {
+		return config
+	}
+	ac := cfg.AnomalyDetection
+
+	config.EnableRapidSigning = ac.EnableRapidSigningDetection
+	config.EnableSuspiciousPattern = ac.EnableSuspiciousPatternDetection
+	config.EnableTimestampDrift = ac.EnableTimestampDriftDetection
+	if ac.MaxBlocksPerSigner > 0 {
+		config.MaxBlocksPerSigner = ac.MaxBlocksPerSigner
+	}
+	if ac.ConsecutiveBlockThreshold > 0 {
+		config.SuspiciousThreshold = ac.ConsecutiveBlockThreshold
+	}
+	if ac.TimestampDriftThreshold > 0 {
+		config.MaxTimestampDrift = ac.TimestampDriftThreshold
+	}
+	if ac.AlertThreshold > 0 {
+		config.MaxSignerFrequency = ac.AlertThreshold
+	}
+	config.AnalysisWindow = params.PoatcDuration(ac.EvaluationWindow, config.AnalysisWindow)
+	if len(ac.Detectors) > 0 {
+		config.Detectors = make(map[string]DetectorConfig, len(ac.Detectors))
+		for name, detector := range ac.Detectors {
+			config.Detectors[name] = DetectorConfig{Enabled: detector.Enabled, Settings: detector.Settings}
+		}
+	}
+	return config
+}
+
+// whitelistBlacklistConfig returns the whitelist/blacklist config for the chain.
+func whitelistBlacklistConfig(cfg *params.PoatcConfig) *WhitelistBlacklistConfig {
+	config := DefaultWhitelistBlacklistConfig()
+	if cfg == nil {
+		return config
+	}
+	config.EnableWhitelist = cfg.EnableWhitelistBlacklist
+	config.EnableBlacklist = cfg.EnableWhitelistBlacklist
+
+	wc := cfg.WhitelistBlacklist
+	if wc == nil {
+		return config
+	}
+	config.WhitelistMode = wc.EnableStrictMode
+	if !wc.EnablePersistence {
+		config.PersistencePath = ""
+	}
+	if wc.EnableExpiration {
+		config.DefaultExpiration = params.PoatcDuration(wc.DefaultExpiration, config.DefaultExpiration)
+	} else {
+		config.DefaultExpiration = 0
+	}
+	if wc.MaxEntries > 0 {
+		config.MaxEntries = wc.MaxEntries
+	}
+	if wc.ProbationBlocks > 0 {
+		config.ProbationBlocks = wc.ProbationBlocks
+	}
+	if wc.WarningBlocks > 0 {
+		config.WarningBlocks = wc.WarningBlocks
+	}
+	if wc.SuspensionBlocks > 0 {
+		config.SuspensionBlocks = wc.SuspensionBlocks
+	}
+	if wc.MaxSuspensions > 0 {
+		config.MaxSuspensions = wc.MaxSuspensions
+	}
+	if wc.HysteresisBand > 0 {
+		config.HysteresisBand = wc.HysteresisBand
+	}
+	return config
+}
+
+// listRules returns the voted whitelist/blacklist rules for the chain, or nil if
+// the chain config doesn't opt into list governance.
+func listRules(cfg *params.PoatcConfig, clique *params.CliqueConfig) *ListRules {
+	if cfg == nil || !cfg.EnableWhitelistBlacklist {
+		return nil
+	}
+	rules := new(ListRules)
+
+	wc := cfg.WhitelistBlacklist
+	if wc == nil {
+		return rules
+	}
+	rules.StrictWhitelist = wc.EnableStrictMode
+	rules.VoteExpiry = wc.VoteExpiry
+	if wc.EnableExpiration {
+		switch {
+		case wc.EntryExpiry > 0:
+			rules.EntryExpiry = wc.EntryExpiry
+		case wc.DefaultExpiration != "" && clique.Period > 0:
+			// Wall-clock lifetime, convert it using the block period
+			lifetime := params.PoatcDuration(wc.DefaultExpiration, 0)
+			rules.EntryExpiry = uint64(lifetime / (time.Duration(clique.Period) * time.Second))
+		}
+	}
+	return rules
+}
+
+// validatorSelectionConfig returns the validator selection config for the chain.
+// The selection interval is counted in blocks and always divides the epoch
+// length, so checkpoint snapshots fall on election boundaries.
+func validatorSelectionConfig(cfg *params.PoatcConfig, clique *params.CliqueConfig) *ValidatorSelectionConfig {
+	config := DefaultValidatorSelectionConfig()
+	if cfg != nil && cfg.ValidatorSelection != nil {
+		vc := cfg.ValidatorSelection
+
+		config.EnableValidatorSelection = vc.EnableValidatorSelection
+		if vc.SelectionMethod != "" {
+			config.SelectionMethod = vc.SelectionMethod
+		}
+		if vc.SmallValidatorSetSize > 0 {
+			config.SmallValidatorSetSize = vc.SmallValidatorSetSize
+		}
+		switch {
+		case vc.ElectionInterval > 0:
+			config.SelectionInterval = vc.ElectionInterval
+		case vc.SelectionWindow != "" && clique.Period > 0:
+			// Legacy wall-clock window, convert it using the block period
+			window := params.PoatcDuration(vc.SelectionWindow, 0)
+			if blocks := uint64(window / (time.Duration(clique.Period) * time.Second)); blocks > 0 {
+				config.SelectionInterval = blocks
+			}
+		}
+		config.StakeWeight = vc.StakeWeight
+		config.ReputationWeight = vc.ReputationWeight
+		config.RandomWeight = vc.RandomWeight
+	}
+	if clique.Epoch%config.SelectionInterval != 0 {
+		interval := gcd(clique.Epoch, config.SelectionInterval)
+		log.Warn("Selection interval does not divide epoch length, adjusting", "interval", config.SelectionInterval, "epoch", clique.Epoch, "adjusted", interval)
+		config.SelectionInterval = interval
+	}
+	return config
+}
+
+// gcd returns the greatest common divisor of a and b.
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// reputationConfig returns the reputation system config for the chain.
+func reputationConfig(cfg *params.PoatcConfig) *ReputationConfig {
+	config := DefaultReputationConfig()
+	if cfg == nil || cfg.Reputation == nil {
+		return config
+	}
+	rc := cfg.Reputation
+
+	config.EnableReputationSystem = rc.EnableReputationSystem
+	if rc.InitialReputation > 0 {
+		config.InitialReputation = rc.InitialReputation
+	}
+	if rc.MaxReputation > 0 {
+		config.MaxReputation = rc.MaxReputation
+	}
+	if rc.MinReputation > 0 {
+		config.MinReputation = rc.MinReputation
+	}
+	config.BlockMiningWeight = rc.BlockMiningWeight
+	config.UptimeWeight = rc.UptimeWeight
+	config.ConsistencyWeight = rc.ConsistencyWeight
+	config.PenaltyWeight = rc.PenaltyWeight
+	if rc.DecayRate > 0 {
+		config.DecayFactor = 1 - rc.DecayRate
+	}
+	config.UpdateInterval = params.PoatcDuration(rc.UpdateInterval, config.UpdateInterval)
+	config.EvaluationWindow = params.PoatcDuration(rc.EvaluationWindow, config.EvaluationWindow)
+	if rc.HighReputationThreshold > 0 {
+		config.HighReputationThreshold = rc.HighReputationThreshold
+	}
+	if rc.LowReputationThreshold > 0 {
+		config.LowReputationThreshold = rc.LowReputationThreshold
+	}
+	return config
+}
+
+// tracingConfig returns the tracing system config for the chain.
+func tracingConfig(cfg *params.PoatcConfig) *TracingConfig {
+	config := DefaultTracingConfig()
+	if cfg == nil || cfg.Tracing == nil {
+		return config
+	}
+	tc := cfg.Tracing
+
+	config.EnableTracing = tc.EnableTracing
+	config.TraceLevel = TraceLevel(tc.TraceLevel)
+	if tc.MaxTraceEvents > 0 {
+		config.MaxTraceEvents = tc.MaxTraceEvents
+	}
+	config.TraceRetention = params.PoatcDuration(tc.TraceRetention, config.TraceRetention)
+	config.EnableMerkleTree = tc.EnableMerkleTree
+	config.EnableMetrics = tc.EnableMetrics
+	config.EnablePersistence = tc.EnablePersistence
+	config.MerkleRootInBlock = tc.MerkleRootInBlock
+	return config
+}
+
+// timeDynamicConfig returns the time dynamic config for the chain.
+func timeDynamicConfig(cfg *params.PoatcConfig) *TimeDynamicConfig {
+	config := DefaultTimeDynamicConfig()
+	if cfg == nil || cfg.TimeDynamic == nil {
+		return config
+	}
+	tc := cfg.TimeDynamic
+
+	config.EnableDynamicBlockTime = tc.EnableDynamicBlockTime
+	config.EnableDynamicValidatorSelection = tc.EnableDynamicValidatorSelection
+	config.EnableDynamicReputationDecay = tc.EnableDynamicReputationDecay
+	if tc.BaseBlockTime > 0 {
+		config.BaseBlockTime = time.Duration(tc.BaseBlockTime) * time.Second
+	}
+	if tc.MinBlockTime > 0 {
+		config.MinBlockTime = time.Duration(tc.MinBlockTime) * time.Second
+	}
+	if tc.MaxBlockTime > 0 {
+		config.MaxBlockTime = time.Duration(tc.MaxBlockTime) * time.Second
+	}
+	if tc.TxVolumeThreshold > 0 {
+		config.TxThresholdHigh = tc.TxVolumeThreshold
+	}
+	if tc.TxLowThreshold > 0 {
+		config.TxThresholdLow = tc.TxLowThreshold
+	}
+	config.ValidatorSelectionInterval = params.PoatcDuration(tc.SelectionInterval, config.ValidatorSelectionInterval)
+	config.ReputationUpdateInterval = params.PoatcDuration(tc.DecayInterval, config.ReputationUpdateInterval)
+	if tc.DecayRate > 0 {
+		config.ReputationDecayRate = tc.DecayRate
+	}
+	return config
+}
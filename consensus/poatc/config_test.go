@@ -0,0 +1,109 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the poatc section of the shipped testnet genesis is decoded into
+// the chain config and used to tune every subsystem instead of the defaults.
+func TestGenesisPoatcConfig(t *testing.T) {
+	blob, err := os.ReadFile("../../Testnet/poatc-genesis.json")
+	if err != nil {
+		t.Fatalf("failed to read genesis: %v", err)
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(blob, genesis); err != nil {
+		t.Fatalf("failed to decode genesis: %v", err)
+	}
+	config := genesis.Config.Poatc
+	if config == nil {
+		t.Fatal("poatc section dropped from chain config")
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("testnet poatc config invalid: %v", err)
+	}
+	engine := NewWithConfig(genesis.Config.Clique, config, rawdb.NewMemoryDatabase())
+
+	signers := []common.Address{{0x1}, {0x2}}
+	engine.initializeAnomalyDetector(signers)
+	engine.initializeValidatorSelectionManager(newSnapshot(engine.config, engine.signatures, 0, common.Hash{}, signers))
+	engine.initializeReputationSystem(signers)
+	engine.initializeTracingSystem()
+	engine.initializeTimeDynamicManager()
+
+	if have := engine.anomalyDetector.config.MaxBlocksPerSigner; have != 3 {
+		t.Errorf("anomaly max blocks per signer mismatch: have %d, want 3", have)
+	}
+	if have := engine.anomalyDetector.config.SuspiciousThreshold; have != 3 {
+		t.Errorf("anomaly consecutive threshold mismatch: have %d, want 3", have)
+	}
+	if have := engine.validatorSelectionManager.config.SmallValidatorSetSize; have != 5 {
+		t.Errorf("small validator set size mismatch: have %d, want 5", have)
+	}
+	if have := engine.validatorSelectionManager.config.ReputationWeight; have != 0.6 {
+		t.Errorf("reputation weight mismatch: have %v, want 0.6", have)
+	}
+	if have := engine.selectionConfig.SelectionInterval; have != 600 {
+		t.Errorf("selection interval mismatch: have %d, want 600", have)
+	}
+	if have := engine.reputationSystem.config.HighReputationThreshold; have != 8.0 {
+		t.Errorf("high reputation threshold mismatch: have %v, want 8", have)
+	}
+	if have := engine.reputationSystem.config.DecayFactor; have != 0.95 {
+		t.Errorf("decay factor mismatch: have %v, want 0.95", have)
+	}
+	if have := engine.timeDynamicManager.config.MaxBlockTime; have != time.Minute {
+		t.Errorf("max block time mismatch: have %v, want 1m", have)
+	}
+	if have := engine.tracingSystem.config.TraceRetention; have != 24*time.Hour {
+		t.Errorf("trace retention mismatch: have %v, want 24h", have)
+	}
+}
+
+// Tests that disabling a subsystem in the chain config keeps it uninitialized.
+func TestGenesisPoatcConfigDisabled(t *testing.T) {
+	genesis := new(core.Genesis)
+	blob, err := os.ReadFile("../../Testnet/poatc-genesis.json")
+	if err != nil {
+		t.Fatalf("failed to read genesis: %v", err)
+	}
+	if err := json.Unmarshal(blob, genesis); err != nil {
+		t.Fatalf("failed to decode genesis: %v", err)
+	}
+	genesis.Config.Poatc.EnableAnomalyDetection = false
+	genesis.Config.Poatc.EnableTimeDynamic = false
+
+	engine := NewWithConfig(genesis.Config.Clique, genesis.Config.Poatc, rawdb.NewMemoryDatabase())
+	engine.initializeAnomalyDetector([]common.Address{{0x1}})
+	engine.initializeTimeDynamicManager()
+
+	if engine.anomalyDetector != nil {
+		t.Error("anomaly detector initialized although disabled")
+	}
+	if engine.timeDynamicManager != nil {
+		t.Error("time dynamic manager initialized although disabled")
+	}
+}
@@ -67,7 +67,7 @@ func TestConsecutiveBlockPrevention(t *testing.T) {
 func TestDynamicBlockTimeMinimumDelay(t *testing.T) {
 	config := DefaultTimeDynamicConfig()
 	config.MinBlockTime = 3 * time.Second  // Very fast for testing
-	tdm := NewTimeDynamicManager(config)
+	tdm := NewTimeDynamicManager(config, nil)
 
 	// Simulate high transaction volume to get very fast block time
 	for i := 0; i < 5; i++ {
@@ -0,0 +1,254 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Inspector reads the POATC state persisted in the database of a stopped node.
+// Unlike the engine it never writes to the database nor starts any subsystem,
+// so it's safe to use on a read-only database, e.g. when the node won't start.
+type Inspector struct {
+	engine *POATC
+	db     ethdb.Database
+}
+
+// NewInspector creates an inspector of the POATC state of a chain.
+func NewInspector(config *params.ChainConfig, db ethdb.Database) (*Inspector, error) {
+	if config.Clique == nil {
+		return nil, errors.New("chain is not a proof-of-authority chain")
+	}
+	return &Inspector{
+		engine: NewWithConfig(config.Clique, config.Poatc, db),
+		db:     db,
+	}, nil
+}
+
+// header retrieves the canonical header with the given number.
+func (in *Inspector) header(number uint64) (*types.Header, error) {
+	hash := rawdb.ReadCanonicalHash(in.db, number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("%w #%d", errUnknownBlock, number)
+	}
+	header := rawdb.ReadHeader(in.db, hash, number)
+	if header == nil {
+		return nil, fmt.Errorf("%w #%d", errUnknownBlock, number)
+	}
+	return header, nil
+}
+
+// Snapshot returns the voting snapshot after the canonical block with the given
+// number. It's rebuilt on top of the closest checkpoint stored at or below the
+// block, or the genesis block if there is none.
+func (in *Inspector) Snapshot(number uint64) (*Snapshot, error) {
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+	)
+	for snap == nil {
+		header, err := in.header(number)
+		if err != nil {
+			return nil, err
+		}
+		if number%checkpointInterval == 0 {
+			if s, err := loadSnapshot(in.engine.config, in.engine.signatures, in.db, header.Hash()); err == nil {
+				snap = s
+				in.engine.configureSnapshot(snap)
+				break
+			}
+		}
+		if number == 0 {
+			snap = in.engine.checkpointSnapshot(header)
+			break
+		}
+		headers = append(headers, header)
+		number--
+	}
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	return snap.apply(headers)
+}
+
+// Checkpoints returns the checkpoint snapshots stored in the database, ordered
+// by block number.
+func (in *Inspector) Checkpoints() ([]*Snapshot, error) {
+	it := in.db.NewIterator(rawdb.CliqueSnapshotPrefix, nil)
+	defer it.Release()
+
+	var snaps []*Snapshot
+	for it.Next() {
+		if len(it.Key()) != len(rawdb.CliqueSnapshotPrefix)+common.HashLength {
+			continue
+		}
+		snap := new(Snapshot)
+		if err := json.Unmarshal(it.Value(), snap); err != nil {
+			return nil, fmt.Errorf("invalid snapshot %x: %v", it.Key()[len(rawdb.CliqueSnapshotPrefix):], err)
+		}
+		snaps = append(snaps, snap)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		if snaps[i].Number != snaps[j].Number {
+			return snaps[i].Number < snaps[j].Number
+		}
+		return bytes.Compare(snaps[i].Hash[:], snaps[j].Hash[:]) < 0
+	})
+	return snaps, nil
+}
+
+// ReputationScores returns the local reputation scores last saved by the
+// reputation system, empty if it never saved any.
+func (in *Inspector) ReputationScores() (map[common.Address]*ReputationScore, error) {
+	scores := make(map[common.Address]*ReputationScore)
+	if blob, err := in.db.Get(rawdb.ReputationScoresKey); err == nil {
+		if err := json.Unmarshal(blob, &scores); err != nil {
+			return nil, fmt.Errorf("invalid reputation scores: %v", err)
+		}
+	}
+	return scores, nil
+}
+
+// ReputationEvents returns the recent reputation events last saved by the
+// reputation system, oldest first.
+func (in *Inspector) ReputationEvents() ([]ReputationEvent, error) {
+	var events []ReputationEvent
+	if blob, err := in.db.Get(rawdb.ReputationEventsKey); err == nil {
+		if err := json.Unmarshal(blob, &events); err != nil {
+			return nil, fmt.Errorf("invalid reputation events: %v", err)
+		}
+	}
+	return events, nil
+}
+
+// TraceEvents returns the persisted trace events of the given type (all if
+// empty) matching the filter, ordered by block number.
+func (in *Inspector) TraceEvents(eventType TraceEventType, filter *TraceFilter) []TraceEvent {
+	q := &traceQuery{Type: eventType}
+	if filter != nil {
+		q.TraceFilter = *filter
+	}
+	return newTraceStore(in.db, 0, nil).query(q)
+}
+
+// ReplayAnomalies feeds the canonical blocks in the given range to a fresh
+// anomaly detector, the way the engine does while importing them, and returns
+// the anomalies detected. A nil config uses the one of the chain.
+func (in *Inspector) ReplayAnomalies(from, to uint64, config *AnomalyDetectionConfig) ([]AnomalyResult, error) {
+	if config == nil {
+		config = anomalyDetectionConfig(in.engine.poatcConfig)
+	}
+	if from == 0 {
+		from = 1 // The genesis block isn't sealed
+	}
+	snap, err := in.Snapshot(from - 1)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := in.header(from - 1)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		detector  = NewAnomalyDetector(config, snap.signers())
+		anomalies []AnomalyResult
+	)
+	for number := from; number <= to; number++ {
+		header, err := in.header(number)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ecrecover(header, in.engine.signatures)
+		if err != nil {
+			return nil, err
+		}
+		record := newBlockRecord(header, signer)
+		if body := rawdb.ReadBody(in.db, header.Hash(), number); body != nil {
+			record.TxCount = len(body.Transactions)
+		}
+		record.Delay = time.Duration(header.Time-parent.Time) * time.Second
+
+		detector.UpdateSigners(snap.signers())
+		detector.AddRecord(record)
+		anomalies = append(anomalies, detector.DetectAnomalies()...)
+
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			return nil, err
+		}
+		parent = header
+	}
+	return anomalies, nil
+}
+
+// SignerReport returns the sealing activity of every signer between the given
+// canonical blocks (inclusive).
+func (in *Inspector) SignerReport(from, to uint64) (*SignerReport, error) {
+	if from == 0 {
+		from = 1 // The genesis block isn't sealed
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	snap, err := in.Snapshot(from - 1)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := in.header(from - 1)
+	if err != nil {
+		return nil, err
+	}
+	return buildSignerReport(snap, parent, in.header, from, to, in.engine.reportAnomalyConfig())
+}
+
+// VerifyProof checks a Merkle proof of a trace event. Proofs anchored in a block
+// must also lead to the trace root the canonical block commits to.
+func (in *Inspector) VerifyProof(proof *TraceProof) error {
+	if !proof.Verify() {
+		return errors.New("proof does not lead to its root")
+	}
+	if proof.Hash == (common.Hash{}) {
+		return nil
+	}
+	if !in.engine.traceRoots {
+		return errors.New("trace roots are not committed on chain")
+	}
+	header, err := in.header(proof.Number)
+	if err != nil {
+		return err
+	}
+	if header.Hash() != proof.Hash {
+		return fmt.Errorf("block #%d hash mismatch: have %x, want %x", proof.Number, header.Hash(), proof.Hash)
+	}
+	if root := traceRoot(header); root != proof.Root {
+		return fmt.Errorf("%w: block #%d commits to %x, proof to %x", errMismatchingTraceRoot, proof.Number, root, proof.Root)
+	}
+	return nil
+}
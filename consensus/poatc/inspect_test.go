@@ -0,0 +1,177 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newInspectedChain imports a chain of the given length sealed by a single
+// signer and returns the database it was imported into.
+func newInspectedChain(t *testing.T, length int) (ethdb.Database, *params.ChainConfig, common.Address) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		engine = New(params.AllCliqueProtocolChanges.Clique, db)
+	)
+	genspec := &core.Genesis{
+		Config:    params.AllCliqueProtocolChanges,
+		ExtraData: make([]byte, extraVanity+common.AddressLength+extraSeal),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	copy(genspec.ExtraData[extraVanity:], addr[:])
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genspec, engine, length, func(i int, block *core.BlockGen) {
+		block.SetDifficulty(diffInTurn)
+	})
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Extra = make([]byte, extraVanity+extraSeal)
+		header.Difficulty = diffInTurn
+
+		sig, _ := crypto.Sign(SealHash(header).Bytes(), key)
+		copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+		blocks[i] = block.WithSeal(header)
+	}
+	chain, err := core.NewBlockChain(db, nil, genspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	return db, genspec.Config, addr
+}
+
+// Tests that the inspector rebuilds snapshots and replays blocks from the
+// database of a node without writing to it.
+func TestInspectorSnapshots(t *testing.T) {
+	db, config, signer := newInspectedChain(t, 5)
+
+	inspector, err := NewInspector(config, db)
+	if err != nil {
+		t.Fatalf("failed to create inspector: %v", err)
+	}
+	checkpoints, err := inspector.Checkpoints()
+	if err != nil {
+		t.Fatalf("failed to list checkpoints: %v", err)
+	}
+	if len(checkpoints) != 1 || checkpoints[0].Number != 0 {
+		t.Fatalf("checkpoints mismatch: have %d, want genesis", len(checkpoints))
+	}
+	snap, err := inspector.Snapshot(5)
+	if err != nil {
+		t.Fatalf("failed to rebuild snapshot: %v", err)
+	}
+	if snap.Number != 5 || snap.Hash != rawdb.ReadCanonicalHash(db, 5) {
+		t.Errorf("snapshot mismatch: have #%d [%x]", snap.Number, snap.Hash)
+	}
+	if _, ok := snap.Signers[signer]; !ok || len(snap.Signers) != 1 {
+		t.Errorf("snapshot signers mismatch: have %v, want [%x]", snap.Signers, signer)
+	}
+	if _, err := inspector.Snapshot(6); err == nil {
+		t.Errorf("snapshot of unknown block rebuilt")
+	}
+	if _, err := inspector.ReplayAnomalies(1, 5, nil); err != nil {
+		t.Errorf("failed to replay blocks: %v", err)
+	}
+	if _, err := inspector.ReplayAnomalies(1, 6, nil); err == nil {
+		t.Errorf("unknown blocks replayed")
+	}
+	if snaps, _ := inspector.Checkpoints(); len(snaps) != 1 {
+		t.Errorf("inspection stored %d checkpoints", len(snaps)-1)
+	}
+}
+
+// Tests that the inspector reads the reputation data saved by the reputation
+// system.
+func TestInspectorReputation(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	inspector, err := NewInspector(params.AllCliqueProtocolChanges, db)
+	if err != nil {
+		t.Fatalf("failed to create inspector: %v", err)
+	}
+	if scores, err := inspector.ReputationScores(); err != nil || len(scores) != 0 {
+		t.Fatalf("scores of empty database mismatch: have %v, %v", scores, err)
+	}
+	validator := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	reputation := NewReputationSystem(DefaultReputationConfig(), db, nil)
+	reputation.AddValidator(validator)
+	reputation.RecordViolation(validator, 1, "test", "inspected violation")
+	reputation.saveToDatabase()
+
+	scores, err := inspector.ReputationScores()
+	if err != nil {
+		t.Fatalf("failed to read scores: %v", err)
+	}
+	if score := scores[validator]; score == nil || score.ViolationCount != 1 {
+		t.Errorf("score mismatch: have %+v", score)
+	}
+	events, err := inspector.ReputationEvents()
+	if err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+	if len(events) == 0 || events[len(events)-1].Description == "" {
+		t.Errorf("events mismatch: have %+v", events)
+	}
+}
+
+// Tests that proof files produced by the API are verified offline.
+func TestInspectorVerifyProof(t *testing.T) {
+	inspector, err := NewInspector(params.AllCliqueProtocolChanges, rawdb.NewMemoryDatabase())
+	if err != nil {
+		t.Fatalf("failed to create inspector: %v", err)
+	}
+	leaves := []common.Hash{{0x01}, {0x02}, {0x03}}
+	proof, index := merkleProof(1, leaves)
+
+	blob, _ := json.Marshal(&TraceProof{Root: merkleRoot(leaves), Leaf: leaves[1], Index: index, Proof: proof})
+	valid := new(TraceProof)
+	if err := json.Unmarshal(blob, valid); err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	if err := inspector.VerifyProof(valid); err != nil {
+		t.Errorf("valid proof rejected: %v", err)
+	}
+	invalid := *valid
+	invalid.Leaf = common.Hash{0x04}
+	if err := inspector.VerifyProof(&invalid); err == nil {
+		t.Errorf("proof of foreign leaf accepted")
+	}
+	anchored := *valid
+	anchored.Hash = common.Hash{0x05}
+	if err := inspector.VerifyProof(&anchored); err == nil {
+		t.Errorf("proof anchored in unknown block accepted")
+	}
+}
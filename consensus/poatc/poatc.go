@@ -19,6 +19,7 @@ package poatc
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -31,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	lru "github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
@@ -38,6 +40,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -64,6 +67,11 @@ var (
 	nonceAuthVote = hexutil.MustDecode("0xffffffffffffffff") // Magic nonce number to vote on adding a new signer
 	nonceDropVote = hexutil.MustDecode("0x0000000000000000") // Magic nonce number to vote on removing a signer.
 
+	nonceWhitelistAdd    = hexutil.MustDecode("0x574c000000000001") // Magic nonce number to vote on whitelisting an address
+	nonceWhitelistRemove = hexutil.MustDecode("0x574c000000000000") // Magic nonce number to vote on unwhitelisting an address
+	nonceBlacklistAdd    = hexutil.MustDecode("0x424c000000000001") // Magic nonce number to vote on blacklisting an address
+	nonceBlacklistRemove = hexutil.MustDecode("0x424c000000000000") // Magic nonce number to vote on unblacklisting an address
+
 	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
 
 	diffInTurn = big.NewInt(2) // Block difficulty for in-turn signatures
@@ -111,6 +119,42 @@ var (
 	// list of signers different than the one the local node calculated.
 	errMismatchingCheckpointSigners = errors.New("mismatching signer list on checkpoint block")
 
+	// errMismatchingCheckpointReputation is returned if a checkpoint block commits
+	// to signer reputation scores different than the ones the local node calculated.
+	errMismatchingCheckpointReputation = errors.New("mismatching reputation scores on checkpoint block")
+
+	// errMismatchingCheckpointLiveness is returned if a checkpoint block commits
+	// to a missed slot accounting different from the one of the snapshot.
+	errMismatchingCheckpointLiveness = errors.New("mismatching liveness on checkpoint block")
+
+	// errMismatchingCheckpointStakes is returned if a checkpoint block commits to
+	// signer stakes different than the ones held in its parent state.
+	errMismatchingCheckpointStakes = errors.New("mismatching stakes on checkpoint block")
+
+	// errStakesUnavailable is returned when sealing a checkpoint on top of a chain
+	// without access to the state holding the stakes.
+	errStakesUnavailable = errors.New("stakes unavailable without chain state")
+
+	// errBlacklistedSigner is returned if a block is signed by a signer the other
+	// signers voted onto the blacklist.
+	errBlacklistedSigner = errors.New("blacklisted signer")
+
+	// errNotWhitelistedSigner is returned if a block is signed by a signer missing
+	// from a non-empty whitelist while strict whitelisting is enabled.
+	errNotWhitelistedSigner = errors.New("signer not whitelisted")
+
+	// errMissingTraceRoot is returned if a block's extra-data section is too short
+	// to contain the trace Merkle root.
+	errMissingTraceRoot = errors.New("extra-data 32 byte trace root missing")
+
+	// errMismatchingTraceRoot is returned if a block commits to a trace Merkle root
+	// different than the one the local node calculated from the block's events.
+	errMismatchingTraceRoot = errors.New("mismatching trace root")
+
+	// errInvalidEvidence is returned if a block carries double signing evidence
+	// that doesn't prove a current signer sealed two headers at the same height.
+	errInvalidEvidence = errors.New("invalid double signing evidence")
+
 	// errInvalidMixDigest is returned if a block's mix digest is non-zero.
 	errInvalidMixDigest = errors.New("non-zero mix digest")
 
@@ -138,6 +182,86 @@ var (
 	// errRecentlySigned is returned if a header is signed by an authorized entity
 	// that already signed a header recently, thus is temporarily not allowed to.
 	errRecentlySigned = errors.New("recently signed")
+
+	// errInvalidListTransition is returned if an address is moved between the
+	// whitelist/blacklist states in a way the state machine doesn't allow.
+	errInvalidListTransition = errors.New("invalid whitelist/blacklist transition")
+
+	// errUnknownRegistryCall is returned if a transaction to the candidate registry
+	// doesn't call one of its methods.
+	errUnknownRegistryCall = errors.New("unknown registry call")
+
+	// errAlreadyRegistered is returned if an account registers as a candidate
+	// while already being one.
+	errAlreadyRegistered = errors.New("already registered")
+
+	// errInsufficientDeposit is returned if a candidate registers with less than
+	// the minimum deposit.
+	errInsufficientDeposit = errors.New("insufficient deposit")
+
+	// errEnodeTooLong is returned if a candidate registers with an enode URL
+	// longer than any valid one.
+	errEnodeTooLong = errors.New("enode URL too long")
+
+	// errNotCandidate is returned if an account that isn't a registered candidate
+	// attests blocks or withdraws.
+	errNotCandidate = errors.New("not a registered candidate")
+
+	// errInvalidAttestation is returned if a candidate attests a block that isn't
+	// a recent ancestor of the including block, or already attested a later one.
+	errInvalidAttestation = errors.New("invalid attestation")
+
+	// errRegistryNotPayable is returned if value is sent along a registry call
+	// other than a registration.
+	errRegistryNotPayable = errors.New("registry call not payable")
+
+	// errRegistryUnavailable is returned if the candidate registry is queried
+	// without access to chain state.
+	errRegistryUnavailable = errors.New("registry unavailable without chain state")
+
+	// errFinalityDisabled is returned if attestations are signed or submitted on
+	// a chain that doesn't finalize blocks.
+	errFinalityDisabled = errors.New("finality not enabled")
+
+	// errInvalidAttestationData is returned if data requested to be signed as an
+	// attestation isn't one.
+	errInvalidAttestationData = errors.New("invalid attestation data")
+
+	// errInvalidAttestationSignature is returned if the signer of an attestation
+	// can't be recovered from its signature.
+	errInvalidAttestationSignature = errors.New("invalid attestation signature")
+
+	// errStaleAttestation is returned if an attestation is of a block that is
+	// already final or too far behind the head.
+	errStaleAttestation = errors.New("stale attestation")
+
+	// errFutureAttestation is returned if an attestation is of a block too far
+	// ahead of the head.
+	errFutureAttestation = errors.New("attestation too far ahead of the head")
+
+	// errUnauthorizedAttestation is returned if an attestation is signed by an
+	// entity that isn't a signer at the attested block.
+	errUnauthorizedAttestation = errors.New("attestation not signed by a signer")
+
+	// errFinalizedConflict is returned if a header forks the chain off before the
+	// finalized block.
+	errFinalizedConflict = errors.New("header conflicts with the finalized block")
+
+	// errExpiredAdminRequest is returned if an admin request is submitted after
+	// its deadline.
+	errExpiredAdminRequest = errors.New("admin request expired")
+
+	// errInvalidAdminSignature is returned if the signer of an admin request
+	// can't be recovered from its signature.
+	errInvalidAdminSignature = errors.New("invalid admin request signature")
+
+	// errUnauthorizedAdmin is returned if an admin request is signed by an entity
+	// that isn't a signer at the current head.
+	errUnauthorizedAdmin = errors.New("admin request not signed by a current signer")
+
+	// errUsedAdminNonce is returned if an admin request doesn't exceed the last
+	// nonce its signer used, thus may be a replay.
+	errUsedAdminNonce = errors.New("admin request nonce already used")
 )
 
 // SignerFn hashes and signs the data to be signed by a backing account.
@@ -168,37 +292,166 @@ func ecrecover(header *types.Header, sigcache *sigLRU) (common.Address, error) {
 	return signer, nil
 }
 
+// checkpointEntryLength returns the number of extra-data bytes a checkpoint
+// block uses for every authorized signer.
+func (c *POATC) checkpointEntryLength() int {
+	length := common.AddressLength
+	if c.reputationRules != nil {
+		length += reputationScoreLength
+	}
+	if c.livenessRules != nil {
+		length += livenessEntryLength
+	}
+	if c.stakingRules != nil {
+		length += stakeLength
+	}
+	return length
+}
+
+// traceRootLength returns the size of the trace Merkle root preceding the seal
+// in the extra-data of non-genesis blocks, zero if roots are not committed.
+func (c *POATC) traceRootLength() int {
+	if c.traceRoots {
+		return traceRootLength
+	}
+	return 0
+}
+
+// checkpointData returns the checkpoint section of a header's extra-data, the
+// bytes between the vanity and the trace root (if any) preceding the seal.
+func (c *POATC) checkpointData(header *types.Header) []byte {
+	end := len(header.Extra) - extraSeal
+	if header.Number.Uint64() > 0 {
+		end -= c.traceRootLength()
+	}
+	return header.Extra[extraVanity:end]
+}
+
+// traceRoot returns the trace Merkle root committed to by a header.
+func traceRoot(header *types.Header) common.Hash {
+	end := len(header.Extra) - extraSeal
+	return common.BytesToHash(header.Extra[end-traceRootLength : end])
+}
+
+// parseCheckpoint extracts the list of signers and, if reputation is committed
+// on chain, their reputation scores from a checkpoint header. The genesis block
+// only ever carries the signers. Any liveness accounting and stakes follow the
+// scores and are extracted separately.
+func (c *POATC) parseCheckpoint(header *types.Header) ([]common.Address, []uint64) {
+	entryLength := c.checkpointEntryLength()
+	if header.Number.Uint64() == 0 {
+		entryLength = common.AddressLength
+	}
+	section := c.checkpointData(header)
+
+	signers := make([]common.Address, len(section)/entryLength)
+	for i := 0; i < len(signers); i++ {
+		copy(signers[i][:], section[i*common.AddressLength:])
+	}
+	if c.reputationRules == nil || header.Number.Uint64() == 0 {
+		return signers, nil
+	}
+	scores := make([]uint64, len(signers))
+	for i, offset := 0, len(signers)*common.AddressLength; i < len(scores); i++ {
+		scores[i] = binary.BigEndian.Uint64(section[offset+i*reputationScoreLength:])
+	}
+	return signers, scores
+}
+
+// checkpointLiveness extracts the missed slot accounting of the given number of
+// signers committed to by a checkpoint header, following their reputation
+// scores if any.
+func (c *POATC) checkpointLiveness(header *types.Header, signers int) []byte {
+	offset := signers * common.AddressLength
+	if c.reputationRules != nil {
+		offset += signers * reputationScoreLength
+	}
+	return c.checkpointData(header)[offset : offset+signers*livenessEntryLength]
+}
+
+// checkpointSection assembles the checkpoint part of the extra-data for the
+// block at the given number and timestamp sealed by signer on top of snap: the
+// list of signers, followed by their reputation scores and their missed slot
+// accounting after the block if these are tracked.
+func (c *POATC) checkpointSection(snap *Snapshot, number uint64, timestamp uint64, signer common.Address) []byte {
+	var section []byte
+	for _, auth := range snap.signers() {
+		section = append(section, auth[:]...)
+	}
+	// Apply the block on a copy in the order the snapshot does
+	next := snap.copy()
+	if snap.liveness != nil {
+		next.trackLiveness(number, snap.Hash, signer)
+	}
+	if snap.reputationRules != nil {
+		next.updateReputation(number, timestamp, signer)
+		for _, score := range next.reputationScores() {
+			section = binary.BigEndian.AppendUint64(section, score)
+		}
+	}
+	if snap.liveness != nil {
+		section = append(section, next.encodeLiveness()...)
+	}
+	return section
+}
+
 // POATC (Proof of Authority with AI Tracing) is the proof-of-authority consensus engine
 // enhanced with AI-powered tracing, reputation system, and dynamic mechanisms.
 type POATC struct {
-	config *params.CliqueConfig // Consensus engine configuration parameters
-	db     ethdb.Database       // Database to store and retrieve snapshot checkpoints
+	config      *params.CliqueConfig // Consensus engine configuration parameters
+	poatcConfig *params.PoatcConfig  // POATC subsystem tuning from the chain config (nil = defaults)
+	db          ethdb.Database       // Database to store and retrieve snapshot checkpoints
 
 	recents    *lru.Cache[common.Hash, *Snapshot] // Snapshots for recent block to speed up reorgs
 	signatures *sigLRU                            // Signatures of recent blocks to speed up mining
+	executed   *lru.Cache[common.Hash, struct{}]  // Recently executed blocks, to only record them once
 
-	proposals map[common.Address]bool // Current list of proposals we are pushing
+	proposals     map[common.Address]bool         // Current list of proposals we are pushing
+	listProposals map[listProposal]bool           // Current list of whitelist/blacklist proposals we are pushing
+	autoProposals map[common.Address]autoProposal // Proposals pushed by the engine itself rather than the user
+	rotation      *RotationPolicy                 // Signer rotation policy, nil if the engine doesn't rotate signers
+	registry      *RegistryRules                  // Signer candidate registry, nil if the chain has none
+	finality      *FinalityGadget                 // Attestation based finality, nil if the chain doesn't finalize blocks
+
+	slashingRules *SlashingRules                     // Double signing slashing rules
+	evidence      map[sealKey]*Evidence              // Double signing evidence waiting to be included in a block
+	seals         *lru.Cache[sealKey, *types.Header] // Recently verified headers to detect double signing
+	livenessRules *LivenessRules                     // Missed slot tracking rules, nil if liveness is not tracked
 
 	signer common.Address // Ethereum address of the signing key
 	signFn SignerFn       // Signer function to authorize hashes with
-	lock   sync.RWMutex   // Protects the signer and proposals fields
+	lock   sync.RWMutex   // Protects the signer, proposals and evidence fields
+
+	adminLock sync.Mutex // Serializes the consumption of admin request nonces
+
+	clock mclock.Clock // Source of time of the engine and its subsystems, the system clock unless simulated
 
 	// Anomaly detection
 	anomalyDetector *AnomalyDetector // Anomaly detection system
+	anomalyFeed     event.Feed       // Feed of the anomalies detected in the sealed blocks
 
 	// Whitelist/Blacklist management
+	listRules                 *ListRules                 // Voted whitelist/blacklist rules, nil if lists are not governed on chain
 	whitelistBlacklistManager *WhitelistBlacklistManager // Whitelist/blacklist management system
+	listFeed                  event.Feed                 // Feed of the local whitelist/blacklist state transitions
 
 	// Validator selection management
+	selectionConfig           *ValidatorSelectionConfig  // Small validator set election parameters, nil if disabled
+	stakingRules              *StakingRules              // Location of the stakes in chain state, nil if elections ignore stake
 	validatorSelectionManager *ValidatorSelectionManager // Validator selection system for 2-tier selection
 
 	// Reputation system
+	reputationRules  *ReputationRules  // Consensus reputation rules, nil if reputation is not committed on chain
 	reputationSystem *ReputationSystem // On-chain reputation scoring system
+	reputationFeed   event.Feed        // Feed of the local reputation score changes
 
 	// Tracing system
+	traceRoots    bool           // Whether blocks commit to the Merkle root of their trace events
 	tracingSystem *TracingSystem // Tracing system with Merkle Tree support
+	traceFeed     event.Feed     // Feed of the recorded trace events
 
 	// Time dynamic system
+	blockTimeRules     *BlockTimeRules     // Dynamic block time rules, nil if blocks follow the fixed period
 	timeDynamicManager *TimeDynamicManager // Time dynamic mechanisms
 
 	// The fields below are for testing only
@@ -208,6 +461,13 @@ type POATC struct {
 // New creates a POATC proof-of-authority consensus engine with the initial
 // signers set to the ones provided by the user.
 func New(config *params.CliqueConfig, db ethdb.Database) *POATC {
+	return NewWithConfig(config, nil, db)
+}
+
+// NewWithConfig creates a POATC proof-of-authority consensus engine whose
+// subsystems are tuned by the "poatc" section of the chain config. A nil
+// poatcConfig runs every subsystem with its default configuration.
+func NewWithConfig(config *params.CliqueConfig, poatcConfig *params.PoatcConfig, db ethdb.Database) *POATC {
 	// Set any missing consensus parameters to their defaults
 	conf := *config
 	if conf.Epoch == 0 {
@@ -217,12 +477,46 @@ func New(config *params.CliqueConfig, db ethdb.Database) *POATC {
 	recents := lru.NewCache[common.Hash, *Snapshot](inmemorySnapshots)
 	signatures := lru.NewCache[common.Hash, common.Address](inmemorySignatures)
 
+	// The small validator set election is part of consensus, so its parameters
+	// are fixed by the chain config for the lifetime of the engine
+	var selectionConfig *ValidatorSelectionConfig
+	if poatcConfig == nil || poatcConfig.EnableValidatorSelection {
+		selectionConfig = validatorSelectionConfig(poatcConfig, &conf)
+	}
+	// Reputation changes the checkpoint layout, so it is only tracked in the
+	// snapshots if the chain config explicitly opts in
+	var reputationRules *ReputationRules
+	if poatcConfig != nil && poatcConfig.EnableReputationSystem {
+		reputationRules = newReputationRules(reputationConfig(poatcConfig), anomalyDetectionConfig(poatcConfig).MaxTimestampDrift)
+	}
+	// Trace roots extend the extra-data of every block, so they too are only
+	// committed if the chain config explicitly opts in
+	traceRoots := poatcConfig != nil && poatcConfig.EnableTracingSystem && poatcConfig.Tracing != nil &&
+		poatcConfig.Tracing.EnableTracing && poatcConfig.Tracing.MerkleRootInBlock
 	return &POATC{
-		config:     &conf,
-		db:         db,
-		recents:    recents,
-		signatures: signatures,
-		proposals:  make(map[common.Address]bool),
+		config:          &conf,
+		poatcConfig:     poatcConfig,
+		db:              db,
+		recents:         recents,
+		signatures:      signatures,
+		proposals:       make(map[common.Address]bool),
+		listProposals:   make(map[listProposal]bool),
+		autoProposals:   make(map[common.Address]autoProposal),
+		rotation:        newRotationPolicy(poatcConfig),
+		registry:        newRegistryRules(poatcConfig),
+		finality:        newFinalityGadget(poatcConfig),
+		listRules:       listRules(poatcConfig, &conf),
+		slashingRules:   newSlashingRules(poatcConfig, &conf, traceRoots),
+		evidence:        make(map[sealKey]*Evidence),
+		seals:           lru.NewCache[sealKey, *types.Header](inmemorySignatures),
+		executed:        lru.NewCache[common.Hash, struct{}](inmemorySignatures),
+		livenessRules:   newLivenessRules(poatcConfig),
+		selectionConfig: selectionConfig,
+		stakingRules:    newStakingRules(poatcConfig, traceRoots),
+		reputationRules: reputationRules,
+		traceRoots:      traceRoots,
+		blockTimeRules:  newBlockTimeRules(poatcConfig, &conf),
+		clock:           mclock.System{},
 		// anomalyDetector will be initialized when signers are available
 		// timeDynamicManager will be initialized when needed
 	}
@@ -230,31 +524,47 @@ func New(config *params.CliqueConfig, db ethdb.Database) *POATC {
 
 // initializeAnomalyDetector initializes the anomaly detector with current signers
 func (c *POATC) initializeAnomalyDetector(signers []common.Address) {
+	if c.poatcConfig != nil && !c.poatcConfig.EnableAnomalyDetection {
+		return
+	}
 	if c.anomalyDetector == nil {
-		c.anomalyDetector = NewAnomalyDetector(DefaultAnomalyDetectionConfig(), signers)
+		c.anomalyDetector = NewAnomalyDetector(anomalyDetectionConfig(c.poatcConfig), signers)
+		c.anomalyDetector.SetFeed(&c.anomalyFeed)
 		log.Info("Anomaly detector initialized", "signers", len(signers))
 	}
 }
 
 // initializeWhitelistBlacklistManager initializes the whitelist/blacklist manager
 func (c *POATC) initializeWhitelistBlacklistManager() {
+	if c.poatcConfig != nil && !c.poatcConfig.EnableWhitelistBlacklist {
+		return
+	}
 	if c.whitelistBlacklistManager == nil {
-		c.whitelistBlacklistManager = NewWhitelistBlacklistManager(DefaultWhitelistBlacklistConfig())
+		c.whitelistBlacklistManager = NewWhitelistBlacklistManager(whitelistBlacklistConfig(c.poatcConfig), c.clock)
+		c.whitelistBlacklistManager.SetIntegrationComponents(c.tracingSystem, &c.listFeed)
 		log.Info("Whitelist/Blacklist manager initialized")
 	}
 }
 
 // initializeValidatorSelectionManager initializes the validator selection manager
-func (c *POATC) initializeValidatorSelectionManager(signers []common.Address) {
+// with the signers of the given snapshot and the stakes it committed to.
+func (c *POATC) initializeValidatorSelectionManager(snap *Snapshot) {
+	if c.selectionConfig == nil {
+		return
+	}
 	if c.validatorSelectionManager == nil {
-		c.validatorSelectionManager = NewValidatorSelectionManager(DefaultValidatorSelectionConfig())
+		c.validatorSelectionManager = NewValidatorSelectionManager(c.selectionConfig, c.clock)
 
 		// Add all signers to the validator selection manager
+		signers := snap.signers()
 		for _, signer := range signers {
-			// Initialize with default stake and reputation
-			defaultStake := big.NewInt(1000000) // 1M wei default stake
-			defaultReputation := 1.0            // Default reputation
-			c.validatorSelectionManager.AddValidator(signer, defaultStake, defaultReputation)
+			// Signers without a committed stake have none
+			stake := new(big.Int)
+			if committed := snap.Stakes[signer]; committed != nil {
+				stake.Set(committed)
+			}
+			defaultReputation := 1.0 // Default reputation
+			c.validatorSelectionManager.AddValidator(signer, stake, defaultReputation)
 		}
 
 		log.Info("Validator selection manager initialized", "signers", len(signers))
@@ -263,8 +573,12 @@ func (c *POATC) initializeValidatorSelectionManager(signers []common.Address) {
 
 // initializeReputationSystem initializes the reputation system
 func (c *POATC) initializeReputationSystem(signers []common.Address) {
+	if c.poatcConfig != nil && !c.poatcConfig.EnableReputationSystem {
+		return
+	}
 	if c.reputationSystem == nil {
-		c.reputationSystem = NewReputationSystem(DefaultReputationConfig(), c.db)
+		c.reputationSystem = NewReputationSystem(reputationConfig(c.poatcConfig), c.db, c.clock)
+		c.reputationSystem.SetFeed(&c.reputationFeed)
 
 		// Add all signers to the reputation system
 		for _, signer := range signers {
@@ -277,16 +591,23 @@ func (c *POATC) initializeReputationSystem(signers []common.Address) {
 
 // initializeTracingSystem initializes the tracing system
 func (c *POATC) initializeTracingSystem() {
+	if c.poatcConfig != nil && !c.poatcConfig.EnableTracingSystem {
+		return
+	}
 	if c.tracingSystem == nil {
-		c.tracingSystem = NewTracingSystem(DefaultTracingConfig())
+		c.tracingSystem = NewTracingSystem(tracingConfig(c.poatcConfig), c.db, c.clock)
+		c.tracingSystem.SetFeed(&c.traceFeed)
 		log.Info("Tracing system initialized with Merkle Tree support")
 	}
 }
 
 // initializeTimeDynamicManager initializes the time dynamic manager
 func (c *POATC) initializeTimeDynamicManager() {
+	if c.poatcConfig != nil && !c.poatcConfig.EnableTimeDynamic {
+		return
+	}
 	if c.timeDynamicManager == nil {
-		c.timeDynamicManager = NewTimeDynamicManager(DefaultTimeDynamicConfig())
+		c.timeDynamicManager = NewTimeDynamicManager(timeDynamicConfig(c.poatcConfig), c.clock)
 
 		// Set integration components
 		c.timeDynamicManager.SetIntegrationComponents(
@@ -302,7 +623,8 @@ func (c *POATC) initializeTimeDynamicManager() {
 	}
 }
 
-// manageWhitelistBlacklistByReputation automatically manages whitelist/blacklist based on reputation
+// manageWhitelistBlacklistByReputation feeds the reputation of a signer to the
+// whitelist/blacklist state machine, which moves it between the lists.
 func (c *POATC) manageWhitelistBlacklistByReputation(signer common.Address, blockNumber uint64) {
 	if c.reputationSystem == nil || c.whitelistBlacklistManager == nil {
 		return
@@ -314,56 +636,8 @@ func (c *POATC) manageWhitelistBlacklistByReputation(signer common.Address, bloc
 	}
 
 	config := c.reputationSystem.config
-
-	// Check if reputation is too low (should be blacklisted)
-	if score.CurrentScore < config.LowReputationThreshold {
-		if !c.whitelistBlacklistManager.IsBlacklisted(signer) {
-			// Auto-blacklist validator with low reputation
-			expiresAt := time.Now().Add(24 * time.Hour) // Auto-remove after 24 hours
-			err := c.whitelistBlacklistManager.AddToBlacklist(
-				signer,
-				common.Address{}, // System address
-				fmt.Sprintf("Auto-blacklisted due to low reputation: %.2f", score.CurrentScore),
-				&expiresAt,
-			)
-			if err == nil {
-				log.Warn("Validator auto-blacklisted due to low reputation",
-					"address", signer.Hex(),
-					"reputation", score.CurrentScore,
-					"threshold", config.LowReputationThreshold)
-			}
-		}
-	}
-
-	// Check if reputation is high enough (should be whitelisted)
-	if score.CurrentScore >= config.HighReputationThreshold {
-		if !c.whitelistBlacklistManager.IsWhitelisted(signer) {
-			// Auto-whitelist validator with high reputation
-			err := c.whitelistBlacklistManager.AddToWhitelist(
-				signer,
-				common.Address{}, // System address
-				fmt.Sprintf("Auto-whitelisted due to high reputation: %.2f", score.CurrentScore),
-				nil, // No expiration
-			)
-			if err == nil {
-				log.Info("Validator auto-whitelisted due to high reputation",
-					"address", signer.Hex(),
-					"reputation", score.CurrentScore,
-					"threshold", config.HighReputationThreshold)
-			}
-		}
-	}
-
-	// Check if blacklisted validator has improved reputation
-	if c.whitelistBlacklistManager.IsBlacklisted(signer) && score.CurrentScore >= config.HighReputationThreshold {
-		// Remove from blacklist if reputation has improved
-		err := c.whitelistBlacklistManager.RemoveFromBlacklist(signer)
-		if err == nil {
-			log.Info("Validator removed from blacklist due to improved reputation",
-				"address", signer.Hex(),
-				"reputation", score.CurrentScore)
-		}
-	}
+	c.whitelistBlacklistManager.UpdateStanding(signer, score.CurrentScore,
+		config.LowReputationThreshold, config.HighReputationThreshold, blockNumber)
 }
 
 // Author implements consensus.Engine, returning the Ethereum address recovered
@@ -409,7 +683,7 @@ func (c *POATC) verifyHeader(chain consensus.ChainHeaderReader, header *types.He
 	number := header.Number.Uint64()
 
 	// Don't waste time checking blocks from the future
-	if header.Time > uint64(time.Now().Unix()) {
+	if header.Time > uint64(c.now().Unix()) {
 		return consensus.ErrFutureBlock
 	}
 	// Checkpoint blocks need to enforce zero beneficiary
@@ -417,8 +691,9 @@ func (c *POATC) verifyHeader(chain consensus.ChainHeaderReader, header *types.He
 	if checkpoint && header.Coinbase != (common.Address{}) {
 		return errInvalidCheckpointBeneficiary
 	}
-	// Nonces must be 0x00..0 or 0xff..f, zeroes enforced on checkpoints
-	if !bytes.Equal(header.Nonce[:], nonceAuthVote) && !bytes.Equal(header.Nonce[:], nonceDropVote) {
+	// Nonces must be 0x00..0 or 0xff..f (or a list vote if lists are governed),
+	// zeroes enforced on checkpoints
+	if !bytes.Equal(header.Nonce[:], nonceAuthVote) && !bytes.Equal(header.Nonce[:], nonceDropVote) && !c.isListVote(header.Nonce) {
 		return errInvalidVote
 	}
 	if checkpoint && !bytes.Equal(header.Nonce[:], nonceDropVote) {
@@ -431,12 +706,23 @@ func (c *POATC) verifyHeader(chain consensus.ChainHeaderReader, header *types.He
 	if len(header.Extra) < extraVanity+extraSeal {
 		return errMissingSignature
 	}
-	// Ensure that the extra-data contains a signer list on checkpoint, but none otherwise
-	signersBytes := len(header.Extra) - extraVanity - extraSeal
+	if number > 0 && len(header.Extra) < extraVanity+extraSeal+c.traceRootLength() {
+		return errMissingTraceRoot
+	}
+	// Ensure that the extra-data contains a signer list on checkpoint, but at most
+	// some evidence otherwise
+	signersBytes := len(c.checkpointData(header))
 	if !checkpoint && signersBytes != 0 {
-		return errExtraSigners
+		// Non-checkpoint blocks may carry double signing evidence instead, if
+		// slashing is enabled
+		if c.slashingRules == nil {
+			return errExtraSigners
+		}
+		if _, err := decodeEvidence(c.checkpointData(header)); err != nil {
+			return err
+		}
 	}
-	if checkpoint && signersBytes%common.AddressLength != 0 {
+	if checkpoint && signersBytes%c.checkpointEntryLength() != 0 {
 		return errInvalidCheckpointSigners
 	}
 	// Ensure that the mix digest is zero as we don't have fork protection currently
@@ -476,6 +762,10 @@ func (c *POATC) verifyHeader(chain consensus.ChainHeaderReader, header *types.He
 	case header.ParentBeaconRoot != nil:
 		return fmt.Errorf("invalid parentBeaconRoot, have %#x, expected nil", header.ParentBeaconRoot)
 	}
+	// Ensure that the block doesn't fork off before the finalized one
+	if err := c.verifyFinality(chain, header); err != nil {
+		return err
+	}
 	// All basic checks passed, verify cascading fields
 	return c.verifyCascadingFields(chain, header, parents)
 }
@@ -500,7 +790,7 @@ func (c *POATC) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
-	if parent.Time+c.config.Period > header.Time {
+	if parent.Time+c.period(parent) > header.Time {
 		return errInvalidTimestamp
 	}
 	// Verify that the gasUsed is <= gasLimit
@@ -524,16 +814,61 @@ func (c *POATC) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 	if err != nil {
 		return err
 	}
-	// If the block is a checkpoint block, verify the signer list
+	// If the block is a checkpoint block, verify the signer list and scores
 	if number%c.config.Epoch == 0 {
-		signers := make([]byte, len(snap.Signers)*common.AddressLength)
-		for i, signer := range snap.signers() {
-			copy(signers[i*common.AddressLength:], signer[:])
+		var signer common.Address
+		if snap.reputationRules != nil || snap.liveness != nil {
+			if signer, err = ecrecover(header, c.signatures); err != nil {
+				return err
+			}
+		}
+		expect := c.checkpointSection(snap, number, header.Time, signer)
+		have := c.checkpointData(header)
+
+		// Stakes are verified against the parent state along with the body
+		if c.stakingRules != nil {
+			if len(have) != len(expect)+len(snap.Signers)*stakeLength {
+				return errMismatchingCheckpointSigners
+			}
+			have = have[:len(expect)]
 		}
-		extraSuffix := len(header.Extra) - extraSeal
-		if !bytes.Equal(header.Extra[extraVanity:extraSuffix], signers) {
+
+		signersLength := len(snap.Signers) * common.AddressLength
+		if len(have) != len(expect) || !bytes.Equal(have[:signersLength], expect[:signersLength]) {
 			return errMismatchingCheckpointSigners
 		}
+		scoresLength := signersLength
+		if snap.reputationRules != nil {
+			scoresLength += len(snap.Signers) * reputationScoreLength
+		}
+		if !bytes.Equal(have[signersLength:scoresLength], expect[signersLength:scoresLength]) {
+			return errMismatchingCheckpointReputation
+		}
+		if !bytes.Equal(have[scoresLength:], expect[scoresLength:]) {
+			return errMismatchingCheckpointLiveness
+		}
+	}
+	// Ensure any double signing evidence holds against the parent snapshot
+	var evidence *Evidence
+	if c.slashingRules != nil {
+		if evidence, err = c.slashingRules.evidence(c.config, header); err != nil {
+			return err
+		}
+		if evidence != nil {
+			if _, err := snap.verifyEvidence(evidence, number); err != nil {
+				return err
+			}
+		}
+	}
+	// If trace roots are committed, verify the root against the block's events
+	if c.traceRoots {
+		signer, err := ecrecover(header, c.signatures)
+		if err != nil {
+			return err
+		}
+		if traceRoot(header) != blockTraceRoot(blockTraceEvents(snap, header, signer, evidence)) {
+			return errMismatchingTraceRoot
+		}
 	}
 	// All basic checks passed, verify the seal and return
 	return c.verifySeal(snap, header, parents)
@@ -546,6 +881,10 @@ func (c *POATC) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 		headers []*types.Header
 		snap    *Snapshot
 	)
+	// Initialize tracing system if not already done
+	if c.tracingSystem == nil {
+		c.initializeTracingSystem()
+	}
 	for snap == nil {
 		// If an in-memory snapshot was found, use that
 		if s, ok := c.recents.Get(hash); ok {
@@ -557,6 +896,7 @@ func (c *POATC) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 			if s, err := loadSnapshot(c.config, c.signatures, c.db, hash); err == nil {
 				log.Trace("Loaded voting snapshot from disk", "number", number, "hash", hash)
 				snap = s
+				c.configureSnapshot(snap)
 				break
 			}
 		}
@@ -567,17 +907,11 @@ func (c *POATC) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 		if number == 0 || (number%c.config.Epoch == 0 && (len(headers) > params.FullImmutabilityThreshold || chain.GetHeaderByNumber(number-1) == nil)) {
 			checkpoint := chain.GetHeaderByNumber(number)
 			if checkpoint != nil {
-				hash := checkpoint.Hash()
-
-				signers := make([]common.Address, (len(checkpoint.Extra)-extraVanity-extraSeal)/common.AddressLength)
-				for i := 0; i < len(signers); i++ {
-					copy(signers[i][:], checkpoint.Extra[extraVanity+i*common.AddressLength:])
-				}
-				snap = newSnapshot(c.config, c.signatures, number, hash, signers)
+				snap = c.checkpointSnapshot(checkpoint)
 				if err := snap.store(c.db); err != nil {
 					return nil, err
 				}
-				log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", hash)
+				log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", snap.Hash)
 				break
 			}
 		}
@@ -620,8 +954,7 @@ func (c *POATC) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 
 	// Initialize validator selection manager if not already done
 	if c.validatorSelectionManager == nil {
-		signers := snap.signers()
-		c.initializeValidatorSelectionManager(signers)
+		c.initializeValidatorSelectionManager(snap)
 	}
 
 	// Initialize reputation system if not already done
@@ -630,34 +963,82 @@ func (c *POATC) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 		c.initializeReputationSystem(signers)
 	}
 
-	// Initialize tracing system if not already done
-	if c.tracingSystem == nil {
-		c.initializeTracingSystem()
-	}
-
 	// Initialize time dynamic manager if not already done
 	if c.timeDynamicManager == nil {
 		c.initializeTimeDynamicManager()
 	}
 
-	// Set validator selection manager for this snapshot
-	if c.validatorSelectionManager != nil {
-		snap.setValidatorSelectionManager(c.validatorSelectionManager)
-		// Set tracing system for validator selection manager
-		if c.tracingSystem != nil {
-			c.validatorSelectionManager.SetTracingSystem(c.tracingSystem)
-		}
+	// Set tracing system for validator selection manager
+	if c.validatorSelectionManager != nil && c.tracingSystem != nil {
+		c.validatorSelectionManager.SetTracingSystem(c.tracingSystem)
 	}
 
 	return snap, err
 }
 
+// configureSnapshot attaches the consensus rules of the engine to a snapshot
+// created from a checkpoint or loaded from disk.
+func (c *POATC) configureSnapshot(snap *Snapshot) {
+	snap.setSelection(c.selectionConfig, c.tracingSystem)
+	snap.setReputationRules(c.reputationRules)
+	snap.setListRules(c.listRules)
+	snap.setSlashingRules(c.slashingRules)
+	snap.setStakingRules(c.stakingRules)
+	snap.setLivenessRules(c.livenessRules)
+}
+
+// checkpointSnapshot creates a snapshot trusting the signers, reputation scores,
+// missed slot accounting and stakes committed to by a checkpoint header.
+func (c *POATC) checkpointSnapshot(checkpoint *types.Header) *Snapshot {
+	number, hash := checkpoint.Number.Uint64(), checkpoint.Hash()
+
+	signers, scores := c.parseCheckpoint(checkpoint)
+	snap := newSnapshot(c.config, c.signatures, number, hash, signers)
+	snap.Time = checkpoint.Time
+	c.configureSnapshot(snap)
+	if snap.reputationRules != nil {
+		snap.seedReputation(scores)
+	}
+	if snap.liveness != nil && number > 0 {
+		snap.seedLiveness(c.checkpointLiveness(checkpoint, len(signers)))
+	}
+	if snap.staking != nil && number > 0 {
+		snap.updateStakes(checkpoint)
+	}
+	if snap.selection != nil {
+		snap.electSmallValidatorSet(number, hash)
+	}
+	return snap
+}
+
 // VerifyUncles implements consensus.Engine, always returning an error for any
 // uncles as this consensus mechanism doesn't permit uncles.
 func (c *POATC) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
 	if len(block.Uncles()) > 0 {
 		return errors.New("uncles not allowed")
 	}
+	// Verify any stakes committed to by a checkpoint against the parent state,
+	// which may not be available yet if the block is on a side chain
+	if number := block.NumberU64(); c.stakingRules != nil && number > 0 && number%c.config.Epoch == 0 {
+		return c.verifyStakes(chain, block.Header())
+	}
+	return nil
+}
+
+// verifyStakes checks that a checkpoint header commits to the stakes held in the
+// state of its parent.
+func (c *POATC) verifyStakes(chain consensus.ChainHeaderReader, header *types.Header) error {
+	signers, _ := c.parseCheckpoint(header)
+	expect, err := c.stakingRules.readStakes(chain, header, signers)
+	if err != nil {
+		return err
+	}
+	have := c.stakingRules.stakes(header, len(signers))
+	for i := range expect {
+		if have == nil || have[i].Cmp(expect[i]) != 0 {
+			return errMismatchingCheckpointStakes
+		}
+	}
 	return nil
 }
 
@@ -676,6 +1057,9 @@ func (c *POATC) verifySeal(snap *Snapshot, header *types.Header, parents []*type
 	if err != nil {
 		return err
 	}
+	if c.slashingRules != nil {
+		c.detectDoubleSigning(header, signer)
+	}
 
 	// Initialize tracing system if not already done
 	if c.tracingSystem == nil {
@@ -732,99 +1116,28 @@ func (c *POATC) verifySeal(snap *Snapshot, header *types.Header, parents []*type
 		c.initializeWhitelistBlacklistManager()
 	}
 
-	// Validate signer against whitelist/blacklist
-	if c.whitelistBlacklistManager != nil {
-		valid, reason := c.whitelistBlacklistManager.ValidateSigner(signer)
-		if !valid {
-			// Trace whitelist/blacklist validation failure
-			if c.tracingSystem != nil {
-				c.tracingSystem.Trace(TraceEventWhitelistBlacklist, TraceLevelBasic, number, signer,
-					"Whitelist/Blacklist validation failed", map[string]interface{}{
-						"error":  "whitelist_blacklist_validation_failed",
-						"signer": signer.Hex(),
-						"reason": reason,
-					})
-			}
-			log.Error("Whitelist/Blacklist validation failed", "signer", signer.Hex(), "reason", reason)
-			return fmt.Errorf("whitelist/blacklist validation failed: %s", reason)
-		}
-	}
-
-	// Initialize anomaly detector if not already done
-	if c.anomalyDetector == nil {
-		signers := make([]common.Address, 0, len(snap.Signers))
-		for signer := range snap.Signers {
-			signers = append(signers, signer)
+	// Validate signer against the voted whitelist/blacklist
+	if err := snap.checkLists(signer, number); err != nil {
+		if c.tracingSystem != nil {
+			c.tracingSystem.Trace(TraceEventWhitelistBlacklist, TraceLevelBasic, number, signer,
+				"Whitelist/Blacklist validation failed", map[string]interface{}{
+					"error":  "whitelist_blacklist_validation_failed",
+					"signer": signer.Hex(),
+					"reason": err.Error(),
+				})
 		}
-		c.initializeAnomalyDetector(signers)
+		return err
 	}
-
-	// Add block to anomaly detector and check for anomalies
-	if c.anomalyDetector != nil {
-		c.anomalyDetector.AddBlock(header, signer)
-		anomalies := c.anomalyDetector.DetectAnomalies()
-		c.anomalyDetector.LogAnomalies(anomalies)
-
-		// Trace anomaly detection results
-		if c.tracingSystem != nil && len(anomalies) > 0 {
-			for _, anomaly := range anomalies {
-				// Convert AnomalyType to string
-				anomalyTypeStr := ""
-				switch anomaly.Type {
-				case AnomalyRapidSigning:
-					anomalyTypeStr = "RapidSigning"
-				case AnomalySuspiciousPattern:
-					anomalyTypeStr = "SuspiciousPattern"
-				case AnomalyHighFrequency:
-					anomalyTypeStr = "HighFrequency"
-				case AnomalyMissingSigner:
-					anomalyTypeStr = "MissingSigner"
-				case AnomalyTimestampDrift:
-					anomalyTypeStr = "TimestampDrift"
-				default:
-					anomalyTypeStr = "Unknown"
-				}
-
-				c.tracingSystem.TraceAnomalyDetection(
-					anomalyTypeStr,
-					anomaly.Signer,
-					number,
-					"medium", // severity
-					map[string]interface{}{
-						"anomaly_type": anomalyTypeStr,
-						"message":      anomaly.Message,
-						"timestamp":    anomaly.Timestamp,
-						"severity":     anomaly.Severity,
-					},
-				)
-			}
-		}
-
-		// Record violations in reputation system
-		if c.reputationSystem != nil {
-			for _, anomaly := range anomalies {
-				if anomaly.Type == AnomalyRapidSigning || anomaly.Type == AnomalySuspiciousPattern ||
-					anomaly.Type == AnomalyTimestampDrift || anomaly.Type == AnomalyMissingSigner {
-					// Convert AnomalyType to string for RecordViolation
-					violationType := ""
-					switch anomaly.Type {
-					case AnomalyRapidSigning:
-						violationType = "RapidSigning"
-					case AnomalySuspiciousPattern:
-						violationType = "SuspiciousPattern"
-					case AnomalyTimestampDrift:
-						violationType = "TimestampDrift"
-					case AnomalyMissingSigner:
-						violationType = "MissingSigner"
-					}
-					c.reputationSystem.RecordViolation(signer, number, violationType, anomaly.Message)
-				}
-			}
+	// The local lists differ between nodes, so they are advisory only
+	if c.whitelistBlacklistManager != nil {
+		if valid, reason := c.whitelistBlacklistManager.ValidateSigner(signer); !valid {
+			log.Warn("Signer rejected by local whitelist/blacklist", "signer", signer.Hex(), "reason", reason)
 		}
 	}
 
 	// Record block mining in reputation system
 	if c.reputationSystem != nil {
+		c.updateUptimeScores(snap)
 		c.reputationSystem.RecordBlockMining(signer, number)
 
 		// Update validator selection manager with new reputation
@@ -862,6 +1175,10 @@ func (c *POATC) verifySeal(snap *Snapshot, header *types.Header, parents []*type
 
 	// Handle time dynamic mechanisms
 	if c.timeDynamicManager != nil {
+		// Report the block time the chain enforces on the next block
+		if c.blockTimeRules != nil {
+			c.timeDynamicManager.SetBlockTime(number, time.Duration(c.blockTimeRules.period(header))*time.Second)
+		}
 		// Check and trigger dynamic validator selection
 		if c.timeDynamicManager.ShouldUpdateValidatorSelection() {
 			err := c.timeDynamicManager.UpdateValidatorSelection(number, header.Hash())
@@ -892,6 +1209,108 @@ func (c *POATC) verifySeal(snap *Snapshot, header *types.Header, parents []*type
 	return nil
 }
 
+// sealKey identifies the header a signer sealed at a height on top of a parent.
+// Signers may legitimately seal a height again on another parent after a reorg,
+// so only headers sharing the parent can prove double signing.
+type sealKey struct {
+	Number uint64
+	Parent common.Hash
+	Signer common.Address
+}
+
+// detectDoubleSigning remembers the header a signer sealed at its height, and
+// queues evidence for inclusion if the signer sealed a different one before.
+func (c *POATC) detectDoubleSigning(header *types.Header, signer common.Address) {
+	key := sealKey{Number: header.Number.Uint64(), Parent: header.ParentHash, Signer: signer}
+
+	seen, ok := c.seals.Get(key)
+	if !ok {
+		c.seals.Add(key, types.CopyHeader(header))
+		return
+	}
+	if SealHash(seen) == SealHash(header) {
+		return
+	}
+	c.addEvidence(key, &Evidence{First: seen, Second: types.CopyHeader(header)})
+
+	log.Warn("Detected double signing", "signer", signer, "number", key.Number, "first", seen.Hash(), "second", header.Hash())
+	if c.tracingSystem != nil {
+		c.tracingSystem.TraceAccusation(key.Number, c.signer, signer, "double_signing", map[string]interface{}{
+			"first":  seen.Hash().Hex(),
+			"second": header.Hash().Hex(),
+		})
+	}
+}
+
+// addEvidence queues double signing evidence for inclusion in a block.
+func (c *POATC) addEvidence(key sealKey, evidence *Evidence) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.evidence[key]; !ok {
+		c.evidence[key] = evidence
+	}
+}
+
+// pendingEvidence returns a piece of queued double signing evidence that holds
+// for the block at the given number, dropping any that no longer does.
+func (c *POATC) pendingEvidence(snap *Snapshot, number uint64) *Evidence {
+	if c.slashingRules == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key, evidence := range c.evidence {
+		if key.Number >= number {
+			continue // can't be included before the offence
+		}
+		if _, err := snap.verifyEvidence(evidence, number); err != nil {
+			delete(c.evidence, key)
+			continue
+		}
+		return evidence
+	}
+	return nil
+}
+
+// listProposal identifies a whitelist/blacklist entry we are voting on.
+type listProposal struct {
+	List    string
+	Address common.Address
+}
+
+// isListVote returns whether the nonce is a whitelist/blacklist vote and lists
+// are governed by votes on this chain.
+func (c *POATC) isListVote(nonce types.BlockNonce) bool {
+	if c.listRules == nil {
+		return false
+	}
+	for _, vote := range [][]byte{nonceWhitelistAdd, nonceWhitelistRemove, nonceBlacklistAdd, nonceBlacklistRemove} {
+		if bytes.Equal(nonce[:], vote) {
+			return true
+		}
+	}
+	return false
+}
+
+// voteNonce returns the header nonce casting the given vote.
+func voteNonce(vote *Vote) []byte {
+	switch {
+	case vote.List == listWhitelist && vote.Authorize:
+		return nonceWhitelistAdd
+	case vote.List == listWhitelist:
+		return nonceWhitelistRemove
+	case vote.List == listBlacklist && vote.Authorize:
+		return nonceBlacklistAdd
+	case vote.List == listBlacklist:
+		return nonceBlacklistRemove
+	case vote.Authorize:
+		return nonceAuthVote
+	}
+	return nonceDropVote
+}
+
 // Prepare implements consensus.Engine, preparing all the consensus fields of the
 // header for running the transactions on top.
 func (c *POATC) Prepare(chain consensus.ChainHeaderReader, header *types.Header) error {
@@ -905,24 +1324,30 @@ func (c *POATC) Prepare(chain consensus.ChainHeaderReader, header *types.Header)
 	if err != nil {
 		return err
 	}
+	c.rotateSigners(chain, snap, number)
+
 	c.lock.RLock()
 	if number%c.config.Epoch != 0 {
 		// Gather all the proposals that make sense voting on
-		addresses := make([]common.Address, 0, len(c.proposals))
+		votes := make([]*Vote, 0, len(c.proposals)+len(c.listProposals))
 		for address, authorize := range c.proposals {
 			if snap.validVote(address, authorize) {
-				addresses = append(addresses, address)
+				votes = append(votes, &Vote{Address: address, Authorize: authorize})
 			}
 		}
-		// If there's pending proposals, cast a vote on them
-		if len(addresses) > 0 {
-			header.Coinbase = addresses[rand.Intn(len(addresses))]
-			if c.proposals[header.Coinbase] {
-				copy(header.Nonce[:], nonceAuthVote)
-			} else {
-				copy(header.Nonce[:], nonceDropVote)
+		if snap.listRules != nil {
+			for proposal, add := range c.listProposals {
+				if snap.validListVote(proposal.List, proposal.Address, add) {
+					votes = append(votes, &Vote{Address: proposal.Address, Authorize: add, List: proposal.List})
+				}
 			}
 		}
+		// If there's pending proposals, cast a vote on them
+		if len(votes) > 0 {
+			vote := votes[rand.Intn(len(votes))]
+			header.Coinbase = vote.Address
+			copy(header.Nonce[:], voteNonce(vote))
+		}
 	}
 
 	// Copy signer protected by mutex to avoid race condition
@@ -932,38 +1357,175 @@ func (c *POATC) Prepare(chain consensus.ChainHeaderReader, header *types.Header)
 	// Set the correct difficulty
 	header.Difficulty = calcDifficulty(snap, signer)
 
+	// Ensure the timestamp has the correct delay
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Time = parent.Time + c.period(parent)
+	if now := uint64(c.now().Unix()); header.Time < now {
+		header.Time = now
+	}
 	// Ensure the extra data has all its components
 	if len(header.Extra) < extraVanity {
 		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, extraVanity-len(header.Extra))...)
 	}
 	header.Extra = header.Extra[:extraVanity]
 
+	var evidence *Evidence
 	if number%c.config.Epoch == 0 {
-		for _, signer := range snap.signers() {
-			header.Extra = append(header.Extra, signer[:]...)
+		header.Extra = append(header.Extra, c.checkpointSection(snap, number, header.Time, signer)...)
+		if c.stakingRules != nil {
+			stakes, err := c.stakingRules.readStakes(chain, header, snap.signers())
+			if err != nil {
+				return err
+			}
+			header.Extra = append(header.Extra, encodeStakes(stakes)...)
+		}
+	} else if evidence = c.pendingEvidence(snap, number); evidence != nil {
+		blob, err := rlp.EncodeToBytes(evidence)
+		if err != nil {
+			return err
 		}
+		header.Extra = append(header.Extra, blob...)
+	}
+	if c.traceRoots {
+		root := blockTraceRoot(blockTraceEvents(snap, header, signer, evidence))
+		header.Extra = append(header.Extra, root[:]...)
 	}
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
 
 	// Mix digest is reserved for now, set to empty
 	header.MixDigest = common.Hash{}
 
-	// Ensure the timestamp has the correct delay
+	return nil
+}
+
+// Finalize implements consensus.Engine, executing the calls to the signer
+// candidate registry, if any.
+func (c *POATC) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, withdrawals []*types.Withdrawal) {
+	// No block rewards in PoA, so the state only changes by the registry calls
+	record := c.firstExecution(chain, header)
+	c.processRegistry(chain, header, state, txs, record)
+	if record {
+		c.observeBlock(chain, header, len(txs))
+	}
+}
+
+// firstExecution returns whether a sealed block is executed for the first time.
+// Blocks are executed again to regenerate their state or trace them, and blocks
+// being assembled are executed anew at every recommit, so the anomalies and the
+// trace events of a block are only recorded on its first execution as part of
+// its insertion.
+func (c *POATC) firstExecution(chain consensus.ChainHeaderReader, header *types.Header) bool {
+	if _, err := ecrecover(header, c.signatures); err != nil {
+		return false // not sealed yet
+	}
+	hash := header.Hash()
+	if canonical := chain.GetHeaderByNumber(header.Number.Uint64()); canonical != nil && canonical.Hash() == hash {
+		return false // already inserted
+	}
+	if c.executed.Contains(hash) {
+		return false
+	}
+	c.executed.Add(hash, struct{}{})
+	return true
+}
+
+// observeBlock feeds a block being processed to the anomaly detector, along with
+// its transaction count, and records the anomalies found.
+func (c *POATC) observeBlock(chain consensus.ChainHeaderReader, header *types.Header, txs int) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return
+	}
+	signer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return
+	}
 	parent := chain.GetHeader(header.ParentHash, number-1)
 	if parent == nil {
-		return consensus.ErrUnknownAncestor
+		return
 	}
-	header.Time = parent.Time + c.config.Period
-	if header.Time < uint64(time.Now().Unix()) {
-		header.Time = uint64(time.Now().Unix())
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return
+	}
+	// Initialize anomaly detector if not already done
+	if c.anomalyDetector == nil {
+		c.initializeAnomalyDetector(snap.signers())
+	}
+
+	// Add block to anomaly detector and check for anomalies
+	if c.anomalyDetector != nil {
+		record := newBlockRecord(header, signer)
+		record.TxCount = txs
+		record.Delay = time.Duration(header.Time-parent.Time) * time.Second
+
+		c.anomalyDetector.UpdateSigners(snap.signers())
+		c.anomalyDetector.AddRecord(record)
+		anomalies := c.anomalyDetector.DetectAnomalies()
+		c.anomalyDetector.LogAnomalies(anomalies)
+		c.anomalyDetector.PublishAnomalies(anomalies)
+
+		// Trace anomaly detection results
+		if c.tracingSystem != nil && len(anomalies) > 0 {
+			for _, anomaly := range anomalies {
+				// Convert AnomalyType to string
+				anomalyTypeStr := ""
+				switch anomaly.Type {
+				case AnomalyRapidSigning:
+					anomalyTypeStr = "RapidSigning"
+				case AnomalySuspiciousPattern:
+					anomalyTypeStr = "SuspiciousPattern"
+				case AnomalyHighFrequency:
+					anomalyTypeStr = "HighFrequency"
+				case AnomalyMissingSigner:
+					anomalyTypeStr = "MissingSigner"
+				case AnomalyTimestampDrift:
+					anomalyTypeStr = "TimestampDrift"
+				default:
+					anomalyTypeStr = anomaly.Detector
+				}
+
+				c.tracingSystem.TraceAnomalyDetection(
+					anomalyTypeStr,
+					anomaly.Signer,
+					number,
+					"medium", // severity
+					map[string]interface{}{
+						"anomaly_type": anomalyTypeStr,
+						"message":      anomaly.Message,
+						"timestamp":    anomaly.Timestamp,
+						"severity":     anomaly.Severity,
+					},
+				)
+			}
+		}
+
+		// Record violations in reputation system
+		if c.reputationSystem != nil {
+			for _, anomaly := range anomalies {
+				if anomaly.Type == AnomalyRapidSigning || anomaly.Type == AnomalySuspiciousPattern ||
+					anomaly.Type == AnomalyTimestampDrift || anomaly.Type == AnomalyMissingSigner {
+					// Convert AnomalyType to string for RecordViolation
+					violationType := ""
+					switch anomaly.Type {
+					case AnomalyRapidSigning:
+						violationType = "RapidSigning"
+					case AnomalySuspiciousPattern:
+						violationType = "SuspiciousPattern"
+					case AnomalyTimestampDrift:
+						violationType = "TimestampDrift"
+					case AnomalyMissingSigner:
+						violationType = "MissingSigner"
+					}
+					c.reputationSystem.RecordViolation(signer, number, violationType, anomaly.Message)
+				}
+			}
+		}
 	}
-	return nil
-}
 
-// Finalize implements consensus.Engine. There is no post-transaction
-// consensus rules in poatc, do nothing here.
-func (c *POATC) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, withdrawals []*types.Withdrawal) {
-	// No block rewards in PoA, so the state remains as is
 }
 
 // FinalizeAndAssemble implements consensus.Engine, ensuring no uncles are set,
@@ -982,6 +1544,18 @@ func (c *POATC) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *t
 	return types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil)), nil
 }
 
+// SetClock replaces the system clock of the engine and of the subsystems it
+// creates, such as with a simulated clock counting the time since the Unix epoch.
+// It must be called before the engine is used.
+func (c *POATC) SetClock(clock mclock.Clock) {
+	c.clock = orSystemClock(clock)
+}
+
+// now returns the current wall time of the engine.
+func (c *POATC) now() time.Time {
+	return wallTime(c.clock)
+}
+
 // Authorize injects a private key into the consensus engine to mint new blocks
 // with.
 func (c *POATC) Authorize(signer common.Address, signFn SignerFn) {
@@ -1028,56 +1602,13 @@ func (c *POATC) Seal(chain consensus.ChainHeaderReader, block *types.Block, resu
 			}
 		}
 	}
-	// Update transaction count for dynamic block time
-	if c.timeDynamicManager != nil {
-		txCount := len(block.Transactions())
-		c.timeDynamicManager.UpdateTransactionCount(txCount)
-	}
-
-	// Sweet, the protocol permits us to sign the block, wait for our time
-	delay := time.Unix(int64(header.Time), 0).Sub(time.Now()) // nolint: gosimple
-
-	// Apply dynamic block time adjustment
-	if c.timeDynamicManager != nil {
-		dynamicBlockTime := c.timeDynamicManager.GetCurrentBlockTime()
-		baseBlockTime := time.Duration(c.config.Period) * time.Second
-
-		// Adjust delay based on dynamic block time
-		if dynamicBlockTime != baseBlockTime {
-			timeRatio := float64(dynamicBlockTime) / float64(baseBlockTime)
-			adjustedDelay := time.Duration(float64(delay) * timeRatio)
-
-			// Ensure minimum delay to prevent consecutive blocks
-			minDelay := 2 * time.Second // Minimum 2 seconds between blocks
-			if adjustedDelay < minDelay {
-				adjustedDelay = minDelay
-			}
-
-			// Ensure delay is not too large
-			maxDelay := dynamicBlockTime + 5*time.Second // Max delay is dynamic block time + 5s buffer
-			if adjustedDelay > maxDelay {
-				adjustedDelay = maxDelay
-			}
-
-			delay = adjustedDelay
-
-			log.Debug("Dynamic block time applied",
-				"base_block_time", baseBlockTime,
-				"dynamic_block_time", dynamicBlockTime,
-				"time_ratio", timeRatio,
-				"original_delay", time.Unix(int64(header.Time), 0).Sub(time.Now()),
-				"adjusted_delay", delay,
-				"tx_count", len(block.Transactions()))
-		}
-	}
-
-	// Ensure absolute minimum delay to prevent consecutive blocks
-	absoluteMinDelay := 1 * time.Second
-	if delay < absoluteMinDelay {
-		delay = absoluteMinDelay
-		log.Debug("Applied absolute minimum delay", "delay", delay)
+	// Feed the local block time estimate, unless the chain enforces one
+	if c.timeDynamicManager != nil && c.blockTimeRules == nil {
+		c.timeDynamicManager.UpdateTransactionCount(len(block.Transactions()))
 	}
-
+	// Sweet, the protocol permits us to sign the block, wait for our time. The
+	// timestamp already accounts for the dynamic block time, if enforced.
+	delay := time.Unix(int64(header.Time), 0).Sub(c.now()) // nolint: gosimple
 	if header.Difficulty.Cmp(diffNoTurn) == 0 {
 		// It's not our turn explicitly to sign, delay it a bit
 		wiggle := time.Duration(len(snap.Signers)/2+1) * wiggleTime
@@ -1097,7 +1628,7 @@ func (c *POATC) Seal(chain consensus.ChainHeaderReader, block *types.Block, resu
 		select {
 		case <-stop:
 			return
-		case <-time.After(delay):
+		case <-c.clock.After(delay):
 		}
 
 		select {
@@ -1157,6 +1688,11 @@ func (c *POATC) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 			Namespace: "poatc",
 			Service:   &API{chain: chain, poatc: c},
 		},
+		{
+			Namespace:     AdminNamespace,
+			Service:       &AdminAPI{chain: chain, poatc: c},
+			Authenticated: true,
+		},
 	}
 }
 
@@ -0,0 +1,208 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SignerReport is the sealing activity of the signers over a range of blocks.
+type SignerReport struct {
+	From          uint64                             `json:"from"`
+	To            uint64                             `json:"to"`
+	Blocks        uint64                             `json:"blocks"`
+	InturnPercent float64                            `json:"inturnPercent"`
+	Signers       map[common.Address]*SignerActivity `json:"signers"` // Every signer authorized or sealing during the range
+}
+
+// SignerActivity is the sealing activity of a single signer over a range of
+// blocks. Delays are measured from the parent's timestamp, in seconds.
+type SignerActivity struct {
+	Sealed         uint64  `json:"sealed"`
+	InTurn         uint64  `json:"inTurn"`
+	OutOfTurn      uint64  `json:"outOfTurn"`
+	MissedInTurn   uint64  `json:"missedInTurn"`   // In-turn slots sealed by another signer
+	AvgDelay       float64 `json:"avgDelay"`       // Average seal delay
+	P95Delay       float64 `json:"p95Delay"`       // 95th percentile seal delay
+	LongestAbsence uint64  `json:"longestAbsence"` // Most consecutive blocks sealed by others while authorized
+	Anomalies      int     `json:"anomalies"`      // Anomalies detected on the signer's blocks
+	Violations     uint64  `json:"violations"`     // Violations recorded on chain, including slashings
+
+	delays  []float64
+	absence uint64 // Blocks sealed by others since the signer's last block
+}
+
+// activity returns the activity of a signer, adding it to the report if new.
+func (r *SignerReport) activity(signer common.Address) *SignerActivity {
+	activity := r.Signers[signer]
+	if activity == nil {
+		activity = new(SignerActivity)
+		r.Signers[signer] = activity
+	}
+	return activity
+}
+
+// reportAnomalyConfig returns the configuration of the anomaly detector signer
+// reports count anomalies with, nil if anomaly detection is disabled.
+func (c *POATC) reportAnomalyConfig() *AnomalyDetectionConfig {
+	if c.poatcConfig != nil && !c.poatcConfig.EnableAnomalyDetection {
+		return nil
+	}
+	return anomalyDetectionConfig(c.poatcConfig)
+}
+
+// buildSignerReport replays the headers in [from, to] on top of the snapshot at
+// block from-1, accounting for the sealing activity of the signers. Anomalies
+// are counted by a fresh detector fed the replayed headers, unless config is nil.
+func buildSignerReport(snap *Snapshot, parent *types.Header, header func(number uint64) (*types.Header, error), from, to uint64, config *AnomalyDetectionConfig) (*SignerReport, error) {
+	if from == 0 || from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	report := &SignerReport{
+		From:    from,
+		To:      to,
+		Blocks:  to - from + 1,
+		Signers: make(map[common.Address]*SignerActivity),
+	}
+	var detector *AnomalyDetector
+	if config != nil {
+		detector = NewAnomalyDetector(config, snap.signers())
+	}
+	var inturns uint64
+	for number := from; number <= to; number++ {
+		current, err := header(number)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ecrecover(current, snap.sigcache)
+		if err != nil {
+			return nil, err
+		}
+		// Account the block to its sealer, and the slot to the in-turn signer
+		sealer := report.activity(signer)
+		sealer.Sealed++
+		if current.Difficulty != nil && current.Difficulty.Cmp(diffInTurn) == 0 {
+			sealer.InTurn++
+			inturns++
+		} else {
+			sealer.OutOfTurn++
+		}
+		if expected, ok := snap.inturnSigner(number); ok && expected != signer {
+			report.activity(expected).MissedInTurn++
+		}
+		sealer.delays = append(sealer.delays, float64(current.Time-parent.Time))
+
+		for address := range snap.Signers {
+			activity := report.activity(address)
+			if address == signer {
+				activity.absence = 0
+				continue
+			}
+			activity.absence++
+			if activity.absence > activity.LongestAbsence {
+				activity.LongestAbsence = activity.absence
+			}
+		}
+		if detector != nil {
+			record := newBlockRecord(current, signer)
+			record.Delay = time.Duration(current.Time-parent.Time) * time.Second
+
+			detector.UpdateSigners(snap.signers())
+			detector.AddRecord(record)
+			for _, anomaly := range detector.DetectAnomalies() {
+				if anomaly.Signer != (common.Address{}) {
+					report.activity(anomaly.Signer).Anomalies++
+				}
+			}
+		}
+		// Advance the snapshot, counting the violations the block recorded
+		next, err := snap.apply([]*types.Header{current})
+		if err != nil {
+			return nil, err
+		}
+		if next.Reputation[signer].Violations > snap.Reputation[signer].Violations {
+			sealer.Violations += next.Reputation[signer].Violations - snap.Reputation[signer].Violations
+		}
+		for address, slashed := range next.Slashed {
+			if slashed == number {
+				report.activity(address).Violations++
+			}
+		}
+		// Absences only run while authorized, restart them for removed signers
+		for address := range snap.Signers {
+			if _, ok := next.Signers[address]; !ok {
+				report.activity(address).absence = 0
+			}
+		}
+		snap, parent = next, current
+	}
+	report.InturnPercent = float64(100*inturns) / float64(report.Blocks)
+
+	for _, activity := range report.Signers {
+		if len(activity.delays) == 0 {
+			continue
+		}
+		var total float64
+		for _, delay := range activity.delays {
+			total += delay
+		}
+		activity.AvgDelay = total / float64(len(activity.delays))
+
+		sort.Float64s(activity.delays)
+		rank := int(math.Ceil(0.95*float64(len(activity.delays)))) - 1
+		activity.P95Delay = activity.delays[rank]
+	}
+	return report, nil
+}
+
+// WriteCSV writes the report as CSV, one row per signer in address order.
+func (r *SignerReport) WriteCSV(w io.Writer) error {
+	signers := make([]common.Address, 0, len(r.Signers))
+	for signer := range r.Signers {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool { return signers[i].Cmp(signers[j]) < 0 })
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"signer", "sealed", "in_turn", "out_of_turn", "missed_in_turn", "avg_delay", "p95_delay", "longest_absence", "anomalies", "violations"})
+	for _, signer := range signers {
+		activity := r.Signers[signer]
+		out.Write([]string{
+			signer.Hex(),
+			strconv.FormatUint(activity.Sealed, 10),
+			strconv.FormatUint(activity.InTurn, 10),
+			strconv.FormatUint(activity.OutOfTurn, 10),
+			strconv.FormatUint(activity.MissedInTurn, 10),
+			strconv.FormatFloat(activity.AvgDelay, 'f', 2, 64),
+			strconv.FormatFloat(activity.P95Delay, 'f', 2, 64),
+			strconv.FormatUint(activity.LongestAbsence, 10),
+			strconv.Itoa(activity.Anomalies),
+			strconv.FormatUint(activity.Violations, 10),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
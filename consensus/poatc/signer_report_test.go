@@ -0,0 +1,148 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the signer report accounts sealed blocks, turns, delays and
+// absences to the right signers.
+func TestSignerReport(t *testing.T) {
+	accounts := newTesterAccountPool()
+	engine := New(&params.CliqueConfig{Period: 1, Epoch: 30000}, nil)
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	genesis := &types.Header{Number: big.NewInt(0), Time: 100}
+	snap := newSnapshot(engine.config, engine.signatures, 0, genesis.Hash(), signers)
+
+	// A and B take turns sealing, C never shows up, and A's second block is late
+	var (
+		sealers = []string{"A", "B", "A", "B", "A", "B"}
+		delays  = []uint64{1, 1, 5, 1, 1, 1}
+		headers []*types.Header
+		missed  = make(map[common.Address]uint64)
+		outturn uint64
+	)
+	replay, parent := snap, genesis
+	for i, name := range sealers {
+		number := uint64(i + 1)
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			ParentHash: parent.Hash(),
+			Time:       parent.Time + delays[i],
+			Extra:      make([]byte, extraVanity+extraSeal),
+			Difficulty: diffNoTurn,
+		}
+		if expected, _ := replay.inturnSigner(number); expected == accounts.address(name) {
+			header.Difficulty = diffInTurn
+		} else {
+			missed[expected]++
+			outturn++
+		}
+		accounts.sign(header, name)
+
+		var err error
+		if replay, err = replay.apply([]*types.Header{header}); err != nil {
+			t.Fatalf("failed to apply block %d: %v", number, err)
+		}
+		headers, parent = append(headers, header), header
+	}
+	header := func(number uint64) (*types.Header, error) {
+		if number == 0 || number > uint64(len(headers)) {
+			return nil, errUnknownBlock
+		}
+		return headers[number-1], nil
+	}
+	report, err := buildSignerReport(snap, genesis, header, 1, uint64(len(headers)), nil)
+	if err != nil {
+		t.Fatalf("failed to build report: %v", err)
+	}
+	if report.Blocks != 6 || len(report.Signers) != 3 {
+		t.Fatalf("report size mismatch: have %d blocks of %d signers, want 6 of 3", report.Blocks, len(report.Signers))
+	}
+	if want := float64(100*(6-outturn)) / 6; report.InturnPercent != want {
+		t.Errorf("in-turn percent mismatch: have %v, want %v", report.InturnPercent, want)
+	}
+	var sealedOutOfTurn uint64
+	for _, name := range []string{"A", "B", "C"} {
+		activity := report.Signers[accounts.address(name)]
+		if activity.MissedInTurn != missed[accounts.address(name)] {
+			t.Errorf("signer %s missed slots mismatch: have %d, want %d", name, activity.MissedInTurn, missed[accounts.address(name)])
+		}
+		if activity.InTurn+activity.OutOfTurn != activity.Sealed {
+			t.Errorf("signer %s turns mismatch: %d in + %d out of %d sealed", name, activity.InTurn, activity.OutOfTurn, activity.Sealed)
+		}
+		sealedOutOfTurn += activity.OutOfTurn
+	}
+	if sealedOutOfTurn != outturn {
+		t.Errorf("out-of-turn blocks mismatch: have %d, want %d", sealedOutOfTurn, outturn)
+	}
+	a, b, c := report.Signers[accounts.address("A")], report.Signers[accounts.address("B")], report.Signers[accounts.address("C")]
+	if a.Sealed != 3 || b.Sealed != 3 || c.Sealed != 0 {
+		t.Errorf("sealed blocks mismatch: have A=%d B=%d C=%d, want 3/3/0", a.Sealed, b.Sealed, c.Sealed)
+	}
+	if a.AvgDelay != 7.0/3 || a.P95Delay != 5 || b.AvgDelay != 1 || b.P95Delay != 1 {
+		t.Errorf("delays mismatch: have A=%v/%v B=%v/%v", a.AvgDelay, a.P95Delay, b.AvgDelay, b.P95Delay)
+	}
+	if a.LongestAbsence != 1 || b.LongestAbsence != 1 || c.LongestAbsence != 6 {
+		t.Errorf("absences mismatch: have A=%d B=%d C=%d, want 1/1/6", a.LongestAbsence, b.LongestAbsence, c.LongestAbsence)
+	}
+	// The CSV export has a header and a row per signer
+	var out bytes.Buffer
+	if err := report.WriteCSV(&out); err != nil {
+		t.Fatalf("failed to export report: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "signer,sealed,") {
+		t.Errorf("csv export mismatch: have %q", out.String())
+	}
+	if want := fmt.Sprintf("%s,0,0,0,%d,", accounts.address("C").Hex(), missed[accounts.address("C")]); !strings.Contains(out.String(), want) {
+		t.Errorf("csv export misses absent signer row %q", want)
+	}
+	if _, err := buildSignerReport(snap, genesis, header, 1, 7, nil); err == nil {
+		t.Errorf("report over unknown blocks built")
+	}
+}
+
+// Tests that the inspector reports the signer activity of a stored chain.
+func TestInspectorSignerReport(t *testing.T) {
+	db, config, signer := newInspectedChain(t, 5)
+
+	inspector, err := NewInspector(config, db)
+	if err != nil {
+		t.Fatalf("failed to create inspector: %v", err)
+	}
+	report, err := inspector.SignerReport(0, 5)
+	if err != nil {
+		t.Fatalf("failed to build report: %v", err)
+	}
+	if report.From != 1 || report.Blocks != 5 || report.InturnPercent != 100 {
+		t.Errorf("report mismatch: have %+v", report)
+	}
+	if activity := report.Signers[signer]; activity == nil || activity.Sealed != 5 || activity.InTurn != 5 || activity.LongestAbsence != 0 {
+		t.Errorf("signer activity mismatch: have %+v", activity)
+	}
+}
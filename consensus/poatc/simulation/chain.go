@@ -0,0 +1,127 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// chain is the header chain of a simulated validator, following the branch with
+// the highest total difficulty like a clique node does.
+type chain struct {
+	config    *params.ChainConfig
+	headers   map[common.Hash]*types.Header
+	tds       map[common.Hash]*big.Int
+	canonical []common.Hash // Hashes of the canonical blocks, by number
+	head      *types.Header
+
+	reorgs uint64 // Head changes to a block not descending from the previous head
+}
+
+// Make sure the simulated chain satisfies the engine.
+var _ consensus.ChainHeaderReader = (*chain)(nil)
+
+// newChain creates a chain made of the genesis block.
+func newChain(config *params.ChainConfig, genesis *types.Header) *chain {
+	hash := genesis.Hash()
+	return &chain{
+		config:    config,
+		headers:   map[common.Hash]*types.Header{hash: genesis},
+		tds:       map[common.Hash]*big.Int{hash: new(big.Int).Set(genesis.Difficulty)},
+		canonical: []common.Hash{hash},
+		head:      genesis,
+	}
+}
+
+// Config implements consensus.ChainHeaderReader.
+func (c *chain) Config() *params.ChainConfig { return c.config }
+
+// CurrentHeader implements consensus.ChainHeaderReader.
+func (c *chain) CurrentHeader() *types.Header { return c.head }
+
+// GetHeader implements consensus.ChainHeaderReader.
+func (c *chain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+// GetHeaderByNumber implements consensus.ChainHeaderReader.
+func (c *chain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.canonical)) {
+		return nil
+	}
+	return c.headers[c.canonical[number]]
+}
+
+// GetHeaderByHash implements consensus.ChainHeaderReader.
+func (c *chain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return c.headers[hash]
+}
+
+// GetTd implements consensus.ChainHeaderReader.
+func (c *chain) GetTd(hash common.Hash, number uint64) *big.Int {
+	if c.GetHeader(hash, number) == nil {
+		return nil
+	}
+	return c.tds[hash]
+}
+
+// has returns whether the chain contains a block.
+func (c *chain) has(hash common.Hash) bool {
+	_, ok := c.headers[hash]
+	return ok
+}
+
+// insert adds a verified block whose parent is known, returning whether it
+// became the new head. Ties in total difficulty keep the current head.
+func (c *chain) insert(header *types.Header) bool {
+	hash := header.Hash()
+	c.headers[hash] = header
+	c.tds[hash] = new(big.Int).Add(c.tds[header.ParentHash], header.Difficulty)
+
+	if c.tds[hash].Cmp(c.tds[c.head.Hash()]) <= 0 {
+		return false
+	}
+	// Rewrite the canonical chain from the new head back to the common ancestor
+	prev := c.head
+	number := header.Number.Uint64()
+	if number+1 < uint64(len(c.canonical)) {
+		c.canonical = c.canonical[:number+1]
+	}
+	for len(c.canonical) <= int(number) {
+		c.canonical = append(c.canonical, common.Hash{})
+	}
+	for ancestor := header; c.canonical[ancestor.Number.Uint64()] != ancestor.Hash(); {
+		c.canonical[ancestor.Number.Uint64()] = ancestor.Hash()
+		if ancestor = c.headers[ancestor.ParentHash]; ancestor == nil {
+			break
+		}
+	}
+	c.head = header
+
+	if number := prev.Number.Uint64(); number >= uint64(len(c.canonical)) || c.canonical[number] != prev.Hash() {
+		c.reorgs++
+	}
+	return true
+}
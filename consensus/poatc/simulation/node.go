@@ -0,0 +1,294 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// wiggleTime is the random delay unit of out-of-turn signers, as in the engine.
+const wiggleTime = 500 * time.Millisecond
+
+// diffInTurn is the difficulty of blocks sealed in turn.
+var diffInTurn = big.NewInt(2)
+
+// skewedClock is the virtual clock as seen by a validator, counting the time
+// since the Unix epoch as the engine expects. The clock of a validator may run
+// ahead of the others.
+type skewedClock struct {
+	*mclock.Simulated
+	node *node
+}
+
+// Now implements mclock.Clock, adding the genesis time and the drift of the
+// validator to the virtual time.
+func (c *skewedClock) Now() mclock.AbsTime {
+	return c.Simulated.Now().Add(genesisTime*time.Second + c.node.drift())
+}
+
+// node is a simulated validator running its own engine and chain.
+type node struct {
+	index int
+	key   *ecdsa.PrivateKey
+	addr  common.Address
+	net   *network
+
+	engine *poatc.POATC
+	api    *poatc.API
+	chain  *chain
+
+	rejected uint64 // Blocks received from others and failing verification
+}
+
+// init creates the engine and chain of a validator on top of the genesis block.
+func (n *node) init(genesis *types.Header) error {
+	n.chain = newChain(n.net.chain, genesis)
+	n.engine = poatc.NewWithConfig(n.net.chain.Clique, n.net.chain.Poatc, rawdb.NewMemoryDatabase())
+	n.engine.SetClock(&skewedClock{Simulated: n.net.clock, node: n})
+	n.engine.Authorize(n.addr, func(account accounts.Account, mimeType string, message []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(message), n.key)
+	})
+	for _, api := range n.engine.APIs(n.chain) {
+		if service, ok := api.Service.(*poatc.API); ok && api.Namespace == "poatc" {
+			n.api = service
+		}
+	}
+	if n.api == nil {
+		return errors.New("engine exposes no poatc API")
+	}
+	// Offline validators resume sealing on their stale head once back
+	for _, fault := range n.net.config.Faults[n.index] {
+		if fault.Behaviour == Offline && fault.End > 0 {
+			n.net.after(fault.End, n.prepare)
+		}
+	}
+	return nil
+}
+
+// behaviour returns the way the validator behaves at the current virtual time.
+// Faults listed later take precedence over earlier ones.
+func (n *node) behaviour() (Behaviour, Fault) {
+	var (
+		now       = n.net.now()
+		behaviour = Honest
+		active    Fault
+	)
+	for _, fault := range n.net.config.Faults[n.index] {
+		if fault.active(now) {
+			behaviour, active = fault.Behaviour, fault
+		}
+	}
+	return behaviour, active
+}
+
+// drift returns how far the clock of the validator runs ahead.
+func (n *node) drift() time.Duration {
+	if behaviour, fault := n.behaviour(); behaviour == TimestampDrift {
+		return fault.Drift
+	}
+	return 0
+}
+
+// now returns the wall time as seen by the validator.
+func (n *node) now() time.Time {
+	return time.Unix(genesisTime, 0).Add(n.net.now() + n.drift())
+}
+
+// prepare assembles a block on top of the current head if the validator may
+// seal it, and schedules sealing it once its slot arrived.
+func (n *node) prepare() {
+	behaviour, _ := n.behaviour()
+	if behaviour == Offline {
+		return
+	}
+	snap, err := n.api.GetSnapshot(nil)
+	if err != nil {
+		return
+	}
+	parent := n.chain.CurrentHeader()
+	number := parent.Number.Uint64() + 1
+	if !mayStillSeal(snap, n.addr, number) {
+		return
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).SetUint64(number),
+		GasLimit:   parent.GasLimit,
+		GasUsed:    uint64(n.net.config.GasUsage * float64(parent.GasLimit)),
+		BaseFee:    eip1559.CalcBaseFee(n.net.chain, parent),
+		UncleHash:  types.EmptyUncleHash,
+		TxHash:     types.EmptyTxsHash,
+	}
+	if err := n.engine.Prepare(n.chain, header); err != nil {
+		return
+	}
+	// Wait for the slot, and a bit more when out of turn unless racing
+	delay := time.Unix(int64(header.Time), 0).Sub(n.now())
+	if header.Difficulty.Cmp(diffInTurn) != 0 && behaviour != RapidSigning {
+		wiggle := time.Duration(len(snap.Signers)/2+1) * wiggleTime
+		delay += time.Duration(n.net.rand.Int63n(int64(wiggle)))
+	}
+	n.net.after(delay, func() {
+		// Abandon the block if the head moved or the validator went offline
+		if n.chain.CurrentHeader().Hash() != parent.Hash() {
+			return
+		}
+		if behaviour, _ := n.behaviour(); behaviour == Offline {
+			return
+		}
+		n.seal(header)
+	})
+}
+
+// mayStillSeal returns whether a signer may seal the block with the given
+// number on top of a snapshot, as the engine checks when sealing.
+func mayStillSeal(snap *poatc.Snapshot, signer common.Address, number uint64) bool {
+	if _, ok := snap.Signers[signer]; !ok {
+		return false
+	}
+	for seen, recent := range snap.Recents {
+		if recent == signer {
+			if limit := uint64(len(snap.Signers)/2 + 1); number < limit || seen > number-limit {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// seal signs a prepared block, imports it and sends it to the peers. Validators
+// equivocating sign a second block with a different vanity for half the peers.
+func (n *node) seal(header *types.Header) {
+	n.sign(header)
+	n.net.recordSeal(header)
+	if err := n.importHeader(header); err != nil {
+		return
+	}
+	var twin *types.Header
+	if behaviour, _ := n.behaviour(); behaviour == Equivocation {
+		twin = types.CopyHeader(header)
+		twin.Extra[0] ^= 0xff
+		n.sign(twin)
+		n.net.recordSeal(twin)
+	}
+	for i, peer := range n.net.nodes {
+		switch {
+		case peer == n:
+		case twin != nil && i%2 == 1:
+			n.net.send(n, peer, twin)
+		default:
+			n.net.send(n, peer, header)
+		}
+	}
+}
+
+// sign seals a block with the key of the validator.
+func (n *node) sign(header *types.Header) {
+	sig, err := crypto.Sign(poatc.SealHash(header).Bytes(), n.key)
+	if err != nil {
+		panic(fmt.Sprintf("failed to sign block: %v", err))
+	}
+	copy(header.Extra[len(header.Extra)-crypto.SignatureLength:], sig)
+}
+
+// receive imports a block sent by a peer, along with any of its ancestors the
+// validator missed, as a syncing node would fetch them.
+func (n *node) receive(from *node, header *types.Header) {
+	if behaviour, _ := n.behaviour(); behaviour == Offline {
+		return
+	}
+	if n.chain.has(header.Hash()) {
+		return
+	}
+	headers := []*types.Header{header}
+	for parent := header; !n.chain.has(parent.ParentHash); {
+		if parent = from.chain.GetHeader(parent.ParentHash, parent.Number.Uint64()-1); parent == nil {
+			return
+		}
+		headers = append([]*types.Header{parent}, headers...)
+	}
+	for _, header := range headers {
+		if err := n.importHeader(header); err != nil {
+			if errors.Is(err, consensus.ErrFutureBlock) {
+				// Retry once the block is due, as the block queue would
+				n.net.after(time.Unix(int64(header.Time), 0).Sub(n.now()), func() { n.receive(from, header) })
+			} else {
+				n.rejected++
+			}
+			return
+		}
+	}
+}
+
+// importHeader verifies a block and adds it to the chain of the validator,
+// preparing the next block if it became the head.
+func (n *node) importHeader(header *types.Header) error {
+	if n.chain.has(header.Hash()) {
+		return nil
+	}
+	if err := n.engine.VerifyHeader(n.chain, header); err != nil {
+		return err
+	}
+	headChanged := n.chain.insert(header)
+
+	// Feed the block to the anomaly detector and the reputation system, the
+	// simulated chains carry no state for the engine to process
+	n.engine.Finalize(n.chain, header, nil, nil, nil, nil)
+	n.net.recordImport(n, header)
+
+	if headChanged {
+		n.prepare()
+	}
+	return nil
+}
+
+// reputations returns the reputation of every validator as seen by this one:
+// the consensus scores if reputation is committed on chain, the local ones
+// otherwise.
+func (n *node) reputations() map[common.Address]float64 {
+	scores := make(map[common.Address]float64)
+	for _, v := range n.net.nodes {
+		if score, err := n.api.GetReputationScore(v.addr, nil); err == nil {
+			scores[v.addr] = score.Score
+		}
+	}
+	if len(scores) > 0 {
+		return scores
+	}
+	local, err := n.api.GetTopValidators(0)
+	if err != nil {
+		return scores
+	}
+	for _, score := range local {
+		scores[score.Address] = score.CurrentScore
+	}
+	return scores
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Report is the outcome of a simulation. Chain wide figures are taken from the
+// reference validator, the first one without faults.
+type Report struct {
+	Reference   int           `json:"reference"`   // Validator the chain wide figures are taken from
+	Blocks      uint64        `json:"blocks"`      // Height of the canonical chain
+	Duration    time.Duration `json:"duration"`    // Virtual time simulated
+	Stalled     bool          `json:"stalled"`     // Whether sealing stopped before reaching the configured height
+	Forks       int           `json:"forks"`       // Heights at which competing blocks were sealed
+	Reorgs      uint64        `json:"reorgs"`      // Head changes to blocks not descending from the previous head, over all validators
+	Rejected    uint64        `json:"rejected"`    // Blocks failing verification, over all validators
+	InturnRatio float64       `json:"inturnRatio"` // Share of the canonical blocks sealed in turn
+
+	Validators []*ValidatorReport `json:"validators"`
+}
+
+// ValidatorReport is the outcome of a simulation for a single validator.
+type ValidatorReport struct {
+	Index      int                   `json:"index"`
+	Address    common.Address        `json:"address"`
+	Faults     []Fault               `json:"faults,omitempty"`
+	Head       uint64                `json:"head"`                 // Head of the validator's own chain
+	Authorized bool                  `json:"authorized"`           // Whether the validator is still a signer
+	Activity   *poatc.SignerActivity `json:"activity,omitempty"`   // Sealing activity on the canonical chain
+	Uptime     float64               `json:"uptime"`               // Share of its in-turn slots the validator sealed
+	Standing   poatc.ListState       `json:"standing,omitempty"`   // Local whitelist/blacklist state, if tracked
+	Reputation []ReputationSample    `json:"reputation,omitempty"` // Reputation trajectory
+}
+
+// ReputationSample is the reputation of a validator at a block.
+type ReputationSample struct {
+	Number uint64  `json:"number"`
+	Score  float64 `json:"score"`
+}
+
+// report builds the report of a finished simulation.
+func (net *network) report(stalled bool) (*Report, error) {
+	reference := net.reference
+	head := reference.chain.CurrentHeader().Number.Uint64()
+
+	report := &Report{
+		Reference: reference.index,
+		Blocks:    head,
+		Duration:  net.now(),
+		Stalled:   stalled,
+	}
+	for _, sealed := range net.sealed {
+		if len(sealed) > 1 {
+			report.Forks++
+		}
+	}
+	var activity *poatc.SignerReport
+	if head > 0 {
+		var err error
+		if activity, err = reference.api.SignerReport(1, rpc.BlockNumber(head)); err != nil {
+			return nil, err
+		}
+		report.InturnRatio = activity.InturnPercent / 100
+	}
+	snap, err := reference.api.GetSnapshot(nil)
+	if err != nil {
+		return nil, err
+	}
+	standings, _ := reference.api.GetListStandings()
+
+	for _, n := range net.nodes {
+		report.Reorgs += n.chain.reorgs
+		report.Rejected += n.rejected
+
+		_, authorized := snap.Signers[n.addr]
+		validator := &ValidatorReport{
+			Index:      n.index,
+			Address:    n.addr,
+			Faults:     net.config.Faults[n.index],
+			Head:       n.chain.CurrentHeader().Number.Uint64(),
+			Authorized: authorized,
+			Uptime:     1,
+			Reputation: net.samples[n.addr],
+		}
+		if activity != nil {
+			if validator.Activity = activity.Signers[n.addr]; validator.Activity != nil {
+				if slots := validator.Activity.InTurn + validator.Activity.MissedInTurn; slots > 0 {
+					validator.Uptime = float64(validator.Activity.InTurn) / float64(slots)
+				}
+			}
+		}
+		if standing, ok := standings[n.addr]; ok {
+			validator.Standing = standing.State
+		}
+		report.Validators = append(report.Validators, validator)
+	}
+	return report, nil
+}
@@ -0,0 +1,389 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulation runs networks of POATC engines sealing header chains on a
+// virtual clock, so the consensus and reputation parameters of a chain config
+// can be evaluated over thousands of blocks, with network delays, partitions and
+// misbehaving validators, before rolling them out.
+package simulation
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// genesisTime is the timestamp of the simulated genesis blocks. Simulations start
+// at a fixed time so their headers, and thus their hashes, are reproducible.
+const genesisTime = 1_700_000_000
+
+// gasLimit is the gas limit of all the simulated blocks.
+const gasLimit = 30_000_000
+
+// Behaviour is the way a simulated validator seals and relays blocks.
+type Behaviour int
+
+const (
+	Honest         Behaviour = iota // Follows the protocol
+	Offline                         // Neither seals nor receives blocks
+	RapidSigning                    // Seals out of turn without waiting, racing the in-turn signer
+	TimestampDrift                  // Runs its clock ahead by the drift of the fault
+	Equivocation                    // Seals two blocks for every slot, each sent to half of the peers
+)
+
+// String implements fmt.Stringer.
+func (b Behaviour) String() string {
+	switch b {
+	case Honest:
+		return "honest"
+	case Offline:
+		return "offline"
+	case RapidSigning:
+		return "rapid_signing"
+	case TimestampDrift:
+		return "timestamp_drift"
+	case Equivocation:
+		return "equivocation"
+	default:
+		return fmt.Sprintf("behaviour(%d)", int(b))
+	}
+}
+
+// Fault makes a validator misbehave during a window of virtual time.
+type Fault struct {
+	Behaviour Behaviour
+	Start     time.Duration // Virtual time the fault begins at
+	End       time.Duration // Virtual time the fault ends at, never if zero
+	Drift     time.Duration // Clock skew of timestamp drift faults
+}
+
+// active returns whether the fault is ongoing at the given virtual time.
+func (f Fault) active(now time.Duration) bool {
+	return now >= f.Start && (f.End == 0 || now < f.End)
+}
+
+// Partition splits the network into groups of validators that can't reach each
+// other for a window of virtual time. Validators left out of every group are
+// isolated.
+type Partition struct {
+	Start  time.Duration
+	End    time.Duration
+	Groups [][]int // Validator indexes
+}
+
+// group returns the group a validator is in, -1 if isolated.
+func (p Partition) group(validator int) int {
+	for i, group := range p.Groups {
+		for _, member := range group {
+			if member == validator {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Config is the setup of a simulated network.
+type Config struct {
+	Validators int                 // Number of validators, all of them signers at genesis
+	Blocks     uint64              // Height of the chain to simulate up to
+	Period     uint64              // Clique block period, in seconds
+	Epoch      uint64              // Clique epoch length, 30000 if zero
+	Poatc      *params.PoatcConfig // POATC extensions of the chain config, none if nil
+
+	// Seed feeds all the randomness of the simulation: the validator keys, the
+	// propagation delays and the out-of-turn wiggles. Running the same config
+	// twice yields the same report, except when engines pick between several
+	// pending votes, which they do at random on their own.
+	Seed int64
+
+	GasUsage   float64       // Share of the gas limit used by every block, drives dynamic block times
+	Latency    time.Duration // Base delay of the block propagation
+	Jitter     time.Duration // Maximum random delay added to the latency
+	Partitions []Partition   // Network partitions, may not overlap in time
+	Faults     map[int][]Fault
+
+	SampleInterval uint64 // Blocks between two reputation samples, 100 if zero
+}
+
+// validate checks the config for settings the simulation can't run with.
+func (c *Config) validate() error {
+	if c.Validators <= 0 {
+		return errors.New("no validators")
+	}
+	if c.Blocks == 0 {
+		return errors.New("no blocks to simulate")
+	}
+	if c.GasUsage < 0 || c.GasUsage > 1 {
+		return fmt.Errorf("gas usage %v not in [0, 1]", c.GasUsage)
+	}
+	for index := range c.Faults {
+		if index < 0 || index >= c.Validators {
+			return fmt.Errorf("fault of unknown validator %d", index)
+		}
+	}
+	if cfg := c.Poatc; cfg != nil {
+		// Simulated chains only consist of headers, without any state
+		if cfg.Registry != nil {
+			return errors.New("signer candidate registry needs chain state")
+		}
+		if cfg.ValidatorSelection != nil && cfg.ValidatorSelection.Staking != nil {
+			return errors.New("staking needs chain state")
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// event is an action scheduled on the virtual clock.
+type event struct {
+	at  time.Duration
+	seq uint64 // Scheduling order, to run simultaneous events deterministically
+	fn  func()
+}
+
+// eventQueue is a priority queue of events, earliest first.
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	return q[i].at < q[j].at || (q[i].at == q[j].at && q[i].seq < q[j].seq)
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
+
+// network is a running simulation.
+type network struct {
+	config *Config
+	chain  *params.ChainConfig
+	clock  *mclock.Simulated
+	rand   *rand.Rand
+
+	queue eventQueue
+	seq   uint64
+
+	nodes     []*node
+	reference *node                               // First validator without faults, whose chain the report is built from
+	sealed    map[uint64]map[common.Hash]struct{} // Blocks sealed at every height, by any validator
+	height    uint64                              // Highest block imported by any validator
+
+	samples map[common.Address][]ReputationSample
+	sampled uint64 // Last height reputation was sampled at
+}
+
+// Run simulates a network until the reference validator imports a block at the
+// configured height, or until no validator can seal anymore.
+func Run(config *Config) (*Report, error) {
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid simulation config: %w", err)
+	}
+	net, err := newNetwork(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range net.nodes {
+		n.prepare()
+	}
+	stalled := true
+	for net.queue.Len() > 0 {
+		ev := heap.Pop(&net.queue).(*event)
+		if now := net.now(); ev.at > now {
+			net.clock.Run(ev.at - now)
+		}
+		ev.fn()
+
+		if net.reference.chain.CurrentHeader().Number.Uint64() >= config.Blocks {
+			stalled = false
+			break
+		}
+	}
+	return net.report(stalled)
+}
+
+// newNetwork creates the validators of a simulation and their genesis block.
+func newNetwork(config *Config) (*network, error) {
+	chain := *params.AllCliqueProtocolChanges
+	chain.Clique = &params.CliqueConfig{Period: config.Period, Epoch: config.Epoch}
+	if chain.Clique.Epoch == 0 {
+		chain.Clique.Epoch = 30000
+	}
+	chain.Poatc = simulatedPoatcConfig(config.Poatc)
+
+	net := &network{
+		config:  config,
+		chain:   &chain,
+		clock:   new(mclock.Simulated),
+		rand:    rand.New(rand.NewSource(config.Seed)),
+		sealed:  make(map[uint64]map[common.Hash]struct{}),
+		samples: make(map[common.Address][]ReputationSample),
+	}
+	// Derive the validator keys from the seed and authorize them at genesis
+	keys := make([][]byte, config.Validators)
+	for i := range keys {
+		var seed [16]byte
+		binary.BigEndian.PutUint64(seed[:8], uint64(config.Seed))
+		binary.BigEndian.PutUint64(seed[8:], uint64(i))
+		keys[i] = crypto.Keccak256(seed[:])
+	}
+	nodes := make([]*node, config.Validators)
+	for i := range nodes {
+		key, err := crypto.ToECDSA(keys[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key of validator %d: %w", i, err)
+		}
+		nodes[i] = &node{index: i, key: key, addr: crypto.PubkeyToAddress(key.PublicKey), net: net}
+	}
+	signers := make([]common.Address, len(nodes))
+	for i, n := range nodes {
+		signers[i] = n.addr
+	}
+	sort.Slice(signers, func(i, j int) bool { return signers[i].Cmp(signers[j]) < 0 })
+
+	genesis := &types.Header{
+		Number:     common.Big0,
+		Time:       genesisTime,
+		GasLimit:   gasLimit,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: big.NewInt(1),
+		UncleHash:  types.EmptyUncleHash,
+		TxHash:     types.EmptyTxsHash,
+		Extra:      make([]byte, 32+len(signers)*common.AddressLength+crypto.SignatureLength),
+	}
+	for i, signer := range signers {
+		copy(genesis.Extra[32+i*common.AddressLength:], signer[:])
+	}
+	for _, n := range nodes {
+		if err := n.init(genesis); err != nil {
+			return nil, err
+		}
+	}
+	net.nodes, net.reference = nodes, nodes[0]
+	for _, n := range nodes {
+		if len(config.Faults[n.index]) == 0 {
+			net.reference = n
+			break
+		}
+	}
+	return net, nil
+}
+
+// simulatedPoatcConfig copies the POATC extensions of a simulation, keeping the
+// local lists of the validators in memory.
+func simulatedPoatcConfig(config *params.PoatcConfig) *params.PoatcConfig {
+	if config == nil {
+		config = new(params.PoatcConfig)
+	}
+	cpy := *config
+	lists := new(params.PoatcWhitelistBlacklistConfig)
+	if cpy.WhitelistBlacklist != nil {
+		*lists = *cpy.WhitelistBlacklist
+	}
+	lists.EnablePersistence = false
+	cpy.WhitelistBlacklist = lists
+	return &cpy
+}
+
+// now returns the current virtual time.
+func (net *network) now() time.Duration {
+	return time.Duration(net.clock.Now())
+}
+
+// after schedules an action on the virtual clock.
+func (net *network) after(d time.Duration, fn func()) {
+	if d < 0 {
+		d = 0
+	}
+	net.seq++
+	heap.Push(&net.queue, &event{at: net.now() + d, seq: net.seq, fn: fn})
+}
+
+// reachable returns whether a block sent by one validator reaches another one
+// at the current virtual time.
+func (net *network) reachable(from, to int) bool {
+	now := net.now()
+	for _, p := range net.config.Partitions {
+		if now < p.Start || now >= p.End {
+			continue
+		}
+		if group := p.group(from); group < 0 || group != p.group(to) {
+			return false
+		}
+	}
+	return true
+}
+
+// send propagates a block from one validator to another with the configured
+// latency, unless the network is partitioned between them.
+func (net *network) send(from, to *node, header *types.Header) {
+	if !net.reachable(from.index, to.index) {
+		return
+	}
+	delay := net.config.Latency
+	if net.config.Jitter > 0 {
+		delay += time.Duration(net.rand.Int63n(int64(net.config.Jitter)))
+	}
+	net.after(delay, func() { to.receive(from, header) })
+}
+
+// recordSeal tracks the blocks sealed at every height to count the forks.
+func (net *network) recordSeal(header *types.Header) {
+	number := header.Number.Uint64()
+	if net.sealed[number] == nil {
+		net.sealed[number] = make(map[common.Hash]struct{})
+	}
+	net.sealed[number][header.Hash()] = struct{}{}
+}
+
+// recordImport tracks the highest block imported by any validator, sampling the
+// reputation of the validators as seen by the importer whenever a sampling
+// interval is crossed.
+func (net *network) recordImport(n *node, header *types.Header) {
+	number := header.Number.Uint64()
+	if number <= net.height {
+		return
+	}
+	net.height = number
+
+	interval := net.config.SampleInterval
+	if interval == 0 {
+		interval = 100
+	}
+	if number/interval > net.sampled/interval || number == net.config.Blocks {
+		net.sampled = number
+		for addr, score := range n.reputations() {
+			net.samples[addr] = append(net.samples[addr], ReputationSample{Number: number, Score: score})
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that an honest network seals up to the configured height, every validator
+// taking part and agreeing on every block.
+func TestHonestNetwork(t *testing.T) {
+	report, err := Run(&Config{
+		Validators: 4,
+		Blocks:     200,
+		Period:     5,
+		Seed:       1,
+		Latency:    100 * time.Millisecond,
+		Jitter:     200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if report.Stalled || report.Blocks != 200 {
+		t.Fatalf("chain not completed: stalled %v, blocks %d", report.Stalled, report.Blocks)
+	}
+	if report.Rejected != 0 {
+		t.Errorf("honest blocks rejected: %d", report.Rejected)
+	}
+	if min := 200 * 5 * time.Second; report.Duration < min {
+		t.Errorf("blocks sealed faster than the period: %v for %d blocks", report.Duration, report.Blocks)
+	}
+	for _, v := range report.Validators {
+		if !v.Authorized || v.Activity == nil || v.Activity.Sealed == 0 {
+			t.Errorf("validator %d did not take part: authorized %v, activity %+v", v.Index, v.Authorized, v.Activity)
+		}
+		if v.Head+1 < report.Blocks {
+			t.Errorf("validator %d fell behind: head %d", v.Index, v.Head)
+		}
+	}
+}
+
+// Tests that the signers left take over the slots of an offline validator, and
+// that the validator catches up once back online.
+func TestOfflineValidator(t *testing.T) {
+	report, err := Run(&Config{
+		Validators: 5,
+		Blocks:     200,
+		Period:     5,
+		Seed:       2,
+		Latency:    100 * time.Millisecond,
+		Faults: map[int][]Fault{
+			3: {{Behaviour: Offline, Start: 100 * time.Second, End: 400 * time.Second}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if report.Stalled || report.Blocks != 200 {
+		t.Fatalf("chain not completed: stalled %v, blocks %d", report.Stalled, report.Blocks)
+	}
+	if report.Reference == 3 {
+		t.Fatalf("faulty validator used as reference")
+	}
+	offline := report.Validators[3]
+	if offline.Activity == nil || offline.Activity.MissedInTurn == 0 || offline.Uptime >= 1 {
+		t.Errorf("offline validator missed no slots: %+v", offline.Activity)
+	}
+	if offline.Head < 190 {
+		t.Errorf("offline validator did not catch up: head %d", offline.Head)
+	}
+	if report.InturnRatio >= 1 {
+		t.Errorf("in-turn ratio not lowered: %v", report.InturnRatio)
+	}
+}
+
+// Tests that a network stalls once too few validators are left to seal, rather
+// than running forever.
+func TestStalledNetwork(t *testing.T) {
+	report, err := Run(&Config{
+		Validators: 3,
+		Blocks:     100,
+		Period:     5,
+		Faults: map[int][]Fault{
+			0: {{Behaviour: Offline, Start: 50 * time.Second}},
+			1: {{Behaviour: Offline, Start: 50 * time.Second}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if !report.Stalled || report.Blocks >= 100 {
+		t.Errorf("network not stalled: stalled %v, blocks %d", report.Stalled, report.Blocks)
+	}
+}
+
+// Tests that equivocating and racing validators fork the chain, that equivocating
+// signers get dropped, and that validators with drifting clocks lose their
+// slots as their blocks arrive too early.
+func TestFaultyValidators(t *testing.T) {
+	report, err := Run(&Config{
+		Validators: 6,
+		Blocks:     300,
+		Period:     5,
+		Seed:       3,
+		Latency:    200 * time.Millisecond,
+		Jitter:     time.Second,
+		Poatc:      &params.PoatcConfig{EnableSlashing: true},
+		Faults: map[int][]Fault{
+			1: {{Behaviour: Equivocation}},
+			2: {{Behaviour: RapidSigning}},
+			4: {{Behaviour: TimestampDrift, Drift: time.Minute}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if report.Stalled || report.Blocks != 300 {
+		t.Fatalf("chain not completed: stalled %v, blocks %d", report.Stalled, report.Blocks)
+	}
+	if report.Forks == 0 || report.Reorgs == 0 {
+		t.Errorf("faulty validators caused no forks: forks %d, reorgs %d", report.Forks, report.Reorgs)
+	}
+	if equivocator := report.Validators[1]; equivocator.Authorized {
+		t.Errorf("equivocating validator still authorized: %+v", equivocator.Activity)
+	}
+	drifted := report.Validators[4]
+	for _, i := range []int{0, 3, 5} {
+		if honest := report.Validators[i]; drifted.Uptime >= honest.Uptime {
+			t.Errorf("drifted validator uptime %v not below honest validator %d uptime %v", drifted.Uptime, i, honest.Uptime)
+		}
+	}
+}
+
+// Tests that the same config yields the same report.
+func TestDeterminism(t *testing.T) {
+	config := &Config{
+		Validators: 5,
+		Blocks:     150,
+		Period:     3,
+		Seed:       4,
+		Latency:    100 * time.Millisecond,
+		Jitter:     time.Second,
+		Partitions: []Partition{{Start: 60 * time.Second, End: 180 * time.Second, Groups: [][]int{{0, 1, 2}, {3, 4}}}},
+		Faults: map[int][]Fault{
+			4: {{Behaviour: RapidSigning}},
+		},
+		Poatc:          &params.PoatcConfig{EnableReputationSystem: true},
+		SampleInterval: 50,
+	}
+	first, err := Run(config)
+	if err != nil {
+		t.Fatalf("first simulation failed: %v", err)
+	}
+	second, err := Run(config)
+	if err != nil {
+		t.Fatalf("second simulation failed: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("reports differ:\nfirst:  %+v\nsecond: %+v", first, second)
+	}
+}
+
+// Tests that configs the simulation can't run are rejected.
+func TestInvalidConfig(t *testing.T) {
+	tests := []*Config{
+		{Blocks: 10},
+		{Validators: 3},
+		{Validators: 3, Blocks: 10, GasUsage: 2},
+		{Validators: 3, Blocks: 10, Faults: map[int][]Fault{3: {{Behaviour: Offline}}}},
+		{Validators: 3, Blocks: 10, Poatc: &params.PoatcConfig{Registry: new(params.PoatcRegistryConfig)}},
+	}
+	for i, config := range tests {
+		if _, err := Run(config); err == nil {
+			t.Errorf("test %d: invalid config accepted", i)
+		}
+	}
+}
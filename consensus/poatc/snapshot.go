@@ -18,8 +18,10 @@ package poatc
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
-	"math/rand"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -35,10 +37,11 @@ import (
 // Vote represents a single vote that an authorized signer made to modify the
 // list of authorizations.
 type Vote struct {
-	Signer    common.Address `json:"signer"`    // Authorized signer that cast this vote
-	Block     uint64         `json:"block"`     // Block number the vote was cast in (expire old votes)
-	Address   common.Address `json:"address"`   // Account being voted on to change its authorization
-	Authorize bool           `json:"authorize"` // Whether to authorize or deauthorize the voted account
+	Signer    common.Address `json:"signer"`         // Authorized signer that cast this vote
+	Block     uint64         `json:"block"`          // Block number the vote was cast in (expire old votes)
+	Address   common.Address `json:"address"`        // Account being voted on to change its authorization
+	Authorize bool           `json:"authorize"`      // Whether to authorize or deauthorize the voted account
+	List      string         `json:"list,omitempty"` // Governed list the vote is about, empty for signer votes
 }
 
 // Tally is a simple vote tally to keep the current score of votes. Votes that
@@ -62,8 +65,28 @@ type Snapshot struct {
 	Votes   []*Vote                     `json:"votes"`   // List of votes cast in chronological order
 	Tally   map[common.Address]Tally    `json:"tally"`   // Current vote tally to avoid recalculating
 
-	// Validator selection manager for 2-tier selection
-	validatorSelectionManager *ValidatorSelectionManager
+	Time       uint64                              `json:"time"`                 // Timestamp of the block where the snapshot was created
+	SmallSet   []common.Address                    `json:"smallSet,omitempty"`   // Small validator set elected for the current selection interval
+	Reputation map[common.Address]SignerReputation `json:"reputation,omitempty"` // Consensus reputation of the authorized signers
+
+	Whitelist      map[common.Address]uint64 `json:"whitelist,omitempty"`      // Voted whitelist entries with their expiry block (0 = never)
+	Blacklist      map[common.Address]uint64 `json:"blacklist,omitempty"`      // Voted blacklist entries with their expiry block (0 = never)
+	ListVotes      []*Vote                   `json:"listVotes,omitempty"`      // List of whitelist/blacklist votes cast in chronological order
+	WhitelistTally map[common.Address]Tally  `json:"whitelistTally,omitempty"` // Current whitelist vote tally
+	BlacklistTally map[common.Address]Tally  `json:"blacklistTally,omitempty"` // Current blacklist vote tally
+
+	Slashed map[common.Address]uint64   `json:"slashed,omitempty"` // Block each signer was last slashed for double signing at
+	Stakes  map[common.Address]*big.Int `json:"stakes,omitempty"`  // Stakes of the signers committed to by the last checkpoint
+
+	Liveness map[common.Address]SignerLiveness `json:"liveness,omitempty"` // Missed slot accounting of the authorized signers
+
+	selection       *ValidatorSelectionConfig // Small validator set election parameters, nil if disabled
+	reputationRules *ReputationRules          // Consensus reputation rules, nil if reputation is not tracked
+	tracingSystem   *TracingSystem            // Tracing system notified about in-turn selections
+	listRules       *ListRules                // Whitelist/blacklist governance rules, nil if lists are not voted on
+	slashing        *SlashingRules            // Double signing slashing rules
+	staking         *StakingRules             // Location of the stakes in chain state, nil if elections ignore stake
+	liveness        *LivenessRules            // Missed slot tracking rules, nil if liveness is not tracked
 }
 
 // newSnapshot creates a new snapshot with the specified startup parameters. This
@@ -121,6 +144,48 @@ func (s *Snapshot) copy() *Snapshot {
 		Recents:  make(map[uint64]common.Address),
 		Votes:    make([]*Vote, len(s.Votes)),
 		Tally:    make(map[common.Address]Tally),
+		Time:     s.Time,
+
+		selection:       s.selection,
+		reputationRules: s.reputationRules,
+		tracingSystem:   s.tracingSystem,
+		listRules:       s.listRules,
+		slashing:        s.slashing,
+		staking:         s.staking,
+		liveness:        s.liveness,
+	}
+	if s.Slashed != nil {
+		cpy.Slashed = copyEntries(s.Slashed)
+	}
+	if s.Stakes != nil {
+		cpy.Stakes = make(map[common.Address]*big.Int, len(s.Stakes))
+		for signer, stake := range s.Stakes {
+			cpy.Stakes[signer] = new(big.Int).Set(stake)
+		}
+	}
+	if s.listRules != nil {
+		cpy.Whitelist = copyEntries(s.Whitelist)
+		cpy.Blacklist = copyEntries(s.Blacklist)
+		cpy.WhitelistTally = copyTally(s.WhitelistTally)
+		cpy.BlacklistTally = copyTally(s.BlacklistTally)
+		cpy.ListVotes = make([]*Vote, len(s.ListVotes))
+		copy(cpy.ListVotes, s.ListVotes)
+	}
+	if s.Reputation != nil {
+		cpy.Reputation = make(map[common.Address]SignerReputation, len(s.Reputation))
+		for address, reputation := range s.Reputation {
+			cpy.Reputation[address] = reputation
+		}
+	}
+	if s.Liveness != nil {
+		cpy.Liveness = make(map[common.Address]SignerLiveness, len(s.Liveness))
+		for address, liveness := range s.Liveness {
+			cpy.Liveness[address] = liveness
+		}
+	}
+	if s.SmallSet != nil {
+		cpy.SmallSet = make([]common.Address, len(s.SmallSet))
+		copy(cpy.SmallSet, s.SmallSet)
 	}
 	for signer := range s.Signers {
 		cpy.Signers[signer] = struct{}{}
@@ -149,20 +214,29 @@ func (s *Snapshot) cast(address common.Address, authorize bool) bool {
 	if !s.validVote(address, authorize) {
 		return false
 	}
-	// Cast the vote into an existing or new tally
-	if old, ok := s.Tally[address]; ok {
-		old.Votes++
-		s.Tally[address] = old
-	} else {
-		s.Tally[address] = Tally{Authorize: authorize, Votes: 1}
-	}
+	tally(s.Tally, address, authorize)
 	return true
 }
 
 // uncast removes a previously cast vote from the tally.
 func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
+	return untally(s.Tally, address, authorize)
+}
+
+// tally casts a vote into an existing or new tally of the given vote set.
+func tally(tallies map[common.Address]Tally, address common.Address, authorize bool) {
+	if old, ok := tallies[address]; ok {
+		old.Votes++
+		tallies[address] = old
+	} else {
+		tallies[address] = Tally{Authorize: authorize, Votes: 1}
+	}
+}
+
+// untally removes a previously cast vote from the tally of the given vote set.
+func untally(tallies map[common.Address]Tally, address common.Address, authorize bool) bool {
 	// If there's no tally, it's a dangling vote, just drop
-	tally, ok := s.Tally[address]
+	tally, ok := tallies[address]
 	if !ok {
 		return false
 	}
@@ -173,9 +247,9 @@ func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
 	// Otherwise revert the vote
 	if tally.Votes > 1 {
 		tally.Votes--
-		s.Tally[address] = tally
+		tallies[address] = tally
 	} else {
-		delete(s.Tally, address)
+		delete(tallies, address)
 	}
 	return true
 }
@@ -209,6 +283,20 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		if number%s.config.Epoch == 0 {
 			snap.Votes = nil
 			snap.Tally = make(map[common.Address]Tally)
+
+			if snap.listRules != nil {
+				snap.ListVotes = nil
+				snap.WhitelistTally = make(map[common.Address]Tally)
+				snap.BlacklistTally = make(map[common.Address]Tally)
+			}
+			// Checkpoints commit to the stakes weighting the coming elections
+			if snap.staking != nil {
+				snap.updateStakes(header)
+			}
+		}
+		// Drop any expired list entries and votes
+		if snap.listRules != nil {
+			snap.expireLists(number)
 		}
 		// Delete the oldest signer from the recent list to allow it signing again
 		if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
@@ -227,69 +315,50 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 				return nil, errRecentlySigned
 			}
 		}
+		if err := snap.checkLists(signer, number); err != nil {
+			return nil, err
+		}
 		snap.Recents[number] = signer
 
-		// Header authorized, discard any previous votes from the signer
-		for i, vote := range snap.Votes {
-			if vote.Signer == signer && vote.Address == header.Coinbase {
-				// Uncast the vote from the cached tally
-				snap.uncast(vote.Address, vote.Authorize)
-
-				// Uncast the vote from the chronological list
-				snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
-				break // only one vote allowed
-			}
+		// Account the in-turn slot of the block before anything changes the schedule
+		if snap.liveness != nil {
+			snap.trackLiveness(number, header.ParentHash, signer)
 		}
-		// Tally up the new vote from the signer
-		var authorize bool
-		switch {
-		case bytes.Equal(header.Nonce[:], nonceAuthVote):
-			authorize = true
-		case bytes.Equal(header.Nonce[:], nonceDropVote):
-			authorize = false
-		default:
-			return nil, errInvalidVote
-		}
-		if snap.cast(header.Coinbase, authorize) {
-			snap.Votes = append(snap.Votes, &Vote{
-				Signer:    signer,
-				Block:     number,
-				Address:   header.Coinbase,
-				Authorize: authorize,
-			})
+		// Track the reputation effects of the block, then advance the timestamp
+		if snap.reputationRules != nil {
+			snap.updateReputation(number, header.Time, signer)
 		}
-		// If the vote passed, update the list of signers
-		if tally := snap.Tally[header.Coinbase]; tally.Votes > len(snap.Signers)/2 {
-			if tally.Authorize {
-				snap.Signers[header.Coinbase] = struct{}{}
-			} else {
-				delete(snap.Signers, header.Coinbase)
-
-				// Signer list shrunk, delete any leftover recent caches
-				if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
-					delete(snap.Recents, number-limit)
-				}
-				// Discard any previous votes the deauthorized signer cast
-				for i := 0; i < len(snap.Votes); i++ {
-					if snap.Votes[i].Signer == header.Coinbase {
-						// Uncast the vote from the cached tally
-						snap.uncast(snap.Votes[i].Address, snap.Votes[i].Authorize)
-
-						// Uncast the vote from the chronological list
-						snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
-
-						i--
-					}
-				}
+		snap.Time = header.Time
+
+		// Slash the offender of any double signing evidence in the header
+		var signersChanged bool
+		if snap.slashing != nil {
+			evidence, err := snap.slashing.evidence(snap.config, header)
+			if err != nil {
+				return nil, err
 			}
-			// Discard any previous votes around the just changed account
-			for i := 0; i < len(snap.Votes); i++ {
-				if snap.Votes[i].Address == header.Coinbase {
-					snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
-					i--
+			if evidence != nil {
+				offender, err := snap.verifyEvidence(evidence, number)
+				if err != nil {
+					return nil, err
 				}
+				snap.slash(offender, number)
+				signersChanged = true
 			}
-			delete(snap.Tally, header.Coinbase)
+		}
+		// Tally up the vote carried by the header, list votes are kept apart
+		// from the votes on the signers
+		var voted bool
+		if list, add, ok := snap.listVote(header.Nonce); ok {
+			snap.applyListVote(signer, number, header.Coinbase, list, add)
+		} else if voted, err = snap.applySignerVote(signer, number, header); err != nil {
+			return nil, err
+		}
+		signersChanged = signersChanged || voted
+		// Re-elect the small validator set at every selection interval, or right
+		// away if the signer set changed beneath the current one
+		if snap.selection != nil && (snap.selection.SelectionInterval == 0 || number%snap.selection.SelectionInterval == 0 || signersChanged) {
+			snap.electSmallValidatorSet(number, header.Hash())
 		}
 		// If we're taking too much time (ecrecover), notify the user once a while
 		if time.Since(logged) > 8*time.Second {
@@ -306,6 +375,108 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+// applySignerVote tallies the signer vote carried by an authorized header and
+// updates the list of signers if the vote passed, reporting whether it did.
+func (s *Snapshot) applySignerVote(signer common.Address, number uint64, header *types.Header) (bool, error) {
+	// Header authorized, discard any previous votes from the signer
+	for i, vote := range s.Votes {
+		if vote.Signer == signer && vote.Address == header.Coinbase {
+			// Uncast the vote from the cached tally
+			s.uncast(vote.Address, vote.Authorize)
+
+			// Uncast the vote from the chronological list
+			s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+			break // only one vote allowed
+		}
+	}
+	// Tally up the new vote from the signer
+	var authorize bool
+	switch {
+	case bytes.Equal(header.Nonce[:], nonceAuthVote):
+		authorize = true
+	case bytes.Equal(header.Nonce[:], nonceDropVote):
+		authorize = false
+	default:
+		return false, errInvalidVote
+	}
+	// Votes to bring back a recently slashed signer are ignored
+	if (!authorize || !s.coolingDown(header.Coinbase, number)) && s.cast(header.Coinbase, authorize) {
+		s.Votes = append(s.Votes, &Vote{
+			Signer:    signer,
+			Block:     number,
+			Address:   header.Coinbase,
+			Authorize: authorize,
+		})
+	}
+	// If the vote passed, update the list of signers
+	tally := s.Tally[header.Coinbase]
+	if tally.Votes <= len(s.Signers)/2 {
+		return false, nil
+	}
+	if tally.Authorize {
+		s.Signers[header.Coinbase] = struct{}{}
+		if s.reputationRules != nil {
+			// Signers slashed in the past restart from the reputation floor
+			score := s.reputationRules.Initial
+			if _, slashed := s.Slashed[header.Coinbase]; slashed {
+				score = s.reputationRules.Min
+			}
+			s.Reputation[header.Coinbase] = SignerReputation{Score: score}
+		}
+		// Discard any previous votes around the just changed account
+		s.discardVotes(header.Coinbase)
+	} else {
+		s.removeSigner(header.Coinbase, number)
+	}
+	return true, nil
+}
+
+// removeSigner deauthorizes a signer at the given block, discarding any votes
+// it cast and any votes about it.
+func (s *Snapshot) removeSigner(signer common.Address, number uint64) {
+	delete(s.Signers, signer)
+	delete(s.Reputation, signer)
+	delete(s.Stakes, signer)
+	delete(s.Liveness, signer)
+
+	// Signer list shrunk, delete any leftover recent caches
+	if limit := uint64(len(s.Signers)/2 + 1); number >= limit {
+		delete(s.Recents, number-limit)
+	}
+	// Discard any previous votes the deauthorized signer cast
+	for i := 0; i < len(s.Votes); i++ {
+		if s.Votes[i].Signer == signer {
+			// Uncast the vote from the cached tally
+			s.uncast(s.Votes[i].Address, s.Votes[i].Authorize)
+
+			// Uncast the vote from the chronological list
+			s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+
+			i--
+		}
+	}
+	for i := 0; i < len(s.ListVotes); i++ {
+		if vote := s.ListVotes[i]; vote.Signer == signer {
+			untally(s.listTally(vote.List), vote.Address, vote.Authorize)
+			s.ListVotes = append(s.ListVotes[:i], s.ListVotes[i+1:]...)
+			i--
+		}
+	}
+	// Discard any previous votes around the just changed account
+	s.discardVotes(signer)
+}
+
+// discardVotes drops all votes and the tally about an account.
+func (s *Snapshot) discardVotes(address common.Address) {
+	for i := 0; i < len(s.Votes); i++ {
+		if s.Votes[i].Address == address {
+			s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+			i--
+		}
+	}
+	delete(s.Tally, address)
+}
+
 // signers retrieves the list of authorized signers in ascending order.
 func (s *Snapshot) signers() []common.Address {
 	sigs := make([]common.Address, 0, len(s.Signers))
@@ -317,64 +488,31 @@ func (s *Snapshot) signers() []common.Address {
 }
 
 // inturn returns if a signer at a given block height is in-turn or not.
-// Modified to use 2-tier validator selection: first select small validator set, then random from that set
+// Modified to use 2-tier validator selection: the in-turn signer is picked from
+// the elected small validator set, seeded by the parent hash. The outcome only
+// depends on the snapshot contents, so every node agrees on it.
 func (s *Snapshot) inturn(number uint64, signer common.Address) bool {
-	signers := s.signers()
-	if len(signers) == 0 {
+	selectedSigner, ok := s.inturnSigner(number)
+	if !ok {
 		return false
 	}
-
-	// Check if we have a validator selection manager
-	if s.validatorSelectionManager == nil {
-		// Fallback to simple random selection if no validator selection manager
-		return s.simpleRandomSelection(number, signer, signers)
-	}
-
-	// Step 1: Select small validator set from all validators
-	smallValidatorSet, err := s.validatorSelectionManager.SelectSmallValidatorSet(number, s.Hash)
-	if err != nil || len(smallValidatorSet) == 0 {
-		// Fallback to simple random selection if validator selection fails
-		log.Warn("Validator selection failed, falling back to simple random", "error", err)
-		return s.simpleRandomSelection(number, signer, signers)
-	}
-
-	// Step 2: Random selection from small validator set
-	// Use block hash and number as seed for deterministic randomness
-	seedData := make([]byte, 32)
-	// Use block number as part of seed
-	for i := 0; i < 8; i++ {
-		seedData[i] = byte(number >> (i * 8))
-	}
-	// Use block hash as additional entropy
-	copy(seedData[8:], s.Hash[:])
-
-	// Convert to int64 seed
-	seed := int64(0)
-	for i := 0; i < 8; i++ {
-		seed |= int64(seedData[i]) << (i * 8)
-	}
-
-	// Create a new random source for this specific selection
-	rng := rand.New(rand.NewSource(seed))
-
-	// Select random signer from small validator set
-	selectedIndex := rng.Intn(len(smallValidatorSet))
-	selectedSigner := smallValidatorSet[selectedIndex]
+	signers := s.signers()
 
 	log.Debug("Validator selection",
 		"block", number,
 		"total_signers", len(signers),
-		"small_set_size", len(smallValidatorSet),
+		"small_set_size", len(s.SmallSet),
 		"selected_signer", selectedSigner.Hex(),
 		"checking_signer", signer.Hex())
 
 	// Trace validator selection if tracing system is available
-	if s.validatorSelectionManager != nil && s.validatorSelectionManager.tracingSystem != nil {
-		s.validatorSelectionManager.tracingSystem.TraceRandomPOA(
+	if s.tracingSystem != nil {
+		seed := generateSelectionSeed(number, s.Hash)
+		s.tracingSystem.TraceRandomPOA(
 			number,
 			signer,
 			selectedSigner,
-			seed,
+			int64(binary.BigEndian.Uint64(seed)),
 			signers,
 		)
 	}
@@ -382,34 +520,305 @@ func (s *Snapshot) inturn(number uint64, signer common.Address) bool {
 	return selectedSigner == signer
 }
 
-// simpleRandomSelection provides fallback random selection
-func (s *Snapshot) simpleRandomSelection(number uint64, signer common.Address, signers []common.Address) bool {
-	// Use block hash and number as seed for better deterministic randomness
-	seedData := make([]byte, 32)
-	// Use block number as part of seed
-	for i := 0; i < 8; i++ {
-		seedData[i] = byte(number >> (i * 8))
+// inturnSigner returns the signer that is in-turn at the given block number, or
+// false if there are no signers to pick from.
+func (s *Snapshot) inturnSigner(number uint64) (common.Address, bool) {
+	return s.inturnSignerAt(number, s.Hash)
+}
+
+// inturnSignerAt returns the signer that is in-turn at the given block number on
+// top of the given parent, using the signers of the snapshot. It's meant to be
+// used while applying headers, before the snapshot hash is moved forward.
+func (s *Snapshot) inturnSignerAt(number uint64, parent common.Hash) (common.Address, bool) {
+	signers := s.signers()
+	if len(signers) == 0 {
+		return common.Address{}, false
+	}
+	// Pick from the small validator set if one was elected, otherwise fall
+	// back to choosing from all signers
+	candidates := signers
+	if len(s.SmallSet) > 0 {
+		candidates = s.SmallSet
+	}
+	seed := generateSelectionSeed(number, parent)
+	index := new(big.Int).Mod(new(big.Int).SetBytes(seed), big.NewInt(int64(len(candidates))))
+	return candidates[index.Uint64()], true
+}
+
+// electSmallValidatorSet elects the small validator set from the current
+// signers, seeded by the given block. Only weights recorded in the snapshot
+// take part in the election, so the result is identical on every node.
+func (s *Snapshot) electSmallValidatorSet(number uint64, hash common.Hash) {
+	signers := s.signers()
+	candidates := make([]electionCandidate, len(signers))
+	for i, signer := range signers {
+		candidates[i] = electionCandidate{Address: signer, Stake: s.Stakes[signer]}
+		if s.reputationRules != nil {
+			candidates[i].Reputation = s.Reputation[signer].Score
+		}
+	}
+	set, err := electValidators(s.selection, candidates, generateSelectionSeed(number, hash))
+	if err != nil {
+		log.Warn("Small validator set election failed", "number", number, "err", err)
+		set = nil
 	}
-	// Use block hash as additional entropy
-	copy(seedData[8:], s.Hash[:])
+	s.SmallSet = set
 
-	// Convert to int64 seed
-	seed := int64(0)
-	for i := 0; i < 8; i++ {
-		seed |= int64(seedData[i]) << (i * 8)
+	if s.tracingSystem != nil && len(set) > 0 {
+		validators := make([]string, len(set))
+		for i, addr := range set {
+			validators[i] = addr.Hex()
+		}
+		s.tracingSystem.Trace(TraceEventValidatorSelection, TraceLevelBasic, number, common.Address{},
+			fmt.Sprintf("Small validator set of %d elected from %d signers", len(set), len(signers)),
+			map[string]interface{}{
+				"validators":       validators,
+				"signers_count":    len(signers),
+				"selection_method": s.selection.SelectionMethod,
+			})
 	}
+}
 
-	// Create a new random source for this specific selection
-	rng := rand.New(rand.NewSource(seed))
+// setSelection sets the election parameters and the tracing system for this
+// snapshot. A nil or disabled selection config makes every signer eligible.
+func (s *Snapshot) setSelection(config *ValidatorSelectionConfig, tracingSystem *TracingSystem) {
+	if config != nil && !config.EnableValidatorSelection {
+		config = nil
+	}
+	s.selection = config
+	s.tracingSystem = tracingSystem
+}
+
+// setReputationRules sets the consensus reputation rules for this snapshot.
+func (s *Snapshot) setReputationRules(rules *ReputationRules) {
+	s.reputationRules = rules
+	if rules != nil && s.Reputation == nil {
+		s.Reputation = make(map[common.Address]SignerReputation)
+	}
+}
 
-	// Select random signer index
-	selectedIndex := rng.Intn(len(signers))
-	selectedSigner := signers[selectedIndex]
+// seedReputation initializes the reputation of every signer, either from the
+// scores committed to by a checkpoint (in ascending signer order) or, if none
+// are available, with the initial score.
+func (s *Snapshot) seedReputation(scores []uint64) {
+	for i, signer := range s.signers() {
+		score := s.reputationRules.Initial
+		if i < len(scores) {
+			score = scores[i]
+		}
+		s.Reputation[signer] = SignerReputation{Score: score}
+	}
+}
 
-	return selectedSigner == signer
+// updateReputation applies the reputation effects of a block sealed by signer
+// at the given number and timestamp. The snapshot timestamp must still be the
+// one of the parent block when called.
+func (s *Snapshot) updateReputation(number uint64, time uint64, signer common.Address) {
+	rules := s.reputationRules
+
+	// Scores decay at every epoch transition to keep old merit from piling up,
+	// and are then checked against the low threshold
+	if number%s.config.Epoch == 0 {
+		for address, reputation := range s.Reputation {
+			reputation.decay(rules)
+			if reputation.Score < rules.LowThreshold {
+				reputation.LowEpochs++
+			} else {
+				reputation.LowEpochs = 0
+			}
+			s.Reputation[address] = reputation
+		}
+	}
+	reputation, ok := s.Reputation[signer]
+	if !ok {
+		reputation = SignerReputation{Score: rules.Initial}
+	}
+	reputation.reward(rules, rules.BlockReward)
+	reputation.BlocksSigned++
+	reputation.LastSigned = number
+
+	// Sealing a block too long after its parent is recorded as a violation
+	if rules.MaxTimestampDrift > 0 && s.Time > 0 && time > s.Time+rules.MaxTimestampDrift {
+		reputation.Violations++
+		reputation.penalize(rules, rules.ViolationPenalty)
+	}
+	s.Reputation[signer] = reputation
+}
+
+// reputationScores returns the reputation scores of the signers in ascending
+// signer order, as committed to by checkpoint blocks.
+func (s *Snapshot) reputationScores() []uint64 {
+	signers := s.signers()
+	scores := make([]uint64, len(signers))
+	for i, signer := range signers {
+		scores[i] = s.Reputation[signer].Score
+	}
+	return scores
+}
+
+// setListRules sets the whitelist/blacklist governance rules of the snapshot.
+func (s *Snapshot) setListRules(rules *ListRules) {
+	s.listRules = rules
+	if rules == nil {
+		return
+	}
+	if s.Whitelist == nil {
+		s.Whitelist = make(map[common.Address]uint64)
+	}
+	if s.Blacklist == nil {
+		s.Blacklist = make(map[common.Address]uint64)
+	}
+	if s.WhitelistTally == nil {
+		s.WhitelistTally = make(map[common.Address]Tally)
+	}
+	if s.BlacklistTally == nil {
+		s.BlacklistTally = make(map[common.Address]Tally)
+	}
+}
+
+// listVote decodes a whitelist/blacklist vote from a header nonce, returning
+// the list voted on and whether the vote is about adding the address.
+func (s *Snapshot) listVote(nonce types.BlockNonce) (string, bool, bool) {
+	if s.listRules == nil {
+		return "", false, false
+	}
+	switch {
+	case bytes.Equal(nonce[:], nonceWhitelistAdd):
+		return listWhitelist, true, true
+	case bytes.Equal(nonce[:], nonceWhitelistRemove):
+		return listWhitelist, false, true
+	case bytes.Equal(nonce[:], nonceBlacklistAdd):
+		return listBlacklist, true, true
+	case bytes.Equal(nonce[:], nonceBlacklistRemove):
+		return listBlacklist, false, true
+	}
+	return "", false, false
+}
+
+// listEntries returns the entries of the named governed list.
+func (s *Snapshot) listEntries(list string) map[common.Address]uint64 {
+	if list == listWhitelist {
+		return s.Whitelist
+	}
+	return s.Blacklist
+}
+
+// listTally returns the vote tally of the named governed list.
+func (s *Snapshot) listTally(list string) map[common.Address]Tally {
+	if list == listWhitelist {
+		return s.WhitelistTally
+	}
+	return s.BlacklistTally
+}
+
+// validListVote returns whether it makes sense to cast the specified list vote
+// in the given snapshot context (e.g. don't try to add an already listed address).
+func (s *Snapshot) validListVote(list string, address common.Address, add bool) bool {
+	_, listed := s.listEntries(list)[address]
+	return listed != add
+}
+
+// applyListVote tallies a whitelist/blacklist vote carried by an authorized
+// header and updates the governed list if the vote passed.
+func (s *Snapshot) applyListVote(signer common.Address, number uint64, address common.Address, list string, add bool) {
+	// Discard any previous vote from the signer on the same list entry
+	for i, vote := range s.ListVotes {
+		if vote.Signer == signer && vote.List == list && vote.Address == address {
+			untally(s.listTally(list), vote.Address, vote.Authorize)
+			s.ListVotes = append(s.ListVotes[:i], s.ListVotes[i+1:]...)
+			break // only one vote allowed
+		}
+	}
+	// Tally up the new vote from the signer
+	if !s.validListVote(list, address, add) {
+		return
+	}
+	tallies := s.listTally(list)
+	tally(tallies, address, add)
+	s.ListVotes = append(s.ListVotes, &Vote{
+		Signer:    signer,
+		Block:     number,
+		Address:   address,
+		Authorize: add,
+		List:      list,
+	})
+	// If the vote passed, update the governed list
+	if tallies[address].Votes <= len(s.Signers)/2 {
+		return
+	}
+	entries := s.listEntries(list)
+	if add {
+		var expiry uint64
+		if s.listRules.EntryExpiry > 0 {
+			expiry = number + s.listRules.EntryExpiry
+		}
+		entries[address] = expiry
+	} else {
+		delete(entries, address)
+	}
+	// Discard any previous votes around the just changed entry
+	for i := 0; i < len(s.ListVotes); i++ {
+		if s.ListVotes[i].List == list && s.ListVotes[i].Address == address {
+			s.ListVotes = append(s.ListVotes[:i], s.ListVotes[i+1:]...)
+			i--
+		}
+	}
+	delete(tallies, address)
 }
 
-// setValidatorSelectionManager sets the validator selection manager for this snapshot
-func (s *Snapshot) setValidatorSelectionManager(manager *ValidatorSelectionManager) {
-	s.validatorSelectionManager = manager
+// expireLists drops the governed list entries and the list votes that expired
+// at the given block.
+func (s *Snapshot) expireLists(number uint64) {
+	for _, entries := range []map[common.Address]uint64{s.Whitelist, s.Blacklist} {
+		for address, expiry := range entries {
+			if expiry != 0 && expiry <= number {
+				delete(entries, address)
+			}
+		}
+	}
+	if s.listRules.VoteExpiry == 0 {
+		return
+	}
+	for i := 0; i < len(s.ListVotes); i++ {
+		if vote := s.ListVotes[i]; vote.Block+s.listRules.VoteExpiry <= number {
+			untally(s.listTally(vote.List), vote.Address, vote.Authorize)
+			s.ListVotes = append(s.ListVotes[:i], s.ListVotes[i+1:]...)
+			i--
+		}
+	}
+}
+
+// checkLists verifies that the governed lists allow the signer to seal the
+// given block.
+func (s *Snapshot) checkLists(signer common.Address, number uint64) error {
+	if s.listRules == nil {
+		return nil
+	}
+	if expiry, ok := s.Blacklist[signer]; ok && (expiry == 0 || expiry > number) {
+		return errBlacklistedSigner
+	}
+	if s.listRules.StrictWhitelist && len(s.Whitelist) > 0 {
+		if expiry, ok := s.Whitelist[signer]; !ok || (expiry != 0 && expiry <= number) {
+			return errNotWhitelistedSigner
+		}
+	}
+	return nil
+}
+
+// copyEntries creates a copy of a governed list.
+func copyEntries(entries map[common.Address]uint64) map[common.Address]uint64 {
+	cpy := make(map[common.Address]uint64, len(entries))
+	for address, expiry := range entries {
+		cpy[address] = expiry
+	}
+	return cpy
+}
+
+// copyTally creates a copy of a vote tally.
+func copyTally(tallies map[common.Address]Tally) map[common.Address]Tally {
+	cpy := make(map[common.Address]Tally, len(tallies))
+	for address, tally := range tallies {
+		cpy[address] = tally
+	}
+	return cpy
 }
@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/exp/slices"
+)
+
+// subscriptionBuffer is the number of events buffered for every subscription, so
+// slow clients don't hold up the subsystems publishing them.
+const subscriptionBuffer = 128
+
+// SubscribeAnomalies subscribes to the anomalies detected in the sealed blocks.
+func (c *POATC) SubscribeAnomalies(ch chan<- AnomalyResult) event.Subscription {
+	return c.anomalyFeed.Subscribe(ch)
+}
+
+// SubscribeReputationChanges subscribes to the changes of the local reputation
+// scores of the validators.
+func (c *POATC) SubscribeReputationChanges(ch chan<- ReputationChange) event.Subscription {
+	return c.reputationFeed.Subscribe(ch)
+}
+
+// SubscribeListTransitions subscribes to the state transitions of the addresses
+// in the local whitelist and blacklist.
+func (c *POATC) SubscribeListTransitions(ch chan<- ListTransition) event.Subscription {
+	return c.listFeed.Subscribe(ch)
+}
+
+// SubscribeTraceEvents subscribes to the events recorded by the tracing system.
+func (c *POATC) SubscribeTraceEvents(ch chan<- TraceEvent) event.Subscription {
+	return c.traceFeed.Subscribe(ch)
+}
+
+// TraceEventFilter selects the trace events delivered to a subscription.
+type TraceEventFilter struct {
+	Types     []TraceEventType `json:"types"`     // Event types to deliver, all if empty
+	Level     TraceLevel       `json:"level"`     // Most detailed level to deliver, all if off
+	Addresses []common.Address `json:"addresses"` // Addresses to deliver the events of, all if empty
+}
+
+// matches returns whether an event passes the filter.
+func (f *TraceEventFilter) matches(event TraceEvent) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+	if f.Level != TraceLevelOff && event.Level > f.Level {
+		return false
+	}
+	if len(f.Addresses) > 0 && !slices.Contains(f.Addresses, event.Address) {
+		return false
+	}
+	return true
+}
+
+// notify creates an RPC subscription relaying the events of an engine feed to
+// the client, skipping the ones rejected by the filter if one is given.
+func notify[T any](ctx context.Context, subscribe func(chan<- T) event.Subscription, filter func(T) bool) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	// Subscribe before returning, so no event published after the call is missed
+	events := make(chan T, subscriptionBuffer)
+	sub := subscribe(events)
+
+	go func() {
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				if filter == nil || filter(ev) {
+					notifier.Notify(rpcSub.ID, ev)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// Anomalies sends a notification for every anomaly detected in a sealed block.
+func (api *API) Anomalies(ctx context.Context) (*rpc.Subscription, error) {
+	return notify(ctx, api.poatc.SubscribeAnomalies, nil)
+}
+
+// ReputationChanges sends a notification each time the local reputation score
+// of a validator changes.
+func (api *API) ReputationChanges(ctx context.Context) (*rpc.Subscription, error) {
+	return notify(ctx, api.poatc.SubscribeReputationChanges, nil)
+}
+
+// ListChanges sends a notification each time an address moves between the
+// states of the local whitelist and blacklist.
+func (api *API) ListChanges(ctx context.Context) (*rpc.Subscription, error) {
+	return notify(ctx, api.poatc.SubscribeListTransitions, nil)
+}
+
+// ValidatorSelection sends a notification each time a small validator set is
+// elected. The elections are reported by the tracing system, so it must be
+// enabled.
+func (api *API) ValidatorSelection(ctx context.Context) (*rpc.Subscription, error) {
+	filter := &TraceEventFilter{Types: []TraceEventType{TraceEventValidatorSelection}}
+	return notify(ctx, api.poatc.SubscribeTraceEvents, filter.matches)
+}
+
+// TraceEvents sends a notification for every trace event recorded that passes
+// the optional filter.
+func (api *API) TraceEvents(ctx context.Context, filter *TraceEventFilter) (*rpc.Subscription, error) {
+	return notify(ctx, api.poatc.SubscribeTraceEvents, filter.matches)
+}
+
+// BlockTimeChanges sends a notification each time the dynamic block time is
+// adjusted. The adjustments are reported by the tracing system, so it must be
+// enabled at the detailed level at least.
+func (api *API) BlockTimeChanges(ctx context.Context) (*rpc.Subscription, error) {
+	filter := &TraceEventFilter{Types: []TraceEventType{TraceEventBlockTime}}
+	return notify(ctx, api.poatc.SubscribeTraceEvents, filter.matches)
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newTestSubscriptionClient creates an engine and an in-process RPC client
+// connected to its API.
+func newTestSubscriptionClient(t *testing.T) (*POATC, *rpc.Client) {
+	engine := NewWithConfig(&params.CliqueConfig{Period: 1, Epoch: 30000}, nil, nil)
+
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	for _, api := range engine.APIs(nil) {
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			t.Fatalf("failed to register %s API: %v", api.Namespace, err)
+		}
+	}
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+	return engine, client
+}
+
+// Tests that trace event subscriptions only deliver the events passing their
+// filter, and that the topics derived from the traces pick the right events.
+func TestTraceEventSubscription(t *testing.T) {
+	engine, client := newTestSubscriptionClient(t)
+	engine.initializeTracingSystem()
+
+	var (
+		addr  = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		other = common.HexToAddress("0x2345678901234567890123456789012345678901")
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filtered := make(chan TraceEvent, 16)
+	filter := &TraceEventFilter{
+		Types:     []TraceEventType{TraceEventAnomalyDetection, TraceEventBlockTime},
+		Level:     TraceLevelBasic,
+		Addresses: []common.Address{addr},
+	}
+	sub, err := client.Subscribe(ctx, "poatc", filtered, "traceEvents", filter)
+	if err != nil {
+		t.Fatalf("failed to subscribe to trace events: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	blockTimes := make(chan TraceEvent, 16)
+	sub, err = client.Subscribe(ctx, "poatc", blockTimes, "blockTimeChanges")
+	if err != nil {
+		t.Fatalf("failed to subscribe to block time changes: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	engine.tracingSystem.Trace(TraceEventAnomalyDetection, TraceLevelDetailed, 1, addr, "too detailed", nil)
+	engine.tracingSystem.Trace(TraceEventAnomalyDetection, TraceLevelBasic, 2, other, "other address", nil)
+	engine.tracingSystem.Trace(TraceEventReputation, TraceLevelBasic, 3, addr, "other type", nil)
+	engine.tracingSystem.Trace(TraceEventAnomalyDetection, TraceLevelBasic, 4, addr, "matching", nil)
+	engine.tracingSystem.Trace(TraceEventBlockTime, TraceLevelDetailed, 5, common.Address{}, "block time", nil)
+
+	select {
+	case event := <-filtered:
+		if event.BlockNumber != 4 {
+			t.Errorf("filtered event mismatch: have block %d, want %d", event.BlockNumber, 4)
+		}
+	case <-ctx.Done():
+		t.Fatalf("filtered event missing")
+	}
+	select {
+	case event := <-blockTimes:
+		if event.Type != TraceEventBlockTime || event.BlockNumber != 5 {
+			t.Errorf("block time event mismatch: have %s at block %d", event.Type, event.BlockNumber)
+		}
+	case <-ctx.Done():
+		t.Fatalf("block time event missing")
+	}
+	// Events are delivered in order, so nothing else may have passed the filter
+	engine.tracingSystem.Trace(TraceEventAnomalyDetection, TraceLevelBasic, 6, addr, "sentinel", nil)
+	select {
+	case event := <-filtered:
+		if event.BlockNumber != 6 {
+			t.Errorf("unexpected event: have block %d, want %d", event.BlockNumber, 6)
+		}
+	case <-ctx.Done():
+		t.Fatalf("sentinel event missing")
+	}
+}
+
+// Tests that whitelist and blacklist transitions are delivered to subscribers.
+func TestListChangesSubscription(t *testing.T) {
+	engine, client := newTestSubscriptionClient(t)
+	engine.whitelistBlacklistManager = newTestListManager()
+	engine.whitelistBlacklistManager.SetIntegrationComponents(nil, &engine.listFeed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transitions := make(chan ListTransition, 16)
+	sub, err := client.Subscribe(ctx, "poatc", transitions, "listChanges")
+	if err != nil {
+		t.Fatalf("failed to subscribe to list changes: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	admin := common.HexToAddress("0x3456789012345678901234567890123456789012")
+	if err := engine.whitelistBlacklistManager.AddToBlacklist(addr, admin, "misbehaving", nil); err != nil {
+		t.Fatalf("failed to blacklist: %v", err)
+	}
+	select {
+	case transition := <-transitions:
+		if transition.Address != addr || transition.To != ListBanned || !transition.Manual {
+			t.Errorf("transition mismatch: have %+v", transition)
+		}
+	case <-ctx.Done():
+		t.Fatalf("transition missing")
+	}
+}
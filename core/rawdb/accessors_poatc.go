@@ -0,0 +1,256 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReadPoatcTraceHead retrieves the sequence number the next POATC trace event
+// will be stored under.
+func ReadPoatcTraceHead(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(poatcTraceHeadKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WritePoatcTraceHead stores the sequence number the next POATC trace event
+// will be stored under.
+func WritePoatcTraceHead(db ethdb.KeyValueWriter, seq uint64) {
+	if err := db.Put(poatcTraceHeadKey, encodeBlockNumber(seq)); err != nil {
+		log.Crit("Failed to store trace head", "err", err)
+	}
+}
+
+// ReadPoatcTraceEvent retrieves the encoded POATC trace event stored under the
+// given sequence number.
+func ReadPoatcTraceEvent(db ethdb.KeyValueReader, seq uint64) []byte {
+	data, _ := db.Get(poatcTraceEventKey(seq))
+	return data
+}
+
+// WritePoatcTraceEvent stores an encoded POATC trace event under the given
+// sequence number, along with its block, type and address index entries.
+func WritePoatcTraceEvent(db ethdb.KeyValueWriter, seq uint64, number uint64, eventType string, address common.Address, blob []byte) {
+	if err := db.Put(poatcTraceEventKey(seq), blob); err != nil {
+		log.Crit("Failed to store trace event", "err", err)
+	}
+	for _, index := range [][]byte{PoatcTraceBlockIndex(), poatcTraceTypeIndex(eventType), poatcTraceAddrIndex(address)} {
+		if err := db.Put(poatcTraceIndexKey(index, number, seq), nil); err != nil {
+			log.Crit("Failed to store trace index entry", "err", err)
+		}
+	}
+}
+
+// DeletePoatcTraceEvent removes a POATC trace event and its index entries.
+func DeletePoatcTraceEvent(db ethdb.KeyValueWriter, seq uint64, number uint64, eventType string, address common.Address) {
+	if err := db.Delete(poatcTraceEventKey(seq)); err != nil {
+		log.Crit("Failed to delete trace event", "err", err)
+	}
+	for _, index := range [][]byte{PoatcTraceBlockIndex(), poatcTraceTypeIndex(eventType), poatcTraceAddrIndex(address)} {
+		if err := db.Delete(poatcTraceIndexKey(index, number, seq)); err != nil {
+			log.Crit("Failed to delete trace index entry", "err", err)
+		}
+	}
+}
+
+// IteratePoatcTraceEvents calls fn with the sequence number and encoding of
+// every stored POATC trace event, oldest first, until fn returns false.
+func IteratePoatcTraceEvents(db ethdb.Iteratee, fn func(seq uint64, blob []byte) bool) {
+	it := db.NewIterator(poatcTraceEventPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(poatcTraceEventPrefix)+8 {
+			continue
+		}
+		if !fn(binary.BigEndian.Uint64(key[len(poatcTraceEventPrefix):]), it.Value()) {
+			return
+		}
+	}
+}
+
+// PoatcTraceBlockIndex returns the index of all POATC trace events by block.
+func PoatcTraceBlockIndex() []byte {
+	return poatcTraceBlockIndexPrefix
+}
+
+// PoatcTraceTypeIndex returns the index of the POATC trace events of a type.
+func PoatcTraceTypeIndex(eventType string) []byte {
+	return poatcTraceTypeIndex(eventType)
+}
+
+// PoatcTraceAddressIndex returns the index of the POATC trace events about an
+// address.
+func PoatcTraceAddressIndex(address common.Address) []byte {
+	return poatcTraceAddrIndex(address)
+}
+
+// IteratePoatcTraceIndex calls fn with the block and sequence number of every
+// entry of a POATC trace index, starting at block from and ordered by block,
+// until fn returns false.
+func IteratePoatcTraceIndex(db ethdb.Iteratee, index []byte, from uint64, fn func(number uint64, seq uint64) bool) {
+	it := db.NewIterator(index, encodeBlockNumber(from))
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(index)+16 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(index):])
+		seq := binary.BigEndian.Uint64(key[len(index)+8:])
+		if !fn(number, seq) {
+			return
+		}
+	}
+}
+
+// ReadPoatcAdminNonce retrieves the last nonce the given signer authorized a
+// POATC admin action with.
+func ReadPoatcAdminNonce(db ethdb.KeyValueReader, address common.Address) uint64 {
+	data, _ := db.Get(poatcAdminNonceKey(address))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WritePoatcAdminNonce stores the last nonce the given signer authorized a POATC
+// admin action with.
+func WritePoatcAdminNonce(db ethdb.KeyValueWriter, address common.Address, nonce uint64) {
+	if err := db.Put(poatcAdminNonceKey(address), encodeBlockNumber(nonce)); err != nil {
+		log.Crit("Failed to store admin nonce", "err", err)
+	}
+}
+
+// WritePoatcReputationSnapshot stores the encoded local reputation of a validator
+// as of the given block.
+func WritePoatcReputationSnapshot(db ethdb.KeyValueWriter, address common.Address, number uint64, blob []byte) {
+	if err := db.Put(poatcReputationHistoryKey(address, number), blob); err != nil {
+		log.Crit("Failed to store reputation snapshot", "err", err)
+	}
+}
+
+// ReadPoatcReputationSnapshotAt retrieves the block number and encoding of the
+// latest reputation snapshot stored for a validator at or before the given
+// block, or a nil encoding if there is none. Iterators only seek forward, so the
+// snapshot is found by bisecting the blocks up to the given one.
+func ReadPoatcReputationSnapshotAt(db ethdb.Iteratee, address common.Address, number uint64) (uint64, []byte) {
+	prefix := poatcReputationHistory(address)
+
+	// first retrieves the first snapshot stored at or after the given block
+	first := func(start uint64) (uint64, []byte, bool) {
+		it := db.NewIterator(prefix, encodeBlockNumber(start))
+		defer it.Release()
+
+		for it.Next() {
+			key := it.Key()
+			if len(key) != len(prefix)+8 {
+				continue
+			}
+			return binary.BigEndian.Uint64(key[len(prefix):]), common.CopyBytes(it.Value()), true
+		}
+		return 0, nil, false
+	}
+	found, blob, ok := first(0)
+	if !ok || found > number {
+		return 0, nil
+	}
+	// Narrow down the blocks the latest snapshot may be stored at, which are
+	// always after the one found so far
+	for lo, hi := found+1, number; lo <= hi; {
+		mid := lo + (hi-lo)/2
+		if next, nextBlob, ok := first(mid); ok && next <= number {
+			found, blob, lo = next, nextBlob, next+1
+		} else {
+			hi = mid - 1
+		}
+	}
+	return found, blob
+}
+
+// IteratePoatcReputationHistory calls fn with the block number and encoding of
+// every reputation snapshot stored for a validator, starting at block from and
+// ordered by block, until fn returns false.
+func IteratePoatcReputationHistory(db ethdb.Iteratee, address common.Address, from uint64, fn func(number uint64, blob []byte) bool) {
+	prefix := poatcReputationHistory(address)
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		if !fn(binary.BigEndian.Uint64(key[len(prefix):]), it.Value()) {
+			return
+		}
+	}
+}
+
+// ReadPoatcReputationLedgerHead retrieves the sequence number the next POATC
+// reputation score change will be stored under.
+func ReadPoatcReputationLedgerHead(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(poatcReputationLedgerHeadKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WritePoatcReputationLedgerHead stores the sequence number the next POATC
+// reputation score change will be stored under.
+func WritePoatcReputationLedgerHead(db ethdb.KeyValueWriter, seq uint64) {
+	if err := db.Put(poatcReputationLedgerHeadKey, encodeBlockNumber(seq)); err != nil {
+		log.Crit("Failed to store reputation ledger head", "err", err)
+	}
+}
+
+// WritePoatcReputationDelta stores an encoded reputation score change of a
+// validator recorded at the given block under the given sequence number.
+func WritePoatcReputationDelta(db ethdb.KeyValueWriter, address common.Address, number uint64, seq uint64, blob []byte) {
+	if err := db.Put(poatcReputationLedgerKey(address, number, seq), blob); err != nil {
+		log.Crit("Failed to store reputation delta", "err", err)
+	}
+}
+
+// IteratePoatcReputationLedger calls fn with the sequence number and encoding of
+// every reputation score change of a validator recorded at the given block, in
+// the order they were stored, until fn returns false.
+func IteratePoatcReputationLedger(db ethdb.Iteratee, address common.Address, number uint64, fn func(seq uint64, blob []byte) bool) {
+	prefix := poatcReputationLedger(address, number)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		if !fn(binary.BigEndian.Uint64(key[len(prefix):]), it.Value()) {
+			return
+		}
+	}
+}
@@ -138,6 +138,21 @@ var (
 	ReputationScoresKey = []byte("reputation-scores") // ReputationScoresKey -> JSON(ReputationScore map)
 	ReputationEventsKey = []byte("reputation-events") // ReputationEventsKey -> JSON(ReputationEvent slice)
 
+	// POATC trace store keys
+	poatcTraceHeadKey          = []byte("poatc-trace-head") // poatcTraceHeadKey -> sequence number of the next trace event (uint64 big endian)
+	poatcTraceEventPrefix      = []byte("poatc-trace-e")    // poatcTraceEventPrefix + seq (uint64 big endian) -> JSON(TraceEvent)
+	poatcTraceBlockIndexPrefix = []byte("poatc-trace-b")    // poatcTraceBlockIndexPrefix + num (uint64 big endian) + seq (uint64 big endian) -> nil
+	poatcTraceTypeIndexPrefix  = []byte("poatc-trace-t")    // poatcTraceTypeIndexPrefix + type + 0x00 + num (uint64 big endian) + seq (uint64 big endian) -> nil
+	poatcTraceAddrIndexPrefix  = []byte("poatc-trace-a")    // poatcTraceAddrIndexPrefix + address + num (uint64 big endian) + seq (uint64 big endian) -> nil
+
+	// POATC admin keys
+	poatcAdminNoncePrefix = []byte("poatc-admin-n") // poatcAdminNoncePrefix + address -> last admin nonce used by the signer (uint64 big endian)
+
+	// POATC reputation history keys
+	poatcReputationHistoryPrefix = []byte("poatc-rep-h")   // poatcReputationHistoryPrefix + address + num (uint64 big endian) -> JSON(ReputationSnapshot)
+	poatcReputationLedgerHeadKey = []byte("poatc-rep-seq") // poatcReputationLedgerHeadKey -> sequence number of the next ledger entry (uint64 big endian)
+	poatcReputationLedgerPrefix  = []byte("poatc-rep-l")   // poatcReputationLedgerPrefix + address + num (uint64 big endian) + seq (uint64 big endian) -> JSON(ScoreDelta)
+
 	BestUpdateKey         = []byte("update-")    // bigEndian64(syncPeriod) -> RLP(types.LightClientUpdate)  (nextCommittee only referenced by root hash)
 	FixedCommitteeRootKey = []byte("fixedRoot-") // bigEndian64(syncPeriod) -> committee root hash
 	SyncCommitteeKey      = []byte("committee-") // bigEndian64(syncPeriod) -> serialized committee
@@ -161,6 +176,52 @@ func encodeBlockNumber(number uint64) []byte {
 	return enc
 }
 
+// poatcTraceEventKey = poatcTraceEventPrefix + seq (uint64 big endian)
+func poatcTraceEventKey(seq uint64) []byte {
+	return append(poatcTraceEventPrefix, encodeBlockNumber(seq)...)
+}
+
+// poatcTraceTypeIndex = poatcTraceTypeIndexPrefix + type + 0x00
+func poatcTraceTypeIndex(eventType string) []byte {
+	return append(append(append([]byte{}, poatcTraceTypeIndexPrefix...), eventType...), 0x00)
+}
+
+// poatcTraceAddrIndex = poatcTraceAddrIndexPrefix + address
+func poatcTraceAddrIndex(address common.Address) []byte {
+	return append(append([]byte{}, poatcTraceAddrIndexPrefix...), address.Bytes()...)
+}
+
+// poatcTraceIndexKey = index + num (uint64 big endian) + seq (uint64 big endian)
+func poatcTraceIndexKey(index []byte, number uint64, seq uint64) []byte {
+	return append(append(append([]byte{}, index...), encodeBlockNumber(number)...), encodeBlockNumber(seq)...)
+}
+
+// poatcAdminNonceKey = poatcAdminNoncePrefix + address
+func poatcAdminNonceKey(address common.Address) []byte {
+	return append(append([]byte{}, poatcAdminNoncePrefix...), address.Bytes()...)
+}
+
+// poatcReputationHistory = poatcReputationHistoryPrefix + address
+func poatcReputationHistory(address common.Address) []byte {
+	return append(append([]byte{}, poatcReputationHistoryPrefix...), address.Bytes()...)
+}
+
+// poatcReputationHistoryKey = poatcReputationHistoryPrefix + address + num (uint64 big endian)
+func poatcReputationHistoryKey(address common.Address, number uint64) []byte {
+	return append(poatcReputationHistory(address), encodeBlockNumber(number)...)
+}
+
+// poatcReputationLedger = poatcReputationLedgerPrefix + address + num (uint64 big endian)
+func poatcReputationLedger(address common.Address, number uint64) []byte {
+	key := append(append([]byte{}, poatcReputationLedgerPrefix...), address.Bytes()...)
+	return append(key, encodeBlockNumber(number)...)
+}
+
+// poatcReputationLedgerKey = poatcReputationLedgerPrefix + address + num (uint64 big endian) + seq (uint64 big endian)
+func poatcReputationLedgerKey(address common.Address, number uint64, seq uint64) []byte {
+	return append(poatcReputationLedger(address, number), encodeBlockNumber(seq)...)
+}
+
 // headerKeyPrefix = headerPrefix + num (uint64 big endian)
 func headerKeyPrefix(number uint64) []byte {
 	return append(headerPrefix, encodeBlockNumber(number)...)
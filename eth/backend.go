@@ -43,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/finality"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -72,6 +73,7 @@ type Ethereum struct {
 
 	blockchain         *core.BlockChain
 	handler            *handler
+	finality           *finalityHandler // Attestation exchange of POATC signers, nil if blocks aren't finalized
 	ethDialCandidates  enode.Iterator
 	snapDialCandidates enode.Iterator
 	merger             *consensus.Merger
@@ -249,6 +251,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		return nil, err
 	}
 
+	eth.finality = newFinalityHandler(eth.blockchain, eth.engine)
+
 	eth.miner = miner.New(eth, &config.Miner, eth.blockchain.Config(), eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
@@ -499,6 +503,9 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
+	if s.finality != nil {
+		protos = append(protos, finality.MakeProtocols(s.finality)...)
+	}
 	return protos
 }
 
@@ -523,6 +530,9 @@ func (s *Ethereum) Start() error {
 	}
 	// Start the networking layer and the light server if requested
 	s.handler.Start(maxPeers)
+	if s.finality != nil {
+		s.finality.start()
+	}
 	return nil
 }
 
@@ -533,6 +543,9 @@ func (s *Ethereum) Stop() error {
 	s.ethDialCandidates.Close()
 	s.snapDialCandidates.Close()
 	s.handler.Stop()
+	if s.finality != nil {
+		s.finality.stop()
+	}
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
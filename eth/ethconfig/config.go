@@ -167,7 +167,7 @@ type Config struct {
 func CreateConsensusEngine(config *params.ChainConfig, db ethdb.Database) (consensus.Engine, error) {
 	// If proof-of-authority is requested, set it up
 	if config.Clique != nil {
-		return beacon.New(poatc.New(config.Clique, db)), nil
+		return beacon.New(poatc.NewWithConfig(config.Clique, config.Poatc, db)), nil
 	}
 	// If defaulting to proof-of-work, enforce an already merged network since
 	// we cannot run PoW algorithms anymore, so we cannot even follow a chain
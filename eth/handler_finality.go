@@ -0,0 +1,182 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth/protocols/finality"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// errFinalityPeerRegistered is returned if a `fin` peer joins twice.
+var errFinalityPeerRegistered = errors.New("peer already registered")
+
+// finalityHandler implements the finality.Backend interface, exchanging the
+// attestations of the POATC signers with the `fin` peers: the local signer
+// attests every new head, and every attestation accepted by the engine is
+// relayed to the peers not knowing it yet.
+type finalityHandler struct {
+	chain  *core.BlockChain
+	engine *poatc.POATC
+
+	peers map[string]*finality.Peer
+	lock  sync.RWMutex // Protects the peer set
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newFinalityHandler creates the `fin` protocol handler of the chain, nil if the
+// chain isn't sealed by a POATC engine finalizing blocks.
+func newFinalityHandler(chain *core.BlockChain, engine consensus.Engine) *finalityHandler {
+	if b, ok := engine.(*beacon.Beacon); ok {
+		engine = b.InnerEngine()
+	}
+	p, ok := engine.(*poatc.POATC)
+	if !ok || !p.FinalityEnabled() {
+		return nil
+	}
+	return &finalityHandler{
+		chain:  chain,
+		engine: p,
+		peers:  make(map[string]*finality.Peer),
+		quit:   make(chan struct{}),
+	}
+}
+
+// RunPeer is invoked when a peer joins on the `fin` protocol.
+func (h *finalityHandler) RunPeer(peer *finality.Peer, hand finality.Handler) error {
+	h.lock.Lock()
+	if _, ok := h.peers[peer.ID()]; ok {
+		h.lock.Unlock()
+		return errFinalityPeerRegistered
+	}
+	h.peers[peer.ID()] = peer
+	h.lock.Unlock()
+
+	defer func() {
+		h.lock.Lock()
+		delete(h.peers, peer.ID())
+		h.lock.Unlock()
+	}()
+	return hand(peer)
+}
+
+// PeerInfo retrieves all known `fin` information about a peer.
+func (h *finalityHandler) PeerInfo(id enode.ID) interface{} {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if p, ok := h.peers[id.String()]; ok {
+		return map[string]interface{}{"version": p.Version()}
+	}
+	return nil
+}
+
+// Handle is invoked from a peer's message handler when it receives attestations.
+// Attestations already accepted are skipped without verifying them again. The
+// ones the engine can't verify yet are dropped without penalizing the peer, as
+// they may simply be of blocks the local node doesn't know yet, but the peer is
+// disconnected on invalid ones.
+func (h *finalityHandler) Handle(peer *finality.Peer, attestations []*poatc.Attestation) error {
+	for _, attestation := range attestations {
+		if h.engine.KnownAttestation(attestation) {
+			continue
+		}
+		if err := h.engine.AddAttestation(h.chain, attestation); err != nil {
+			if errors.Is(err, poatc.ErrInvalidAttestation) {
+				return err
+			}
+			peer.Log().Trace("Dropped attestation", "number", attestation.Number, "hash", attestation.Hash, "err", err)
+		}
+	}
+	return nil
+}
+
+// start launches the attestation of the new heads and the relay of the accepted
+// attestations.
+func (h *finalityHandler) start() {
+	h.wg.Add(2)
+	go h.attestLoop()
+	go h.broadcastLoop()
+}
+
+// stop terminates the loops of the handler.
+func (h *finalityHandler) stop() {
+	close(h.quit)
+	h.wg.Wait()
+}
+
+// attestLoop attests every new head with the local signer, if any.
+func (h *finalityHandler) attestLoop() {
+	defer h.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := h.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-heads:
+			header := ev.Block.Header()
+			if _, err := h.engine.Attest(h.chain, header); err != nil {
+				log.Debug("Failed to attest block", "number", header.Number, "hash", header.Hash(), "err", err)
+			}
+		case <-sub.Err():
+			return
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// broadcastLoop queues the attestations accepted by the engine for relay to the
+// peers not knowing them yet.
+func (h *finalityHandler) broadcastLoop() {
+	defer h.wg.Done()
+
+	attestations := make(chan *poatc.Attestation, 256)
+	sub := h.engine.SubscribeAttestations(attestations)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case attestation := <-attestations:
+			id := attestation.ID()
+
+			h.lock.RLock()
+			for _, peer := range h.peers {
+				if !peer.KnownAttestation(id) {
+					peer.AsyncSendAttestation(attestation)
+				}
+			}
+			h.lock.RUnlock()
+
+		case <-sub.Err():
+			return
+		case <-h.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/finality"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the `fin` handler only runs on chains finalizing blocks, and that
+// local attestations finalize blocks and are relayed to the peers.
+func TestFinalityRelay(t *testing.T) {
+	if h := newFinalityHandler(nil, ethash.NewFaker()); h != nil {
+		t.Fatalf("finality handler created for ethash")
+	}
+	if h := newFinalityHandler(nil, poatc.New(params.AllCliqueProtocolChanges.Clique, nil)); h != nil {
+		t.Fatalf("finality handler created without finality enabled")
+	}
+	config := *params.AllCliqueProtocolChanges
+	config.Poatc = &params.PoatcConfig{EnableFinality: true}
+	gspec := &core.Genesis{
+		Config:    &config,
+		ExtraData: make([]byte, 32+len(testAddr)+crypto.SignatureLength),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	copy(gspec.ExtraData[32:], testAddr[:])
+
+	engine := poatc.NewWithConfig(config.Clique, config.Poatc, rawdb.NewMemoryDatabase())
+	engine.Authorize(testAddr, func(account accounts.Account, mimeType string, message []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(message), testKey)
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	handler := newFinalityHandler(chain, engine)
+	if handler == nil {
+		t.Fatalf("finality handler not created")
+	}
+	handler.start()
+	defer handler.stop()
+
+	local, remote := p2p.MsgPipe()
+	defer local.Close()
+	defer remote.Close()
+
+	peer := finality.NewFakePeer(finality.FIN1, "0123456789abcdef", local)
+	defer peer.Close()
+
+	go handler.RunPeer(peer, func(peer *finality.Peer) error {
+		return finality.Handle(handler, peer)
+	})
+	// Wait for the peer to register, then attest the head as its only signer
+	registered := func() bool {
+		handler.lock.RLock()
+		defer handler.lock.RUnlock()
+		return len(handler.peers) > 0
+	}
+	for i := 0; !registered(); i++ {
+		if i == 100 {
+			t.Fatalf("peer not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Attestations of unknown blocks are dropped, but invalid ones tear the
+	// connection down
+	head := chain.CurrentBlock()
+
+	key, _ := crypto.GenerateKey()
+	unknown, _ := poatc.SignAttestation(key, config.ChainID, &types.Header{Number: big.NewInt(1)})
+	if err := handler.Handle(peer, []*poatc.Attestation{unknown}); err != nil {
+		t.Errorf("attestation of an unknown block rejected: %v", err)
+	}
+	forged, _ := poatc.SignAttestation(key, config.ChainID, head)
+	if err := handler.Handle(peer, []*poatc.Attestation{forged}); !errors.Is(err, poatc.ErrInvalidAttestation) {
+		t.Errorf("invalid attestation error mismatch: have %v, want %v", err, poatc.ErrInvalidAttestation)
+	}
+	if _, err := engine.Attest(chain, head); err != nil {
+		t.Fatalf("failed to attest: %v", err)
+	}
+	if final := chain.CurrentFinalBlock(); final == nil || final.Hash() != head.Hash() {
+		t.Fatalf("head not finalized: %v", final)
+	}
+	msg, err := remote.ReadMsg()
+	if err != nil {
+		t.Fatalf("failed to read relayed attestation: %v", err)
+	}
+	var packet finality.AttestationsPacket
+	if err := msg.Decode(&packet); err != nil {
+		t.Fatalf("failed to decode relayed attestation: %v", err)
+	}
+	if len(packet) != 1 || packet[0].Hash != head.Hash() {
+		t.Errorf("relayed attestation mismatch: have %v", packet)
+	}
+	// Attestations already accepted are skipped without being verified again
+	if err := handler.Handle(peer, packet); err != nil {
+		t.Errorf("known attestation rejected: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package finality
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// maxAttestationsPerMsg is the maximum number of attestations a single message
+// may carry.
+const maxAttestationsPerMsg = 1024
+
+// Handler is a callback to invoke from an outside runner after the boilerplate
+// exchanges have passed.
+type Handler func(peer *Peer) error
+
+// Backend defines the callback methods to invoke on remote deliveries.
+type Backend interface {
+	// RunPeer is invoked when a peer joins on the `fin` protocol. The handler
+	// should do any peer maintenance work. If all is passed, control should be
+	// given back to the `handler` to process the inbound messages going forward.
+	RunPeer(peer *Peer, handler Handler) error
+
+	// PeerInfo retrieves all known `fin` information about a peer.
+	PeerInfo(id enode.ID) interface{}
+
+	// Handle is a callback to be invoked when attestations are received from
+	// the remote peer. Attestations that can't be verified yet should not tear
+	// the connection down, as the peer may be ahead or behind of the local
+	// chain, but invalid ones should.
+	Handle(peer *Peer, attestations []*poatc.Attestation) error
+}
+
+// MakeProtocols constructs the P2P protocol definitions for `fin`.
+func MakeProtocols(backend Backend) []p2p.Protocol {
+	protocols := make([]p2p.Protocol, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure
+
+		protocols[i] = p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  protocolLengths[version],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				peer := NewPeer(version, p, rw)
+				defer peer.Close()
+
+				return backend.RunPeer(peer, func(peer *Peer) error {
+					return Handle(backend, peer)
+				})
+			},
+			PeerInfo: func(id enode.ID) interface{} {
+				return backend.PeerInfo(id)
+			},
+		}
+	}
+	return protocols
+}
+
+// Handle is the callback invoked to manage the life cycle of a `fin` peer.
+// When this function terminates, the peer is disconnected.
+func Handle(backend Backend, peer *Peer) error {
+	for {
+		if err := HandleMessage(backend, peer); err != nil {
+			peer.Log().Debug("Message handling failed in `fin`", "err", err)
+			return err
+		}
+	}
+}
+
+// HandleMessage is invoked whenever an inbound message is received from a
+// remote peer on the `fin` protocol. The remote connection is torn down upon
+// returning any error.
+func HandleMessage(backend Backend, peer *Peer) error {
+	// Read the next message from the remote peer, and ensure it's fully consumed
+	msg, err := peer.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > maxMessageSize {
+		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case AttestationsMsg:
+		var attestations AttestationsPacket
+		if err := msg.Decode(&attestations); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if len(attestations) > maxAttestationsPerMsg {
+			return fmt.Errorf("%w: %d attestations", errDecode, len(attestations))
+		}
+		for i, attestation := range attestations {
+			if attestation == nil {
+				return fmt.Errorf("%w: attestation %d nil", errDecode, i)
+			}
+			peer.markAttestation(attestation.ID())
+		}
+		return backend.Handle(peer, attestations)
+
+	default:
+		return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package finality
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// testBackend records the attestations delivered by the protocol handler.
+type testBackend struct {
+	delivered []*poatc.Attestation
+}
+
+func (b *testBackend) RunPeer(peer *Peer, handler Handler) error { return handler(peer) }
+func (b *testBackend) PeerInfo(id enode.ID) interface{}          { return nil }
+
+func (b *testBackend) Handle(peer *Peer, attestations []*poatc.Attestation) error {
+	b.delivered = append(b.delivered, attestations...)
+	return nil
+}
+
+// Tests that attestations are delivered to the backend and marked as known by
+// the sending peer, and that malformed messages tear the connection down.
+func TestHandleAttestations(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	header := &types.Header{Number: big.NewInt(1), ParentHash: common.Hash{0x01}}
+	attestation, err := poatc.SignAttestation(key, big.NewInt(1), header)
+	if err != nil {
+		t.Fatalf("failed to sign attestation: %v", err)
+	}
+	local, remote := p2p.MsgPipe()
+	defer local.Close()
+	defer remote.Close()
+
+	backend := new(testBackend)
+	peer := NewFakePeer(FIN1, "0123456789abcdef", local)
+	defer peer.Close()
+
+	sender := NewFakePeer(FIN1, "fedcba9876543210", remote)
+	defer sender.Close()
+
+	sender.AsyncSendAttestation(attestation)
+	if !sender.KnownAttestation(attestation.ID()) {
+		t.Errorf("queued attestation not marked as known")
+	}
+	if err := HandleMessage(backend, peer); err != nil {
+		t.Fatalf("failed to handle attestations: %v", err)
+	}
+	if len(backend.delivered) != 1 || backend.delivered[0].ID() != attestation.ID() {
+		t.Fatalf("delivered attestations mismatch: have %v", backend.delivered)
+	}
+	if !peer.KnownAttestation(attestation.ID()) {
+		t.Errorf("delivered attestation not marked as known")
+	}
+	go p2p.Send(remote, AttestationsMsg, []byte{0x01})
+	if err := HandleMessage(backend, peer); !errors.Is(err, errDecode) {
+		t.Errorf("malformed message error mismatch: have %v, want %v", err, errDecode)
+	}
+	go p2p.Send(remote, AttestationsMsg+1, AttestationsPacket{attestation})
+	if err := HandleMessage(backend, peer); !errors.Is(err, errInvalidMsgCode) {
+		t.Errorf("unknown message error mismatch: have %v, want %v", err, errInvalidMsgCode)
+	}
+}
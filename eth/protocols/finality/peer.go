@@ -0,0 +1,156 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package finality
+
+import (
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+const (
+	// maxKnownAttestations is the maximum attestation IDs to keep in the known
+	// list before starting to randomly evict them.
+	maxKnownAttestations = 4096
+
+	// maxQueuedAttestations is the maximum number of attestations to queue up
+	// before dropping relays.
+	maxQueuedAttestations = 256
+)
+
+// Peer is a collection of relevant information we have about a `fin` peer.
+type Peer struct {
+	id string // Unique ID for the peer, cached
+
+	*p2p.Peer                   // The embedded P2P package peer
+	rw        p2p.MsgReadWriter // Input/output streams for fin
+	version   uint              // Protocol version negotiated
+
+	known  mapset.Set[common.Hash] // Set of attestation IDs known to be known by this peer
+	queued chan *poatc.Attestation // Queue of attestations to relay to the peer
+
+	logger log.Logger    // Contextual logger with the peer id injected
+	term   chan struct{} // Termination channel to stop the broadcaster
+}
+
+// NewPeer creates a wrapper for a network connection and negotiated  protocol
+// version.
+func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
+	id := p.ID().String()
+	peer := &Peer{
+		id:      id,
+		Peer:    p,
+		rw:      rw,
+		version: version,
+		known:   mapset.NewSet[common.Hash](),
+		queued:  make(chan *poatc.Attestation, maxQueuedAttestations),
+		logger:  log.New("peer", id[:8]),
+		term:    make(chan struct{}),
+	}
+	go peer.broadcastAttestations()
+	return peer
+}
+
+// NewFakePeer creates a fake fin peer without a backing p2p peer, for testing purposes.
+func NewFakePeer(version uint, id string, rw p2p.MsgReadWriter) *Peer {
+	peer := &Peer{
+		id:      id,
+		rw:      rw,
+		version: version,
+		known:   mapset.NewSet[common.Hash](),
+		queued:  make(chan *poatc.Attestation, maxQueuedAttestations),
+		logger:  log.New("peer", id[:8]),
+		term:    make(chan struct{}),
+	}
+	go peer.broadcastAttestations()
+	return peer
+}
+
+// Close signals the broadcast goroutine to terminate. Only ever call this if
+// you created the peer yourself via NewPeer. Otherwise let whoever created it
+// clean it up!
+func (p *Peer) Close() {
+	close(p.term)
+}
+
+// ID retrieves the peer's unique identifier.
+func (p *Peer) ID() string {
+	return p.id
+}
+
+// Version retrieves the peer's negotiated `fin` protocol version.
+func (p *Peer) Version() uint {
+	return p.version
+}
+
+// Log overrides the P2P logger with the higher level one containing only the id.
+func (p *Peer) Log() log.Logger {
+	return p.logger
+}
+
+// KnownAttestation returns whether peer is known to already have an attestation.
+func (p *Peer) KnownAttestation(id common.Hash) bool {
+	return p.known.Contains(id)
+}
+
+// markAttestation marks an attestation as known for the peer, ensuring that it
+// will never be propagated to this particular peer.
+func (p *Peer) markAttestation(id common.Hash) {
+	for p.known.Cardinality() >= maxKnownAttestations {
+		p.known.Pop()
+	}
+	p.known.Add(id)
+}
+
+// broadcastAttestations is a write loop relaying the queued attestations to the
+// remote peer, so slow peers don't lock up the relay of the others.
+func (p *Peer) broadcastAttestations() {
+	for {
+		select {
+		case attestation := <-p.queued:
+			if err := p.SendAttestations([]*poatc.Attestation{attestation}); err != nil {
+				return
+			}
+			p.Log().Trace("Relayed attestation", "number", attestation.Number, "hash", attestation.Hash)
+
+		case <-p.term:
+			return
+		}
+	}
+}
+
+// AsyncSendAttestation queues an attestation for relay to the peer. If the
+// peer's relay queue is full, the attestation is silently dropped.
+func (p *Peer) AsyncSendAttestation(attestation *poatc.Attestation) {
+	select {
+	case p.queued <- attestation:
+		p.markAttestation(attestation.ID())
+	default:
+		p.Log().Debug("Dropping attestation relay", "number", attestation.Number, "hash", attestation.Hash)
+	}
+}
+
+// SendAttestations sends a batch of attestations to the peer and marks them as
+// known.
+func (p *Peer) SendAttestations(attestations []*poatc.Attestation) error {
+	for _, attestation := range attestations {
+		p.markAttestation(attestation.ID())
+	}
+	return p2p.Send(p.rw, AttestationsMsg, AttestationsPacket(attestations))
+}
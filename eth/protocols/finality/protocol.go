@@ -0,0 +1,58 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package finality implements the `fin` protocol, over which POATC signers
+// exchange their attestations of recent blocks.
+package finality
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	FIN1 = 1
+)
+
+// ProtocolName is the official short name of the `fin` protocol used during
+// devp2p capability negotiation.
+const ProtocolName = "fin"
+
+// ProtocolVersions are the supported versions of the `fin` protocol (first
+// is primary).
+var ProtocolVersions = []uint{FIN1}
+
+// protocolLengths are the number of implemented message corresponding to
+// different protocol versions.
+var protocolLengths = map[uint]uint64{FIN1: 1}
+
+// maxMessageSize is the maximum cap on the size of a protocol message.
+const maxMessageSize = 1024 * 1024
+
+const (
+	AttestationsMsg = 0x00
+)
+
+var (
+	errMsgTooLarge    = errors.New("message too long")
+	errDecode         = errors.New("invalid message")
+	errInvalidMsgCode = errors.New("invalid message code")
+)
+
+// AttestationsPacket is the network packet for broadcasting block attestations.
+type AttestationsPacket []*poatc.Attestation
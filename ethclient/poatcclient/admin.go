@@ -0,0 +1,227 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatcclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// adminRequestTTL is how long the admin requests signed by the client are valid.
+const adminRequestTTL = 5 * time.Minute
+
+// AdminClient is a wrapper around rpc.Client that implements the poatcadmin
+// namespace, authorizing every call with the key of a signer. The namespace is
+// only served over IPC and the authenticated endpoints.
+type AdminClient struct {
+	c       *rpc.Client
+	chainID *big.Int
+	key     *ecdsa.PrivateKey
+
+	nonce uint64     // Last nonce the client signed a request with
+	lock  sync.Mutex // Protects the nonce
+}
+
+// NewAdmin creates an admin client that uses the given RPC client, signing its
+// requests for the given chain with the given signer key.
+func NewAdmin(c *rpc.Client, chainID *big.Int, key *ecdsa.PrivateKey) *AdminClient {
+	return &AdminClient{c: c, chainID: chainID, key: key}
+}
+
+// call signs the arguments of an admin method and invokes it.
+func (ac *AdminClient) call(ctx context.Context, result interface{}, method string, args interface{}) error {
+	// Nonces must grow across restarts of the client, use the clock as a floor
+	ac.lock.Lock()
+	nonce := uint64(time.Now().UnixNano())
+	if nonce <= ac.nonce {
+		nonce = ac.nonce + 1
+	}
+	ac.nonce = nonce
+	ac.lock.Unlock()
+
+	deadline := uint64(time.Now().Add(adminRequestTTL).Unix())
+	req, err := poatc.SignAdminRequest(ac.key, ac.chainID, method, args, nonce, deadline)
+	if err != nil {
+		return err
+	}
+	return ac.c.CallContext(ctx, result, poatc.AdminNamespace+"_"+method, req)
+}
+
+// listArgs are the arguments of the list admin methods.
+type listArgs struct {
+	Address common.Address `json:"address"`
+	Reason  string         `json:"reason,omitempty"`
+}
+
+// validatorArgs are the arguments of the validator selection admin methods.
+type validatorArgs struct {
+	Address    common.Address `json:"address"`
+	Stake      *big.Int       `json:"stake,omitempty"`
+	Reputation float64        `json:"reputation,omitempty"`
+}
+
+// blockArgs identify the block an admin method acts on.
+type blockArgs struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// addressArgs are the arguments of admin methods acting on a single validator.
+type addressArgs struct {
+	Address common.Address `json:"address"`
+}
+
+// ===== Whitelist and blacklist =====
+
+// AddToWhitelist adds an address to the node's whitelist.
+func (ac *AdminClient) AddToWhitelist(ctx context.Context, address common.Address, reason string) error {
+	return ac.call(ctx, nil, "addToWhitelist", listArgs{Address: address, Reason: reason})
+}
+
+// RemoveFromWhitelist removes an address from the node's whitelist.
+func (ac *AdminClient) RemoveFromWhitelist(ctx context.Context, address common.Address) error {
+	return ac.call(ctx, nil, "removeFromWhitelist", listArgs{Address: address})
+}
+
+// AddToBlacklist adds an address to the node's blacklist.
+func (ac *AdminClient) AddToBlacklist(ctx context.Context, address common.Address, reason string) error {
+	return ac.call(ctx, nil, "addToBlacklist", listArgs{Address: address, Reason: reason})
+}
+
+// RemoveFromBlacklist removes an address from the node's blacklist.
+func (ac *AdminClient) RemoveFromBlacklist(ctx context.Context, address common.Address) error {
+	return ac.call(ctx, nil, "removeFromBlacklist", listArgs{Address: address})
+}
+
+// CleanupExpiredEntries removes the expired entries from both lists.
+func (ac *AdminClient) CleanupExpiredEntries(ctx context.Context) error {
+	return ac.call(ctx, nil, "cleanupExpiredEntries", struct{}{})
+}
+
+// ForceReputationBasedWhitelistBlacklist updates the lists based on the current
+// reputation of the validators.
+func (ac *AdminClient) ForceReputationBasedWhitelistBlacklist(ctx context.Context) error {
+	return ac.call(ctx, nil, "forceReputationBasedWhitelistBlacklist", struct{}{})
+}
+
+// ===== Validator selection =====
+
+// AddValidator adds a validator to the election.
+func (ac *AdminClient) AddValidator(ctx context.Context, address common.Address, stake *big.Int, reputation float64) error {
+	return ac.call(ctx, nil, "addValidator", validatorArgs{Address: address, Stake: stake, Reputation: reputation})
+}
+
+// UpdateValidatorStake updates the stake of a validator.
+func (ac *AdminClient) UpdateValidatorStake(ctx context.Context, address common.Address, stake *big.Int) error {
+	return ac.call(ctx, nil, "updateValidatorStake", validatorArgs{Address: address, Stake: stake})
+}
+
+// UpdateValidatorReputation updates the election reputation of a validator.
+func (ac *AdminClient) UpdateValidatorReputation(ctx context.Context, address common.Address, reputation float64) error {
+	return ac.call(ctx, nil, "updateValidatorReputation", validatorArgs{Address: address, Reputation: reputation})
+}
+
+// ForceValidatorSelection elects a small validator set for the given block.
+func (ac *AdminClient) ForceValidatorSelection(ctx context.Context, number uint64, hash common.Hash) ([]common.Address, error) {
+	var result []common.Address
+	err := ac.call(ctx, &result, "forceValidatorSelection", blockArgs{Number: number, Hash: hash})
+	return result, err
+}
+
+// ===== Reputation =====
+
+// RecordViolation records a violation against a validator.
+func (ac *AdminClient) RecordViolation(ctx context.Context, address common.Address, number uint64, violationType, description string) error {
+	return ac.call(ctx, nil, "recordViolation", struct {
+		Address       common.Address `json:"address"`
+		Number        uint64         `json:"number"`
+		ViolationType string         `json:"violationType"`
+		Description   string         `json:"description"`
+	}{address, number, violationType, description})
+}
+
+// UpdateReputation recalculates the reputation of all validators.
+func (ac *AdminClient) UpdateReputation(ctx context.Context) error {
+	return ac.call(ctx, nil, "updateReputation", struct{}{})
+}
+
+// MarkValidatorOffline marks a validator as offline.
+func (ac *AdminClient) MarkValidatorOffline(ctx context.Context, address common.Address) error {
+	return ac.call(ctx, nil, "markValidatorOffline", addressArgs{Address: address})
+}
+
+// UpdateValidatorUptime marks a validator as online.
+func (ac *AdminClient) UpdateValidatorUptime(ctx context.Context, address common.Address) error {
+	return ac.call(ctx, nil, "updateValidatorUptime", addressArgs{Address: address})
+}
+
+// ForcePartialReset forces a partial reputation reset of a validator.
+func (ac *AdminClient) ForcePartialReset(ctx context.Context, address common.Address) error {
+	return ac.call(ctx, nil, "forcePartialReset", addressArgs{Address: address})
+}
+
+// ===== Tracing =====
+
+// ClearTraceEvents clears the in-memory trace events.
+func (ac *AdminClient) ClearTraceEvents(ctx context.Context) error {
+	return ac.call(ctx, nil, "clearTraceEvents", struct{}{})
+}
+
+// SetTraceLevel sets the most verbose level of events traced.
+func (ac *AdminClient) SetTraceLevel(ctx context.Context, level poatc.TraceLevel) error {
+	return ac.call(ctx, nil, "setTraceLevel", struct {
+		Level int `json:"level"`
+	}{int(level)})
+}
+
+// EnableTracing turns tracing on or off.
+func (ac *AdminClient) EnableTracing(ctx context.Context, enable bool) error {
+	return ac.call(ctx, nil, "enableTracing", struct {
+		Enable bool `json:"enable"`
+	}{enable})
+}
+
+// ===== Time dynamics =====
+
+// UpdateTransactionCount feeds a block transaction count to the dynamic block time.
+func (ac *AdminClient) UpdateTransactionCount(ctx context.Context, txCount int) error {
+	return ac.call(ctx, nil, "updateTransactionCount", struct {
+		TxCount int `json:"txCount"`
+	}{txCount})
+}
+
+// TriggerValidatorSelection triggers a timed validator selection for the block.
+func (ac *AdminClient) TriggerValidatorSelection(ctx context.Context, number uint64, hash common.Hash) error {
+	return ac.call(ctx, nil, "triggerValidatorSelection", blockArgs{Number: number, Hash: hash})
+}
+
+// TriggerReputationDecay triggers a reputation decay.
+func (ac *AdminClient) TriggerReputationDecay(ctx context.Context) error {
+	return ac.call(ctx, nil, "triggerReputationDecay", struct{}{})
+}
+
+// UpdateTimeDynamicConfig replaces the time dynamic configuration.
+func (ac *AdminClient) UpdateTimeDynamicConfig(ctx context.Context, config *poatc.TimeDynamicConfig) error {
+	return ac.call(ctx, nil, "updateTimeDynamicConfig", config)
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatcclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestAdminCalls(t *testing.T) {
+	client, chain := newTestClient(t)
+	ctx := context.Background()
+
+	// Subsystems are started by the first snapshot retrieval
+	if _, err := client.GetSnapshot(ctx, nil); err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	admin := NewAdmin(client.c, chain.Config().ChainID, testKey)
+	if err := admin.RecordViolation(ctx, testSigner, 0, "test", "manual violation"); err != nil {
+		t.Fatalf("failed to record violation: %v", err)
+	}
+	// Consecutive calls get growing nonces
+	if err := admin.RecordViolation(ctx, testSigner, 0, "test", "manual violation"); err != nil {
+		t.Fatalf("failed to record second violation: %v", err)
+	}
+	events, err := client.GetReputationEvents(ctx, 0)
+	if err != nil {
+		t.Fatalf("failed to retrieve reputation events: %v", err)
+	}
+	violations := 0
+	for _, event := range events {
+		if event.Address == testSigner && (event.EventType == "violation" || event.EventType == "penalty") {
+			violations++
+		}
+	}
+	if violations != 2 {
+		t.Errorf("violation events mismatch: have %d, want 2", violations)
+	}
+	// Calls signed by other keys are rejected
+	other, _ := crypto.GenerateKey()
+	if err := NewAdmin(client.c, chain.Config().ChainID, other).UpdateReputation(ctx); err == nil {
+		t.Errorf("call by non-signer executed")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatcclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ShadowSigner is a signer candidate registered in the candidate registry of a
+// chain. While on probation it follows the chain and attests every new head,
+// building up the reputation the engine promotes candidates by.
+type ShadowSigner struct {
+	ec       *ethclient.Client
+	chainID  *big.Int
+	registry common.Address
+	key      *ecdsa.PrivateKey
+}
+
+// NewShadowSigner creates a shadow signer calling the registry at the given
+// address with transactions signed by the given candidate key.
+func NewShadowSigner(ec *ethclient.Client, chainID *big.Int, registry common.Address, key *ecdsa.PrivateKey) *ShadowSigner {
+	return &ShadowSigner{ec: ec, chainID: chainID, registry: registry, key: key}
+}
+
+// Address returns the account of the candidate.
+func (s *ShadowSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+// Register registers the candidate to sign with the given node, depositing the
+// given amount of wei.
+func (s *ShadowSigner) Register(ctx context.Context, enode string, deposit *big.Int) (*types.Transaction, error) {
+	data, err := poatc.PackRegister(enode)
+	if err != nil {
+		return nil, err
+	}
+	return s.send(ctx, deposit, data)
+}
+
+// Attest attests that the candidate saw the given block.
+func (s *ShadowSigner) Attest(ctx context.Context, header *types.Header) (*types.Transaction, error) {
+	data, err := poatc.PackAttest(header.Number.Uint64(), header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return s.send(ctx, nil, data)
+}
+
+// Withdraw unregisters the candidate, refunding its deposit.
+func (s *ShadowSigner) Withdraw(ctx context.Context) (*types.Transaction, error) {
+	data, err := poatc.PackWithdraw()
+	if err != nil {
+		return nil, err
+	}
+	return s.send(ctx, nil, data)
+}
+
+// Run attests every new head of the chain until the context is canceled or the
+// head subscription fails. Failed attestations are logged and skipped, the next
+// head makes up for them.
+func (s *ShadowSigner) Run(ctx context.Context) error {
+	heads := make(chan *types.Header, 16)
+	sub, err := s.ec.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-heads:
+			if tx, err := s.Attest(ctx, head); err != nil {
+				log.Warn("Failed to attest block", "number", head.Number, "hash", head.Hash(), "err", err)
+			} else {
+				log.Debug("Attested block", "number", head.Number, "hash", head.Hash(), "tx", tx.Hash())
+			}
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// send signs and submits a registry call.
+func (s *ShadowSigner) send(ctx context.Context, value *big.Int, data []byte) (*types.Transaction, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	from := s.Address()
+	nonce, err := s.ec.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := s.ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := s.ec.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &s.registry, Value: value, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	tx, err := types.SignNewTx(s.key, types.LatestSignerForChainID(s.chainID), &types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      gas,
+		To:       &s.registry,
+		Value:    value,
+		Data:     data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ec.SendTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatcclient
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testTxPool is a minimal eth namespace accepting the transactions of a shadow
+// signer.
+type testTxPool struct {
+	txs []*types.Transaction
+}
+
+func (p *testTxPool) GetTransactionCount(account common.Address, block string) hexutil.Uint64 {
+	return hexutil.Uint64(len(p.txs))
+}
+
+func (p *testTxPool) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1))
+}
+
+func (p *testTxPool) EstimateGas(args map[string]interface{}) hexutil.Uint64 {
+	return 50000
+}
+
+func (p *testTxPool) SendRawTransaction(blob hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(blob); err != nil {
+		return common.Hash{}, err
+	}
+	p.txs = append(p.txs, tx)
+	return tx.Hash(), nil
+}
+
+// Tests that the shadow signer sends its registry calls to the registry, signed
+// by the candidate.
+func TestShadowSigner(t *testing.T) {
+	pool := new(testTxPool)
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", pool); err != nil {
+		t.Fatalf("failed to register eth API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer server.Stop()
+	defer client.Close()
+
+	var (
+		ctx      = context.Background()
+		chainID  = big.NewInt(1337)
+		registry = common.Address{0xee}
+		deposit  = big.NewInt(1000)
+		head     = &types.Header{Number: big.NewInt(7)}
+	)
+	shadow := NewShadowSigner(ethclient.NewClient(client), chainID, registry, testKey)
+	if _, err := shadow.Register(ctx, "enode://test", deposit); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	if _, err := shadow.Attest(ctx, head); err != nil {
+		t.Fatalf("failed to attest: %v", err)
+	}
+	if _, err := shadow.Withdraw(ctx); err != nil {
+		t.Fatalf("failed to withdraw: %v", err)
+	}
+	register, _ := poatc.PackRegister("enode://test")
+	attest, _ := poatc.PackAttest(7, head.Hash())
+	withdraw, _ := poatc.PackWithdraw()
+	want := []struct {
+		value *big.Int
+		data  []byte
+	}{
+		{deposit, register},
+		{new(big.Int), attest},
+		{new(big.Int), withdraw},
+	}
+	if len(pool.txs) != len(want) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(pool.txs), len(want))
+	}
+	for i, tx := range pool.txs {
+		from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+		if err != nil || from != testSigner {
+			t.Errorf("tx %d: sender mismatch: have %x, want %x (%v)", i, from, testSigner, err)
+		}
+		if tx.Nonce() != uint64(i) || *tx.To() != registry || tx.Value().Cmp(want[i].value) != 0 || !bytes.Equal(tx.Data(), want[i].data) {
+			t.Errorf("tx %d: mismatch: nonce %d, to %x, value %v, data %x", i, tx.Nonce(), tx.To(), tx.Value(), tx.Data())
+		}
+	}
+}
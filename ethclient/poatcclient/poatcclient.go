@@ -0,0 +1,551 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package poatcclient provides an RPC client for the POATC consensus APIs.
+package poatcclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Client is a wrapper around rpc.Client that implements the poatc namespace.
+type Client struct {
+	c *rpc.Client
+}
+
+// New creates a client that uses the given RPC client.
+func New(c *rpc.Client) *Client {
+	return &Client{c}
+}
+
+// ===== Snapshot and signers =====
+
+// GetSnapshot retrieves the voting snapshot at the given block, or the current
+// head if number is nil.
+func (pc *Client) GetSnapshot(ctx context.Context, number *big.Int) (*poatc.Snapshot, error) {
+	var snap *poatc.Snapshot
+	if err := pc.c.CallContext(ctx, &snap, "poatc_getSnapshot", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// GetSnapshotAtHash retrieves the voting snapshot at the given block.
+func (pc *Client) GetSnapshotAtHash(ctx context.Context, hash common.Hash) (*poatc.Snapshot, error) {
+	var snap *poatc.Snapshot
+	if err := pc.c.CallContext(ctx, &snap, "poatc_getSnapshotAtHash", hash); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// GetSigners retrieves the authorized signers at the given block, or the current
+// head if number is nil.
+func (pc *Client) GetSigners(ctx context.Context, number *big.Int) ([]common.Address, error) {
+	var signers []common.Address
+	err := pc.c.CallContext(ctx, &signers, "poatc_getSigners", toBlockNumArg(number))
+	return signers, err
+}
+
+// GetSignersAtHash retrieves the authorized signers at the given block.
+func (pc *Client) GetSignersAtHash(ctx context.Context, hash common.Hash) ([]common.Address, error) {
+	var signers []common.Address
+	err := pc.c.CallContext(ctx, &signers, "poatc_getSignersAtHash", hash)
+	return signers, err
+}
+
+// GetSigner returns the signer of the given block.
+func (pc *Client) GetSigner(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (common.Address, error) {
+	var signer common.Address
+	err := pc.c.CallContext(ctx, &signer, "poatc_getSigner", blockNrOrHash)
+	return signer, err
+}
+
+// GetHeaderSigner returns the signer of the given header, which doesn't need to
+// be known by the node.
+func (pc *Client) GetHeaderSigner(ctx context.Context, header *types.Header) (common.Address, error) {
+	blob, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	err = pc.c.CallContext(ctx, &signer, "poatc_getSigner", hexutil.Bytes(blob))
+	return signer, err
+}
+
+// Status returns the sealing activity of the signers over the recent blocks.
+//
+// Deprecated: use SignerReport, which covers any range and reports more.
+func (pc *Client) Status(ctx context.Context) (*poatc.SealerStatus, error) {
+	var status *poatc.SealerStatus
+	if err := pc.c.CallContext(ctx, &status, "poatc_status"); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// SignerReport returns the sealing activity of every signer between the given
+// blocks (inclusive), where nil stands for the current head.
+func (pc *Client) SignerReport(ctx context.Context, from, to *big.Int) (*poatc.SignerReport, error) {
+	var report *poatc.SignerReport
+	if err := pc.c.CallContext(ctx, &report, "poatc_signerReport", toBlockNumArg(from), toBlockNumArg(to)); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetCandidates returns the signer candidates registered at the given block, or
+// the current head if number is nil.
+func (pc *Client) GetCandidates(ctx context.Context, number *big.Int) ([]*poatc.Candidate, error) {
+	var candidates []*poatc.Candidate
+	err := pc.c.CallContext(ctx, &candidates, "poatc_getCandidates", toBlockNumArg(number))
+	return candidates, err
+}
+
+// ===== Proposals and evidence =====
+
+// Proposals returns the signer proposals the node votes on.
+func (pc *Client) Proposals(ctx context.Context) (map[common.Address]bool, error) {
+	var proposals map[common.Address]bool
+	err := pc.c.CallContext(ctx, &proposals, "poatc_proposals")
+	return proposals, err
+}
+
+// Propose injects a proposal to authorize or deauthorize a signer.
+func (pc *Client) Propose(ctx context.Context, address common.Address, auth bool) error {
+	return pc.c.CallContext(ctx, nil, "poatc_propose", address, auth)
+}
+
+// Discard drops a currently running signer proposal.
+func (pc *Client) Discard(ctx context.Context, address common.Address) error {
+	return pc.c.CallContext(ctx, nil, "poatc_discard", address)
+}
+
+// SubmitEvidence submits two conflicting headers sealed by the same signer, to
+// be included in a block by the node.
+func (pc *Client) SubmitEvidence(ctx context.Context, first, second *types.Header) error {
+	return pc.c.CallContext(ctx, nil, "poatc_submitEvidence", first, second)
+}
+
+// PendingEvidence returns the double signing evidence waiting for inclusion.
+func (pc *Client) PendingEvidence(ctx context.Context) ([]*poatc.Evidence, error) {
+	var evidence []*poatc.Evidence
+	err := pc.c.CallContext(ctx, &evidence, "poatc_pendingEvidence")
+	return evidence, err
+}
+
+// ListProposals returns the whitelist and blacklist proposals the node votes on,
+// keyed by list.
+func (pc *Client) ListProposals(ctx context.Context) (map[string]map[common.Address]bool, error) {
+	var proposals map[string]map[common.Address]bool
+	err := pc.c.CallContext(ctx, &proposals, "poatc_listProposals")
+	return proposals, err
+}
+
+// ProposeList injects a proposal to add an address to or remove it from a list.
+func (pc *Client) ProposeList(ctx context.Context, list string, address common.Address, add bool) error {
+	return pc.c.CallContext(ctx, nil, "poatc_proposeList", list, address, add)
+}
+
+// DiscardList drops a currently running list proposal.
+func (pc *Client) DiscardList(ctx context.Context, list string, address common.Address) error {
+	return pc.c.CallContext(ctx, nil, "poatc_discardList", list, address)
+}
+
+// ===== Anomaly detection =====
+
+// GetAnomalyStats returns statistics about the detected anomalies.
+func (pc *Client) GetAnomalyStats(ctx context.Context) (*poatc.AnomalyStats, error) {
+	var stats *poatc.AnomalyStats
+	if err := pc.c.CallContext(ctx, &stats, "poatc_getAnomalyStats"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// DetectAnomalies runs anomaly detection and returns the results.
+func (pc *Client) DetectAnomalies(ctx context.Context) ([]poatc.AnomalyResult, error) {
+	var anomalies []poatc.AnomalyResult
+	err := pc.c.CallContext(ctx, &anomalies, "poatc_detectAnomalies")
+	return anomalies, err
+}
+
+// GetAnomalyConfig returns the anomaly detection configuration.
+func (pc *Client) GetAnomalyConfig(ctx context.Context) (*poatc.AnomalyDetectionConfig, error) {
+	var config *poatc.AnomalyDetectionConfig
+	if err := pc.c.CallContext(ctx, &config, "poatc_getAnomalyConfig"); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ===== Whitelist and blacklist =====
+
+// GetGovernedLists returns the voted whitelist and blacklist at the given block,
+// or the current head if number is nil.
+func (pc *Client) GetGovernedLists(ctx context.Context, number *big.Int) (*poatc.GovernedLists, error) {
+	var lists *poatc.GovernedLists
+	if err := pc.c.CallContext(ctx, &lists, "poatc_getGovernedLists", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// GetWhitelistBlacklistStats returns statistics about the whitelist and blacklist.
+func (pc *Client) GetWhitelistBlacklistStats(ctx context.Context) (*poatc.ListStats, error) {
+	var stats *poatc.ListStats
+	if err := pc.c.CallContext(ctx, &stats, "poatc_getWhitelistBlacklistStats"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetWhitelist returns the node's whitelist.
+func (pc *Client) GetWhitelist(ctx context.Context) (map[common.Address]poatc.WhitelistEntry, error) {
+	var whitelist map[common.Address]poatc.WhitelistEntry
+	err := pc.c.CallContext(ctx, &whitelist, "poatc_getWhitelist")
+	return whitelist, err
+}
+
+// GetBlacklist returns the node's blacklist.
+func (pc *Client) GetBlacklist(ctx context.Context) (map[common.Address]poatc.BlacklistEntry, error) {
+	var blacklist map[common.Address]poatc.BlacklistEntry
+	err := pc.c.CallContext(ctx, &blacklist, "poatc_getBlacklist")
+	return blacklist, err
+}
+
+// IsWhitelisted reports whether an address is whitelisted.
+func (pc *Client) IsWhitelisted(ctx context.Context, address common.Address) (bool, error) {
+	var listed bool
+	err := pc.c.CallContext(ctx, &listed, "poatc_isWhitelisted", address)
+	return listed, err
+}
+
+// IsBlacklisted reports whether an address is blacklisted.
+func (pc *Client) IsBlacklisted(ctx context.Context, address common.Address) (bool, error) {
+	var listed bool
+	err := pc.c.CallContext(ctx, &listed, "poatc_isBlacklisted", address)
+	return listed, err
+}
+
+// ValidateSigner reports whether the lists allow a signer to sign, and why not.
+func (pc *Client) ValidateSigner(ctx context.Context, address common.Address) (*poatc.SignerValidation, error) {
+	var validation *poatc.SignerValidation
+	if err := pc.c.CallContext(ctx, &validation, "poatc_validateSigner", address); err != nil {
+		return nil, err
+	}
+	return validation, nil
+}
+
+// GetListStandings returns the whitelist/blacklist state of every address known
+// to the node.
+func (pc *Client) GetListStandings(ctx context.Context) (map[common.Address]poatc.ListStanding, error) {
+	var standings map[common.Address]poatc.ListStanding
+	err := pc.c.CallContext(ctx, &standings, "poatc_getListStandings")
+	return standings, err
+}
+
+// ===== Validator selection =====
+
+// GetValidatorSelectionStats returns statistics about the validator selection.
+func (pc *Client) GetValidatorSelectionStats(ctx context.Context) (*poatc.SelectionStats, error) {
+	var stats *poatc.SelectionStats
+	if err := pc.c.CallContext(ctx, &stats, "poatc_getValidatorSelectionStats"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetSmallValidatorSet returns the currently elected small validator set.
+func (pc *Client) GetSmallValidatorSet(ctx context.Context) ([]common.Address, error) {
+	var set []common.Address
+	err := pc.c.CallContext(ctx, &set, "poatc_getSmallValidatorSet")
+	return set, err
+}
+
+// GetValidatorInfo returns the election parameters of a validator.
+func (pc *Client) GetValidatorInfo(ctx context.Context, address common.Address) (*poatc.ValidatorInfo, error) {
+	var info *poatc.ValidatorInfo
+	if err := pc.c.CallContext(ctx, &info, "poatc_getValidatorInfo", address); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// GetSelectionHistory returns the past elections of small validator sets.
+func (pc *Client) GetSelectionHistory(ctx context.Context) ([]poatc.ValidatorSelectionRecord, error) {
+	var history []poatc.ValidatorSelectionRecord
+	err := pc.c.CallContext(ctx, &history, "poatc_getSelectionHistory")
+	return history, err
+}
+
+// ===== Reputation =====
+
+// GetReputationStats returns statistics about the reputation system.
+func (pc *Client) GetReputationStats(ctx context.Context) (*poatc.ReputationStats, error) {
+	var stats *poatc.ReputationStats
+	if err := pc.c.CallContext(ctx, &stats, "poatc_getReputationStats"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetReputationScore returns the consensus reputation of a signer at the given
+// block, or the current head if number is nil.
+func (pc *Client) GetReputationScore(ctx context.Context, address common.Address, number *big.Int) (*poatc.ChainReputation, error) {
+	var reputation *poatc.ChainReputation
+	if err := pc.c.CallContext(ctx, &reputation, "poatc_getReputationScore", address, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return reputation, nil
+}
+
+// GetTopValidators returns the validators with the highest reputation, all of
+// them if limit is zero.
+func (pc *Client) GetTopValidators(ctx context.Context, limit int) ([]*poatc.ReputationScore, error) {
+	var scores []*poatc.ReputationScore
+	err := pc.c.CallContext(ctx, &scores, "poatc_getTopValidators", limit)
+	return scores, err
+}
+
+// GetReputationEvents returns the most recent reputation events.
+func (pc *Client) GetReputationEvents(ctx context.Context, limit int) ([]poatc.ReputationEvent, error) {
+	var events []poatc.ReputationEvent
+	err := pc.c.CallContext(ctx, &events, "poatc_getReputationEvents", limit)
+	return events, err
+}
+
+// GetReputationHistory returns the local reputation of a validator with its
+// components at every step-th block between the given blocks (inclusive), where
+// nil stands for the current head.
+func (pc *Client) GetReputationHistory(ctx context.Context, address common.Address, from, to *big.Int, step uint64) ([]*poatc.ReputationSnapshot, error) {
+	var history []*poatc.ReputationSnapshot
+	err := pc.c.CallContext(ctx, &history, "poatc_getReputationHistory", address, toBlockNumArg(from), toBlockNumArg(to), step)
+	return history, err
+}
+
+// ExplainReputation returns the components of the local reputation of a
+// validator at the given block (nil for the current head) and the changes that
+// led to it, each with its cause.
+func (pc *Client) ExplainReputation(ctx context.Context, address common.Address, number *big.Int) (*poatc.ReputationExplanation, error) {
+	var explanation *poatc.ReputationExplanation
+	if err := pc.c.CallContext(ctx, &explanation, "poatc_explainReputation", address, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return explanation, nil
+}
+
+// GetIntegrationStatus returns which subsystems are running and feed each other.
+func (pc *Client) GetIntegrationStatus(ctx context.Context) (*poatc.IntegrationStatus, error) {
+	var status *poatc.IntegrationStatus
+	if err := pc.c.CallContext(ctx, &status, "poatc_getIntegrationStatus"); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// GetReputationBasedRecommendations returns the validators recommended for the
+// whitelist and blacklist based on their reputation.
+func (pc *Client) GetReputationBasedRecommendations(ctx context.Context) (*poatc.ReputationRecommendations, error) {
+	var recommendations *poatc.ReputationRecommendations
+	if err := pc.c.CallContext(ctx, &recommendations, "poatc_getReputationBasedRecommendations"); err != nil {
+		return nil, err
+	}
+	return recommendations, nil
+}
+
+// GetFairnessStats returns statistics about the fairness mechanisms.
+func (pc *Client) GetFairnessStats(ctx context.Context) (*poatc.FairnessStats, error) {
+	var stats *poatc.FairnessStats
+	if err := pc.c.CallContext(ctx, &stats, "poatc_getFairnessStats"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetValidatorFairnessInfo returns the fairness standing of a validator.
+func (pc *Client) GetValidatorFairnessInfo(ctx context.Context, address common.Address) (*poatc.ValidatorFairness, error) {
+	var info *poatc.ValidatorFairness
+	if err := pc.c.CallContext(ctx, &info, "poatc_getValidatorFairnessInfo", address); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ===== Tracing =====
+
+// GetTracingStats returns statistics about the tracing system.
+func (pc *Client) GetTracingStats(ctx context.Context) (*poatc.TraceStats, error) {
+	var stats *poatc.TraceStats
+	if err := pc.c.CallContext(ctx, &stats, "poatc_getTracingStats"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetTraceEvents returns the trace events of the given type (all if empty) up to
+// the given level, optionally narrowed down and paged through by the filter.
+func (pc *Client) GetTraceEvents(ctx context.Context, eventType string, level int, limit int, filter *poatc.TraceFilter) ([]poatc.TraceEvent, error) {
+	var events []poatc.TraceEvent
+	err := pc.c.CallContext(ctx, &events, "poatc_getTraceEvents", eventType, level, limit, filter)
+	return events, err
+}
+
+// GetMerkleRoot returns the current Merkle root of the trace events.
+func (pc *Client) GetMerkleRoot(ctx context.Context) (common.Hash, error) {
+	var root common.Hash
+	err := pc.c.CallContext(ctx, &root, "poatc_getMerkleRoot")
+	return root, err
+}
+
+// VerifyEventInMerkleTree reports whether an event is in the current Merkle tree.
+func (pc *Client) VerifyEventInMerkleTree(ctx context.Context, event poatc.TraceEvent) (bool, error) {
+	var included bool
+	err := pc.c.CallContext(ctx, &included, "poatc_verifyEventInMerkleTree", event)
+	return included, err
+}
+
+// GetMerkleProof returns the Merkle proof of an event against the current root.
+func (pc *Client) GetMerkleProof(ctx context.Context, event poatc.TraceEvent) (*poatc.TraceProof, error) {
+	var proof *poatc.TraceProof
+	if err := pc.c.CallContext(ctx, &proof, "poatc_getMerkleProof", event); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// GetBlockTrace returns the trace root committed to by the given block, or the
+// current head if number is nil.
+func (pc *Client) GetBlockTrace(ctx context.Context, number *big.Int) (*poatc.BlockTrace, error) {
+	var trace *poatc.BlockTrace
+	if err := pc.c.CallContext(ctx, &trace, "poatc_getBlockTrace", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// ExportTraceEvents exports the trace events and Merkle tree as JSON.
+func (pc *Client) ExportTraceEvents(ctx context.Context) (string, error) {
+	var export string
+	err := pc.c.CallContext(ctx, &export, "poatc_exportTraceEvents")
+	return export, err
+}
+
+// GetTraceMetrics returns the aggregated metrics of the traced events.
+func (pc *Client) GetTraceMetrics(ctx context.Context) (*poatc.TraceMetrics, error) {
+	var metrics *poatc.TraceMetrics
+	if err := pc.c.CallContext(ctx, &metrics, "poatc_getTraceMetrics"); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// ===== Time dynamics =====
+
+// GetTimeDynamicStats returns statistics about the time dynamic mechanisms.
+func (pc *Client) GetTimeDynamicStats(ctx context.Context) (*poatc.TimeDynamicStats, error) {
+	var stats *poatc.TimeDynamicStats
+	if err := pc.c.CallContext(ctx, &stats, "poatc_getTimeDynamicStats"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetCurrentBlockTime returns the current dynamic block time.
+func (pc *Client) GetCurrentBlockTime(ctx context.Context) (time.Duration, error) {
+	var seconds float64
+	if err := pc.c.CallContext(ctx, &seconds, "poatc_getCurrentBlockTime"); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// GetDecayHistory returns the most recent reputation decays.
+func (pc *Client) GetDecayHistory(ctx context.Context, limit int) ([]poatc.DecayRecord, error) {
+	var history []poatc.DecayRecord
+	err := pc.c.CallContext(ctx, &history, "poatc_getDecayHistory", limit)
+	return history, err
+}
+
+// GetTimeDynamicConfig returns the time dynamic configuration.
+func (pc *Client) GetTimeDynamicConfig(ctx context.Context) (*poatc.TimeDynamicConfig, error) {
+	var config *poatc.TimeDynamicConfig
+	if err := pc.c.CallContext(ctx, &config, "poatc_getTimeDynamicConfig"); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ===== Subscriptions =====
+
+// SubscribeAnomalies subscribes to the anomalies detected in the sealed blocks.
+func (pc *Client) SubscribeAnomalies(ctx context.Context, ch chan<- poatc.AnomalyResult) (*rpc.ClientSubscription, error) {
+	return pc.c.Subscribe(ctx, "poatc", ch, "anomalies")
+}
+
+// SubscribeReputationChanges subscribes to the changes of the reputation scores
+// tracked by the node.
+func (pc *Client) SubscribeReputationChanges(ctx context.Context, ch chan<- poatc.ReputationChange) (*rpc.ClientSubscription, error) {
+	return pc.c.Subscribe(ctx, "poatc", ch, "reputationChanges")
+}
+
+// SubscribeListChanges subscribes to the state transitions of the addresses in
+// the node's whitelist and blacklist.
+func (pc *Client) SubscribeListChanges(ctx context.Context, ch chan<- poatc.ListTransition) (*rpc.ClientSubscription, error) {
+	return pc.c.Subscribe(ctx, "poatc", ch, "listChanges")
+}
+
+// SubscribeValidatorSelection subscribes to the small validator set elections.
+func (pc *Client) SubscribeValidatorSelection(ctx context.Context, ch chan<- poatc.TraceEvent) (*rpc.ClientSubscription, error) {
+	return pc.c.Subscribe(ctx, "poatc", ch, "validatorSelection")
+}
+
+// SubscribeTraceEvents subscribes to the trace events passing the filter, all of
+// them if the filter is nil.
+func (pc *Client) SubscribeTraceEvents(ctx context.Context, ch chan<- poatc.TraceEvent, filter *poatc.TraceEventFilter) (*rpc.ClientSubscription, error) {
+	return pc.c.Subscribe(ctx, "poatc", ch, "traceEvents", filter)
+}
+
+// SubscribeBlockTimeChanges subscribes to the adjustments of the dynamic block
+// time.
+func (pc *Client) SubscribeBlockTimeChanges(ctx context.Context, ch chan<- poatc.TraceEvent) (*rpc.ClientSubscription, error) {
+	return pc.c.Subscribe(ctx, "poatc", ch, "blockTimeChanges")
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	if number.Sign() >= 0 {
+		return hexutil.EncodeBig(number)
+	}
+	// It's negative.
+	if number.IsInt64() {
+		return rpc.BlockNumber(number.Int64()).String()
+	}
+	// It's negative and large, which is invalid.
+	return fmt.Sprintf("<invalid %d>", number)
+}
@@ -0,0 +1,230 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poatcclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poatc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testSigner = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// newTestClient creates a chain with a single authorized signer and serves its
+// poatc namespace in-process.
+func newTestClient(t *testing.T) (*Client, *core.BlockChain) {
+	config := *params.TestChainConfig
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30}
+
+	extra := make([]byte, 32)
+	extra = append(extra, testSigner[:]...)
+	extra = append(extra, make([]byte, crypto.SignatureLength)...)
+
+	genesis := &core.Genesis{
+		Config:    &config,
+		ExtraData: extra,
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+	}
+	db := rawdb.NewMemoryDatabase()
+	engine := poatc.NewWithConfig(config.Clique, nil, db)
+
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	server := rpc.NewServer()
+	for _, api := range engine.APIs(chain) {
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			t.Fatalf("failed to register %s API: %v", api.Namespace, err)
+		}
+	}
+	client := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+		chain.Stop()
+	})
+	return New(client), chain
+}
+
+func TestSnapshot(t *testing.T) {
+	client, chain := newTestClient(t)
+	ctx := context.Background()
+
+	snap, err := client.GetSnapshot(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	if snap.Hash != chain.Genesis().Hash() {
+		t.Errorf("snapshot hash mismatch: have %x, want %x", snap.Hash, chain.Genesis().Hash())
+	}
+	if _, ok := snap.Signers[testSigner]; !ok || len(snap.Signers) != 1 {
+		t.Errorf("snapshot signers mismatch: have %v, want [%x]", snap.Signers, testSigner)
+	}
+	signers, err := client.GetSignersAtHash(ctx, chain.Genesis().Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve signers: %v", err)
+	}
+	if len(signers) != 1 || signers[0] != testSigner {
+		t.Errorf("signers mismatch: have %v, want [%x]", signers, testSigner)
+	}
+	if _, err := client.GetSnapshot(ctx, big.NewInt(1)); err == nil {
+		t.Errorf("snapshot of unknown block returned")
+	}
+}
+
+func TestProposals(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	candidate := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	if err := client.Propose(ctx, candidate, true); err != nil {
+		t.Fatalf("failed to propose signer: %v", err)
+	}
+	proposals, err := client.Proposals(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve proposals: %v", err)
+	}
+	if auth, ok := proposals[candidate]; !ok || !auth || len(proposals) != 1 {
+		t.Errorf("proposals mismatch: have %v, want %x authorized", proposals, candidate)
+	}
+	if err := client.Discard(ctx, candidate); err != nil {
+		t.Fatalf("failed to discard proposal: %v", err)
+	}
+	if proposals, _ = client.Proposals(ctx); len(proposals) != 0 {
+		t.Errorf("discarded proposal still pending: %v", proposals)
+	}
+}
+
+func TestSubsystemStats(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	// Subsystems are started by the first snapshot retrieval
+	if _, err := client.GetSnapshot(ctx, nil); err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	status, err := client.GetIntegrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve integration status: %v", err)
+	}
+	if !status.ReputationSystem.Initialized || !status.ValidatorSelection.Initialized || !status.Integrations.ReputationToValidatorSelection {
+		t.Errorf("integration status mismatch: %+v", status)
+	}
+	reputation, err := client.GetReputationStats(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve reputation stats: %v", err)
+	}
+	if reputation.Validators.Total != 1 || reputation.Validators.Active != 1 {
+		t.Errorf("reputation validators mismatch: have %+v, want 1 active", reputation.Validators)
+	}
+	selection, err := client.GetValidatorSelectionStats(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve selection stats: %v", err)
+	}
+	if selection.Validators.Total != 1 || selection.Totals.Stake.Sign() != 0 {
+		t.Errorf("selection stats mismatch: have %+v/%+v", selection.Validators, selection.Totals)
+	}
+	tracing, err := client.GetTracingStats(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve tracing stats: %v", err)
+	}
+	root, err := client.GetMerkleRoot(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve merkle root: %v", err)
+	}
+	if tracing.MerkleRoot != root {
+		t.Errorf("merkle root mismatch: have %x, want %x", tracing.MerkleRoot, root)
+	}
+	metrics, err := client.GetTraceMetrics(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve trace metrics: %v", err)
+	}
+	if metrics.TotalEvents != tracing.Metrics.TotalEvents {
+		t.Errorf("trace metrics mismatch: have %d events, want %d", metrics.TotalEvents, tracing.Metrics.TotalEvents)
+	}
+	dynamics, err := client.GetTimeDynamicStats(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve time dynamic stats: %v", err)
+	}
+	blockTime, err := client.GetCurrentBlockTime(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve block time: %v", err)
+	}
+	if blockTime.Seconds() != dynamics.DynamicBlockTime.CurrentBlockTime {
+		t.Errorf("block time mismatch: have %v, want %vs", blockTime, dynamics.DynamicBlockTime.CurrentBlockTime)
+	}
+	// Subsystems started by sealing blocks report their absence as errors
+	if _, err := client.GetAnomalyStats(ctx); err == nil {
+		t.Errorf("stats of uninitialized anomaly detector returned")
+	}
+	if _, err := client.ValidateSigner(ctx, testSigner); err == nil {
+		t.Errorf("validation by uninitialized whitelist/blacklist returned")
+	}
+}
+
+func TestReputationHistory(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	// Subsystems are started by the first snapshot retrieval
+	if _, err := client.GetSnapshot(ctx, nil); err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	history, err := client.GetReputationHistory(ctx, testSigner, big.NewInt(0), nil, 1)
+	if err != nil {
+		t.Fatalf("failed to retrieve reputation history: %v", err)
+	}
+	if len(history) != 1 || history[0].Number != 0 || history[0].Score != poatc.DefaultReputationConfig().InitialReputation {
+		t.Errorf("reputation history mismatch: have %+v, want initial reputation at genesis", history)
+	}
+	if _, err := client.GetReputationHistory(ctx, testSigner, nil, big.NewInt(1), 1); err == nil {
+		t.Errorf("reputation history of unknown block returned")
+	}
+}
+
+func TestExplainReputation(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	// Subsystems are started by the first snapshot retrieval
+	if _, err := client.GetSnapshot(ctx, nil); err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	explanation, err := client.ExplainReputation(ctx, testSigner, nil)
+	if err != nil {
+		t.Fatalf("failed to explain reputation: %v", err)
+	}
+	if len(explanation.Changes) != 1 || explanation.Changes[0].Cause != poatc.CauseJoined {
+		t.Errorf("reputation changes mismatch: have %+v, want the signer joining", explanation.Changes)
+	}
+	if _, err := client.ExplainReputation(ctx, common.Address{0x11}, nil); err == nil {
+		t.Errorf("explanation of unknown validator returned")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 )
 
@@ -137,3 +138,55 @@ func TestConfigRules(t *testing.T) {
 		t.Errorf("expected %v to be shanghai", stamp)
 	}
 }
+
+func TestPoatcConfigValidate(t *testing.T) {
+	valid := func() *PoatcConfig {
+		return &PoatcConfig{
+			ValidatorSelection: &PoatcValidatorSelectionConfig{SelectionMethod: "hybrid", StakeWeight: 0.4, ReputationWeight: 0.6},
+			Reputation: &PoatcReputationConfig{
+				InitialReputation: 1, MinReputation: 0.1, MaxReputation: 10,
+				BlockMiningWeight: 0.4, UptimeWeight: 0.3, ConsistencyWeight: 0.2, PenaltyWeight: 0.1,
+				LowReputationThreshold: 2, HighReputationThreshold: 8, UpdateInterval: "1h0m0s",
+			},
+			TimeDynamic: &PoatcTimeDynamicConfig{BaseBlockTime: 15, MinBlockTime: 5, MaxBlockTime: 60},
+		}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+	var nilConfig *PoatcConfig
+	if err := nilConfig.Validate(); err != nil {
+		t.Fatalf("nil config rejected: %v", err)
+	}
+	tests := []struct {
+		name   string
+		mutate func(c *PoatcConfig)
+	}{
+		{"selection weights", func(c *PoatcConfig) { c.ValidatorSelection.RandomWeight = 0.3 }},
+		{"selection method", func(c *PoatcConfig) { c.ValidatorSelection.SelectionMethod = "lottery" }},
+		{"reputation weights", func(c *PoatcConfig) { c.Reputation.PenaltyWeight = 0.5 }},
+		{"reputation thresholds", func(c *PoatcConfig) { c.Reputation.LowReputationThreshold = 9 }},
+		{"reputation bounds", func(c *PoatcConfig) { c.Reputation.MinReputation = 11 }},
+		{"reputation duration", func(c *PoatcConfig) { c.Reputation.UpdateInterval = "hourly" }},
+		{"block time bounds", func(c *PoatcConfig) { c.TimeDynamic.BaseBlockTime = 90 }},
+		{"tracing level", func(c *PoatcConfig) { c.Tracing = &PoatcTracingConfig{TraceLevel: 7} }},
+		{"tracing retention", func(c *PoatcConfig) { c.Tracing = &PoatcTracingConfig{TraceRetention: "-1h"} }},
+		{"slashing cooldown", func(c *PoatcConfig) { c.SlashingCooldown = 5 }},
+		{"missed slots", func(c *PoatcConfig) { c.MaxMissedSlots = 3 }},
+		{"finality window", func(c *PoatcConfig) { c.FinalityWindow = 16 }},
+		{"hysteresis band", func(c *PoatcConfig) { c.WhitelistBlacklist = &PoatcWhitelistBlacklistConfig{HysteresisBand: -1} }},
+		{"rotation epochs", func(c *PoatcConfig) { c.Rotation = &PoatcRotationConfig{LowReputationEpochs: 2} }},
+		{"rotation target", func(c *PoatcConfig) { c.Rotation = &PoatcRotationConfig{StandbySigners: []common.Address{{1}}} }},
+		{"registry address", func(c *PoatcConfig) { c.Registry = &PoatcRegistryConfig{} }},
+		{"registry deposit", func(c *PoatcConfig) {
+			c.Registry = &PoatcRegistryConfig{Address: common.Address{1}, MinDeposit: big.NewInt(-1)}
+		}},
+	}
+	for _, tt := range tests {
+		config := valid()
+		tt.mutate(config)
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: invalid config accepted", tt.name)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// weightTolerance is the allowed rounding error when checking that a set of
+// configured weights adds up to one.
+const weightTolerance = 1e-6
+
+// PoatcConfig is the consensus engine configs for the POATC extensions layered
+// on top of proof-of-authority sealing. It is decoded from the "poatc" section
+// of the genesis chain config so that every node of a network runs the same
+// tuning. Sub-configs that are left out fall back to the engine defaults.
+type PoatcConfig struct {
+	EnableAnomalyDetection   bool `json:"enable_anomaly_detection"`
+	EnableWhitelistBlacklist bool `json:"enable_whitelist_blacklist"`
+	EnableValidatorSelection bool `json:"enable_validator_selection"`
+	EnableReputationSystem   bool `json:"enable_reputation_system"`
+	EnableTracingSystem      bool `json:"enable_tracing_system"`
+	EnableTimeDynamic        bool `json:"enable_time_dynamic"`
+
+	EnableSlashing   bool   `json:"enable_slashing"`
+	SlashingCooldown uint64 `json:"slashing_cooldown,omitempty"` // Blocks before a signer slashed for double signing may be voted back in (0 = one epoch)
+
+	EnableLivenessTracking bool   `json:"enable_liveness_tracking"`
+	MaxMissedSlots         uint64 `json:"max_missed_slots,omitempty"` // In-turn slots a signer may miss in a row before signers propose to drop it (0 = never)
+
+	EnableFinality bool   `json:"enable_finality"`
+	FinalityWindow uint64 `json:"finality_window,omitempty"` // Blocks behind or ahead of the head signers may attest (0 = 64)
+
+	AnomalyDetection   *PoatcAnomalyDetectionConfig   `json:"anomaly_detection_config,omitempty"`
+	WhitelistBlacklist *PoatcWhitelistBlacklistConfig `json:"whitelist_blacklist_config,omitempty"`
+	ValidatorSelection *PoatcValidatorSelectionConfig `json:"validator_selection_config,omitempty"`
+	Reputation         *PoatcReputationConfig         `json:"reputation_config,omitempty"`
+	Tracing            *PoatcTracingConfig            `json:"tracing_config,omitempty"`
+	TimeDynamic        *PoatcTimeDynamicConfig        `json:"time_dynamic_config,omitempty"`
+	Rotation           *PoatcRotationConfig           `json:"rotation_config,omitempty"`
+	Registry           *PoatcRegistryConfig           `json:"registry_config,omitempty"`
+}
+
+// PoatcAnomalyDetectionConfig tunes the anomaly detector.
+type PoatcAnomalyDetectionConfig struct {
+	EnableRapidSigningDetection      bool    `json:"enable_rapid_signing_detection"`
+	EnableSuspiciousPatternDetection bool    `json:"enable_suspicious_pattern_detection"`
+	EnableTimestampDriftDetection    bool    `json:"enable_timestamp_drift_detection"`
+	MaxBlocksPerSigner               int     `json:"max_blocks_per_signer,omitempty"`       // Maximum blocks a signer may seal in the analysis window
+	ConsecutiveBlockThreshold        int     `json:"consecutive_block_threshold,omitempty"` // Consecutive blocks by one signer to trigger an alert
+	TimestampDriftThreshold          int64   `json:"timestamp_drift_threshold,omitempty"`   // Maximum drift between blocks in seconds
+	EvaluationWindow                 string  `json:"evaluation_window,omitempty"`           // Analysis window, Go duration syntax
+	AlertThreshold                   float64 `json:"alert_threshold,omitempty"`             // Maximum share of blocks a single signer may seal
+
+	Detectors map[string]*PoatcDetectorConfig `json:"detectors,omitempty"` // Per detector switches and settings, keyed by detector name
+}
+
+// PoatcDetectorConfig enables or disables a single anomaly detector. Detectors
+// not listed run with their default settings.
+type PoatcDetectorConfig struct {
+	Enabled  bool            `json:"enabled"`
+	Settings json.RawMessage `json:"settings,omitempty"` // Detector specific settings
+}
+
+// PoatcWhitelistBlacklistConfig tunes the whitelist/blacklist manager.
+type PoatcWhitelistBlacklistConfig struct {
+	EnableStrictMode  bool   `json:"enable_strict_mode"`           // Only whitelisted signers may seal
+	EnablePersistence bool   `json:"enable_persistence"`           // Persist the lists across restarts
+	EnableExpiration  bool   `json:"enable_expiration"`            // Forced blacklistings expire after DefaultExpiration
+	DefaultExpiration string `json:"default_expiration,omitempty"` // Lifetime of forced blacklistings, Go duration syntax
+	CleanupInterval   string `json:"cleanup_interval,omitempty"`   // Interval between expired entry sweeps, Go duration syntax
+	MaxEntries        int    `json:"max_entries,omitempty"`        // Maximum number of entries per list
+	VoteExpiry        uint64 `json:"vote_expiry,omitempty"`        // Blocks after which an unpassed list vote is discarded
+	EntryExpiry       uint64 `json:"entry_expiry,omitempty"`       // Blocks after which a voted list entry expires
+
+	ProbationBlocks  uint64  `json:"probation_blocks,omitempty"`  // Blocks on probation before an address may be whitelisted
+	WarningBlocks    uint64  `json:"warning_blocks,omitempty"`    // Blocks a warned address gets to recover before it is suspended
+	SuspensionBlocks uint64  `json:"suspension_blocks,omitempty"` // Blocks a suspended address stays blacklisted at least
+	MaxSuspensions   int     `json:"max_suspensions,omitempty"`   // Suspensions after which the next one is a ban
+	HysteresisBand   float64 `json:"hysteresis_band,omitempty"`   // Reputation margin around the thresholds before changing state
+}
+
+// PoatcValidatorSelectionConfig tunes the 2-tier validator selection.
+type PoatcValidatorSelectionConfig struct {
+	EnableValidatorSelection bool    `json:"enable_validator_selection"`
+	SelectionMethod          string  `json:"selection_method,omitempty"`         // "random", "stake", "reputation" or "hybrid"
+	SmallValidatorSetSize    int     `json:"small_validator_set_size,omitempty"` // Size of the small validator set
+	ElectionInterval         uint64  `json:"election_interval,omitempty"`        // Blocks between small validator set re-elections
+	SelectionWindow          string  `json:"selection_window,omitempty"`         // Deprecated: reselection window, used if no election interval is set
+	StakeWeight              float64 `json:"stake_weight"`
+	ReputationWeight         float64 `json:"reputation_weight"`
+	RandomWeight             float64 `json:"random_weight"`
+
+	Staking *PoatcStakingConfig `json:"staking,omitempty"` // Source of the stakes weighting the election, none if unset
+}
+
+// PoatcStakingConfig locates the bonded stakes of the signers in chain state.
+// The stakes are read at every checkpoint from a mapping(address => uint256)
+// held by a staking contract, or by a precompile allocated in the genesis.
+type PoatcStakingConfig struct {
+	Contract common.Address `json:"contract"` // Account holding the stakes
+	Slot     uint64         `json:"slot"`     // Storage slot of the stake mapping
+}
+
+// PoatcReputationConfig tunes the reputation system.
+type PoatcReputationConfig struct {
+	EnableReputationSystem  bool    `json:"enable_reputation_system"`
+	InitialReputation       float64 `json:"initial_reputation,omitempty"`
+	MaxReputation           float64 `json:"max_reputation,omitempty"`
+	MinReputation           float64 `json:"min_reputation,omitempty"`
+	BlockMiningWeight       float64 `json:"block_mining_weight"`
+	UptimeWeight            float64 `json:"uptime_weight"`
+	ConsistencyWeight       float64 `json:"consistency_weight"`
+	PenaltyWeight           float64 `json:"penalty_weight"`
+	DecayRate               float64 `json:"decay_rate,omitempty"`        // Fraction of the score lost per update interval
+	UpdateInterval          string  `json:"update_interval,omitempty"`   // Go duration syntax
+	EvaluationWindow        string  `json:"evaluation_window,omitempty"` // Go duration syntax
+	HighReputationThreshold float64 `json:"high_reputation_threshold,omitempty"`
+	LowReputationThreshold  float64 `json:"low_reputation_threshold,omitempty"`
+}
+
+// PoatcTracingConfig tunes the tracing system.
+type PoatcTracingConfig struct {
+	EnableTracing     bool   `json:"enable_tracing"`
+	TraceLevel        int    `json:"trace_level"`
+	MaxTraceEvents    int    `json:"max_trace_events,omitempty"`
+	TraceRetention    string `json:"trace_retention,omitempty"` // Go duration syntax
+	EnableMerkleTree  bool   `json:"enable_merkle_tree"`
+	EnableMetrics     bool   `json:"enable_metrics"`
+	EnablePersistence bool   `json:"enable_persistence"`
+	MerkleRootInBlock bool   `json:"merkle_root_in_block"`
+}
+
+// PoatcTimeDynamicConfig tunes the time dynamic mechanisms.
+type PoatcTimeDynamicConfig struct {
+	EnableDynamicBlockTime          bool    `json:"enable_dynamic_block_time"`
+	EnableDynamicValidatorSelection bool    `json:"enable_dynamic_validator_selection"`
+	EnableDynamicReputationDecay    bool    `json:"enable_dynamic_reputation_decay"`
+	BlockTimeInHeader               bool    `json:"block_time_in_header"`          // Enforce the dynamic block time on the header timestamps
+	BaseBlockTime                   uint64  `json:"base_block_time,omitempty"`     // Seconds
+	MinBlockTime                    uint64  `json:"min_block_time,omitempty"`      // Seconds
+	MaxBlockTime                    uint64  `json:"max_block_time,omitempty"`      // Seconds
+	TxVolumeThreshold               int     `json:"tx_volume_threshold,omitempty"` // Transactions per block considered high volume
+	TxLowThreshold                  int     `json:"tx_low_threshold,omitempty"`    // Transactions per block considered low volume
+	SelectionInterval               string  `json:"selection_interval,omitempty"`  // Go duration syntax
+	DecayInterval                   string  `json:"decay_interval,omitempty"`      // Go duration syntax
+	DecayRate                       float64 `json:"decay_rate,omitempty"`          // Fraction of the score lost per hour
+}
+
+// PoatcRotationConfig tunes the signer rotation, in which signers vote on their
+// own to drop poorly performing signers and to authorize standby candidates.
+type PoatcRotationConfig struct {
+	EnableRotation      bool             `json:"enable_rotation"`
+	LowReputationEpochs uint64           `json:"low_reputation_epochs,omitempty"` // Epochs in a row a signer may stay below low_reputation_threshold before signers propose to drop it (0 = never)
+	StandbySigners      []common.Address `json:"standby_signers,omitempty"`       // Candidates proposed in order whenever there are fewer signers than targeted
+	TargetSigners       int              `json:"target_signers,omitempty"`        // Number of signers to maintain from the standby candidates
+}
+
+// PoatcRegistryConfig locates the signer candidate registry. The registry lives
+// in the storage of an account without code, which candidates send transactions
+// to in order to register with a deposit, attest blocks and withdraw. The engine
+// executes these calls while finalizing every block.
+type PoatcRegistryConfig struct {
+	Address               common.Address `json:"address"`                          // Account holding the registry and the deposits
+	MinDeposit            *big.Int       `json:"min_deposit,omitempty"`            // Wei a candidate deposits to register
+	AttestationWindow     uint64         `json:"attestation_window,omitempty"`     // Blocks an attested block may lag behind the attestation (0 = 32)
+	ProbationAttestations uint64         `json:"probation_attestations,omitempty"` // Attestations a candidate needs before it may be promoted
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *PoatcConfig) String() string {
+	return "poatc"
+}
+
+// Validate checks that the POATC configuration is internally consistent: all
+// weight sets sum to one, thresholds are ordered and durations are parseable.
+func (c *PoatcConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.SlashingCooldown > 0 && !c.EnableSlashing {
+		return fmt.Errorf("invalid poatc config: slashing_cooldown set without enable_slashing")
+	}
+	if c.MaxMissedSlots > 0 && !c.EnableLivenessTracking {
+		return fmt.Errorf("invalid poatc config: max_missed_slots set without enable_liveness_tracking")
+	}
+	if c.FinalityWindow > 0 && !c.EnableFinality {
+		return fmt.Errorf("invalid poatc config: finality_window set without enable_finality")
+	}
+	if cfg := c.AnomalyDetection; cfg != nil {
+		if cfg.MaxBlocksPerSigner < 0 || cfg.ConsecutiveBlockThreshold < 0 || cfg.TimestampDriftThreshold < 0 {
+			return fmt.Errorf("invalid poatc anomaly config: negative threshold")
+		}
+		if cfg.AlertThreshold < 0 || cfg.AlertThreshold > 1 {
+			return fmt.Errorf("invalid poatc anomaly config: alert_threshold %v not in [0, 1]", cfg.AlertThreshold)
+		}
+		if err := checkDuration("anomaly_detection_config.evaluation_window", cfg.EvaluationWindow); err != nil {
+			return err
+		}
+		for name, detector := range cfg.Detectors {
+			if detector == nil {
+				return fmt.Errorf("invalid poatc anomaly config: empty %q detector config", name)
+			}
+		}
+	}
+	if cfg := c.WhitelistBlacklist; cfg != nil {
+		if cfg.MaxEntries < 0 {
+			return fmt.Errorf("invalid poatc whitelist/blacklist config: negative max_entries")
+		}
+		if err := checkDuration("whitelist_blacklist_config.default_expiration", cfg.DefaultExpiration); err != nil {
+			return err
+		}
+		if err := checkDuration("whitelist_blacklist_config.cleanup_interval", cfg.CleanupInterval); err != nil {
+			return err
+		}
+		if cfg.MaxSuspensions < 0 {
+			return fmt.Errorf("invalid poatc whitelist/blacklist config: negative max_suspensions")
+		}
+		if cfg.HysteresisBand < 0 {
+			return fmt.Errorf("invalid poatc whitelist/blacklist config: negative hysteresis_band")
+		}
+	}
+	if cfg := c.ValidatorSelection; cfg != nil {
+		switch cfg.SelectionMethod {
+		case "", "random", "stake", "reputation", "hybrid":
+		default:
+			return fmt.Errorf("invalid poatc validator selection config: unknown selection_method %q", cfg.SelectionMethod)
+		}
+		if cfg.SmallValidatorSetSize < 0 {
+			return fmt.Errorf("invalid poatc validator selection config: negative small_validator_set_size")
+		}
+		if err := checkWeights("validator_selection_config", cfg.StakeWeight, cfg.ReputationWeight, cfg.RandomWeight); err != nil {
+			return err
+		}
+		if err := checkDuration("validator_selection_config.selection_window", cfg.SelectionWindow); err != nil {
+			return err
+		}
+		if cfg.Staking != nil && cfg.Staking.Contract == (common.Address{}) {
+			return fmt.Errorf("invalid poatc validator selection config: missing staking contract")
+		}
+	}
+	if cfg := c.Reputation; cfg != nil {
+		if err := checkWeights("reputation_config", cfg.BlockMiningWeight, cfg.UptimeWeight, cfg.ConsistencyWeight, cfg.PenaltyWeight); err != nil {
+			return err
+		}
+		if cfg.MinReputation > cfg.MaxReputation {
+			return fmt.Errorf("invalid poatc reputation config: min_reputation %v above max_reputation %v", cfg.MinReputation, cfg.MaxReputation)
+		}
+		if cfg.InitialReputation != 0 && (cfg.InitialReputation < cfg.MinReputation || cfg.InitialReputation > cfg.MaxReputation) {
+			return fmt.Errorf("invalid poatc reputation config: initial_reputation %v outside [%v, %v]", cfg.InitialReputation, cfg.MinReputation, cfg.MaxReputation)
+		}
+		if cfg.LowReputationThreshold > cfg.HighReputationThreshold {
+			return fmt.Errorf("invalid poatc reputation config: low_reputation_threshold %v above high_reputation_threshold %v", cfg.LowReputationThreshold, cfg.HighReputationThreshold)
+		}
+		if cfg.HighReputationThreshold > cfg.MaxReputation {
+			return fmt.Errorf("invalid poatc reputation config: high_reputation_threshold %v above max_reputation %v", cfg.HighReputationThreshold, cfg.MaxReputation)
+		}
+		if cfg.DecayRate < 0 || cfg.DecayRate >= 1 {
+			return fmt.Errorf("invalid poatc reputation config: decay_rate %v not in [0, 1)", cfg.DecayRate)
+		}
+		if err := checkDuration("reputation_config.update_interval", cfg.UpdateInterval); err != nil {
+			return err
+		}
+		if err := checkDuration("reputation_config.evaluation_window", cfg.EvaluationWindow); err != nil {
+			return err
+		}
+	}
+	if cfg := c.Tracing; cfg != nil {
+		if cfg.TraceLevel < 0 || cfg.TraceLevel > 3 {
+			return fmt.Errorf("invalid poatc tracing config: trace_level %d not in [0, 3]", cfg.TraceLevel)
+		}
+		if cfg.MaxTraceEvents < 0 {
+			return fmt.Errorf("invalid poatc tracing config: negative max_trace_events")
+		}
+		if err := checkDuration("tracing_config.trace_retention", cfg.TraceRetention); err != nil {
+			return err
+		}
+	}
+	if cfg := c.TimeDynamic; cfg != nil {
+		if cfg.MinBlockTime > cfg.MaxBlockTime {
+			return fmt.Errorf("invalid poatc time dynamic config: min_block_time %d above max_block_time %d", cfg.MinBlockTime, cfg.MaxBlockTime)
+		}
+		if cfg.BaseBlockTime != 0 && (cfg.BaseBlockTime < cfg.MinBlockTime || cfg.BaseBlockTime > cfg.MaxBlockTime) {
+			return fmt.Errorf("invalid poatc time dynamic config: base_block_time %d outside [%d, %d]", cfg.BaseBlockTime, cfg.MinBlockTime, cfg.MaxBlockTime)
+		}
+		if cfg.TxLowThreshold != 0 && cfg.TxVolumeThreshold != 0 && cfg.TxLowThreshold >= cfg.TxVolumeThreshold {
+			return fmt.Errorf("invalid poatc time dynamic config: tx_low_threshold %d not below tx_volume_threshold %d", cfg.TxLowThreshold, cfg.TxVolumeThreshold)
+		}
+		if cfg.DecayRate < 0 || cfg.DecayRate >= 1 {
+			return fmt.Errorf("invalid poatc time dynamic config: decay_rate %v not in [0, 1)", cfg.DecayRate)
+		}
+		if err := checkDuration("time_dynamic_config.selection_interval", cfg.SelectionInterval); err != nil {
+			return err
+		}
+		if err := checkDuration("time_dynamic_config.decay_interval", cfg.DecayInterval); err != nil {
+			return err
+		}
+	}
+	if cfg := c.Rotation; cfg != nil {
+		if cfg.LowReputationEpochs > 0 && !c.EnableReputationSystem {
+			return fmt.Errorf("invalid poatc rotation config: low_reputation_epochs set without enable_reputation_system")
+		}
+		if cfg.TargetSigners < 0 {
+			return fmt.Errorf("invalid poatc rotation config: negative target_signers")
+		}
+		if len(cfg.StandbySigners) > 0 && cfg.TargetSigners == 0 {
+			return fmt.Errorf("invalid poatc rotation config: standby_signers set without target_signers")
+		}
+	}
+	if cfg := c.Registry; cfg != nil {
+		if cfg.Address == (common.Address{}) {
+			return fmt.Errorf("invalid poatc registry config: missing address")
+		}
+		if cfg.MinDeposit != nil && cfg.MinDeposit.Sign() < 0 {
+			return fmt.Errorf("invalid poatc registry config: negative min_deposit")
+		}
+	}
+	return nil
+}
+
+// PoatcDuration parses an optional duration field of the POATC config. Empty
+// or malformed values yield the given fallback.
+func PoatcDuration(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// checkWeights ensures that the given non-negative weights add up to one.
+func checkWeights(field string, weights ...float64) error {
+	var sum float64
+	for _, w := range weights {
+		if w < 0 {
+			return fmt.Errorf("invalid poatc %s: negative weight %v", field, w)
+		}
+		sum += w
+	}
+	if math.Abs(sum-1) > weightTolerance {
+		return fmt.Errorf("invalid poatc %s: weights sum to %v, want 1", field, sum)
+	}
+	return nil
+}
+
+// checkDuration ensures that an optional duration field is parseable and positive.
+func checkDuration(field string, value string) error {
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid poatc %s: %v", field, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("invalid poatc %s: duration %v must be positive", field, d)
+	}
+	return nil
+}
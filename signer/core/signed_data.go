@@ -164,6 +164,26 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 		// Clique uses V on the form 0 or 1
 		useEthereumV = false
 		req = &SignDataRequest{ContentType: mediaType, Rawdata: cliqueRlp, Messages: messages, Hash: sighash}
+	case apitypes.ApplicationPoatcAttestation.Mime:
+		// POATC attestations are signed over the hash of their domain separated data
+		attestationData, err := fromHex(data)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		chainID, number, hash, err := poatc.ParseAttestationData(attestationData)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		messages := []*apitypes.NameValueType{
+			{
+				Name:  "POATC attestation",
+				Typ:   "poatc",
+				Value: fmt.Sprintf("attestation of block %d [%#x] on chain %v", number, hash, chainID),
+			},
+		}
+		// Attestations use V on the form 0 or 1, like Clique
+		useEthereumV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: attestationData, Messages: messages, Hash: crypto.Keccak256(attestationData)}
 	case apitypes.DataTyped.Mime:
 		// EIP-712 conformant typed data
 		var err error